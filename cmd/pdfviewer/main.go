@@ -8,6 +8,14 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		if err := runRender(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "render: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	pdfFile, err := os.Open("testdata/minimal.pdf")
 
 	if err != nil {
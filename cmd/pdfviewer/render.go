@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/graphics"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+const renderUsage = `usage: pdfviewer render [flags] file.pdf
+
+Renders pages of a PDF file to PNG images.
+
+Flags:
+`
+
+// runRender implements the render subcommand.
+func runRender(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), renderUsage)
+		fs.PrintDefaults()
+	}
+
+	pages := fs.String("page", "1", "pages to render, e.g. 3, 1-5 or 1,4-6")
+	dpi := fs.Float64("dpi", 72, "output resolution in dots per inch")
+	output := fs.String("o", "out.png", "output file; use a pattern such as out-%03d.png for several pages")
+	background := fs.String("bg", "white", "background: white or transparent")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one input file")
+	}
+
+	opts := graphics.RenderOptions{DPI: *dpi}
+	switch *background {
+	case "white":
+		opts.Background = color.White
+	case "transparent":
+	default:
+		return fmt.Errorf("invalid background %q", *background)
+	}
+
+	doc, err := openDocument(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	selected, err := parsePageRanges(*pages, len(doc.Pages))
+	if err != nil {
+		return err
+	}
+
+	patterned := strings.Contains(*output, "%")
+	if len(selected) > 1 && !patterned {
+		return fmt.Errorf("rendering %d pages needs an output pattern such as out-%%03d.png", len(selected))
+	}
+
+	for _, n := range selected {
+		name := *output
+		if patterned {
+			name = fmt.Sprintf(*output, n)
+		}
+
+		if err := renderPage(doc, n, opts, name); err != nil {
+			return fmt.Errorf("page %d: %w", n, err)
+		}
+	}
+
+	return nil
+}
+
+// renderPage writes page n to a PNG file. Errors in the page contents are
+// reported as warnings, and the page written as far as it could be painted.
+func renderPage(doc *parser.Document, n int, opts graphics.RenderOptions, name string) error {
+	page, err := doc.Page(n - 1)
+	if err != nil {
+		return err
+	}
+
+	img, err := graphics.RenderPage(page, doc, opts)
+	if img == nil {
+		return err
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "render: warning: page %d: %v\n", n, strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// openDocument parses a PDF file and resolves its page tree.
+func openDocument(path string) (*parser.Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := parser.NewParser(parser.NewLexer(f)).ParseDocument()
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if err := doc.ResolveCatalog(); err != nil {
+		return nil, err
+	}
+
+	if err := doc.ResolvePages(); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// parsePageRanges expands a page selection such as "1,3-5" into one-based
+// page numbers, checking them against the page count.
+func parsePageRanges(spec string, count int) ([]int, error) {
+	var pages []int
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+
+		first, last, isRange := strings.Cut(part, "-")
+
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page %q", part)
+		}
+
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil {
+				return nil, fmt.Errorf("invalid page range %q", part)
+			}
+		}
+
+		if from < 1 || to > count || from > to {
+			return nil, fmt.Errorf("page range %q outside 1-%d", part, count)
+		}
+
+		for p := from; p <= to; p++ {
+			pages = append(pages, p)
+		}
+	}
+
+	return pages, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePageRanges(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected []int
+		wantErr  bool
+	}{
+		{spec: "3", expected: []int{3}},
+		{spec: "1-3", expected: []int{1, 2, 3}},
+		{spec: "1, 4-5", expected: []int{1, 4, 5}},
+		{spec: "0", wantErr: true},
+		{spec: "4-2", wantErr: true},
+		{spec: "6", wantErr: true},
+		{spec: "a", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := parsePageRanges(tc.spec, 5)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parsePageRanges(%q) error = %v, wantErr %v", tc.spec, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("parsePageRanges(%q) = %v, expected %v", tc.spec, got, tc.expected)
+		}
+	}
+}
+
+func TestRunRender(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name  string
+		args  []string
+		file  string
+		size  int
+		alpha uint8
+	}{
+		{"Defaults", []string{"-o", filepath.Join(dir, "page.png")}, "page.png", 300, 0xff},
+		{"Pattern", []string{"-page", "1", "-dpi", "144", "-bg", "transparent", "-o", filepath.Join(dir, "out-%03d.png")}, "out-001.png", 600, 0},
+		{"White", []string{"-dpi", "36", "-bg", "white", "-o", filepath.Join(dir, "small.png")}, "small.png", 150, 0xff},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := runRender(append(tc.args, "../../testdata/minimal.pdf")); err != nil {
+				t.Fatalf("runRender() error = %v", err)
+			}
+
+			f, err := os.Open(filepath.Join(dir, tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			img, err := png.Decode(f)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+
+			if b := img.Bounds(); b.Dx() != tc.size || b.Dy() != tc.size {
+				t.Errorf("image size = %v, expected %dx%d", b.Size(), tc.size, tc.size)
+			}
+			if _, _, _, a := img.At(0, 0).RGBA(); uint8(a>>8) != tc.alpha {
+				t.Errorf("background alpha = %d, expected %d", a>>8, tc.alpha)
+			}
+			// The page strokes a line through (136, 136) in PDF space.
+			s := float64(tc.size) / 300
+			if _, _, _, a := img.At(int(136*s), int((300-136)*s)).RGBA(); a == 0 {
+				t.Errorf("pixel on the line is unpainted")
+			}
+		})
+	}
+
+	errors := [][]string{
+		{"-bg", "blue"},
+		{"-page", "2"},
+		{"-page", "1,1", "-o", filepath.Join(dir, "single.png")},
+		{"-dpi", "0"},
+	}
+	for _, args := range errors {
+		if err := runRender(append(args, "../../testdata/minimal.pdf")); err == nil {
+			t.Errorf("runRender(%q) expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package colorspace
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// Reference whites in CIE XYZ.
+var (
+	whiteD50 = [3]float64{0.9642, 1, 0.8249}
+	whiteD65 = [3]float64{0.95047, 1, 1.08883}
+)
+
+type matrix3 [3][3]float64
+
+func (m matrix3) apply(v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+func (m matrix3) mul(n matrix3) matrix3 {
+	var out matrix3
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				out[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return out
+}
+
+var (
+	bradford = matrix3{
+		{0.8951, 0.2664, -0.1614},
+		{-0.7502, 1.7135, 0.0367},
+		{0.0389, -0.0685, 1.0296},
+	}
+	bradfordInverse = matrix3{
+		{0.9869929, -0.1470543, 0.1599627},
+		{0.4323053, 0.5183603, 0.0492912},
+		{-0.0085287, 0.0400428, 0.9684867},
+	}
+
+	// xyzToLinearSRGB converts D65 XYZ to linear sRGB.
+	xyzToLinearSRGB = matrix3{
+		{3.2404542, -1.5371385, -0.4985314},
+		{-0.9692660, 1.8760108, 0.0415560},
+		{0.0556434, -0.2040259, 1.0572252},
+	}
+)
+
+// xyzConverter maps XYZ values relative to a source white point to sRGB.
+type xyzConverter matrix3
+
+// newXYZConverter returns a converter that adapts white to D65 with the
+// Bradford transform before converting to sRGB.
+func newXYZConverter(white [3]float64) xyzConverter {
+	src := bradford.apply(white)
+	dst := bradford.apply(whiteD65)
+
+	var scale matrix3
+	for i := range 3 {
+		scale[i][i] = dst[i] / src[i]
+	}
+
+	return xyzConverter(xyzToLinearSRGB.mul(bradfordInverse.mul(scale.mul(bradford))))
+}
+
+func (c xyzConverter) rgb(xyz [3]float64) (float64, float64, float64) {
+	v := matrix3(c).apply(xyz)
+	return gammaSRGB(v[0]), gammaSRGB(v[1]), gammaSRGB(v[2])
+}
+
+// gammaSRGB applies the sRGB transfer curve to a linear component.
+func gammaSRGB(v float64) float64 {
+	v = util.Clamp(v, 0, 1)
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// cieParams holds the dictionary entries shared by CalGray, CalRGB and Lab.
+type cieParams struct {
+	white [3]float64
+	dict  model.PDFDict
+}
+
+func parseCIEParams(args model.PDFArray, r model.Resolver) (cieParams, error) {
+	if len(args) < 2 {
+		return cieParams{}, fmt.Errorf("missing colour space dictionary")
+	}
+
+	dict, ok := r.Resolve(args[1]).(model.PDFDict)
+	if !ok {
+		return cieParams{}, fmt.Errorf("colour space dictionary is not a dictionary: %v", args[1])
+	}
+
+	white, ok := model.ResolveNumbers(dict["WhitePoint"], r)
+	if !ok || len(white) != 3 || white[1] <= 0 {
+		return cieParams{}, fmt.Errorf("invalid /WhitePoint")
+	}
+
+	return cieParams{white: [3]float64(white), dict: dict}, nil
+}
+
+type calGray struct {
+	conv  xyzConverter
+	white [3]float64
+	gamma float64
+}
+
+func parseCalGray(args model.PDFArray, r model.Resolver) (ColorSpace, error) {
+	p, err := parseCIEParams(args, r)
+	if err != nil {
+		return nil, err
+	}
+
+	gamma := 1.0
+	if g, ok := r.Resolve(p.dict["Gamma"]).(model.PDFNumber); ok && g > 0 {
+		gamma = float64(g)
+	}
+
+	return &calGray{conv: newXYZConverter(p.white), white: p.white, gamma: gamma}, nil
+}
+
+func (*calGray) Family() string          { return "CalGray" }
+func (*calGray) NComponents() int        { return 1 }
+func (*calGray) InitialColor() []float64 { return []float64{0} }
+
+func (*calGray) DefaultDecode(int) []float64 { return linearDecode(1) }
+
+func (cs *calGray) RGB(comps []float64) (float64, float64, float64) {
+	a := math.Pow(util.Clamp(component(comps, 0), 0, 1), cs.gamma)
+	return cs.conv.rgb([3]float64{cs.white[0] * a, cs.white[1] * a, cs.white[2] * a})
+}
+
+type calRGB struct {
+	conv   xyzConverter
+	gamma  [3]float64
+	matrix matrix3
+}
+
+func parseCalRGB(args model.PDFArray, r model.Resolver) (ColorSpace, error) {
+	p, err := parseCIEParams(args, r)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &calRGB{conv: newXYZConverter(p.white), gamma: [3]float64{1, 1, 1}}
+
+	if g, ok := model.ResolveNumbers(p.dict["Gamma"], r); ok && len(g) == 3 {
+		cs.gamma = [3]float64(g)
+	}
+
+	// /Matrix lists the XYZ of each of A, B and C in turn, so it is the
+	// transpose of the matrix applied to a column vector.
+	m := []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}
+	if v, ok := model.ResolveNumbers(p.dict["Matrix"], r); ok && len(v) == 9 {
+		m = v
+	}
+	for i := range 3 {
+		for j := range 3 {
+			cs.matrix[i][j] = m[3*j+i]
+		}
+	}
+
+	return cs, nil
+}
+
+func (*calRGB) Family() string          { return "CalRGB" }
+func (*calRGB) NComponents() int        { return 3 }
+func (*calRGB) InitialColor() []float64 { return []float64{0, 0, 0} }
+
+func (*calRGB) DefaultDecode(int) []float64 { return linearDecode(3) }
+
+func (cs *calRGB) RGB(comps []float64) (float64, float64, float64) {
+	var abc [3]float64
+	for i := range 3 {
+		abc[i] = math.Pow(util.Clamp(component(comps, i), 0, 1), cs.gamma[i])
+	}
+	return cs.conv.rgb(cs.matrix.apply(abc))
+}
+
+type lab struct {
+	conv  xyzConverter
+	white [3]float64
+	rng   [4]float64
+}
+
+func parseLab(args model.PDFArray, r model.Resolver) (ColorSpace, error) {
+	p, err := parseCIEParams(args, r)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &lab{conv: newXYZConverter(p.white), white: p.white, rng: [4]float64{-100, 100, -100, 100}}
+	if v, ok := model.ResolveNumbers(p.dict["Range"], r); ok && len(v) == 4 {
+		cs.rng = [4]float64(v)
+	}
+
+	return cs, nil
+}
+
+func (*lab) Family() string   { return "Lab" }
+func (*lab) NComponents() int { return 3 }
+
+func (cs *lab) InitialColor() []float64 {
+	return []float64{0, util.Clamp(0, cs.rng[0], cs.rng[1]), util.Clamp(0, cs.rng[2], cs.rng[3])}
+}
+
+func (cs *lab) DefaultDecode(int) []float64 {
+	return []float64{0, 100, cs.rng[0], cs.rng[1], cs.rng[2], cs.rng[3]}
+}
+
+func (cs *lab) RGB(comps []float64) (float64, float64, float64) {
+	l := util.Clamp(component(comps, 0), 0, 100)
+	a := util.Clamp(component(comps, 1), cs.rng[0], cs.rng[1])
+	b := util.Clamp(component(comps, 2), cs.rng[2], cs.rng[3])
+
+	m := (l + 16) / 116
+	return cs.conv.rgb([3]float64{
+		cs.white[0] * labInverse(m+a/500),
+		cs.white[1] * labInverse(m),
+		cs.white[2] * labInverse(m-b/200),
+	})
+}
+
+// labInverse is the inverse of the cube-root companding used by CIELAB.
+func labInverse(x float64) float64 {
+	if x >= 6.0/29 {
+		return x * x * x
+	}
+	return 108.0 / 841 * (x - 4.0/29)
+}
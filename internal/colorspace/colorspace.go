@@ -0,0 +1,144 @@
+// Package colorspace implements PDF colour spaces and their conversion to
+// sRGB for the rasterizer.
+package colorspace
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// ColorSpace describes how colour components are interpreted.
+type ColorSpace interface {
+	// Family returns the colour space family name, such as DeviceRGB.
+	Family() string
+
+	// NComponents returns the number of components of a colour value.
+	NComponents() int
+
+	// InitialColor returns the colour selected when the space is made current
+	// with the cs or CS operator.
+	InitialColor() []float64
+
+	// DefaultDecode returns the default image decode array for the space,
+	// as a [min0 max0 min1 max1 ...] slice.
+	DefaultDecode(bitsPerComponent int) []float64
+
+	// RGB converts a colour value to sRGB components in [0, 1].
+	RGB(comps []float64) (r, g, b float64)
+}
+
+// ToRGBA converts a colour value to an opaque 8-bit sRGB colour.
+func ToRGBA(cs ColorSpace, comps []float64) color.RGBA {
+	r, g, b := cs.RGB(comps)
+	return color.RGBA{util.ToByte(r), util.ToByte(g), util.ToByte(b), 0xff}
+}
+
+// maxParseDepth bounds the nesting of base and alternate spaces, which a
+// malformed file could make cyclic.
+const maxParseDepth = 8
+
+// Parse returns the colour space described by a name or array. Names other
+// than the device and pattern families must have been looked up in the
+// resource dictionary by the caller.
+func Parse(v model.PDFValue, r model.Resolver) (ColorSpace, error) {
+	return parse(v, r, 0)
+}
+
+func parse(v model.PDFValue, r model.Resolver, depth int) (ColorSpace, error) {
+	if depth > maxParseDepth {
+		return nil, fmt.Errorf("colour space nested too deeply")
+	}
+
+	switch cs := r.Resolve(v).(type) {
+	case model.PDFName:
+		return parseName(string(cs))
+
+	case model.PDFArray:
+		if len(cs) == 0 {
+			return nil, fmt.Errorf("empty colour space array")
+		}
+
+		family, ok := r.Resolve(cs[0]).(model.PDFName)
+		if !ok {
+			return nil, fmt.Errorf("colour space family is not a name: %v", cs[0])
+		}
+
+		switch family {
+		case "CalGray":
+			return parseCalGray(cs, r)
+		case "CalRGB":
+			return parseCalRGB(cs, r)
+		case "Lab":
+			return parseLab(cs, r)
+		case "ICCBased":
+			return parseICCBased(cs, r, depth)
+		case "Indexed", "I":
+			return parseIndexed(cs, r, depth)
+		case "Separation":
+			return parseSeparation(cs, r, depth)
+		case "DeviceN":
+			return parseDeviceN(cs, r, depth)
+		case "Pattern":
+			return parsePattern(cs, r, depth)
+		}
+
+		// A one-element array is equivalent to the bare name.
+		if len(cs) == 1 {
+			return parseName(string(family))
+		}
+
+		return nil, fmt.Errorf("unsupported colour space %s", family)
+
+	default:
+		return nil, fmt.Errorf("invalid colour space %v", cs)
+	}
+}
+
+// parseName resolves the colour space families that are identified by name
+// alone, including the abbreviations allowed in inline images.
+func parseName(name string) (ColorSpace, error) {
+	switch name {
+	case "DeviceGray", "G":
+		return DeviceGray, nil
+	case "DeviceRGB", "RGB":
+		return DeviceRGB, nil
+	case "DeviceCMYK", "CMYK":
+		return DeviceCMYK, nil
+	case "Pattern":
+		return &PatternSpace{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported colour space %s", name)
+	}
+}
+
+// IsDeviceName reports whether name denotes a colour space that is never
+// looked up in the resource dictionary.
+func IsDeviceName(name string) bool {
+	switch name {
+	case "DeviceGray", "DeviceRGB", "DeviceCMYK", "Pattern":
+		return true
+	default:
+		return false
+	}
+}
+
+// linearDecode returns the decode array mapping each of n components onto
+// [0, 1].
+func linearDecode(n int) []float64 {
+	d := make([]float64, 2*n)
+	for i := range n {
+		d[2*i+1] = 1
+	}
+	return d
+}
+
+// component returns comps[i], or 0 if the value has too few components.
+func component(comps []float64, i int) float64 {
+	if i < len(comps) {
+		return comps[i]
+	}
+	return 0
+}
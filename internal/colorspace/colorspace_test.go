@@ -0,0 +1,308 @@
+package colorspace
+
+import (
+	"encoding/binary"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func TestParseDeviceSpaces(t *testing.T) {
+	tests := []struct {
+		input    model.PDFValue
+		expected ColorSpace
+	}{
+		{model.PDFName("DeviceGray"), DeviceGray},
+		{model.PDFName("RGB"), DeviceRGB},
+		{model.PDFName("DeviceCMYK"), DeviceCMYK},
+		{model.PDFArray{model.PDFName("DeviceRGB")}, DeviceRGB},
+	}
+
+	for _, tc := range tests {
+		cs, err := Parse(tc.input, parser.NewObjectTable())
+		if err != nil {
+			t.Errorf("Parse(%v) error = %v", tc.input, err)
+			continue
+		}
+		if cs != tc.expected {
+			t.Errorf("Parse(%v) = %v, expected %v", tc.input, cs.Family(), tc.expected.Family())
+		}
+	}
+
+	if _, err := Parse(model.PDFName("Bogus"), parser.NewObjectTable()); err == nil {
+		t.Errorf("Parse(/Bogus) expected an error")
+	}
+}
+
+func TestToRGBA(t *testing.T) {
+	tests := []struct {
+		name     string
+		cs       ColorSpace
+		comps    []float64
+		expected color.RGBA
+	}{
+		{"GrayBlack", DeviceGray, []float64{0}, color.RGBA{0, 0, 0, 0xff}},
+		{"GrayHalf", DeviceGray, []float64{0.5}, color.RGBA{0x80, 0x80, 0x80, 0xff}},
+		{"RGB", DeviceRGB, []float64{1, 0, 0.5}, color.RGBA{0xff, 0, 0x80, 0xff}},
+		{"RGBClamped", DeviceRGB, []float64{2, -1, 0}, color.RGBA{0xff, 0, 0, 0xff}},
+		{"CMYKWhite", DeviceCMYK, []float64{0, 0, 0, 0}, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for _, tc := range tests {
+		if got := ToRGBA(tc.cs, tc.comps); got != tc.expected {
+			t.Errorf("%s: ToRGBA(%v) = %v, expected %v", tc.name, tc.comps, got, tc.expected)
+		}
+	}
+}
+
+func TestCMYKApproximation(t *testing.T) {
+	// Black ink renders dark, and cyan keeps its blue-green hue.
+	if r, g, b := CMYKToRGB(0, 0, 0, 1); r > 0.25 || g > 0.25 || b > 0.25 {
+		t.Errorf("K=1 converts to (%.2f, %.2f, %.2f), expected near black", r, g, b)
+	}
+
+	if r, g, b := CMYKToRGB(1, 0, 0, 0); r > 0.1 || g < 0.5 || b < 0.8 {
+		t.Errorf("C=1 converts to (%.2f, %.2f, %.2f), expected cyan", r, g, b)
+	}
+}
+
+// rgbClose reports whether two sRGB triples differ by at most 2/255.
+func rgbClose(r, g, b float64, expected [3]float64) bool {
+	const tol = 2.0 / 255
+	return math.Abs(r-expected[0]) <= tol && math.Abs(g-expected[1]) <= tol && math.Abs(b-expected[2]) <= tol
+}
+
+func TestCIESpaces(t *testing.T) {
+	d65 := model.PDFArray{model.PDFNumber(0.95047), model.PDFNumber(1), model.PDFNumber(1.08883)}
+	d50 := model.PDFArray{model.PDFNumber(0.9642), model.PDFNumber(1), model.PDFNumber(0.8249)}
+
+	// The sRGB primaries with a linear response.
+	srgbMatrix := model.PDFArray{
+		model.PDFNumber(0.4124), model.PDFNumber(0.2126), model.PDFNumber(0.0193),
+		model.PDFNumber(0.3576), model.PDFNumber(0.7152), model.PDFNumber(0.1192),
+		model.PDFNumber(0.1805), model.PDFNumber(0.0722), model.PDFNumber(0.9505),
+	}
+
+	tests := []struct {
+		name     string
+		cs       model.PDFArray
+		comps    []float64
+		expected [3]float64
+	}{
+		{"CalGrayWhite", model.PDFArray{model.PDFName("CalGray"), model.PDFDict{"WhitePoint": d65}}, []float64{1}, [3]float64{1, 1, 1}},
+		{"CalGrayBlack", model.PDFArray{model.PDFName("CalGray"), model.PDFDict{"WhitePoint": d50}}, []float64{0}, [3]float64{0, 0, 0}},
+		{"CalRGBRed", model.PDFArray{model.PDFName("CalRGB"), model.PDFDict{"WhitePoint": d65, "Matrix": srgbMatrix}}, []float64{1, 0, 0}, [3]float64{1, 0, 0}},
+		{"CalRGBWhite", model.PDFArray{model.PDFName("CalRGB"), model.PDFDict{"WhitePoint": d65, "Matrix": srgbMatrix}}, []float64{1, 1, 1}, [3]float64{1, 1, 1}},
+		{"LabWhite", model.PDFArray{model.PDFName("Lab"), model.PDFDict{"WhitePoint": d50}}, []float64{100, 0, 0}, [3]float64{1, 1, 1}},
+		{"LabBlack", model.PDFArray{model.PDFName("Lab"), model.PDFDict{"WhitePoint": d50}}, []float64{0, 0, 0}, [3]float64{0, 0, 0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cs, err := Parse(tc.cs, parser.NewObjectTable())
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if r, g, b := cs.RGB(tc.comps); !rgbClose(r, g, b, tc.expected) {
+				t.Errorf("RGB(%v) = (%.3f, %.3f, %.3f), expected %v", tc.comps, r, g, b, tc.expected)
+			}
+		})
+	}
+
+	if _, err := Parse(model.PDFArray{model.PDFName("CalRGB"), model.PDFDict{}}, parser.NewObjectTable()); err == nil {
+		t.Errorf("Parse() without /WhitePoint expected an error")
+	}
+}
+
+func TestIndexed(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookup model.PDFValue
+	}{
+		{"HexString", model.PDFHexString("000000 FF0000 00FF00")},
+		{"Stream", model.PDFStream{Dict: model.PDFDict{}, Data: []byte{0, 0, 0, 255, 0, 0, 0, 255, 0}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			arr := model.PDFArray{model.PDFName("Indexed"), model.PDFName("DeviceRGB"), model.PDFNumber(2), tc.lookup}
+			cs, err := Parse(arr, parser.NewObjectTable())
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got := ToRGBA(cs, []float64{1}); got != (color.RGBA{0xff, 0, 0, 0xff}) {
+				t.Errorf("entry 1 = %v, expected red", got)
+			}
+			// Out-of-range indices are clamped to hival.
+			if got := ToRGBA(cs, []float64{9}); got != (color.RGBA{0, 0xff, 0, 0xff}) {
+				t.Errorf("entry 9 = %v, expected green", got)
+			}
+			if d := cs.DefaultDecode(4); d[0] != 0 || d[1] != 15 {
+				t.Errorf("DefaultDecode(4) = %v, expected [0 15]", d)
+			}
+		})
+	}
+}
+
+func TestSeparation(t *testing.T) {
+	sep := model.PDFArray{model.PDFName("Separation"), model.PDFName("Spot"), model.PDFName("DeviceCMYK"), model.PDFDict{}}
+	cs, err := Parse(sep, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if cs.NComponents() != 1 || cs.InitialColor()[0] != 1 {
+		t.Errorf("Separation has %d components, initial %v", cs.NComponents(), cs.InitialColor())
+	}
+	if got := ToRGBA(cs, []float64{0}); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("zero tint = %v, expected white", got)
+	}
+
+	// A tint transform mapping the spot colour to pure cyan.
+	tint := model.PDFDict{
+		"FunctionType": model.PDFNumber(2),
+		"Domain":       model.PDFArray{model.PDFNumber(0), model.PDFNumber(1)},
+		"C0":           model.PDFArray{model.PDFNumber(0), model.PDFNumber(0), model.PDFNumber(0), model.PDFNumber(0)},
+		"C1":           model.PDFArray{model.PDFNumber(1), model.PDFNumber(0), model.PDFNumber(0), model.PDFNumber(0)},
+		"N":            model.PDFNumber(1),
+	}
+	sep[3] = tint
+	if cs, err = Parse(sep, parser.NewObjectTable()); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got, expected := ToRGBA(cs, []float64{1}), ToRGBA(DeviceCMYK, []float64{1, 0, 0, 0}); got != expected {
+		t.Errorf("full tint = %v, expected cyan %v", got, expected)
+	}
+
+	devn := model.PDFArray{
+		model.PDFName("DeviceN"),
+		model.PDFArray{model.PDFName("Cyan"), model.PDFName("Spot")},
+		model.PDFName("DeviceGray"),
+		model.PDFDict{},
+	}
+	if cs, err = Parse(devn, parser.NewObjectTable()); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cs.NComponents() != 2 {
+		t.Errorf("DeviceN has %d components, expected 2", cs.NComponents())
+	}
+	if PaintsNothing(cs) {
+		t.Errorf("PaintsNothing() of DeviceN with colourants = true")
+	}
+
+	// Spaces whose colourants are all None paint nothing.
+	devn[1] = model.PDFArray{model.PDFName("None"), model.PDFName("None")}
+	sep[1] = model.PDFName("None")
+	for _, v := range []model.PDFArray{devn, sep} {
+		if cs, err = Parse(v, parser.NewObjectTable()); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if !PaintsNothing(cs) {
+			t.Errorf("PaintsNothing() of %s with None = false", cs.Family())
+		}
+	}
+	if PaintsNothing(DeviceGray) {
+		t.Errorf("PaintsNothing(DeviceGray) = true")
+	}
+}
+
+// iccTestProfile builds an RGB matrix/TRC profile with the D50-adapted sRGB
+// primaries and the given gamma.
+func iccTestProfile(gamma float64) []byte {
+	fixed := func(v float64) []byte {
+		return binary.BigEndian.AppendUint32(nil, uint32(int32(math.Round(v*65536))))
+	}
+	xyz := func(x, y, z float64) []byte {
+		b := append([]byte("XYZ "), 0, 0, 0, 0)
+		return append(append(append(b, fixed(x)...), fixed(y)...), fixed(z)...)
+	}
+	curv := binary.BigEndian.AppendUint16(append([]byte("curv"), 0, 0, 0, 0, 0, 0, 0, 1), uint16(gamma*256))
+
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"rXYZ", xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", curv}, {"gTRC", curv}, {"bTRC", curv},
+	}
+
+	data := make([]byte, 128)
+	copy(data[16:], "RGB XYZ ")
+	copy(data[36:], "acsp")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(tags)))
+
+	off := len(data) + 12*len(tags)
+	var body []byte
+	for _, tag := range tags {
+		data = append(data, tag.sig...)
+		data = binary.BigEndian.AppendUint32(data, uint32(off+len(body)))
+		data = binary.BigEndian.AppendUint32(data, uint32(len(tag.data)))
+		body = append(body, tag.data...)
+	}
+	return append(data, body...)
+}
+
+func TestICCProfile(t *testing.T) {
+	p, err := ParseICCProfile(iccTestProfile(1))
+	if err != nil {
+		t.Fatalf("ParseICCProfile() error = %v", err)
+	}
+	if !p.CanConvert(3) || p.CanConvert(4) {
+		t.Fatalf("CanConvert(3) = %v, CanConvert(4) = %v", p.CanConvert(3), p.CanConvert(4))
+	}
+
+	tests := []struct {
+		comps    []float64
+		expected [3]float64
+	}{
+		{[]float64{1, 1, 1}, [3]float64{1, 1, 1}},
+		{[]float64{0, 0, 0}, [3]float64{0, 0, 0}},
+		{[]float64{1, 0, 0}, [3]float64{1, 0, 0}},
+	}
+	for _, tc := range tests {
+		if r, g, b := p.RGB(tc.comps); !rgbClose(r, g, b, tc.expected) {
+			t.Errorf("RGB(%v) = (%.3f, %.3f, %.3f), expected %v", tc.comps, r, g, b, tc.expected)
+		}
+	}
+
+	if _, err := ParseICCProfile([]byte("short")); err == nil {
+		t.Errorf("ParseICCProfile() of a truncated profile expected an error")
+	}
+}
+
+func TestICCBased(t *testing.T) {
+	r := parser.NewObjectTable()
+
+	profile := model.PDFStream{Dict: model.PDFDict{"N": model.PDFNumber(3)}, Data: iccTestProfile(1)}
+	cs, err := Parse(model.PDFArray{model.PDFName("ICCBased"), profile}, r)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if r, g, b := cs.RGB([]float64{0, 1, 0}); !rgbClose(r, g, b, [3]float64{0, 1, 0}) {
+		t.Errorf("profile green = (%.3f, %.3f, %.3f)", r, g, b)
+	}
+
+	// An unusable profile falls back to /Alternate, or to the device space
+	// with /N components.
+	broken := model.PDFStream{Dict: model.PDFDict{"N": model.PDFNumber(4)}, Data: []byte("junk")}
+	if cs, err = Parse(model.PDFArray{model.PDFName("ICCBased"), broken}, r); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := ToRGBA(cs, []float64{0, 0, 0, 0}); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("CMYK fallback white = %v", got)
+	}
+
+	broken.Dict = model.PDFDict{"N": model.PDFNumber(1), "Alternate": model.PDFName("DeviceGray")}
+	if cs, err = Parse(model.PDFArray{model.PDFName("ICCBased"), broken}, r); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cs.NComponents() != 1 {
+		t.Errorf("gray fallback has %d components", cs.NComponents())
+	}
+}
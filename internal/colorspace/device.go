@@ -0,0 +1,87 @@
+package colorspace
+
+import "github.com/Kantha2004/go-pdfviewer/internal/util"
+
+// The device colour spaces have no parameters and are shared.
+var (
+	DeviceGray ColorSpace = deviceGray{}
+	DeviceRGB  ColorSpace = deviceRGB{}
+	DeviceCMYK ColorSpace = deviceCMYK{}
+)
+
+type deviceGray struct{}
+
+func (deviceGray) Family() string          { return "DeviceGray" }
+func (deviceGray) NComponents() int        { return 1 }
+func (deviceGray) InitialColor() []float64 { return []float64{0} }
+
+func (deviceGray) DefaultDecode(int) []float64 { return linearDecode(1) }
+
+func (deviceGray) RGB(comps []float64) (float64, float64, float64) {
+	g := util.Clamp(component(comps, 0), 0, 1)
+	return g, g, g
+}
+
+type deviceRGB struct{}
+
+func (deviceRGB) Family() string          { return "DeviceRGB" }
+func (deviceRGB) NComponents() int        { return 3 }
+func (deviceRGB) InitialColor() []float64 { return []float64{0, 0, 0} }
+
+func (deviceRGB) DefaultDecode(int) []float64 { return linearDecode(3) }
+
+func (deviceRGB) RGB(comps []float64) (float64, float64, float64) {
+	return util.Clamp(component(comps, 0), 0, 1),
+		util.Clamp(component(comps, 1), 0, 1),
+		util.Clamp(component(comps, 2), 0, 1)
+}
+
+type deviceCMYK struct{}
+
+func (deviceCMYK) Family() string          { return "DeviceCMYK" }
+func (deviceCMYK) NComponents() int        { return 4 }
+func (deviceCMYK) InitialColor() []float64 { return []float64{0, 0, 0, 1} }
+
+func (deviceCMYK) DefaultDecode(int) []float64 { return linearDecode(4) }
+
+func (deviceCMYK) RGB(comps []float64) (float64, float64, float64) {
+	return CMYKToRGB(
+		util.Clamp(component(comps, 0), 0, 1),
+		util.Clamp(component(comps, 1), 0, 1),
+		util.Clamp(component(comps, 2), 0, 1),
+		util.Clamp(component(comps, 3), 0, 1),
+	)
+}
+
+// CMYKToRGB approximates the appearance of process colours on coated stock.
+//
+// The naive complement 1-(c+k) renders rich blacks and overprinted inks far
+// too saturated, so the second-order polynomial of pdf.js is used instead,
+// whose coefficients its authors fitted to samples of the U.S. Web Coated
+// (SWOP) profile. It is exact for white and keeps every primary and
+// secondary close to its printed appearance.
+//
+// The coefficients are taken from src/core/colorspace.js of pdf.js,
+// Copyright Mozilla Foundation, licensed under the Apache License 2.0
+// (https://www.apache.org/licenses/LICENSE-2.0).
+func CMYKToRGB(c, m, y, k float64) (float64, float64, float64) {
+	r := 255 +
+		c*(-4.387332384609988*c+54.48615194189176*m+18.82290502165302*y+212.25662451639585*k-285.2331026137004) +
+		m*(1.7149763477362134*m-5.6096736904047315*y-17.873870861415444*k-5.497006427196366) +
+		y*(-2.5217340131683033*y-21.248923337353073*k+17.5119270841813) +
+		k*(-21.86122147463605*k-189.48180835922747)
+
+	g := 255 +
+		c*(8.841041422036149*c+60.118027045597366*m+6.871425592049007*y+31.159100130055922*k-79.2970844816548) +
+		m*(-15.310361306967817*m+17.575251261109482*y+131.35250912493976*k-190.9453302588951) +
+		y*(4.444339102852739*y+9.8632861493405*k-24.86741582555878) +
+		k*(-20.737325471181034*k-187.80453709719578)
+
+	b := 255 +
+		c*(0.8842522430003296*c+8.078677503112928*m+30.89978309703729*y-0.23883238689178934*k-14.183576799673286) +
+		m*(10.49593273432072*m+63.02378494754052*y+50.606957656360734*k-112.23884253719248) +
+		y*(0.03296041114873217*y+115.60384449646641*k-193.58209356861505) +
+		k*(-22.33816807309886*k-180.12613974708367)
+
+	return util.Clamp(r/255, 0, 1), util.Clamp(g/255, 0, 1), util.Clamp(b/255, 0, 1)
+}
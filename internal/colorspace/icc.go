@@ -0,0 +1,241 @@
+package colorspace
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// ICCProfile is a parsed ICC colour profile. Only the matrix/TRC and gray TRC
+// models are evaluated; profiles built on lookup tables report false from
+// CanConvert and are rendered through their alternate space instead.
+type ICCProfile struct {
+	// ColorSpace is the data colour space signature, such as "RGB " or
+	// "GRAY".
+	ColorSpace string
+
+	// PCS is the profile connection space signature, "XYZ " or "Lab ".
+	PCS string
+
+	gray   *iccCurve
+	trc    [3]*iccCurve
+	matrix matrix3
+	conv   xyzConverter
+}
+
+const iccHeaderSize = 128
+
+// ParseICCProfile parses the header and tag table of an ICC profile.
+func ParseICCProfile(data []byte) (*ICCProfile, error) {
+	if len(data) < iccHeaderSize+4 {
+		return nil, fmt.Errorf("ICC profile too short")
+	}
+	if string(data[36:40]) != "acsp" {
+		return nil, fmt.Errorf("missing ICC profile signature")
+	}
+
+	p := &ICCProfile{
+		ColorSpace: string(data[16:20]),
+		PCS:        string(data[20:24]),
+		conv:       newXYZConverter(whiteD50),
+	}
+
+	tags, err := iccTags(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, ok := tags["kTRC"]; ok {
+		if p.gray, err = parseICCCurve(b); err != nil {
+			return nil, fmt.Errorf("kTRC: %w", err)
+		}
+	}
+
+	for i, sig := range [3]string{"r", "g", "b"} {
+		if b, ok := tags[sig+"TRC"]; ok {
+			if p.trc[i], err = parseICCCurve(b); err != nil {
+				return nil, fmt.Errorf("%sTRC: %w", sig, err)
+			}
+		}
+
+		b, ok := tags[sig+"XYZ"]
+		if !ok {
+			continue
+		}
+		xyz, err := parseICCXYZ(b)
+		if err != nil {
+			return nil, fmt.Errorf("%sXYZ: %w", sig, err)
+		}
+		for j := range 3 {
+			p.matrix[j][i] = xyz[j]
+		}
+	}
+
+	return p, nil
+}
+
+// iccTags returns the data of each tag keyed by its signature.
+func iccTags(data []byte) (map[string][]byte, error) {
+	count := int(binary.BigEndian.Uint32(data[iccHeaderSize:]))
+	if count > (len(data)-iccHeaderSize-4)/12 {
+		return nil, fmt.Errorf("ICC tag table exceeds profile")
+	}
+
+	tags := make(map[string][]byte, count)
+	for i := range count {
+		e := data[iccHeaderSize+4+12*i:]
+		off := int(binary.BigEndian.Uint32(e[4:]))
+		size := int(binary.BigEndian.Uint32(e[8:]))
+		if off < 0 || size < 0 || off > len(data) || size > len(data)-off {
+			return nil, fmt.Errorf("ICC tag %q out of range", e[:4])
+		}
+		tags[string(e[:4])] = data[off : off+size]
+	}
+
+	return tags, nil
+}
+
+// CanConvert reports whether the profile describes n components with a model
+// this package evaluates.
+func (p *ICCProfile) CanConvert(n int) bool {
+	if p.PCS != "XYZ " {
+		return false
+	}
+
+	switch n {
+	case 1:
+		return p.ColorSpace == "GRAY" && p.gray != nil
+	case 3:
+		return p.ColorSpace == "RGB " && p.trc[0] != nil && p.trc[1] != nil && p.trc[2] != nil
+	default:
+		return false
+	}
+}
+
+// RGB converts a colour value through the profile to sRGB.
+func (p *ICCProfile) RGB(comps []float64) (float64, float64, float64) {
+	if p.ColorSpace == "GRAY" {
+		y := p.gray.eval(util.Clamp(component(comps, 0), 0, 1))
+		return p.conv.rgb([3]float64{whiteD50[0] * y, whiteD50[1] * y, whiteD50[2] * y})
+	}
+
+	var lin [3]float64
+	for i := range 3 {
+		lin[i] = p.trc[i].eval(util.Clamp(component(comps, i), 0, 1))
+	}
+	return p.conv.rgb(p.matrix.apply(lin))
+}
+
+// s15Fixed16 decodes a signed 15.16 fixed-point number.
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func parseICCXYZ(b []byte) ([3]float64, error) {
+	if len(b) < 20 || string(b[:4]) != "XYZ " {
+		return [3]float64{}, fmt.Errorf("invalid XYZ tag")
+	}
+	return [3]float64{s15Fixed16(b[8:]), s15Fixed16(b[12:]), s15Fixed16(b[16:])}, nil
+}
+
+// iccCurve is a tone reproduction curve: either a sampled table or a
+// parametric curve.
+type iccCurve struct {
+	table  []float64
+	kind   int
+	params [7]float64
+}
+
+// parametricParams is the number of parameters of each parametricCurveType
+// function.
+var parametricParams = [...]int{1, 3, 4, 5, 7}
+
+func parseICCCurve(b []byte) (*iccCurve, error) {
+	if len(b) < 12 {
+		return nil, fmt.Errorf("curve tag too short")
+	}
+
+	switch string(b[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(b[8:]))
+		switch {
+		case n == 0:
+			return &iccCurve{kind: 0, params: [7]float64{1}}, nil
+		case n == 1:
+			// A single entry is a gamma in u8Fixed8 format.
+			if len(b) < 14 {
+				return nil, fmt.Errorf("curve tag too short")
+			}
+			return &iccCurve{kind: 0, params: [7]float64{float64(binary.BigEndian.Uint16(b[12:])) / 256}}, nil
+		case n > (len(b)-12)/2:
+			return nil, fmt.Errorf("curve table exceeds tag")
+		}
+
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(b[12+2*i:])) / 65535
+		}
+		return &iccCurve{table: table}, nil
+
+	case "para":
+		kind := int(binary.BigEndian.Uint16(b[8:]))
+		if kind >= len(parametricParams) {
+			return nil, fmt.Errorf("unknown parametric curve type %d", kind)
+		}
+		n := parametricParams[kind]
+		if len(b) < 12+4*n {
+			return nil, fmt.Errorf("parametric curve too short")
+		}
+
+		c := &iccCurve{kind: kind}
+		for i := range n {
+			c.params[i] = s15Fixed16(b[12+4*i:])
+		}
+		return c, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported curve type %q", b[:4])
+	}
+}
+
+func (c *iccCurve) eval(x float64) float64 {
+	if c.table != nil {
+		if len(c.table) == 1 {
+			return c.table[0]
+		}
+		pos := x * float64(len(c.table)-1)
+		i := min(int(pos), len(c.table)-2)
+		return util.Lerp(c.table[i], c.table[i+1], pos-float64(i))
+	}
+
+	g, a, b, cc, d, e, f := c.params[0], c.params[1], c.params[2], c.params[3], c.params[4], c.params[5], c.params[6]
+
+	var y float64
+	switch c.kind {
+	case 0:
+		y = math.Pow(x, g)
+	case 1:
+		if x >= -b/a {
+			y = math.Pow(a*x+b, g)
+		}
+	case 2:
+		y = cc
+		if x >= -b/a {
+			y = math.Pow(a*x+b, g) + cc
+		}
+	case 3:
+		y = cc * x
+		if x >= d {
+			y = math.Pow(a*x+b, g)
+		}
+	case 4:
+		y = cc*x + f
+		if x >= d {
+			y = math.Pow(a*x+b, g) + e
+		}
+	}
+
+	return util.Clamp(y, 0, 1)
+}
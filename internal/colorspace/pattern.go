@@ -0,0 +1,58 @@
+package colorspace
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// PatternSpace is the Pattern colour space. A colour in it names a pattern
+// resource; uncoloured tiling patterns also take components in the
+// underlying space, which is nil for coloured patterns and shadings.
+type PatternSpace struct {
+	Underlying ColorSpace
+}
+
+func parsePattern(args model.PDFArray, r model.Resolver, depth int) (ColorSpace, error) {
+	if len(args) == 1 {
+		return &PatternSpace{}, nil
+	}
+
+	base, err := parse(args[1], r, depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("pattern underlying space: %w", err)
+	}
+	if _, ok := base.(*PatternSpace); ok {
+		return nil, fmt.Errorf("pattern underlying space may not be a pattern space")
+	}
+
+	return &PatternSpace{Underlying: base}, nil
+}
+
+func (*PatternSpace) Family() string { return "Pattern" }
+
+func (cs *PatternSpace) NComponents() int {
+	if cs.Underlying == nil {
+		return 0
+	}
+	return cs.Underlying.NComponents()
+}
+
+func (cs *PatternSpace) InitialColor() []float64 {
+	if cs.Underlying == nil {
+		return nil
+	}
+	return cs.Underlying.InitialColor()
+}
+
+// DefaultDecode returns nil: images cannot use a pattern space.
+func (*PatternSpace) DefaultDecode(int) []float64 { return nil }
+
+// RGB converts the components of an uncoloured pattern's colour; colours
+// without components convert to black.
+func (cs *PatternSpace) RGB(comps []float64) (float64, float64, float64) {
+	if cs.Underlying == nil {
+		return 0, 0, 0
+	}
+	return cs.Underlying.RGB(comps)
+}
@@ -0,0 +1,284 @@
+package colorspace
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/function"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+type iccBased struct {
+	n       int
+	rng     []float64
+	profile *ICCProfile
+
+	// alt is used when the profile cannot be evaluated.
+	alt ColorSpace
+}
+
+func parseICCBased(args model.PDFArray, r model.Resolver, depth int) (ColorSpace, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("missing ICC profile stream")
+	}
+
+	stream, ok := r.Resolve(args[1]).(model.PDFStream)
+	if !ok {
+		return nil, fmt.Errorf("ICC profile is not a stream: %v", args[1])
+	}
+
+	n, ok := r.Resolve(stream.Dict["N"]).(model.PDFNumber)
+	if !ok || (n != 1 && n != 3 && n != 4) {
+		return nil, fmt.Errorf("invalid ICC component count %v", stream.Dict["N"])
+	}
+
+	cs := &iccBased{n: int(n), rng: linearDecode(int(n))}
+	if v, ok := model.ResolveNumbers(stream.Dict["Range"], r); ok && len(v) == 2*cs.n {
+		cs.rng = v
+	}
+
+	// A profile that fails to decode or parse is not an error: the
+	// alternate space describes the same colours less precisely.
+	if data, err := parser.DecodeStream(stream, r); err == nil {
+		if p, err := ParseICCProfile(data); err == nil && p.CanConvert(cs.n) {
+			cs.profile = p
+			return cs, nil
+		}
+	}
+
+	if alt, ok := stream.Dict["Alternate"]; ok {
+		a, err := parse(alt, r, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("ICC alternate: %w", err)
+		}
+		if a.NComponents() != cs.n {
+			return nil, fmt.Errorf("ICC alternate has %d components, expected %d", a.NComponents(), cs.n)
+		}
+		cs.alt = a
+		return cs, nil
+	}
+
+	cs.alt = [...]ColorSpace{1: DeviceGray, 3: DeviceRGB, 4: DeviceCMYK}[cs.n]
+	return cs, nil
+}
+
+func (*iccBased) Family() string      { return "ICCBased" }
+func (cs *iccBased) NComponents() int { return cs.n }
+
+func (cs *iccBased) InitialColor() []float64 {
+	c := make([]float64, cs.n)
+	for i := range c {
+		c[i] = util.Clamp(0, cs.rng[2*i], cs.rng[2*i+1])
+	}
+	return c
+}
+
+func (cs *iccBased) DefaultDecode(int) []float64 { return cs.rng }
+
+func (cs *iccBased) RGB(comps []float64) (float64, float64, float64) {
+	if cs.profile != nil {
+		return cs.profile.RGB(comps)
+	}
+	return cs.alt.RGB(comps)
+}
+
+type indexed struct {
+	base   ColorSpace
+	hival  int
+	lookup []byte
+}
+
+func parseIndexed(args model.PDFArray, r model.Resolver, depth int) (ColorSpace, error) {
+	if len(args) != 4 {
+		return nil, fmt.Errorf("indexed colour space has %d elements, expected 4", len(args))
+	}
+
+	base, err := parse(args[1], r, depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("indexed base: %w", err)
+	}
+	if _, ok := base.(*indexed); ok {
+		return nil, fmt.Errorf("indexed base may not be indexed")
+	}
+
+	hival, ok := r.Resolve(args[2]).(model.PDFNumber)
+	if !ok || hival < 0 || hival > 255 {
+		return nil, fmt.Errorf("invalid indexed hival %v", args[2])
+	}
+
+	var lookup []byte
+	switch v := r.Resolve(args[3]).(type) {
+	case model.PDFStream:
+		if lookup, err = parser.DecodeStream(v, r); err != nil {
+			return nil, fmt.Errorf("indexed lookup: %w", err)
+		}
+	default:
+		if lookup, ok = parser.StringBytes(v); !ok {
+			return nil, fmt.Errorf("invalid indexed lookup %v", v)
+		}
+	}
+
+	// Short tables occur in the wild; missing entries read as zero.
+	if need := (int(hival) + 1) * base.NComponents(); len(lookup) < need {
+		lookup = append(lookup, make([]byte, need-len(lookup))...)
+	}
+
+	return &indexed{base: base, hival: int(hival), lookup: lookup}, nil
+}
+
+func (*indexed) Family() string          { return "Indexed" }
+func (*indexed) NComponents() int        { return 1 }
+func (*indexed) InitialColor() []float64 { return []float64{0} }
+
+func (*indexed) DefaultDecode(bpc int) []float64 {
+	return []float64{0, float64(int(1)<<bpc - 1)}
+}
+
+// entry returns the base colour value of palette entry i.
+func (cs *indexed) entry(i int) []float64 {
+	n := cs.base.NComponents()
+	i = max(0, min(i, cs.hival))
+
+	// Palette bytes map onto the base space's default component ranges.
+	dec := cs.base.DefaultDecode(8)
+	comps := make([]float64, n)
+	for j := range comps {
+		comps[j] = util.Interpolate(float64(cs.lookup[i*n+j]), 0, 255, dec[2*j], dec[2*j+1])
+	}
+	return comps
+}
+
+func (cs *indexed) RGB(comps []float64) (float64, float64, float64) {
+	return cs.base.RGB(cs.entry(int(math.Round(component(comps, 0)))))
+}
+
+// TintTransform maps the colourant tints of a Separation or DeviceN colour
+// to components of its alternate space.
+type TintTransform func(tints []float64) []float64
+
+// separation implements both Separation, which has a single colourant, and
+// DeviceN.
+type separation struct {
+	family string
+	names  []string
+	alt    ColorSpace
+	tint   TintTransform
+
+	// none is set when every colourant is None, which paints nothing.
+	none bool
+}
+
+func parseSeparation(args model.PDFArray, r model.Resolver, depth int) (ColorSpace, error) {
+	if len(args) != 4 {
+		return nil, fmt.Errorf("separation colour space has %d elements, expected 4", len(args))
+	}
+
+	name, ok := r.Resolve(args[1]).(model.PDFName)
+	if !ok {
+		return nil, fmt.Errorf("colourant name is not a name: %v", args[1])
+	}
+
+	return newSeparation("Separation", []string{string(name)}, args[2], args[3], r, depth)
+}
+
+func parseDeviceN(args model.PDFArray, r model.Resolver, depth int) (ColorSpace, error) {
+	if len(args) < 4 || len(args) > 5 {
+		return nil, fmt.Errorf("DeviceN colour space has %d elements, expected 4 or 5", len(args))
+	}
+
+	arr, ok := r.Resolve(args[1]).(model.PDFArray)
+	if !ok || len(arr) == 0 {
+		return nil, fmt.Errorf("invalid DeviceN colourant names %v", args[1])
+	}
+
+	names := make([]string, len(arr))
+	for i, v := range arr {
+		n, ok := r.Resolve(v).(model.PDFName)
+		if !ok {
+			return nil, fmt.Errorf("colourant name is not a name: %v", v)
+		}
+		names[i] = string(n)
+	}
+
+	return newSeparation("DeviceN", names, args[2], args[3], r, depth)
+}
+
+func newSeparation(family string, names []string, altv, fn model.PDFValue, r model.Resolver, depth int) (ColorSpace, error) {
+	alt, err := parse(altv, r, depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s alternate: %w", family, err)
+	}
+
+	tint := parseTintTransform(fn, r, len(names), alt)
+	cs := &separation{family: family, names: names, alt: alt, tint: tint, none: true}
+	for _, n := range names {
+		cs.none = cs.none && n == "None"
+	}
+	return cs, nil
+}
+
+// PaintsNothing reports whether painting in a colour space leaves no marks,
+// as in Separation and DeviceN spaces whose colourants are all None
+// (PDF 32000-1 8.6.6.4).
+func PaintsNothing(cs ColorSpace) bool {
+	s, ok := cs.(*separation)
+	return ok && s.none
+}
+
+// parseTintTransform returns the tint transform of a Separation or DeviceN
+// space. A function that cannot be evaluated, or whose dimensions do not
+// match the space, is replaced by a gray approximation so that the content
+// still renders.
+func parseTintTransform(fn model.PDFValue, r model.Resolver, n int, alt ColorSpace) TintTransform {
+	f, err := function.Parse(fn, r)
+	if err != nil || f.NumInputs() != n || f.NumOutputs() != alt.NComponents() {
+		return approximateTint(alt)
+	}
+	return f.Evaluate
+}
+
+// approximateTint renders the strongest of the tints as a gray level in alt.
+func approximateTint(alt ColorSpace) TintTransform {
+	return func(tints []float64) []float64 {
+		t := 0.0
+		for _, v := range tints {
+			t = max(t, util.Clamp(v, 0, 1))
+		}
+
+		switch {
+		case alt.Family() == "Lab":
+			return []float64{100 * (1 - t), 0, 0}
+		case alt.NComponents() == 1:
+			return []float64{1 - t}
+		case alt.NComponents() == 3:
+			return []float64{1 - t, 1 - t, 1 - t}
+		case alt.NComponents() == 4:
+			return []float64{0, 0, 0, t}
+		default:
+			return alt.InitialColor()
+		}
+	}
+}
+
+func (cs *separation) Family() string   { return cs.family }
+func (cs *separation) NComponents() int { return len(cs.names) }
+
+func (cs *separation) InitialColor() []float64 {
+	c := make([]float64, len(cs.names))
+	for i := range c {
+		c[i] = 1
+	}
+	return c
+}
+
+func (cs *separation) DefaultDecode(int) []float64 { return linearDecode(len(cs.names)) }
+
+func (cs *separation) RGB(comps []float64) (float64, float64, float64) {
+	tints := make([]float64, len(cs.names))
+	for i := range tints {
+		tints[i] = util.Clamp(component(comps, i), 0, 1)
+	}
+	return cs.alt.RGB(cs.tint(tints))
+}
@@ -0,0 +1,108 @@
+package font
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// afmMetrics holds the parts of an Adobe Font Metrics file that text layout
+// uses. Widths and kerning amounts are in glyph space.
+type afmMetrics struct {
+	fontName string
+
+	// widths maps glyph names to advance widths.
+	widths map[string]float64
+
+	// encoding is the font's built-in encoding, from the codes of its
+	// character metrics; unencoded codes map to "".
+	encoding [256]string
+
+	// kern maps pairs of glyph names to the adjustment of the space
+	// between them.
+	kern map[[2]string]float64
+}
+
+// parseAFM parses an AFM file (Adobe Technical Note 5004). Sections other
+// than the character metrics and kerning pairs are skipped.
+func parseAFM(data []byte) (*afmMetrics, error) {
+	m := &afmMetrics{
+		widths: make(map[string]float64),
+		kern:   make(map[[2]string]float64),
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "FontName":
+			if len(fields) > 1 {
+				m.fontName = fields[1]
+			}
+		case "C":
+			if err := m.parseCharMetrics(sc.Text()); err != nil {
+				return nil, err
+			}
+		case "KPX":
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("invalid kerning pair %q", sc.Text())
+			}
+			x, err := strconv.ParseFloat(fields[3], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid kerning pair %q", sc.Text())
+			}
+			m.kern[[2]string{fields[1], fields[2]}] = x
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if m.fontName == "" {
+		return nil, fmt.Errorf("AFM file without a FontName")
+	}
+	return m, nil
+}
+
+// parseCharMetrics parses one line of character metrics, a list of
+// semicolon-separated keys with their values such as
+// "C 65 ; WX 667 ; N A ; B 14 0 654 718 ;".
+func (m *afmMetrics) parseCharMetrics(line string) error {
+	code := -1
+	width, name := 0.0, ""
+
+	for _, item := range strings.Split(line, ";") {
+		f := strings.Fields(item)
+		if len(f) < 2 {
+			continue
+		}
+
+		var err error
+		switch f[0] {
+		case "C":
+			code, err = strconv.Atoi(f[1])
+		case "WX":
+			width, err = strconv.ParseFloat(f[1], 64)
+		case "N":
+			name = f[1]
+		}
+		if err != nil {
+			return fmt.Errorf("invalid character metrics %q", line)
+		}
+	}
+
+	if name == "" {
+		return fmt.Errorf("character metrics without a name %q", line)
+	}
+	m.widths[name] = width
+	if code >= 0 && code < 256 {
+		m.encoding[code] = name
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package font
+
+import "github.com/Kantha2004/go-pdfviewer/internal/render"
+
+// cffGlyphs maps the codes of a simple font with a bare CFF program to
+// glyphs by the names the encoding gives them. Names the program lacks
+// select .notdef, glyph 0.
+func (f *Font) cffGlyphs() *[256]int {
+	var glyphs [256]int
+	for code, name := range f.encoding {
+		glyphs[code], _ = f.cff.GlyphByName(name)
+	}
+	return &glyphs
+}
+
+// cffGlyph returns the outline and width of the glyph of the CFF program
+// with an index, in glyph space.
+func (f *Font) cffGlyph(gid int) (*render.Path, float64, bool) {
+	g, err := f.cff.Glyph(gid)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	// The glyph's matrix maps charstring space to text space, which is
+	// 1000 units of glyph space.
+	m := g.Matrix.Multiply(render.Scale(1000, 1000))
+	return g.Outline.Transform(m), g.Width * m[0], true
+}
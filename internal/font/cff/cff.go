@@ -0,0 +1,385 @@
+// Package cff reads Compact Font Format programs embedded in PDF files with
+// /FontFile3, and the CFF and CFF2 tables of OpenType fonts: their charsets
+// and built-in encodings, and the outlines and widths their Type 2
+// charstrings draw.
+package cff
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// Font is the first font of a parsed CFF or CFF2 program.
+type Font struct {
+	Name string
+
+	// FontMatrix maps charstring space to text space. The font dicts of
+	// CID-keyed fonts may modify it for their glyphs, so Glyph returns the
+	// matrix of each glyph.
+	FontMatrix render.Matrix
+
+	// CIDKeyed is set for fonts whose charset gives CIDs rather than
+	// glyph names.
+	CIDKeyed bool
+
+	// Encoding is the built-in encoding, mapping codes to glyph names.
+	// Unencoded codes map to "", as do all codes of fonts that use the
+	// predefined Standard or Expert encodings, and of CID-keyed and CFF2
+	// fonts, which have no encoding.
+	Encoding [256]string
+
+	charStrings [][]byte
+	gsubrs      [][]byte
+
+	// names and cids map the glyph names or CIDs of the charset to glyph
+	// indices. CFF2 fonts have neither.
+	names map[string]int
+	cids  map[int]int
+
+	// fds holds the font dicts with the private data of the glyphs, which
+	// fdSelect assigns them; fonts with a single font dict have no
+	// fdSelect.
+	fds      []fontDict
+	fdSelect []int
+
+	// hasMatrix is set if the top DICT gives a FontMatrix, which font
+	// dicts with matrices of their own then modify.
+	hasMatrix bool
+
+	// cff2 is set for CFF2 fonts, whose regions give the number of
+	// variation regions of each item variation data.
+	cff2    bool
+	regions []int
+}
+
+// fontDict is the private data that charstrings use.
+type fontDict struct {
+	matrix    render.Matrix
+	hasMatrix bool
+
+	subrs        [][]byte
+	defaultWidth float64
+	nominalWidth float64
+	vsindex      int
+}
+
+// DICT operators; those of two bytes are escape (12) operators plus 1200.
+const (
+	opCharset        = 15
+	opEncoding       = 16
+	opCharStrings    = 17
+	opPrivate        = 18
+	opSubrs          = 19
+	opDefaultWidthX  = 20
+	opNominalWidthX  = 21
+	opVSIndex        = 22
+	opBlend          = 23
+	opVStore         = 24
+	opCharstringType = 1200 + 6
+	opFontMatrix     = 1200 + 7
+	opROS            = 1200 + 30
+	opFDArray        = 1200 + 36
+	opFDSelect       = 1200 + 37
+)
+
+// Parse parses a CFF or CFF2 program. Only its first font is read, as PDF
+// files embed one font per program.
+func Parse(data []byte) (*Font, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("CFF program too short")
+	}
+	switch data[0] {
+	case 1:
+		return parseCFF(data)
+	case 2:
+		return parseCFF2(data)
+	}
+	return nil, fmt.Errorf("unknown CFF version %d", data[0])
+}
+
+// parseCFF parses a CFF program: a header followed by the Name, Top DICT,
+// String and Global Subr INDEXes.
+func parseCFF(data []byte) (*Font, error) {
+	names, off, err := readIndex(data, int(data[2]), 2)
+	if err != nil {
+		return nil, fmt.Errorf("Name INDEX: %w", err)
+	}
+	topDicts, off, err := readIndex(data, off, 2)
+	if err != nil {
+		return nil, fmt.Errorf("Top DICT INDEX: %w", err)
+	}
+	strs, off, err := readIndex(data, off, 2)
+	if err != nil {
+		return nil, fmt.Errorf("String INDEX: %w", err)
+	}
+	gsubrs, _, err := readIndex(data, off, 2)
+	if err != nil {
+		return nil, fmt.Errorf("Global Subr INDEX: %w", err)
+	}
+	if len(names) == 0 || len(topDicts) == 0 {
+		return nil, fmt.Errorf("CFF program has no fonts")
+	}
+
+	top, err := parseDict(topDicts[0], nil)
+	if err != nil {
+		return nil, fmt.Errorf("Top DICT: %w", err)
+	}
+	if t := top[opCharstringType]; len(t) == 1 && t[0] != 2 {
+		return nil, fmt.Errorf("unsupported charstring type %v", t[0])
+	}
+
+	f := &Font{Name: string(names[0]), gsubrs: gsubrs}
+	if err := f.readTop(data, top, 2); err != nil {
+		return nil, err
+	}
+	_, f.CIDKeyed = top[opROS]
+
+	charset, err := readCharset(data, top.int(opCharset, 0), len(f.charStrings), f.CIDKeyed)
+	if err != nil {
+		return nil, fmt.Errorf("charset: %w", err)
+	}
+	if f.CIDKeyed {
+		f.cids = make(map[int]int, len(charset))
+		for gid, cid := range charset {
+			f.cids[cid] = gid
+		}
+		return f, f.readFDs(data, top, 2)
+	}
+
+	sid := func(sid int) string {
+		if sid < len(standardStrings) {
+			return standardStrings[sid]
+		}
+		if i := sid - len(standardStrings); i < len(strs) {
+			return string(strs[i])
+		}
+		return ""
+	}
+	f.names = make(map[string]int, len(charset))
+	for gid, s := range charset {
+		f.names[sid(s)] = gid
+	}
+	if err := f.readEncoding(data, top.int(opEncoding, 0), charset, sid); err != nil {
+		return nil, fmt.Errorf("encoding: %w", err)
+	}
+
+	fd, err := f.readPrivate(data, top[opPrivate], 2)
+	if err != nil {
+		return nil, err
+	}
+	f.fds = []fontDict{fd}
+	return f, nil
+}
+
+// parseCFF2 parses a CFF2 program: a header followed by the Top DICT and
+// the Global Subr INDEX.
+func parseCFF2(data []byte) (*Font, error) {
+	if len(data) < 5 || int(data[2]) < 5 {
+		return nil, fmt.Errorf("CFF2 header too short")
+	}
+	hdrSize, topSize := int(data[2]), int(binary.BigEndian.Uint16(data[3:]))
+	if hdrSize+topSize > len(data) {
+		return nil, fmt.Errorf("Top DICT out of range")
+	}
+	top, err := parseDict(data[hdrSize:hdrSize+topSize], nil)
+	if err != nil {
+		return nil, fmt.Errorf("Top DICT: %w", err)
+	}
+	gsubrs, _, err := readIndex(data, hdrSize+topSize, 4)
+	if err != nil {
+		return nil, fmt.Errorf("Global Subr INDEX: %w", err)
+	}
+
+	f := &Font{gsubrs: gsubrs, cff2: true}
+	if off, ok := top[opVStore]; ok && len(off) == 1 {
+		if f.regions, err = readVariationStore(data, int(off[0])); err != nil {
+			return nil, fmt.Errorf("VariationStore: %w", err)
+		}
+	}
+	if err := f.readTop(data, top, 4); err != nil {
+		return nil, err
+	}
+	return f, f.readFDs(data, top, 4)
+}
+
+// readTop reads the font matrix and charstrings a Top DICT gives.
+func (f *Font) readTop(data []byte, top dict, countSize int) error {
+	f.FontMatrix = render.Scale(0.001, 0.001)
+	if m := top[opFontMatrix]; len(m) == 6 {
+		f.FontMatrix, f.hasMatrix = render.Matrix(m), true
+	}
+
+	off, ok := top[opCharStrings]
+	if !ok || len(off) != 1 {
+		return fmt.Errorf("font has no charstrings")
+	}
+	var err error
+	f.charStrings, _, err = readIndex(data, int(off[0]), countSize)
+	if err != nil {
+		return fmt.Errorf("CharStrings INDEX: %w", err)
+	}
+	if len(f.charStrings) == 0 {
+		return fmt.Errorf("font has no charstrings")
+	}
+	return nil
+}
+
+// readFDs reads the font dicts of a CID-keyed or CFF2 font and the
+// FDSelect that assigns them to glyphs.
+func (f *Font) readFDs(data []byte, top dict, countSize int) error {
+	off, ok := top[opFDArray]
+	if !ok || len(off) != 1 {
+		return fmt.Errorf("font has no FDArray")
+	}
+	dicts, _, err := readIndex(data, int(off[0]), countSize)
+	if err != nil {
+		return fmt.Errorf("FDArray: %w", err)
+	}
+	if len(dicts) == 0 {
+		return fmt.Errorf("empty FDArray")
+	}
+
+	for _, b := range dicts {
+		d, err := parseDict(b, nil)
+		if err != nil {
+			return fmt.Errorf("font dict: %w", err)
+		}
+		fd, err := f.readPrivate(data, d[opPrivate], countSize)
+		if err != nil {
+			return err
+		}
+		if m := d[opFontMatrix]; len(m) == 6 {
+			fd.matrix, fd.hasMatrix = render.Matrix(m), true
+		}
+		f.fds = append(f.fds, fd)
+	}
+
+	if off, ok := top[opFDSelect]; ok && len(off) == 1 {
+		f.fdSelect, err = readFDSelect(data, int(off[0]), len(f.charStrings), len(f.fds))
+		if err != nil {
+			return fmt.Errorf("FDSelect: %w", err)
+		}
+	} else if len(f.fds) > 1 {
+		return fmt.Errorf("font has no FDSelect")
+	}
+	return nil
+}
+
+// readPrivate reads the Private DICT that the size and offset of a Private
+// operator locate, and its local subroutines. Fonts may have none.
+func (f *Font) readPrivate(data []byte, loc []float64, countSize int) (fontDict, error) {
+	var fd fontDict
+	if len(loc) != 2 {
+		return fd, nil
+	}
+	size, off := int(loc[0]), int(loc[1])
+	if size < 0 || off < 0 || off+size > len(data) {
+		return fd, fmt.Errorf("Private DICT out of range")
+	}
+	d, err := parseDict(data[off:off+size], f.regions)
+	if err != nil {
+		return fd, fmt.Errorf("Private DICT: %w", err)
+	}
+
+	fd.defaultWidth = d.float(opDefaultWidthX, 0)
+	fd.nominalWidth = d.float(opNominalWidthX, 0)
+	fd.vsindex = d.int(opVSIndex, 0)
+	if subrs, ok := d[opSubrs]; ok && len(subrs) == 1 {
+		// The offset is relative to the Private DICT.
+		if fd.subrs, _, err = readIndex(data, off+int(subrs[0]), countSize); err != nil {
+			return fd, fmt.Errorf("Local Subr INDEX: %w", err)
+		}
+	}
+	return fd, nil
+}
+
+// readUint reads a big-endian unsigned integer of n bytes.
+func readUint(b []byte, n int) int {
+	v := 0
+	for _, c := range b[:n] {
+		v = v<<8 | int(c)
+	}
+	return v
+}
+
+// readIndex reads the INDEX at an offset, whose count takes countSize
+// bytes: 2 in CFF and 4 in CFF2. It returns the items and the offset
+// following the INDEX.
+func readIndex(data []byte, off, countSize int) ([][]byte, int, error) {
+	if off < 0 || off+countSize > len(data) {
+		return nil, 0, fmt.Errorf("INDEX out of range")
+	}
+	count := readUint(data[off:], countSize)
+	off += countSize
+	if count == 0 {
+		return nil, off, nil
+	}
+
+	if off >= len(data) {
+		return nil, 0, fmt.Errorf("truncated INDEX")
+	}
+	offSize := int(data[off])
+	off++
+	if offSize < 1 || offSize > 4 {
+		return nil, 0, fmt.Errorf("invalid INDEX offset size %d", offSize)
+	}
+	if count >= (len(data)-off)/offSize {
+		return nil, 0, fmt.Errorf("truncated INDEX")
+	}
+
+	// Offsets are relative to the byte preceding the data.
+	base := off + (count+1)*offSize - 1
+	items := make([][]byte, count)
+	start := readUint(data[off:], offSize)
+	for i := range items {
+		end := readUint(data[off+(i+1)*offSize:], offSize)
+		if start < 1 || end < start || base+end > len(data) {
+			return nil, 0, fmt.Errorf("invalid INDEX offsets")
+		}
+		items[i] = data[base+start : base+end]
+		start = end
+	}
+	return items, base + start, nil
+}
+
+// readVariationStore reads the number of regions of each item variation
+// data of a CFF2 VariationStore, which blend operators need to find the
+// default values among their operands.
+func readVariationStore(data []byte, off int) ([]int, error) {
+	// The store follows a 2-byte length.
+	s := off + 2
+	if off < 0 || s+8 > len(data) {
+		return nil, fmt.Errorf("out of range")
+	}
+	n := int(binary.BigEndian.Uint16(data[s+6:]))
+	if s+8+4*n > len(data) {
+		return nil, fmt.Errorf("truncated")
+	}
+
+	regions := make([]int, n)
+	for i := range regions {
+		d := s + int(binary.BigEndian.Uint32(data[s+8+4*i:]))
+		if d+6 > len(data) {
+			return nil, fmt.Errorf("item variation data out of range")
+		}
+		regions[i] = int(binary.BigEndian.Uint16(data[d+4:]))
+	}
+	return regions, nil
+}
+
+// blend replaces the operands of a CFF2 blend operator on top of a stack
+// with their default values: n values, each followed by the deltas of the
+// regions, and n itself.
+func blend(stack []float64, regions int) ([]float64, error) {
+	if len(stack) == 0 {
+		return nil, fmt.Errorf("stack underflow")
+	}
+	n := int(stack[len(stack)-1])
+	stack = stack[:len(stack)-1]
+	if n < 0 || n*(regions+1) > len(stack) {
+		return nil, fmt.Errorf("stack underflow")
+	}
+	return stack[:len(stack)-n*regions], nil
+}
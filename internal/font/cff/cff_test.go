@@ -0,0 +1,216 @@
+package cff
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// glyphs.cff has a square A, a B with hints and a curve from a local
+// subroutine, an F drawn with flex, an Aacute built with endchar, a slash
+// from a global subroutine, and a glyph named in the String INDEX.
+// glyphs-cid.cff is CID-keyed, with two font dicts of different matrices,
+// and glyphs.cff2 a CFF2 font with blend operators.
+func parseTestFont(t *testing.T, name string) *Font {
+	t.Helper()
+	data, err := os.ReadFile("../../../testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(%s) error = %v", name, err)
+	}
+	return f
+}
+
+func TestParse(t *testing.T) {
+	f := parseTestFont(t, "glyphs.cff")
+	if f.Name != "Glyphs" || f.CIDKeyed || f.NumGlyphs() != 8 {
+		t.Errorf("Parse() = %q, CID-keyed %v, %d glyphs", f.Name, f.CIDKeyed, f.NumGlyphs())
+	}
+	if f.FontMatrix != render.Scale(0.001, 0.001) {
+		t.Errorf("FontMatrix = %v", f.FontMatrix)
+	}
+	// Aacute is encoded twice, once by a supplement.
+	for code, name := range map[int]string{'A': "A", 194: "acute", 201: "Aacute", 200: "Aacute", '/': "slash", 'C': ""} {
+		if f.Encoding[code] != name {
+			t.Errorf("Encoding[%d] = %q, expected %q", code, f.Encoding[code], name)
+		}
+	}
+	for name, gid := range map[string]int{".notdef": 0, "B": 2, "slash": 6, "custom": 7} {
+		if got, ok := f.GlyphByName(name); !ok || got != gid {
+			t.Errorf("GlyphByName(%q) = %d, %v, expected %d", name, got, ok, gid)
+		}
+	}
+
+	cid := parseTestFont(t, "glyphs-cid.cff")
+	if !cid.CIDKeyed {
+		t.Errorf("CIDKeyed = false")
+	}
+	for c, gid := range map[int]int{0: 0, 10: 1, 11: 2, 20: 3} {
+		if got, ok := cid.GlyphByCID(c); !ok || got != gid {
+			t.Errorf("GlyphByCID(%d) = %d, %v, expected %d", c, got, ok, gid)
+		}
+	}
+	if _, ok := cid.GlyphByCID(12); ok {
+		t.Errorf("GlyphByCID(12) found a glyph")
+	}
+
+	cff2 := parseTestFont(t, "glyphs.cff2")
+	if cff2.NumGlyphs() != 3 || cff2.fds[0].vsindex != 0 {
+		t.Errorf("Parse() of CFF2 = %d glyphs", cff2.NumGlyphs())
+	}
+
+	errors := [][]byte{
+		{1, 0},
+		{3, 0, 4, 4},
+		{1, 0, 4, 4, 0, 0},
+		{1, 0, 4, 4, 0, 1, 1, 1, 9},
+		{2, 0, 5, 0, 10},
+		{2, 0, 5, 0},
+		{2, 0, 4, 0, 0, 0, 0, 0, 0},
+	}
+	for _, data := range errors {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("Parse(%v) expected an error", data)
+		}
+	}
+}
+
+func TestGlyph(t *testing.T) {
+	line, cube, move, close := render.SegLineTo, render.SegCubeTo, render.SegMoveTo, render.SegClose
+	milli, doubled := render.Scale(0.001, 0.001), render.Scale(0.002, 0.002)
+	tests := []struct {
+		font     string
+		gid      int
+		width    float64
+		matrix   render.Matrix
+		kinds    []render.SegmentKind
+		min, max render.Point
+	}{
+		{"glyphs.cff", 1, 600, milli, []render.SegmentKind{move, line, line, line, close}, render.Point{X: 100}, render.Point{X: 600, Y: 500}},
+		// Hints come before the outline, which a subroutine draws.
+		{"glyphs.cff", 2, 700, milli, []render.SegmentKind{move, cube, line, close}, render.Point{}, render.Point{X: 300, Y: 300}},
+		{"glyphs.cff", 3, 600, milli, []render.SegmentKind{move, cube, cube, line, close}, render.Point{}, render.Point{X: 500, Y: 200}},
+		// The accent lies at (100, 0) from the base glyph.
+		{"glyphs.cff", 5, 600, milli, []render.SegmentKind{move, line, line, line, close, move, line, line, line, close},
+			render.Point{X: 100}, render.Point{X: 600, Y: 600}},
+		{"glyphs.cff", 6, 278, milli, []render.SegmentKind{move, line, line, close}, render.Point{}, render.Point{X: 500, Y: 300}},
+		{"glyphs.cff", 7, 400, milli, []render.SegmentKind{move, cube, cube, close}, render.Point{}, render.Point{X: 600, Y: 50}},
+		{"glyphs-cid.cff", 1, 1000, milli, []render.SegmentKind{move, line, line, line, close}, render.Point{}, render.Point{X: 200, Y: 100}},
+		{"glyphs-cid.cff", 2, 1000, milli, []render.SegmentKind{move, line, close}, render.Point{}, render.Point{X: 200, Y: 100}},
+		// The second font dict's matrix applies, and the width is given.
+		{"glyphs-cid.cff", 3, 100, doubled, []render.SegmentKind{move, line, line, line, close}, render.Point{}, render.Point{X: 100, Y: 100}},
+		// Blend operands resolve to their defaults; CFF2 glyphs have no
+		// width and no endchar.
+		{"glyphs.cff2", 1, 0, milli, []render.SegmentKind{move, line, line, line, close}, render.Point{X: 100}, render.Point{X: 600, Y: 500}},
+		{"glyphs.cff2", 2, 0, milli, []render.SegmentKind{move, line, line, close}, render.Point{}, render.Point{X: 100, Y: 100}},
+	}
+	for _, tc := range tests {
+		f := parseTestFont(t, tc.font)
+		g, err := f.Glyph(tc.gid)
+		if err != nil {
+			t.Errorf("%s: Glyph(%d) error = %v", tc.font, tc.gid, err)
+			continue
+		}
+		if g.Width != tc.width || g.Matrix != tc.matrix {
+			t.Errorf("%s: Glyph(%d) width = %v, matrix = %v, expected %v, %v", tc.font, tc.gid, g.Width, g.Matrix, tc.width, tc.matrix)
+		}
+		var kinds []render.SegmentKind
+		for _, s := range g.Outline.Segs {
+			kinds = append(kinds, s.Kind)
+		}
+		if !slices.Equal(kinds, tc.kinds) {
+			t.Errorf("%s: Glyph(%d) segments = %v, expected %v", tc.font, tc.gid, kinds, tc.kinds)
+		}
+		if min, max := g.Outline.Bounds(); min != tc.min || max != tc.max {
+			t.Errorf("%s: Glyph(%d) bounds = %v, %v, expected %v, %v", tc.font, tc.gid, min, max, tc.min, tc.max)
+		}
+	}
+}
+
+// op marks an operator among the numbers of charString.
+func op(n int) int {
+	return 1000 + n
+}
+
+// charString encodes numbers from -107 to 107 and operators as a Type 2
+// charstring.
+func charString(values ...int) []byte {
+	var b []byte
+	for _, v := range values {
+		switch {
+		case v >= op(32):
+			b = append(b, csEscape, byte(v-op(32)))
+		case v >= op(0):
+			b = append(b, byte(v-op(0)))
+		default:
+			b = append(b, byte(v+139))
+		}
+	}
+	return b
+}
+
+func TestCharStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		cs   []byte
+		end  render.Point
+		n    int
+	}{
+		{"hvcurveto", charString(0, 0, op(csRMoveTo), 10, 10, 10, 10, 10, 10, 10, 10, 5, op(csHVCurveTo)), render.Point{X: 40, Y: 45}, 4},
+		{"vhcurveto", charString(0, 0, op(csRMoveTo), 10, 10, 10, 10, op(csVHCurveTo)), render.Point{X: 20, Y: 20}, 3},
+		{"vvcurveto", charString(0, 0, op(csRMoveTo), 5, 10, 10, 10, 10, op(csVVCurveTo)), render.Point{X: 15, Y: 30}, 3},
+		{"hhcurveto", charString(0, 0, op(csRMoveTo), 5, 10, 10, 10, 10, op(csHHCurveTo)), render.Point{X: 30, Y: 15}, 3},
+		{"rcurveline", charString(0, 0, op(csRMoveTo), 1, 1, 1, 1, 1, 1, 5, 0, op(csRCurveLine)), render.Point{X: 8, Y: 3}, 4},
+		{"rlinecurve", charString(0, 0, op(csRMoveTo), 5, 0, 1, 1, 1, 1, 1, 1, op(csRLineCurve)), render.Point{X: 8, Y: 3}, 4},
+		{"vlineto", charString(0, 0, op(csRMoveTo), 10, 20, 30, op(csVLineTo)), render.Point{X: 20, Y: 40}, 5},
+		{"hflex1", charString(0, 0, op(csRMoveTo), 1, 1, 1, 1, 1, 1, 1, 1, 1, op(csHFlex1)), render.Point{X: 6}, 4},
+		{"flex1", charString(0, 0, op(csRMoveTo), 1, 0, 1, 0, 1, 1, 1, 0, 1, 0, 9, op(csFlex1)), render.Point{X: 14}, 4},
+	}
+	for _, tc := range tests {
+		f := &Font{charStrings: [][]byte{append(tc.cs, csEndChar)}, fds: []fontDict{{}}}
+		g, err := f.Glyph(0)
+		if err != nil {
+			t.Errorf("%s: Glyph() error = %v", tc.name, err)
+			continue
+		}
+		segs := g.Outline.Segs
+		if len(segs) != tc.n {
+			t.Errorf("%s: %d segments, expected %d", tc.name, len(segs), tc.n)
+			continue
+		}
+		// The segment before the closing one ends at its last point.
+		last, n := segs[len(segs)-2], 1
+		if last.Kind == render.SegCubeTo {
+			n = 3
+		}
+		if p := last.Pts[n-1]; p != tc.end {
+			t.Errorf("%s: ends at %v, expected %v", tc.name, p, tc.end)
+		}
+	}
+}
+
+func TestCharStringErrors(t *testing.T) {
+	f := &Font{charStrings: [][]byte{
+		charString(op(csRMoveTo)),
+		charString(0, op(csCallSubr)),
+		charString(-107, op(csCallGSubr)),
+		{csEscape},
+		{255, 0},
+		charString(1, op(csBlend)),
+		charString(op(2)),
+	}, fds: []fontDict{{subrs: [][]byte{charString(-107, op(csCallSubr))}}}}
+	f.gsubrs = [][]byte{charString(-107, op(csCallGSubr))}
+	for gid := range f.charStrings {
+		if _, err := f.Glyph(gid); err == nil {
+			t.Errorf("Glyph(%d) expected an error", gid)
+		}
+	}
+	if _, err := f.Glyph(len(f.charStrings)); err == nil {
+		t.Errorf("Glyph() out of range expected an error")
+	}
+}
@@ -0,0 +1,179 @@
+package cff
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// readCharset reads the charset at an offset, which gives the SIDs of the
+// glyph names of a font, or the CIDs of a CID-keyed font, in glyph order.
+// Offsets 0 to 2 of name-keyed fonts select the predefined ISOAdobe,
+// Expert and ExpertSubset charsets.
+func readCharset(data []byte, off, numGlyphs int, cidKeyed bool) ([]int, error) {
+	charset := make([]int, numGlyphs)
+	if !cidKeyed && off <= 2 {
+		var predefined []uint16
+		switch off {
+		case 1:
+			predefined = expertCharset[:]
+		case 2:
+			predefined = expertSubsetCharset[:]
+		}
+		for gid := range charset {
+			switch {
+			case off == 0:
+				charset[gid] = gid
+			case gid < len(predefined):
+				charset[gid] = int(predefined[gid])
+			}
+		}
+		return charset, nil
+	}
+
+	if off < 0 || off >= len(data) {
+		return nil, fmt.Errorf("out of range")
+	}
+	format, b := data[off], data[off+1:]
+
+	// Glyph 0 is always .notdef, or CID 0, and left out.
+	switch format {
+	case 0:
+		if len(b) < 2*(numGlyphs-1) {
+			return nil, fmt.Errorf("truncated")
+		}
+		for gid := 1; gid < numGlyphs; gid++ {
+			charset[gid] = int(binary.BigEndian.Uint16(b[2*(gid-1):]))
+		}
+	case 1, 2:
+		// Ranges give a first SID and the number of those following it.
+		size := 3 + int(format-1)
+		for gid := 1; gid < numGlyphs; {
+			if len(b) < size {
+				return nil, fmt.Errorf("truncated")
+			}
+			first, left := int(binary.BigEndian.Uint16(b)), readUint(b[2:], size-2)
+			for i := 0; i <= left && gid < numGlyphs; i++ {
+				charset[gid] = first + i
+				gid++
+			}
+			b = b[size:]
+		}
+	default:
+		return nil, fmt.Errorf("unknown charset format %d", format)
+	}
+	return charset, nil
+}
+
+// readEncoding reads the encoding at an offset, which maps codes to glyph
+// indices, into the built-in encoding of a font with the glyph names of
+// its charset. Offsets 0 and 1 select the predefined Standard and Expert
+// encodings, which leave the built-in encoding empty.
+func (f *Font) readEncoding(data []byte, off int, charset []int, sid func(int) string) error {
+	if off <= 1 {
+		return nil
+	}
+	if off >= len(data) {
+		return fmt.Errorf("out of range")
+	}
+	format, b := data[off], data[off+1:]
+
+	name := func(gid int) string {
+		if gid < len(charset) {
+			return sid(charset[gid])
+		}
+		return ""
+	}
+
+	// Codes are given for glyphs in order, starting with glyph 1.
+	gid := 1
+	switch format & 0x7f {
+	case 0:
+		if len(b) < 1 || len(b) < 1+int(b[0]) {
+			return fmt.Errorf("truncated")
+		}
+		for _, code := range b[1 : 1+int(b[0])] {
+			f.Encoding[code] = name(gid)
+			gid++
+		}
+		b = b[1+int(b[0]):]
+	case 1:
+		if len(b) < 1 || len(b) < 1+2*int(b[0]) {
+			return fmt.Errorf("truncated")
+		}
+		for i := range int(b[0]) {
+			first, left := int(b[1+2*i]), int(b[2+2*i])
+			for code := first; code <= first+left && code < 256; code++ {
+				f.Encoding[code] = name(gid)
+				gid++
+			}
+		}
+		b = b[1+2*int(b[0]):]
+	default:
+		return fmt.Errorf("unknown encoding format %d", format)
+	}
+
+	// Supplements encode further codes of glyphs by the SIDs of their
+	// names.
+	if format&0x80 != 0 {
+		if len(b) < 1 || len(b) < 1+3*int(b[0]) {
+			return fmt.Errorf("truncated supplements")
+		}
+		for i := range int(b[0]) {
+			s := b[1+3*i:]
+			f.Encoding[s[0]] = sid(int(binary.BigEndian.Uint16(s[1:])))
+		}
+	}
+	return nil
+}
+
+// readFDSelect reads the FDSelect at an offset, which gives the font dict
+// of each glyph.
+func readFDSelect(data []byte, off, numGlyphs, numFDs int) ([]int, error) {
+	if off < 0 || off >= len(data) {
+		return nil, fmt.Errorf("out of range")
+	}
+	format, b := data[off], data[off+1:]
+	fdSelect := make([]int, numGlyphs)
+
+	switch format {
+	case 0:
+		if len(b) < numGlyphs {
+			return nil, fmt.Errorf("truncated")
+		}
+		for gid := range fdSelect {
+			fdSelect[gid] = int(b[gid])
+		}
+	case 3, 4:
+		// Ranges give the first glyph and font dict of each range, and a
+		// sentinel the end of the last. Format 4 of CFF2 has wider fields.
+		gidSize, fdSize := 2, 1
+		if format == 4 {
+			gidSize, fdSize = 4, 2
+		}
+		if len(b) < gidSize {
+			return nil, fmt.Errorf("truncated")
+		}
+		n := readUint(b, gidSize)
+		b = b[gidSize:]
+		size := gidSize + fdSize
+		if n > (len(b)-gidSize)/size {
+			return nil, fmt.Errorf("truncated")
+		}
+		for i := range n {
+			first, fd := readUint(b[size*i:], gidSize), readUint(b[size*i+gidSize:], fdSize)
+			end := readUint(b[size*(i+1):], gidSize)
+			for gid := first; gid < end && gid < numGlyphs; gid++ {
+				fdSelect[gid] = fd
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown FDSelect format %d", format)
+	}
+
+	for _, fd := range fdSelect {
+		if fd >= numFDs {
+			return nil, fmt.Errorf("font dict %d out of range", fd)
+		}
+	}
+	return fdSelect, nil
+}
@@ -0,0 +1,527 @@
+package cff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/font/type1"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// Glyph is a glyph drawn by a Type 2 charstring, in charstring space.
+type Glyph struct {
+	Outline *render.Path
+
+	// Width is the advance width. CFF2 charstrings have none; the hmtx
+	// table of their OpenType font gives it.
+	Width float64
+
+	// Matrix maps charstring space to text space.
+	Matrix render.Matrix
+}
+
+// Limits of the charstring interpreter (Adobe Technical Note #5177,
+// Appendix B, and the CFF2 specification), which damaged programs could
+// otherwise exceed.
+const (
+	maxStack     = 48
+	maxStackCFF2 = 513
+	maxCallDepth = 10
+)
+
+// Charstring operators; those of two bytes are escape (12) operators plus
+// 32.
+const (
+	csHStem      = 1
+	csVStem      = 3
+	csVMoveTo    = 4
+	csRLineTo    = 5
+	csHLineTo    = 6
+	csVLineTo    = 7
+	csRRCurveTo  = 8
+	csCallSubr   = 10
+	csReturn     = 11
+	csEscape     = 12
+	csEndChar    = 14
+	csVSIndex    = 15
+	csBlend      = 16
+	csHStemHM    = 18
+	csHintMask   = 19
+	csCntrMask   = 20
+	csRMoveTo    = 21
+	csHMoveTo    = 22
+	csVStemHM    = 23
+	csRCurveLine = 24
+	csRLineCurve = 25
+	csVVCurveTo  = 26
+	csHHCurveTo  = 27
+	csShortInt   = 28
+	csCallGSubr  = 29
+	csVHCurveTo  = 30
+	csHVCurveTo  = 31
+	csDotSection = 32 + 0
+	csHFlex      = 32 + 34
+	csFlex       = 32 + 35
+	csHFlex1     = 32 + 36
+	csFlex1      = 32 + 37
+)
+
+// NumGlyphs returns the number of glyphs of the font.
+func (f *Font) NumGlyphs() int {
+	return len(f.charStrings)
+}
+
+// GlyphByName returns the index of the glyph of a name-keyed font with a
+// name.
+func (f *Font) GlyphByName(name string) (int, bool) {
+	gid, ok := f.names[name]
+	return gid, ok
+}
+
+// GlyphByCID returns the index of the glyph of a CID-keyed font with a CID.
+func (f *Font) GlyphByCID(cid int) (int, bool) {
+	gid, ok := f.cids[cid]
+	return gid, ok
+}
+
+// Glyph runs the charstring of a glyph. Hints are read but not applied.
+func (f *Font) Glyph(gid int) (*Glyph, error) {
+	if gid < 0 || gid >= len(f.charStrings) {
+		return nil, fmt.Errorf("glyph %d out of range", gid)
+	}
+	fd := &f.fds[0]
+	if f.fdSelect != nil {
+		fd = &f.fds[f.fdSelect[gid]]
+	}
+
+	d := &decoder{font: f, fd: fd, path: render.NewPath(), width: fd.defaultWidth, vsindex: fd.vsindex}
+	d.hasWidth = f.cff2
+	if err := d.run(f.charStrings[gid], 0); err != nil && err != errEndChar {
+		return nil, fmt.Errorf("glyph %d: %w", gid, err)
+	}
+	d.closePath()
+
+	m := f.FontMatrix
+	switch {
+	case fd.hasMatrix && f.hasMatrix:
+		m = fd.matrix.Multiply(f.FontMatrix)
+	case fd.hasMatrix:
+		m = fd.matrix
+	}
+	return &Glyph{Outline: d.path, Width: d.width, Matrix: m}, nil
+}
+
+// errEndChar ends the interpretation of a charstring.
+var errEndChar = fmt.Errorf("endchar")
+
+// decoder is the state of the charstring interpreter.
+type decoder struct {
+	font *Font
+	fd   *fontDict
+	path *render.Path
+
+	stack []float64
+
+	// x and y are the current point; origin offsets the points of an
+	// accent drawn by endchar. open is set while a subpath is open, which
+	// the next moveto closes.
+	x, y   float64
+	origin render.Point
+	open   bool
+
+	// nStems counts the stem hints, whose number gives the length of the
+	// masks of hintmask and cntrmask.
+	nStems int
+
+	width float64
+
+	// hasWidth is set once the first stack-clearing operator has taken the
+	// width, or found none and left the default.
+	hasWidth bool
+
+	vsindex int
+	seac    bool
+}
+
+// run interprets a charstring or subroutine.
+func (d *decoder) run(cs []byte, depth int) error {
+	if depth > maxCallDepth {
+		return fmt.Errorf("subroutines nested too deeply")
+	}
+	limit := maxStack
+	if d.font.cff2 {
+		limit = maxStackCFF2
+	}
+
+	for i := 0; i < len(cs); {
+		b := int(cs[i])
+
+		// Bytes 28 and from 32 on encode numbers.
+		if b == csShortInt || b >= 32 {
+			v, n, err := number(cs[i:])
+			if err != nil {
+				return err
+			}
+			i += n
+			if len(d.stack) >= limit {
+				return fmt.Errorf("stack overflow")
+			}
+			d.stack = append(d.stack, v)
+			continue
+		}
+
+		op := b
+		i++
+		if op == csEscape {
+			if i >= len(cs) {
+				return fmt.Errorf("truncated escape operator")
+			}
+			op = 32 + int(cs[i])
+			i++
+		}
+
+		switch op {
+		case csCallSubr, csCallGSubr:
+			subrs := d.fd.subrs
+			if op == csCallGSubr {
+				subrs = d.font.gsubrs
+			}
+			n, ok := d.pop()
+			idx := int(n) + bias(len(subrs))
+			if !ok || idx < 0 || idx >= len(subrs) {
+				return fmt.Errorf("invalid subroutine %v", n)
+			}
+			if err := d.run(subrs[idx], depth+1); err != nil {
+				return err
+			}
+			continue
+		case csReturn:
+			return nil
+		case csHintMask, csCntrMask:
+			// Stems before a mask are vertical stems whose operator is
+			// left out. The mask has a bit for each stem.
+			d.stems()
+			i += (d.nStems + 7) / 8
+			continue
+		case csVSIndex:
+			n, ok := d.pop()
+			if !ok {
+				return fmt.Errorf("stack underflow")
+			}
+			d.vsindex = int(n)
+			continue
+		case csBlend:
+			if d.vsindex < 0 || d.vsindex >= len(d.font.regions) {
+				return fmt.Errorf("invalid vsindex %d", d.vsindex)
+			}
+			var err error
+			if d.stack, err = blend(d.stack, d.font.regions[d.vsindex]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := d.command(op); err != nil {
+			return err
+		}
+		d.stack = d.stack[:0]
+	}
+	return nil
+}
+
+// bias returns the bias added to the operands of callsubr and callgsubr,
+// which depends on the number of subroutines.
+func bias(n int) int {
+	switch {
+	case n < 1240:
+		return 107
+	case n < 33900:
+		return 1131
+	}
+	return 32768
+}
+
+// number decodes the number a charstring byte sequence starts with, and
+// returns it with the number of bytes it takes.
+func number(b []byte) (float64, int, error) {
+	switch v := int(b[0]); {
+	case v == csShortInt:
+		if len(b) < 3 {
+			return 0, 0, fmt.Errorf("truncated number")
+		}
+		return float64(int16(binary.BigEndian.Uint16(b[1:]))), 3, nil
+	case v <= 246:
+		return float64(v - 139), 1, nil
+	case v <= 254:
+		if len(b) < 2 {
+			return 0, 0, fmt.Errorf("truncated number")
+		}
+		if v <= 250 {
+			return float64((v-247)*256 + int(b[1]) + 108), 2, nil
+		}
+		return float64(-(v-251)*256 - int(b[1]) - 108), 2, nil
+	default:
+		// A 16.16 fixed-point number.
+		if len(b) < 5 {
+			return 0, 0, fmt.Errorf("truncated number")
+		}
+		return float64(int32(binary.BigEndian.Uint32(b[1:]))) / 65536, 5, nil
+	}
+}
+
+func (d *decoder) pop() (float64, bool) {
+	n := len(d.stack)
+	if n == 0 {
+		return 0, false
+	}
+	v := d.stack[n-1]
+	d.stack = d.stack[:n-1]
+	return v, true
+}
+
+// takeWidth removes the width that the first stack-clearing operator of a
+// charstring finds below its operands when it has one more than it takes.
+func (d *decoder) takeWidth(extra bool) {
+	if d.hasWidth {
+		return
+	}
+	d.hasWidth = true
+	if extra {
+		d.width = d.fd.nominalWidth + d.stack[0]
+		d.stack = append(d.stack[:0], d.stack[1:]...)
+	}
+}
+
+// stems counts the stem hints of the operands of a stem or mask operator,
+// which come in pairs.
+func (d *decoder) stems() {
+	d.takeWidth(len(d.stack)%2 == 1)
+	d.nStems += len(d.stack) / 2
+	d.stack = d.stack[:0]
+}
+
+// arities gives the number of operands of the operators that take a fixed
+// number of them.
+var arities = map[int]int{
+	csRMoveTo: 2, csHMoveTo: 1, csVMoveTo: 1,
+	csFlex: 13, csHFlex: 7, csHFlex1: 9, csFlex1: 11,
+}
+
+// command executes an operator that clears the stack.
+func (d *decoder) command(op int) error {
+	switch op {
+	case csRMoveTo, csHMoveTo, csVMoveTo:
+		d.takeWidth(len(d.stack) > arities[op])
+	case csEndChar:
+		d.takeWidth(len(d.stack) == 1 || len(d.stack) == 5)
+	}
+	a := d.stack
+	if len(a) < arities[op] {
+		return fmt.Errorf("stack underflow")
+	}
+
+	switch op {
+	case csHStem, csVStem, csHStemHM, csVStemHM:
+		d.stems()
+	case csDotSection:
+		// Deprecated, and a hint only.
+	case csRMoveTo:
+		d.moveTo(a[0], a[1])
+	case csHMoveTo:
+		d.moveTo(a[0], 0)
+	case csVMoveTo:
+		d.moveTo(0, a[0])
+	case csRLineTo:
+		for ; len(a) >= 2; a = a[2:] {
+			d.lineTo(a[0], a[1])
+		}
+	case csHLineTo, csVLineTo:
+		// Lines alternate between horizontal and vertical.
+		horizontal := op == csHLineTo
+		for _, v := range a {
+			if horizontal {
+				d.lineTo(v, 0)
+			} else {
+				d.lineTo(0, v)
+			}
+			horizontal = !horizontal
+		}
+	case csRRCurveTo:
+		for ; len(a) >= 6; a = a[6:] {
+			d.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		}
+	case csRCurveLine:
+		for ; len(a) >= 8; a = a[6:] {
+			d.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		}
+		if len(a) >= 2 {
+			d.lineTo(a[0], a[1])
+		}
+	case csRLineCurve:
+		for ; len(a) >= 8; a = a[2:] {
+			d.lineTo(a[0], a[1])
+		}
+		if len(a) >= 6 {
+			d.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		}
+	case csVVCurveTo:
+		// Curves start and end vertically, but for an odd first operand
+		// giving the first curve's initial horizontal offset.
+		dx1 := 0.0
+		if len(a)%2 == 1 {
+			dx1, a = a[0], a[1:]
+		}
+		for ; len(a) >= 4; a = a[4:] {
+			d.curveTo(dx1, a[0], a[1], a[2], 0, a[3])
+			dx1 = 0
+		}
+	case csHHCurveTo:
+		dy1 := 0.0
+		if len(a)%2 == 1 {
+			dy1, a = a[0], a[1:]
+		}
+		for ; len(a) >= 4; a = a[4:] {
+			d.curveTo(a[0], dy1, a[1], a[2], a[3], 0)
+			dy1 = 0
+		}
+	case csVHCurveTo, csHVCurveTo:
+		d.alternatingCurves(a, op == csHVCurveTo)
+	case csFlex:
+		d.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		d.curveTo(a[6], a[7], a[8], a[9], a[10], a[11])
+	case csHFlex:
+		d.curveTo(a[0], 0, a[1], a[2], a[3], 0)
+		d.curveTo(a[4], 0, a[5], -a[2], a[6], 0)
+	case csHFlex1:
+		d.curveTo(a[0], a[1], a[2], a[3], a[4], 0)
+		d.curveTo(a[5], 0, a[6], a[7], a[8], -(a[1] + a[3] + a[7]))
+	case csFlex1:
+		// The last operand gives the offset along the axis the curves
+		// move along most; they return to the starting point on the
+		// other.
+		var dx, dy float64
+		for i := 0; i < 10; i += 2 {
+			dx += a[i]
+			dy += a[i+1]
+		}
+		dx6, dy6 := a[10], -dy
+		if math.Abs(dx) <= math.Abs(dy) {
+			dx6, dy6 = -dx, a[10]
+		}
+		d.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		d.curveTo(a[6], a[7], a[8], a[9], dx6, dy6)
+	case csEndChar:
+		if len(a) == 4 {
+			return d.accent(a[0], a[1], int(a[2]), int(a[3]))
+		}
+		return errEndChar
+	default:
+		return fmt.Errorf("unknown operator %d", op)
+	}
+	return nil
+}
+
+// alternatingCurves draws the curves of vhcurveto and hvcurveto, which
+// alternate between starting horizontally and ending vertically, and the
+// reverse. An operand left over ends the last curve off the axis.
+func (d *decoder) alternatingCurves(a []float64, horizontal bool) {
+	for ; len(a) >= 4; a = a[4:] {
+		last := 0.0
+		if len(a) == 5 {
+			last = a[4]
+		}
+		if horizontal {
+			d.curveTo(a[0], 0, a[1], a[2], last, a[3])
+		} else {
+			d.curveTo(0, a[0], a[1], a[2], a[3], last)
+		}
+		horizontal = !horizontal
+	}
+}
+
+func (d *decoder) point() render.Point {
+	return render.Point{X: d.x, Y: d.y}.Add(d.origin)
+}
+
+// closePath closes the open subpath; Type 2 charstrings leave closing
+// implicit.
+func (d *decoder) closePath() {
+	if d.open {
+		d.path.Close()
+		d.open = false
+	}
+}
+
+func (d *decoder) moveTo(dx, dy float64) {
+	d.closePath()
+	d.x += dx
+	d.y += dy
+	p := d.point()
+	d.path.MoveTo(p.X, p.Y)
+	d.open = true
+}
+
+// begin opens a subpath at the current point for drawing operators that
+// come before any moveto.
+func (d *decoder) begin() {
+	if !d.open {
+		p := d.point()
+		d.path.MoveTo(p.X, p.Y)
+		d.open = true
+	}
+}
+
+func (d *decoder) lineTo(dx, dy float64) {
+	d.begin()
+	d.x += dx
+	d.y += dy
+	p := d.point()
+	d.path.LineTo(p.X, p.Y)
+}
+
+func (d *decoder) curveTo(dx1, dy1, dx2, dy2, dx3, dy3 float64) {
+	d.begin()
+	d.x += dx1
+	d.y += dy1
+	p1 := d.point()
+	d.x += dx2
+	d.y += dy2
+	p2 := d.point()
+	d.x += dx3
+	d.y += dy3
+	p3 := d.point()
+	d.path.CubeTo(p1.X, p1.Y, p2.X, p2.Y, p3.X, p3.Y)
+}
+
+// accent draws an accented glyph, as endchar with four operands does, from
+// the glyphs of two codes of StandardEncoding: a base glyph and an accent
+// whose origin lies at (adx, ady) from that of the base.
+func (d *decoder) accent(adx, ady float64, base, accent int) error {
+	if d.seac {
+		return fmt.Errorf("nested seac")
+	}
+	if base < 0 || base > 255 || accent < 0 || accent > 255 {
+		return fmt.Errorf("invalid seac codes")
+	}
+	d.seac = true
+
+	for _, c := range []struct {
+		code   int
+		origin render.Point
+	}{
+		{base, render.Point{}},
+		{accent, render.Point{X: adx, Y: ady}},
+	} {
+		gid, ok := d.font.GlyphByName(type1.StandardEncoding[c.code])
+		if !ok {
+			return fmt.Errorf("seac component %d missing", c.code)
+		}
+		d.closePath()
+		d.stack, d.origin, d.x, d.y, d.nStems = d.stack[:0], c.origin, 0, 0, 0
+		if err := d.run(d.font.charStrings[gid], 1); err != nil && err != errEndChar {
+			return err
+		}
+	}
+	return errEndChar
+}
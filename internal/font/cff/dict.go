@@ -0,0 +1,143 @@
+package cff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// dict is a parsed DICT, mapping operators to their operands.
+type dict map[int][]float64
+
+// int returns the single operand of an operator as an integer, or def if
+// the DICT lacks it.
+func (d dict) int(op, def int) int {
+	if v := d[op]; len(v) == 1 {
+		return int(v[0])
+	}
+	return def
+}
+
+// float returns the single operand of an operator, or def if the DICT
+// lacks it.
+func (d dict) float(op int, def float64) float64 {
+	if v := d[op]; len(v) == 1 {
+		return v[0]
+	}
+	return def
+}
+
+// maxDictOperands bounds the operands of a DICT operator; the largest
+// need 513, as blend operators in CFF2 do.
+const maxDictOperands = 513
+
+// parseDict parses a DICT. The regions of a CFF2 VariationStore resolve
+// blend operators to default values.
+func parseDict(b []byte, regions []int) (dict, error) {
+	d := make(dict)
+	var operands []float64
+	vsindex := 0
+
+	for i := 0; i < len(b); {
+		c := b[i]
+		if c >= 28 && c != 31 && c != 255 {
+			v, n, err := dictNumber(b[i:])
+			if err != nil {
+				return nil, err
+			}
+			if len(operands) >= maxDictOperands {
+				return nil, fmt.Errorf("too many operands")
+			}
+			operands = append(operands, v)
+			i += n
+			continue
+		}
+
+		op := int(c)
+		i++
+		if op == 12 {
+			if i >= len(b) {
+				return nil, fmt.Errorf("truncated operator")
+			}
+			op = 1200 + int(b[i])
+			i++
+		}
+
+		switch op {
+		case opBlend:
+			if vsindex < 0 || vsindex >= len(regions) {
+				return nil, fmt.Errorf("invalid vsindex %d", vsindex)
+			}
+			var err error
+			if operands, err = blend(operands, regions[vsindex]); err != nil {
+				return nil, err
+			}
+			continue
+		case opVSIndex:
+			if len(operands) == 1 {
+				vsindex = int(operands[0])
+			}
+		}
+		d[op] = operands
+		operands = nil
+	}
+	return d, nil
+}
+
+// dictNumber decodes the DICT operand a byte sequence starts with, and
+// returns it with the number of bytes it takes.
+func dictNumber(b []byte) (float64, int, error) {
+	switch c := int(b[0]); {
+	case c == 28:
+		if len(b) < 3 {
+			return 0, 0, fmt.Errorf("truncated number")
+		}
+		return float64(int16(binary.BigEndian.Uint16(b[1:]))), 3, nil
+	case c == 29:
+		if len(b) < 5 {
+			return 0, 0, fmt.Errorf("truncated number")
+		}
+		return float64(int32(binary.BigEndian.Uint32(b[1:]))), 5, nil
+	case c == 30:
+		return realNumber(b)
+	case c <= 246:
+		return float64(c - 139), 1, nil
+	default:
+		if len(b) < 2 {
+			return 0, 0, fmt.Errorf("truncated number")
+		}
+		if c <= 250 {
+			return float64((c-247)*256 + int(b[1]) + 108), 2, nil
+		}
+		return float64(-(c-251)*256 - int(b[1]) - 108), 2, nil
+	}
+}
+
+// realNumber decodes a real operand: nibbles of digits, a decimal point,
+// exponents and a minus sign, ending with the nibble 0xf.
+func realNumber(b []byte) (float64, int, error) {
+	var s []byte
+	for i := 1; i < len(b); i++ {
+		for _, nib := range [2]byte{b[i] >> 4, b[i] & 0xf} {
+			switch {
+			case nib <= 9:
+				s = append(s, '0'+nib)
+			case nib == 0xa:
+				s = append(s, '.')
+			case nib == 0xb:
+				s = append(s, 'e')
+			case nib == 0xc:
+				s = append(s, 'e', '-')
+			case nib == 0xe:
+				s = append(s, '-')
+			case nib == 0xf:
+				v, err := strconv.ParseFloat(string(s), 64)
+				if err != nil {
+					return 0, 0, fmt.Errorf("invalid real number %q", s)
+				}
+				return v, i + 1, nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("truncated real number")
+}
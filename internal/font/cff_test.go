@@ -0,0 +1,81 @@
+package font
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+func cffDescriptor(t *testing.T, name, subtype string) model.PDFDict {
+	t.Helper()
+	data, err := os.ReadFile("../../testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model.PDFDict{
+		"Flags":     model.PDFNumber(4),
+		"FontFile3": model.PDFStream{Dict: model.PDFDict{"Subtype": model.PDFName(subtype)}, Data: data},
+	}
+}
+
+func TestType1CProgram(t *testing.T) {
+	f, err := Load(model.PDFDict{
+		"Subtype":        model.PDFName("Type1"),
+		"BaseFont":       model.PDFName("ABCDEF+Glyphs"),
+		"FontDescriptor": cffDescriptor(t, "glyphs.cff", "Type1C"),
+	}, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Without /Encoding the program's built-in encoding applies.
+	if name := f.GlyphName(200); name != "Aacute" {
+		t.Errorf("GlyphName(200) = %q, expected Aacute", name)
+	}
+	if gid := f.GID(200); gid != 5 {
+		t.Errorf("GID(200) = %d, expected 5", gid)
+	}
+	// Without /Widths the advances come from the charstrings.
+	if w := f.Width('/'); w != 278 {
+		t.Errorf("Width('/') = %v, expected 278", w)
+	}
+
+	p := f.Outline('A')
+	if p == nil {
+		t.Fatalf("Outline('A') = nil")
+	}
+	min, max := p.Bounds()
+	if min != (render.Point{X: 0.1, Y: 0}) || max != (render.Point{X: 0.6, Y: 0.5}) {
+		t.Errorf("Outline('A') bounds = %v, %v", min, max)
+	}
+}
+
+func TestCIDFontType0Outline(t *testing.T) {
+	f, err := Load(type0Font("Identity-H", model.PDFDict{
+		"Subtype":        model.PDFName("CIDFontType0"),
+		"FontDescriptor": cffDescriptor(t, "glyphs-cid.cff", "CIDFontType0C"),
+	}), parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// The charset maps CIDs to glyphs; CIDs it lacks select glyph 0.
+	for cid, gid := range map[int]int{10: 1, 20: 3, 12: 0} {
+		if got := f.GID(cid); got != gid {
+			t.Errorf("GID(%d) = %d, expected %d", cid, got, gid)
+		}
+	}
+
+	// The glyph's font dict doubles the font matrix.
+	p := f.Outline(20)
+	if p == nil {
+		t.Fatalf("Outline(20) = nil")
+	}
+	min, max := p.Bounds()
+	if min != (render.Point{}) || max != (render.Point{X: 0.2, Y: 0.2}) {
+		t.Errorf("Outline(20) bounds = %v, %v", min, max)
+	}
+}
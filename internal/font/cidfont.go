@@ -0,0 +1,245 @@
+package font
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+// cidFont holds the descendant CIDFont of a Type0 font (PDF 32000-1 9.7.4).
+// Metrics are in glyph space.
+type cidFont struct {
+	subtype  string
+	baseFont string
+
+	// widths and widthRanges give the horizontal widths of CIDs from /W;
+	// others are dw wide.
+	widths      map[int]float64
+	widthRanges []widthRange
+	dw          float64
+
+	// vertical and verticalRanges give the vertical metrics of CIDs from
+	// /W2; others take theirs from /DW2.
+	vertical       map[int]verticalMetrics
+	verticalRanges []verticalRange
+	dw2            [2]float64
+
+	// cidToGID maps CIDs to the glyph indices of a CIDFontType2 font; nil
+	// stands for the identity mapping.
+	cidToGID []uint16
+}
+
+// widthRange gives the CIDs lo to hi the same width.
+type widthRange struct {
+	lo, hi int
+	w      float64
+}
+
+// verticalMetrics are the vertical displacement w1 of a glyph and the
+// position vector from its horizontal to its vertical origin.
+type verticalMetrics struct {
+	w1, vx, vy float64
+}
+
+// verticalRange gives the CIDs lo to hi the same vertical metrics.
+type verticalRange struct {
+	lo, hi int
+	m      verticalMetrics
+}
+
+// loadCIDFont parses a CIDFont dictionary.
+func loadCIDFont(v model.PDFValue, r model.Resolver) (*cidFont, error) {
+	dict, ok := r.Resolve(v).(model.PDFDict)
+	if !ok {
+		return nil, fmt.Errorf("CIDFont is not a dictionary")
+	}
+
+	subtype, _ := r.Resolve(dict["Subtype"]).(model.PDFName)
+	if subtype != "CIDFontType0" && subtype != "CIDFontType2" {
+		return nil, fmt.Errorf("unknown CIDFont type %v", dict["Subtype"])
+	}
+	base, _ := r.Resolve(dict["BaseFont"]).(model.PDFName)
+
+	c := &cidFont{
+		subtype:  string(subtype),
+		baseFont: string(base),
+		widths:   make(map[int]float64),
+		dw:       1000,
+		vertical: make(map[int]verticalMetrics),
+		dw2:      [2]float64{880, -1000},
+	}
+
+	if dw, ok := r.Resolve(dict["DW"]).(model.PDFNumber); ok {
+		c.dw = float64(dw)
+	}
+	if dw2, ok := model.ResolveNumbers(dict["DW2"], r); ok && len(dw2) == 2 {
+		c.dw2 = [2]float64(dw2)
+	}
+	c.parseW(dict["W"], r)
+	c.parseW2(dict["W2"], r)
+
+	if s, ok := r.Resolve(dict["CIDToGIDMap"]).(model.PDFStream); ok && subtype == "CIDFontType2" {
+		data, err := parser.DecodeStream(s, r)
+		if err != nil {
+			return nil, fmt.Errorf("/CIDToGIDMap: %w", err)
+		}
+		c.cidToGID = make([]uint16, len(data)/2)
+		for i := range c.cidToGID {
+			c.cidToGID[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		}
+	}
+
+	return c, nil
+}
+
+// parseW reads a /W array, whose elements are either a CID followed by an
+// array of the widths of consecutive CIDs, or a first and last CID
+// followed by their common width. Parsing stops at a malformed element.
+func (c *cidFont) parseW(v model.PDFValue, r model.Resolver) {
+	arr, _ := r.Resolve(v).(model.PDFArray)
+	for i := 0; i+1 < len(arr); {
+		first, ok := r.Resolve(arr[i]).(model.PDFNumber)
+		if !ok {
+			return
+		}
+
+		if ws, ok := model.ResolveNumbers(arr[i+1], r); ok {
+			for j, w := range ws {
+				c.widths[int(first)+j] = w
+			}
+			i += 2
+			continue
+		}
+
+		if i+2 >= len(arr) {
+			return
+		}
+		last, ok1 := r.Resolve(arr[i+1]).(model.PDFNumber)
+		w, ok2 := r.Resolve(arr[i+2]).(model.PDFNumber)
+		if !ok1 || !ok2 {
+			return
+		}
+		c.widthRanges = append(c.widthRanges, widthRange{int(first), int(last), float64(w)})
+		i += 3
+	}
+}
+
+// parseW2 reads a /W2 array, which is laid out like /W with each width
+// replaced by the three numbers w1 vx vy.
+func (c *cidFont) parseW2(v model.PDFValue, r model.Resolver) {
+	arr, _ := r.Resolve(v).(model.PDFArray)
+	for i := 0; i+1 < len(arr); {
+		first, ok := r.Resolve(arr[i]).(model.PDFNumber)
+		if !ok {
+			return
+		}
+
+		if ms, ok := model.ResolveNumbers(arr[i+1], r); ok {
+			for j := 0; j+2 < len(ms); j += 3 {
+				c.vertical[int(first)+j/3] = verticalMetrics{ms[j], ms[j+1], ms[j+2]}
+			}
+			i += 2
+			continue
+		}
+
+		if i+4 >= len(arr) {
+			return
+		}
+		ms, ok := model.ResolveNumbers(arr[i+1:i+5], r)
+		if !ok {
+			return
+		}
+		m := verticalMetrics{ms[1], ms[2], ms[3]}
+		c.verticalRanges = append(c.verticalRanges, verticalRange{int(first), int(ms[0]), m})
+		i += 5
+	}
+}
+
+// width returns the horizontal width of a CID.
+func (c *cidFont) width(cid int) float64 {
+	if w, ok := c.widths[cid]; ok {
+		return w
+	}
+	for _, r := range c.widthRanges {
+		if cid >= r.lo && cid <= r.hi {
+			return r.w
+		}
+	}
+	return c.dw
+}
+
+// verticalMetrics returns the vertical metrics of a CID. By default the
+// vertical origin lies half the width to the right of the horizontal one.
+func (c *cidFont) verticalMetrics(cid int) verticalMetrics {
+	if m, ok := c.vertical[cid]; ok {
+		return m
+	}
+	for _, r := range c.verticalRanges {
+		if cid >= r.lo && cid <= r.hi {
+			return r.m
+		}
+	}
+	return verticalMetrics{c.dw2[1], c.width(cid) / 2, c.dw2[0]}
+}
+
+// loadComposite sets up a Type0 font from its CMap and descendant CIDFont.
+func (f *Font) loadComposite(dict model.PDFDict, r model.Resolver) error {
+	cmap, err := loadCMap(dict["Encoding"], r, 0)
+	if err != nil {
+		return fmt.Errorf("/Encoding: %w", err)
+	}
+
+	desc, _ := r.Resolve(dict["DescendantFonts"]).(model.PDFArray)
+	if len(desc) == 0 {
+		return fmt.Errorf("Type0 font without /DescendantFonts")
+	}
+	cid, err := loadCIDFont(desc[0], r)
+	if err != nil {
+		return err
+	}
+
+	f.cmap, f.cid = cmap, cid
+	if d, ok := r.Resolve(desc[0]).(model.PDFDict); ok {
+		fd, _ := r.Resolve(d["FontDescriptor"]).(model.PDFDict)
+		f.loadFontFile(fd, r)
+	}
+	return nil
+}
+
+// unicodeCMapText returns the text of a code of a predefined Unicode CMap,
+// whose codes are text in the encoding form its name gives.
+func unicodeCMapText(name string, code uint32) string {
+	if !strings.HasPrefix(name, "Uni") {
+		return ""
+	}
+
+	var r rune
+	switch {
+	case strings.Contains(name, "-UCS2-"), strings.Contains(name, "-UTF16-"):
+		r = rune(code)
+		if code > 0xffff {
+			r = utf16.DecodeRune(rune(code>>16), rune(code&0xffff))
+		}
+	case strings.Contains(name, "-UTF8-"):
+		var b []byte
+		for s := 24; s >= 0; s -= 8 {
+			if c := byte(code >> s); c != 0 || len(b) > 0 {
+				b = append(b, c)
+			}
+		}
+		r, _ = utf8.DecodeRune(b)
+	case strings.Contains(name, "-UTF32-"):
+		r = rune(code)
+	default:
+		return ""
+	}
+
+	if r == utf8.RuneError || !utf8.ValidRune(r) {
+		return ""
+	}
+	return string(r)
+}
@@ -0,0 +1,201 @@
+package font
+
+import (
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func TestPredefinedCMap(t *testing.T) {
+	c, err := PredefinedCMap("90ms-RKSJ-H")
+	if err != nil {
+		t.Fatalf("PredefinedCMap() error = %v", err)
+	}
+
+	s := []byte("\x20\x81\x40")
+	tests := []struct {
+		code uint32
+		n    int
+		cid  int
+	}{
+		{0x20, 1, 231},
+		{0x8140, 2, 633},
+	}
+	for _, tc := range tests {
+		code, n := c.NextCode(s)
+		if code != tc.code || n != tc.n {
+			t.Fatalf("NextCode(% x) = %#x, %d, expected %#x, %d", s, code, n, tc.code, tc.n)
+		}
+		if cid := c.CID(code); cid != tc.cid {
+			t.Errorf("CID(%#x) = %d, expected %d", code, cid, tc.cid)
+		}
+		s = s[n:]
+	}
+
+	// The vertical CMap extends the horizontal one with usecmap.
+	v, err := PredefinedCMap("UniJIS-UCS2-V")
+	if err != nil {
+		t.Fatalf("PredefinedCMap() error = %v", err)
+	}
+	if v.WMode != 1 || v.Name != "UniJIS-UCS2-V" {
+		t.Errorf("UniJIS-UCS2-V: WMode = %d, Name = %q", v.WMode, v.Name)
+	}
+	if cid := v.CID(0x3001); cid != 7887 {
+		t.Errorf("CID(0x3001) = %d, expected 7887", cid)
+	}
+	if cid := v.CID(0x41); cid != 34 {
+		t.Errorf("CID(0x41) = %d, expected 34 from UniJIS-UCS2-H", cid)
+	}
+	if code, n := v.NextCode([]byte{0x30, 0x01}); code != 0x3001 || n != 2 {
+		t.Errorf("NextCode() = %#x, %d, expected 0x3001, 2", code, n)
+	}
+
+	if _, err := PredefinedCMap("Missing-H"); err == nil {
+		t.Errorf("PredefinedCMap() with an unknown name expected an error")
+	}
+}
+
+func TestEmbeddedCMap(t *testing.T) {
+	data := `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CMapName /Test-H def
+/Identity-H usecmap
+1 begincidrange
+<0010> <0012> 100
+endcidrange
+endcmap`
+	c, err := loadCMap(model.PDFStream{Dict: model.PDFDict{}, Data: []byte(data)}, parser.NewObjectTable(), 0)
+	if err != nil {
+		t.Fatalf("loadCMap() error = %v", err)
+	}
+	for code, cid := range map[uint32]int{0x10: 100, 0x12: 102, 0x13: 0x13, 0x0400: 0x0400} {
+		if got := c.CID(code); got != cid {
+			t.Errorf("CID(%#x) = %d, expected %d", code, got, cid)
+		}
+	}
+	if code, n := c.NextCode([]byte{0, 0x10}); code != 0x10 || n != 2 {
+		t.Errorf("NextCode() = %#x, %d, expected 0x10, 2", code, n)
+	}
+
+	// A stream that extends itself must not recurse forever.
+	r := parser.NewObjectTable()
+	ref := model.PDFIndirectRef{ObjectNumber: 1}
+	r.Add(&model.PDFObject{Number: 1, Value: model.PDFStream{Dict: model.PDFDict{"UseCMap": ref}, Data: []byte(data)}})
+	if _, err := loadCMap(ref, r, 0); err == nil {
+		t.Errorf("loadCMap() with a circular /UseCMap expected an error")
+	}
+}
+
+func type0Font(encoding string, descendant model.PDFDict) model.PDFDict {
+	return model.PDFDict{
+		"Type":            model.PDFName("Font"),
+		"Subtype":         model.PDFName("Type0"),
+		"BaseFont":        model.PDFName("Test"),
+		"Encoding":        model.PDFName(encoding),
+		"DescendantFonts": model.PDFArray{descendant},
+	}
+}
+
+func TestLoadType0(t *testing.T) {
+	r := parser.NewObjectTable()
+	dict := type0Font("Identity-H", model.PDFDict{
+		"Subtype":     model.PDFName("CIDFontType2"),
+		"DW":          model.PDFNumber(800),
+		"W":           model.PDFArray{model.PDFNumber(1), nums(500, 600), model.PDFNumber(10), model.PDFNumber(20), model.PDFNumber(300)},
+		"CIDToGIDMap": model.PDFStream{Dict: model.PDFDict{}, Data: []byte{0, 0, 0, 7, 0, 9}},
+	})
+
+	f, err := Load(dict, r)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if f.Vertical() {
+		t.Errorf("Vertical() = true for Identity-H")
+	}
+
+	code, n := f.NextCode([]byte{0, 2, 0})
+	if code != 2 || n != 2 {
+		t.Errorf("NextCode() = %d, %d, expected 2, 2", code, n)
+	}
+
+	widths := map[int]float64{0: 800, 1: 500, 2: 600, 3: 800, 10: 300, 20: 300, 21: 800}
+	for code, w := range widths {
+		if got := f.Width(code); got != w {
+			t.Errorf("Width(%d) = %v, expected %v", code, got, w)
+		}
+	}
+
+	for code, gid := range map[int]int{1: 7, 2: 9, 3: 0} {
+		if got := f.GID(code); got != gid {
+			t.Errorf("GID(%d) = %d, expected %d", code, got, gid)
+		}
+	}
+
+	delete(dict, "DescendantFonts")
+	if _, err := Load(dict, r); err == nil {
+		t.Errorf("Load() without /DescendantFonts expected an error")
+	}
+	if _, err := Load(type0Font("Missing-H", model.PDFDict{"Subtype": model.PDFName("CIDFontType0")}), r); err == nil {
+		t.Errorf("Load() with an unknown CMap expected an error")
+	}
+	if _, err := Load(type0Font("Identity-H", model.PDFDict{"Subtype": model.PDFName("Type1")}), r); err == nil {
+		t.Errorf("Load() with a simple descendant font expected an error")
+	}
+}
+
+func TestVerticalFont(t *testing.T) {
+	f, err := Load(type0Font("Identity-V", model.PDFDict{
+		"Subtype": model.PDFName("CIDFontType0"),
+		"W":       model.PDFArray{model.PDFNumber(1), nums(600)},
+		"W2":      model.PDFArray{model.PDFNumber(5), nums(-500, 250, 800), model.PDFNumber(6), model.PDFNumber(8), model.PDFNumber(-750), model.PDFNumber(125), model.PDFNumber(500)},
+	}), parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !f.Vertical() {
+		t.Fatalf("Vertical() = false for Identity-V")
+	}
+
+	tests := []struct {
+		code            int
+		advance, vx, vy float64
+	}{
+		{1, -1, 0.3, 0.88},
+		{2, -1, 0.5, 0.88},
+		{5, -0.5, 0.25, 0.8},
+		{7, -0.75, 0.125, 0.5},
+	}
+	for _, tc := range tests {
+		if got := f.Advance(tc.code); got != tc.advance {
+			t.Errorf("Advance(%d) = %v, expected %v", tc.code, got, tc.advance)
+		}
+		if vx, vy := f.VerticalOrigin(tc.code); vx != tc.vx || vy != tc.vy {
+			t.Errorf("VerticalOrigin(%d) = %v, %v, expected %v, %v", tc.code, vx, vy, tc.vx, tc.vy)
+		}
+	}
+}
+
+func TestType0Unicode(t *testing.T) {
+	tests := []struct {
+		encoding string
+		code, n  int
+		text     string
+	}{
+		{"UniJIS-UCS2-H", 0x3042, 2, "あ"},
+		{"UniJIS-UTF16-H", 0xd83dde00, 4, "😀"},
+		{"UniJIS-UTF8-H", 0xe38182, 3, "あ"},
+		{"UniJIS-UTF32-H", 0x1f600, 4, "😀"},
+		{"Identity-H", 0x41, 2, ""},
+	}
+	for _, tc := range tests {
+		f, err := Load(type0Font(tc.encoding, model.PDFDict{"Subtype": model.PDFName("CIDFontType0")}), parser.NewObjectTable())
+		if err != nil {
+			t.Fatalf("Load(%s) error = %v", tc.encoding, err)
+		}
+		if got := f.Unicode(tc.code, tc.n); got != tc.text {
+			t.Errorf("%s: Unicode(%#x) = %q, expected %q", tc.encoding, tc.code, got, tc.text)
+		}
+	}
+}
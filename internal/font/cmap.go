@@ -0,0 +1,429 @@
+package font
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+// CMap is a parsed CMap file (PDF 32000-1 9.7.5): codespace ranges that
+// split strings into character codes of one to four bytes, and the
+// mappings of those codes. A code is looked up by its bytes read as a
+// big-endian number.
+type CMap struct {
+	Name string
+
+	// WMode is 1 for vertical writing and 0 for horizontal.
+	WMode int
+
+	codespaces []codespaceRange
+
+	// cids and cidRanges map codes to CIDs, and notdefRanges give the CID
+	// of codes that have none. cidRanges is sorted once parsed.
+	cids         map[uint32]int
+	cidRanges    []cidRange
+	notdefRanges []cidRange
+
+	// useCMap names the CMap this one extends with usecmap, and parent is
+	// that CMap once loaded. Mappings of the CMap itself take precedence.
+	useCMap string
+	parent  *CMap
+
+	// text and textRanges hold the bfchar and bfrange mappings of a
+	// ToUnicode CMap (9.10.3), whose codes are told apart by their length
+	// as well as their value. Single codes take precedence; among ranges
+	// later ones do.
+	text       map[textCode]string
+	textRanges []textRange
+}
+
+// textCode is a code of a ToUnicode CMap: its value and its length in
+// bytes.
+type textCode struct {
+	code uint32
+	n    int
+}
+
+// codespaceRange holds the codes of n bytes each of which lies between the
+// corresponding bytes of lo and hi.
+type codespaceRange struct {
+	n      int
+	lo, hi [4]byte
+}
+
+// textRange maps the codes of n bytes from lo to hi to UTF-16BE text.
+// Each code maps to dst incremented by its offset from lo, unless dsts
+// gives the text of each code.
+type textRange struct {
+	lo, hi uint32
+	n      int
+	dst    []byte
+	dsts   [][]byte
+}
+
+// cidRange maps the codes lo to hi to consecutive CIDs from cid.
+type cidRange struct {
+	lo, hi uint32
+	cid    int
+}
+
+// cmapOperand is an operand of a CMap operator: a token or an array of
+// tokens.
+type cmapOperand struct {
+	tok   model.Token
+	array []model.Token
+}
+
+// ParseCMap parses a CMap file. It is read as PostScript tokens, of which
+// only the operators that define the CMap are interpreted.
+func ParseCMap(data []byte) (*CMap, error) {
+	c := &CMap{text: make(map[textCode]string), cids: make(map[uint32]int)}
+	l := parser.NewLexer(bytes.NewReader(data))
+
+	var operands []cmapOperand
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			return nil, fmt.Errorf("CMap: %w", err)
+		}
+
+		switch tok.Type {
+		case model.TokEOF:
+			slices.SortFunc(c.cidRanges, func(a, b cidRange) int { return cmp.Compare(a.lo, b.lo) })
+			return c, nil
+		case model.TokArrayStart:
+			arr, err := readTokenArray(l)
+			if err != nil {
+				return nil, fmt.Errorf("CMap: %w", err)
+			}
+			operands = append(operands, cmapOperand{array: arr})
+		case model.TokKeyword:
+			c.execute(tok.Value, operands)
+			operands = operands[:0]
+		default:
+			operands = append(operands, cmapOperand{tok: tok})
+		}
+	}
+}
+
+// readTokenArray reads the tokens up to the end of an array.
+func readTokenArray(l *parser.Lexer) ([]model.Token, error) {
+	var arr []model.Token
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.Type {
+		case model.TokArrayEnd:
+			return arr, nil
+		case model.TokEOF:
+			return nil, fmt.Errorf("unterminated array")
+		}
+		arr = append(arr, tok)
+	}
+}
+
+// execute interprets a CMap operator. The entries of a section are the
+// operands of its end operator. Malformed entries are skipped.
+func (c *CMap) execute(op string, operands []cmapOperand) {
+	switch op {
+	case "def":
+		if len(operands) != 2 || operands[0].tok.Type != model.TokName {
+			return
+		}
+		switch v := operands[1].tok; operands[0].tok.Value {
+		case "CMapName":
+			if v.Type == model.TokName {
+				c.Name = v.Value
+			}
+		case "WMode":
+			if n, ok := tokenInt(v); ok {
+				c.WMode = n
+			}
+		}
+
+	case "usecmap":
+		if len(operands) == 1 && operands[0].tok.Type == model.TokName {
+			c.useCMap = operands[0].tok.Value
+		}
+
+	case "endcodespacerange":
+		for i := 0; i+1 < len(operands); i += 2 {
+			lo, ok1 := tokenBytes(operands[i].tok)
+			hi, ok2 := tokenBytes(operands[i+1].tok)
+			if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 || len(lo) > 4 {
+				continue
+			}
+			r := codespaceRange{n: len(lo)}
+			copy(r.lo[:], lo)
+			copy(r.hi[:], hi)
+			c.codespaces = append(c.codespaces, r)
+		}
+
+	case "endcidchar", "endnotdefchar":
+		for i := 0; i+1 < len(operands); i += 2 {
+			code, _, ok1 := tokenCode(operands[i].tok)
+			cid, ok2 := tokenInt(operands[i+1].tok)
+			if !ok1 || !ok2 {
+				continue
+			}
+			if op == "endcidchar" {
+				c.cids[code] = cid
+			} else {
+				c.notdefRanges = append(c.notdefRanges, cidRange{code, code, cid})
+			}
+		}
+
+	case "endcidrange", "endnotdefrange":
+		for i := 0; i+2 < len(operands); i += 3 {
+			lo, _, ok1 := tokenCode(operands[i].tok)
+			hi, _, ok2 := tokenCode(operands[i+1].tok)
+			cid, ok3 := tokenInt(operands[i+2].tok)
+			if !ok1 || !ok2 || !ok3 || hi < lo {
+				continue
+			}
+			if op == "endcidrange" {
+				c.cidRanges = append(c.cidRanges, cidRange{lo, hi, cid})
+			} else {
+				c.notdefRanges = append(c.notdefRanges, cidRange{lo, hi, cid})
+			}
+		}
+
+	case "endbfchar":
+		for i := 0; i+1 < len(operands); i += 2 {
+			code, n, ok := tokenCode(operands[i].tok)
+			if !ok {
+				continue
+			}
+			if dst, ok := tokenText(operands[i+1].tok); ok {
+				c.text[textCode{code, n}] = dst
+			}
+		}
+
+	case "endbfrange":
+		for i := 0; i+2 < len(operands); i += 3 {
+			lo, n, ok1 := tokenCode(operands[i].tok)
+			hi, _, ok2 := tokenCode(operands[i+1].tok)
+			if !ok1 || !ok2 || hi < lo {
+				continue
+			}
+
+			r := textRange{lo: lo, hi: hi, n: n}
+			if arr := operands[i+2].array; arr != nil {
+				for _, tok := range arr {
+					b, _ := tokenBytes(tok)
+					r.dsts = append(r.dsts, b)
+				}
+			} else if dst, ok := tokenBytes(operands[i+2].tok); ok {
+				r.dst = dst
+			} else {
+				continue
+			}
+			c.textRanges = append(c.textRanges, r)
+		}
+	}
+}
+
+// tokenBytes returns the bytes of a string token.
+func tokenBytes(tok model.Token) ([]byte, bool) {
+	switch tok.Type {
+	case model.TokHexString:
+		return parser.StringBytes(model.PDFHexString(tok.Value))
+	case model.TokString:
+		return parser.StringBytes(model.PDFString(tok.Value))
+	}
+	return nil, false
+}
+
+// tokenCode returns the character code a string token gives and its
+// length in bytes.
+func tokenCode(tok model.Token) (uint32, int, bool) {
+	b, ok := tokenBytes(tok)
+	if !ok || len(b) == 0 || len(b) > 4 {
+		return 0, 0, false
+	}
+
+	var code uint32
+	for _, c := range b {
+		code = code<<8 | uint32(c)
+	}
+	return code, len(b), true
+}
+
+// tokenInt returns the integer value of a number token.
+func tokenInt(tok model.Token) (int, bool) {
+	if tok.Type != model.TokNumber {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(tok.Value, 64)
+	if err != nil || v < 0 || v > math.MaxInt32 {
+		return 0, false
+	}
+	return int(v), true
+}
+
+// tokenText returns the text a bfchar destination gives: UTF-16BE bytes or,
+// in older files, a glyph name.
+func tokenText(tok model.Token) (string, bool) {
+	if tok.Type == model.TokName {
+		s := GlyphUnicode(tok.Value)
+		return s, s != ""
+	}
+	b, ok := tokenBytes(tok)
+	return utf16Text(b), ok
+}
+
+// utf16Text decodes UTF-16BE text. A single leading byte of odd-length text
+// stands for a code unit of its own.
+func utf16Text(b []byte) string {
+	units := make([]uint16, 0, (len(b)+1)/2)
+	if len(b)%2 == 1 {
+		units = append(units, uint16(b[0]))
+		b = b[1:]
+	}
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// NextCode returns the first character code of a string and the number of
+// bytes it takes (9.7.6.2). Bytes that match no codespace range are taken
+// as a code of the shortest length the CMap defines.
+func (c *CMap) NextCode(s []byte) (code uint32, n int) {
+	for n = 1; n <= 4 && n <= len(s); n++ {
+		code = code<<8 | uint32(s[n-1])
+		for _, r := range c.codespaces {
+			if r.n == n && r.contains(s[:n]) {
+				return code, n
+			}
+		}
+	}
+
+	n = 1
+	if len(c.codespaces) > 0 {
+		n = 4
+		for _, r := range c.codespaces {
+			n = min(n, r.n)
+		}
+	}
+	n = min(n, len(s))
+
+	code = 0
+	for _, b := range s[:n] {
+		code = code<<8 | uint32(b)
+	}
+	return code, n
+}
+
+// contains reports whether the n bytes of s lie in the range.
+func (r codespaceRange) contains(s []byte) bool {
+	for i, b := range s {
+		if b < r.lo[i] || b > r.hi[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CID returns the CID a code maps to. Codes without a mapping take the CID
+// of their notdef range, or 0 (9.7.6.3).
+func (c *CMap) CID(code uint32) int {
+	for m := c; m != nil; m = m.parent {
+		if cid, ok := m.cids[code]; ok {
+			return cid
+		}
+
+		// The last range starting at or before code is the only one that
+		// can hold it, since a CMap's ranges do not overlap.
+		i, _ := slices.BinarySearchFunc(m.cidRanges, code, func(r cidRange, code uint32) int {
+			return cmp.Compare(r.lo, code+1)
+		})
+		if i > 0 && code <= m.cidRanges[i-1].hi {
+			r := m.cidRanges[i-1]
+			return r.cid + int(code-r.lo)
+		}
+	}
+
+	for m := c; m != nil; m = m.parent {
+		for _, r := range m.notdefRanges {
+			if code >= r.lo && code <= r.hi {
+				return r.cid
+			}
+		}
+	}
+	return 0
+}
+
+// Text returns the text a ToUnicode CMap maps a code of n bytes to. A
+// code of another length with the same value is taken if none of n bytes
+// is mapped, as files often write the one-byte codes of simple fonts with
+// two.
+func (c *CMap) Text(code uint32, n int) (string, bool) {
+	if s, ok := c.lookupText(code, n); ok {
+		return s, true
+	}
+	return c.lookupText(code, 0)
+}
+
+// lookupText returns the text a ToUnicode CMap maps a code of n bytes to,
+// or a code of any length if n is 0.
+func (c *CMap) lookupText(code uint32, n int) (string, bool) {
+	if n > 0 {
+		if s, ok := c.text[textCode{code, n}]; ok {
+			return s, true
+		}
+	} else {
+		for m := 1; m <= 4; m++ {
+			if s, ok := c.text[textCode{code, m}]; ok {
+				return s, true
+			}
+		}
+	}
+
+	for i := len(c.textRanges) - 1; i >= 0; i-- {
+		r := c.textRanges[i]
+		if code < r.lo || code > r.hi || n > 0 && r.n != n {
+			continue
+		}
+
+		off := code - r.lo
+		if r.dsts != nil {
+			if int(off) >= len(r.dsts) {
+				return "", false
+			}
+			return utf16Text(r.dsts[off]), true
+		}
+		return utf16Text(addOffset(r.dst, off)), true
+	}
+
+	if c.parent != nil {
+		return c.parent.lookupText(code, n)
+	}
+	return "", false
+}
+
+// setParent makes c extend parent, whose codespace ranges it takes on.
+func (c *CMap) setParent(parent *CMap) {
+	c.parent = parent
+	c.codespaces = append(c.codespaces, parent.codespaces...)
+}
+
+// addOffset returns b, read as a big-endian number, plus off.
+func addOffset(b []byte, off uint32) []byte {
+	out := append([]byte(nil), b...)
+	for i := len(out) - 1; i >= 0 && off > 0; i-- {
+		sum := uint32(out[i]) + off&0xff
+		out[i] = byte(sum)
+		off = off>>8 + sum>>8
+	}
+	return out
+}
@@ -0,0 +1,156 @@
+package font
+
+import (
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+const testToUnicode = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+% Codes below 0x80 take one byte, others two.
+2 begincodespacerange
+<00> <7F>
+<8000> <FFFF>
+endcodespacerange
+5 beginbfchar
+<41> <0042>
+<0041> <0043>
+<8001> <D83DDE00>
+<8002> <00660069>
+<42> /Aacute
+endbfchar
+3 beginbfrange
+<61> <7A> <0041>
+<9000> <9002> [<0031> <0032003300340035>]
+<A0FE> <A101> <00FE>
+endbfrange
+1 beginbfchar
+<61> <0078>
+endbfchar
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`
+
+func TestParseToUnicode(t *testing.T) {
+	c, err := ParseCMap([]byte(testToUnicode))
+	if err != nil {
+		t.Fatalf("ParseCMap() error = %v", err)
+	}
+	if c.Name != "Adobe-Identity-UCS" {
+		t.Errorf("Name = %q", c.Name)
+	}
+
+	tests := []struct {
+		code     uint32
+		n        int
+		expected string
+		ok       bool
+	}{
+		{0x41, 1, "B", true},
+		{0x41, 2, "C", true},
+		{0x8001, 2, "\U0001F600", true},
+		{0x8002, 2, "fi", true},
+		{0x42, 1, "Á", true},
+		{0x61, 1, "x", true},
+		{0x62, 1, "B", true},
+		{0x7a, 1, "Z", true},
+		{0x9000, 2, "1", true},
+		{0x9001, 2, "2345", true},
+		{0x9002, 2, "", false},
+		{0xa0fe, 2, "þ", true},
+		{0xa100, 2, "Ā", true},
+		{0xa101, 2, "ā", true},
+		{0x43, 1, "", false},
+		// Codes of another length stand in for those missing.
+		{0x42, 2, "Á", true},
+		{0x9000, 1, "1", true},
+	}
+	for _, tc := range tests {
+		got, ok := c.Text(tc.code, tc.n)
+		if got != tc.expected || ok != tc.ok {
+			t.Errorf("Text(%#x, %d) = %q, %v, expected %q, %v", tc.code, tc.n, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestCMapNextCode(t *testing.T) {
+	c, err := ParseCMap([]byte(testToUnicode))
+	if err != nil {
+		t.Fatalf("ParseCMap() error = %v", err)
+	}
+
+	s := []byte{0x41, 0x80, 0x01, 0x7f}
+	var codes []uint32
+	for len(s) > 0 {
+		code, n := c.NextCode(s)
+		codes = append(codes, code)
+		s = s[n:]
+	}
+	expected := []uint32{0x41, 0x8001, 0x7f}
+	if len(codes) != len(expected) {
+		t.Fatalf("codes = %#x, expected %#x", codes, expected)
+	}
+	for i := range codes {
+		if codes[i] != expected[i] {
+			t.Errorf("code %d = %#x, expected %#x", i, codes[i], expected[i])
+		}
+	}
+
+	// A truncated two-byte code falls back to the shortest length.
+	if code, n := c.NextCode([]byte{0x90}); code != 0x90 || n != 1 {
+		t.Errorf("NextCode(<90>) = %#x, %d, expected 0x90, 1", code, n)
+	}
+
+	// Without codespace ranges codes take one byte.
+	empty, err := ParseCMap([]byte("begincmap endcmap"))
+	if err != nil {
+		t.Fatalf("ParseCMap() error = %v", err)
+	}
+	if code, n := empty.NextCode([]byte{0x12, 0x34}); code != 0x12 || n != 1 {
+		t.Errorf("NextCode() = %#x, %d, expected 0x12, 1", code, n)
+	}
+}
+
+func TestParseCMapErrors(t *testing.T) {
+	for _, data := range []string{"1 beginbfrange <00> <01> [<0041>", "(unterminated"} {
+		if _, err := ParseCMap([]byte(data)); err == nil {
+			t.Errorf("ParseCMap(%q) expected an error", data)
+		}
+	}
+}
+
+func TestFontToUnicode(t *testing.T) {
+	dict := model.PDFDict{
+		"Subtype":   model.PDFName("Type1"),
+		"BaseFont":  model.PDFName("Helvetica"),
+		"ToUnicode": model.PDFStream{Dict: model.PDFDict{}, Data: []byte(testToUnicode)},
+	}
+	f, err := Load(dict, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Codes the CMap maps take its text; others that of their glyph name.
+	for code, expected := range map[int]string{'A': "B", 'a': "x", 'C': "C"} {
+		if got := f.Unicode(code, 1); got != expected {
+			t.Errorf("Unicode(%q) = %q, expected %q", code, got, expected)
+		}
+	}
+	// Two-byte codes of Type0 fonts take the text of two-byte codes.
+	dict = type0Font("Identity-H", model.PDFDict{"Subtype": model.PDFName("CIDFontType2")})
+	dict["ToUnicode"] = model.PDFStream{Dict: model.PDFDict{}, Data: []byte(testToUnicode)}
+	if f, err = Load(dict, parser.NewObjectTable()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if code, n := f.NextCode([]byte{0, 0x41}); f.Unicode(code, n) != "C" {
+		t.Errorf("Unicode(%#x, %d) = %q, expected \"C\"", code, n, f.Unicode(code, n))
+	}
+}
@@ -0,0 +1,111 @@
+package font
+
+import (
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func TestBaseEncodings(t *testing.T) {
+	tests := []struct {
+		encoding *[256]string
+		code     int
+		expected string
+	}{
+		{&standardEncoding, 0x27, "quoteright"},
+		{&standardEncoding, 0xe9, "Oslash"},
+		{&standardEncoding, 0x80, ""},
+		{&winAnsiEncoding, 0x27, "quotesingle"},
+		{&winAnsiEncoding, 0x80, "Euro"},
+		{&winAnsiEncoding, 0x81, "bullet"},
+		{&winAnsiEncoding, 0xa0, "space"},
+		{&winAnsiEncoding, 0xe9, "eacute"},
+		{&macRomanEncoding, 0x8e, "eacute"},
+		{&macRomanEncoding, 0xdb, "currency"},
+		{&macRomanEncoding, 0xde, "fi"},
+	}
+	for _, tc := range tests {
+		if got := tc.encoding[tc.code]; got != tc.expected {
+			t.Errorf("code %#x = %q, expected %q", tc.code, got, tc.expected)
+		}
+	}
+}
+
+func TestLoadEncoding(t *testing.T) {
+	differences := model.PDFDict{
+		"BaseEncoding": model.PDFName("WinAnsiEncoding"),
+		"Differences": model.PDFArray{
+			model.PDFNumber(65), model.PDFName("Alpha"), model.PDFName("Beta"),
+			model.PDFNumber(0xe9), model.PDFName("uni0416"),
+		},
+	}
+	symbolic := model.PDFDict{"Flags": model.PDFNumber(4)}
+
+	tests := []struct {
+		name     string
+		dict     model.PDFDict
+		code     int
+		glyph    string
+		text     string
+		expected float64
+	}{
+		{"Builtin", model.PDFDict{}, 0x27, "quoteright", "’", 0},
+		{"Name", model.PDFDict{"Encoding": model.PDFName("WinAnsiEncoding")}, 0x80, "Euro", "€", 0},
+		{"UnknownName", model.PDFDict{"Encoding": model.PDFName("Identity-H")}, 'a', "a", "a", 0},
+		{"Differences", model.PDFDict{"Encoding": differences}, 'B', "Beta", "Β", 0},
+		{"DifferencesBase", model.PDFDict{"Encoding": differences}, 0x80, "Euro", "€", 0},
+		{"DifferencesUni", model.PDFDict{"Encoding": differences}, 0xe9, "uni0416", "Ж", 0},
+		{"DifferencesOnly", model.PDFDict{"Encoding": model.PDFDict{"Differences": model.PDFArray{model.PDFNumber(1), model.PDFName("a")}}}, 0x27, "quoteright", "’", 0},
+		{"Symbolic", model.PDFDict{"FontDescriptor": symbolic}, 'a', "", "", 0},
+		{"Standard", model.PDFDict{"BaseFont": model.PDFName("Helvetica"), "Encoding": model.PDFName("WinAnsiEncoding")}, 0xe9, "eacute", "é", 556},
+		{"Symbol", model.PDFDict{"BaseFont": model.PDFName("Symbol")}, 'a', "alpha", "α", 631},
+		{"ZapfDingbats", model.PDFDict{"BaseFont": model.PDFName("ZapfDingbats")}, 0x21, "a1", "✁", 974},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.dict["Subtype"] = model.PDFName("Type1")
+			f, err := Load(tc.dict, parser.NewObjectTable())
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := f.GlyphName(tc.code); got != tc.glyph {
+				t.Errorf("GlyphName(%#x) = %q, expected %q", tc.code, got, tc.glyph)
+			}
+			if got := f.Unicode(tc.code, 1); got != tc.text {
+				t.Errorf("Unicode(%#x) = %q, expected %q", tc.code, got, tc.text)
+			}
+			if got := f.Width(tc.code); got != tc.expected {
+				t.Errorf("Width(%#x) = %v, expected %v", tc.code, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestGlyphUnicode(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"A", "A"},
+		{"Aacute", "Á"},
+		{"dalethatafpatah", "דֲ"},
+		{"a.sc", "a"},
+		{"f_f_i", "ffi"},
+		{"uni0041", "A"},
+		{"uni00410042", "AB"},
+		{"uni20ac", "€"},
+		{"u1F600", "\U0001F600"},
+		{"uniD800", ""},
+		{"uni004", ""},
+		{"u110000", ""},
+		{"unknown", ""},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if got := GlyphUnicode(tc.name); got != tc.expected {
+			t.Errorf("GlyphUnicode(%q) = %q, expected %q", tc.name, got, tc.expected)
+		}
+	}
+}
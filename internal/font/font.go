@@ -0,0 +1,344 @@
+// Package font loads PDF font resources: how the strings shown by the text
+// operators split into character codes, and how far each code advances.
+package font
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/font/cff"
+	"github.com/Kantha2004/go-pdfviewer/internal/font/truetype"
+	"github.com/Kantha2004/go-pdfviewer/internal/font/type1"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// glyphSpace is the font matrix of every font type but Type 3: glyph space
+// has 1000 units per unit of text space.
+var glyphSpace = render.Scale(0.001, 0.001)
+
+// Font is a parsed font resource.
+type Font struct {
+	// Subtype is the font type, such as Type0, Type1, TrueType or Type3.
+	Subtype string
+
+	// BaseFont is the PostScript name of the font, empty for Type 3.
+	BaseFont string
+
+	// Matrix maps glyph space to text space.
+	Matrix render.Matrix
+
+	// widths holds the advance widths, in glyph space, of the codes from
+	// firstChar on. Other codes advance by missingWidth.
+	firstChar    int
+	widths       []float64
+	missingWidth float64
+
+	// std holds the metrics of a standard font, which give the widths
+	// when the font dictionary has none.
+	std *afmMetrics
+
+	// encoding maps the codes of a simple font to glyph names; unencoded
+	// codes map to "".
+	encoding [256]string
+
+	// symbolic is set by the Symbolic flag of the font descriptor.
+	symbolic bool
+
+	// toUnicode maps codes to text ahead of their glyph names.
+	toUnicode *CMap
+
+	// cmap and cid are the CMap and descendant CIDFont of a Type0 font,
+	// which replace the encoding and widths of simple fonts.
+	cmap *CMap
+	cid  *cidFont
+
+	// tt is the embedded TrueType or OpenType font program, and glyphs maps
+	// the codes of a simple font to its glyphs.
+	tt     *truetype.Font
+	glyphs *[256]int
+
+	// t1 is the embedded Type 1 font program.
+	t1 *type1.Font
+
+	// cff is the embedded CFF font program, bare or the outlines of an
+	// OpenType program.
+	cff *cff.Font
+
+	// subst is the bundled face that stands in for a simple font without
+	// a program.
+	subst *substitute
+
+	// charProcs, resources and bbox are the glyph descriptions of a Type 3
+	// font by glyph name, the resources they use and the font's bounding
+	// box.
+	charProcs map[string]model.PDFStream
+	resources model.PDFDict
+	bbox      model.Rectangle
+}
+
+// Load parses a font dictionary, resolving indirect references through r.
+func Load(v model.PDFValue, r model.Resolver) (*Font, error) {
+	dict, ok := r.Resolve(v).(model.PDFDict)
+	if !ok {
+		return nil, fmt.Errorf("font is not a dictionary")
+	}
+
+	subtype, _ := r.Resolve(dict["Subtype"]).(model.PDFName)
+	base, _ := r.Resolve(dict["BaseFont"]).(model.PDFName)
+	f := &Font{Subtype: string(subtype), BaseFont: string(base), Matrix: glyphSpace}
+
+	// A ToUnicode CMap only serves text extraction, so one that cannot be
+	// read is ignored.
+	if s, ok := r.Resolve(dict["ToUnicode"]).(model.PDFStream); ok {
+		if data, err := parser.DecodeStream(s, r); err == nil {
+			f.toUnicode, _ = ParseCMap(data)
+		}
+	}
+
+	if subtype == "Type0" {
+		if err := f.loadComposite(dict, r); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	if subtype == "Type3" {
+		m, ok := model.ResolveNumbers(dict["FontMatrix"], r)
+		if !ok || len(m) != 6 {
+			return nil, fmt.Errorf("Type3 font without a valid /FontMatrix")
+		}
+		f.Matrix = render.Matrix(m)
+		f.loadType3(dict, r)
+	} else {
+		f.std = standardFont(f.BaseFont)
+	}
+
+	fd, _ := r.Resolve(dict["FontDescriptor"]).(model.PDFDict)
+	if w, ok := r.Resolve(fd["MissingWidth"]).(model.PDFNumber); ok {
+		f.missingWidth = float64(w)
+	}
+	flags, _ := r.Resolve(fd["Flags"]).(model.PDFNumber)
+	f.symbolic = int(flags)&flagSymbolic != 0
+
+	f.loadFontFile(fd, r)
+	f.encoding = f.builtinEncoding()
+	f.loadEncoding(dict["Encoding"], r)
+	switch {
+	case f.tt != nil:
+		f.glyphs = f.trueTypeGlyphs()
+	case f.cff != nil:
+		f.glyphs = f.cffGlyphs()
+	case f.t1 == nil && subtype != "Type3":
+		f.loadSubstitute(fd, r)
+	}
+
+	// Entries that are not numbers keep the missing width.
+	if arr, ok := r.Resolve(dict["Widths"]).(model.PDFArray); ok {
+		first, _ := r.Resolve(dict["FirstChar"]).(model.PDFNumber)
+		f.firstChar = int(first)
+		f.widths = make([]float64, len(arr))
+		for i, e := range arr {
+			f.widths[i] = f.missingWidth
+			if w, ok := r.Resolve(e).(model.PDFNumber); ok {
+				f.widths[i] = float64(w)
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// NextCode returns the first character code of a shown string and the
+// number of bytes it takes: one for simple fonts, and as the CMap gives for
+// Type0 fonts.
+func (f *Font) NextCode(s []byte) (code, n int) {
+	if len(s) == 0 {
+		return 0, 0
+	}
+	if f.cmap != nil {
+		c, n := f.cmap.NextCode(s)
+		return int(c), n
+	}
+	return int(s[0]), 1
+}
+
+// CID returns the CID a character code of a Type0 font selects. The codes
+// of simple fonts are returned unchanged.
+func (f *Font) CID(code int) int {
+	if f.cmap == nil {
+		return code
+	}
+	return f.cmap.CID(uint32(code))
+}
+
+// GID returns the glyph index a character code selects. For simple fonts
+// with a TrueType program the character map gives it, and the glyph name
+// with a bare CFF program. For Type0 fonts the CIDToGIDMap gives it for
+// TrueType-based CIDFonts and the charset for CID-keyed CFF programs, and
+// it is the CID otherwise. CIDs beyond the map select glyph 0.
+func (f *Font) GID(code int) int {
+	if f.glyphs != nil {
+		if code < 0 || code >= len(f.glyphs) {
+			return 0
+		}
+		return f.glyphs[code]
+	}
+
+	cid := f.CID(code)
+	if f.cff != nil && f.cff.CIDKeyed {
+		gid, _ := f.cff.GlyphByCID(cid)
+		return gid
+	}
+	if f.cid == nil || f.cid.cidToGID == nil {
+		return cid
+	}
+	if cid < 0 || cid >= len(f.cid.cidToGID) {
+		return 0
+	}
+	return int(f.cid.cidToGID[cid])
+}
+
+// Vertical reports whether the font is written vertically, which only a
+// Type0 font with a vertical CMap is.
+func (f *Font) Vertical() bool {
+	return f.cmap != nil && f.cmap.WMode == 1
+}
+
+// Width returns the advance width of a character code in glyph space.
+func (f *Font) Width(code int) float64 {
+	if f.cid != nil {
+		return f.cid.width(f.CID(code))
+	}
+	if i := code - f.firstChar; i >= 0 && i < len(f.widths) {
+		return f.widths[i]
+	}
+	if f.widths == nil && f.std != nil {
+		if w, ok := f.std.widths[f.GlyphName(code)]; ok {
+			return w
+		}
+	}
+	if f.widths == nil && f.tt != nil {
+		return f.trueTypeWidth(f.GID(code))
+	}
+	if f.widths == nil && f.t1 != nil {
+		if g := f.type1Glyph(code); g != nil {
+			return g.Width
+		}
+	}
+	if f.widths == nil && f.cff != nil {
+		if _, w, ok := f.cffGlyph(f.GID(code)); ok {
+			return w
+		}
+	}
+	return f.missingWidth
+}
+
+// Outline returns the outline of the glyph a character code selects, in
+// text space for a font size of 1, or nil if neither the font's embedded
+// program nor the face standing in for a font without one has the glyph.
+func (f *Font) Outline(code int) *render.Path {
+	var p *render.Path
+	switch {
+	case f.cff != nil:
+		p, _, _ = f.cffGlyph(f.GID(code))
+	case f.tt != nil:
+		p = f.trueTypeOutline(f.GID(code))
+	case f.t1 != nil:
+		if g := f.type1Glyph(code); g != nil {
+			p = g.Outline
+		}
+	case f.subst != nil:
+		p = f.substituteOutline(code)
+	}
+	if p == nil {
+		return nil
+	}
+	return p.Transform(f.Matrix)
+}
+
+// Kern returns the kerning adjustment in glyph space between two character
+// codes of a standard font shown in turn. Showing text does not apply it:
+// producers kern with the numbers of TJ.
+func (f *Font) Kern(left, right int) float64 {
+	if f.std == nil {
+		return 0
+	}
+	return f.std.kern[[2]string{f.GlyphName(left), f.GlyphName(right)}]
+}
+
+// GlyphName returns the name of the glyph a character code selects, or ""
+// if the encoding gives it none.
+func (f *Font) GlyphName(code int) string {
+	if code < 0 || code >= len(f.encoding) {
+		return ""
+	}
+	return f.encoding[code]
+}
+
+// Unicode returns the text of a character code of n bytes, as NextCode
+// returns them, from the ToUnicode CMap or derived from its glyph name, or
+// "" if it is unknown.
+func (f *Font) Unicode(code, n int) string {
+	if f.toUnicode != nil {
+		if s, ok := f.toUnicode.Text(uint32(code), n); ok {
+			return s
+		}
+	}
+	if f.cmap != nil {
+		return unicodeCMapText(f.cmap.Name, uint32(code))
+	}
+
+	name := f.GlyphName(code)
+	if f.std != nil && f.std.fontName == "ZapfDingbats" {
+		return glyphText(name, dingbatsList())
+	}
+	return GlyphUnicode(name)
+}
+
+// flagSymbolic is the font descriptor flag of fonts with glyphs outside the
+// standard Latin character set.
+const flagSymbolic = 1 << 2
+
+// builtinEncoding returns the encoding a simple font has without an
+// /Encoding entry. An embedded Type 1 or bare CFF program or the metrics of
+// a standard font give it; other non-symbolic fonts use StandardEncoding,
+// as do programs that define their encoding as StandardEncoding.
+func (f *Font) builtinEncoding() [256]string {
+	bareCFF := f.cff != nil && f.tt == nil
+	switch {
+	case f.t1 != nil && f.t1.Encoding != [256]string{}:
+		return f.t1.Encoding
+	case bareCFF && f.cff.Encoding != [256]string{}:
+		return f.cff.Encoding
+	case f.t1 != nil, bareCFF:
+		return standardEncoding
+	case f.std != nil:
+		return f.std.encoding
+	case f.Subtype != "Type3" && !f.symbolic:
+		return standardEncoding
+	}
+	return [256]string{}
+}
+
+// Advance returns the displacement of a character code in text space for a
+// font size of 1: horizontal, or vertical for vertical fonts.
+func (f *Font) Advance(code int) float64 {
+	if f.Vertical() {
+		_, dy := f.Matrix.TransformVector(0, f.cid.verticalMetrics(f.CID(code)).w1)
+		return dy
+	}
+	dx, _ := f.Matrix.TransformVector(f.Width(code), 0)
+	return dx
+}
+
+// VerticalOrigin returns the position vector from the horizontal origin of
+// a character code's glyph to its vertical one, in text space for a font
+// size of 1. Glyphs of vertical fonts are shown from their vertical origin.
+func (f *Font) VerticalOrigin(code int) (vx, vy float64) {
+	if f.cid == nil {
+		return 0, 0
+	}
+	m := f.cid.verticalMetrics(f.CID(code))
+	return f.Matrix.TransformVector(m.vx, m.vy)
+}
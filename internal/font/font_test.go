@@ -0,0 +1,103 @@
+package font
+
+import (
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func nums(v ...float64) model.PDFArray {
+	arr := model.PDFArray{}
+	for _, n := range v {
+		arr = append(arr, model.PDFNumber(n))
+	}
+	return arr
+}
+
+func TestLoadWidths(t *testing.T) {
+	dict := model.PDFDict{
+		"Type":           model.PDFName("Font"),
+		"Subtype":        model.PDFName("TrueType"),
+		"BaseFont":       model.PDFName("Example"),
+		"FirstChar":      model.PDFNumber(65),
+		"Widths":         model.PDFArray{model.PDFNumber(600), model.PDFNull{}, model.PDFNumber(500)},
+		"FontDescriptor": model.PDFDict{"MissingWidth": model.PDFNumber(250)},
+	}
+
+	f, err := Load(dict, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if f.Subtype != "TrueType" || f.BaseFont != "Example" {
+		t.Errorf("Load() = %s %s, expected TrueType Example", f.Subtype, f.BaseFont)
+	}
+
+	tests := []struct {
+		code    int
+		advance float64
+	}{
+		{65, 0.6},
+		{66, 0.25},
+		{67, 0.5},
+		{64, 0.25},
+		{68, 0.25},
+	}
+	for _, tc := range tests {
+		if got := f.Advance(tc.code); got != tc.advance {
+			t.Errorf("Advance(%d) = %v, expected %v", tc.code, got, tc.advance)
+		}
+	}
+}
+
+func TestLoadType3(t *testing.T) {
+	r := parser.NewObjectTable()
+	dict := model.PDFDict{
+		"Subtype":    model.PDFName("Type3"),
+		"FontMatrix": nums(0.01, 0, 0, 0.01, 0, 0),
+		"FirstChar":  model.PDFNumber(0),
+		"Widths":     nums(50),
+		"FontBBox":   nums(0, 0, 50, 60),
+		"Encoding":   model.PDFDict{"Differences": model.PDFArray{model.PDFNumber(0), model.PDFName("dot")}},
+		"CharProcs": model.PDFDict{
+			"dot": model.PDFStream{Dict: model.PDFDict{}, Data: []byte("50 0 d0")},
+			"bad": model.PDFNumber(1),
+		},
+		"Resources": model.PDFDict{},
+	}
+
+	f, err := Load(dict, r)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := f.Advance(0); got != 0.5 {
+		t.Errorf("Advance(0) = %v, expected 0.5", got)
+	}
+	if s, ok := f.CharProc(0); !ok || string(s.Data) != "50 0 d0" {
+		t.Errorf("CharProc(0) = %v, %v", s, ok)
+	}
+	if _, ok := f.CharProc(1); ok {
+		t.Errorf("CharProc(1) found a glyph description")
+	}
+	if f.Resources() == nil || f.BBox() != (model.Rectangle{URX: 50, URY: 60}) {
+		t.Errorf("Resources() = %v, BBox() = %v", f.Resources(), f.BBox())
+	}
+
+	delete(dict, "FontMatrix")
+	if _, err := Load(dict, r); err == nil {
+		t.Errorf("Load() without /FontMatrix expected an error")
+	}
+}
+
+func TestNextCode(t *testing.T) {
+	f, err := Load(model.PDFDict{"Subtype": model.PDFName("Type1")}, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if code, n := f.NextCode([]byte("AB")); code != 'A' || n != 1 {
+		t.Errorf("NextCode() = %d, %d, expected 65, 1", code, n)
+	}
+	if _, n := f.NextCode(nil); n != 0 {
+		t.Errorf("NextCode(nil) consumed %d bytes", n)
+	}
+}
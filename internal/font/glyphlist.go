@@ -0,0 +1,105 @@
+package font
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The Adobe Glyph List and its ZapfDingbats counterpart, which map glyph
+// names to Unicode, with their licence in agl/LICENSE.md.
+//
+//go:embed agl/glyphlist.txt agl/zapfdingbats.txt
+var aglFiles embed.FS
+
+var (
+	glyphList    = sync.OnceValue(func() map[string]string { return loadGlyphList("agl/glyphlist.txt") })
+	dingbatsList = sync.OnceValue(func() map[string]string { return loadGlyphList("agl/zapfdingbats.txt") })
+)
+
+// loadGlyphList parses a glyph list file, whose lines hold a glyph name and
+// a space-separated sequence of hexadecimal code points, separated by a
+// semicolon.
+func loadGlyphList(file string) map[string]string {
+	data, _ := aglFiles.ReadFile(file)
+
+	list := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		name, codes, ok := strings.Cut(line, ";")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var sb strings.Builder
+		for _, c := range strings.Fields(codes) {
+			r, err := strconv.ParseUint(c, 16, 32)
+			if err != nil {
+				break
+			}
+			sb.WriteRune(rune(r))
+		}
+		list[name] = sb.String()
+	}
+	return list
+}
+
+// GlyphUnicode returns the text a glyph name stands for under the Adobe Glyph
+// List Specification, or "" if it has none. Suffixes after a period are
+// ignored, components joined by underscores map in turn, and names of the
+// forms uniXXXX and uXXXX[XX] give code points directly.
+func GlyphUnicode(name string) string {
+	return glyphText(name, glyphList())
+}
+
+// glyphText maps a glyph name to text with the given glyph list.
+func glyphText(name string, list map[string]string) string {
+	name, _, _ = strings.Cut(name, ".")
+
+	var sb strings.Builder
+	for _, c := range strings.Split(name, "_") {
+		sb.WriteString(componentText(c, list))
+	}
+	return sb.String()
+}
+
+// componentText maps one component of a glyph name to text.
+func componentText(c string, list map[string]string) string {
+	if s, ok := list[c]; ok {
+		return s
+	}
+
+	// uni is followed by one or more groups of four hex digits, each a
+	// code point of the Basic Multilingual Plane.
+	if hex, ok := strings.CutPrefix(c, "uni"); ok && len(hex) > 0 && len(hex)%4 == 0 {
+		var sb strings.Builder
+		for i := 0; i < len(hex); i += 4 {
+			r, ok := codePoint(hex[i : i+4])
+			if !ok || r > 0xffff {
+				return ""
+			}
+			sb.WriteRune(r)
+		}
+		return sb.String()
+	}
+
+	if hex, ok := strings.CutPrefix(c, "u"); ok && len(hex) >= 4 && len(hex) <= 6 {
+		if r, ok := codePoint(hex); ok {
+			return string(r)
+		}
+	}
+	return ""
+}
+
+// codePoint parses a hexadecimal Unicode scalar value.
+func codePoint(hex string) (rune, bool) {
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || v > 0x10ffff || (v >= 0xd800 && v <= 0xdfff) {
+		return 0, false
+	}
+	return rune(v), true
+}
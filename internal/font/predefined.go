@@ -0,0 +1,106 @@
+package font
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+// The predefined CMaps (PDF 32000-1 9.7.5.2) and the CMaps they extend,
+// gzipped from Adobe's cmap-resources, with their licence in
+// cmaps/LICENSE.md.
+//
+//go:embed cmaps/*.gz
+var cmapFiles embed.FS
+
+// predefinedCMaps holds each predefined CMap, parsed on first use.
+var predefinedCMaps = make(map[string]func() (*CMap, error))
+
+func init() {
+	entries, _ := cmapFiles.ReadDir("cmaps")
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".gz")
+		predefinedCMaps[name] = sync.OnceValues(func() (*CMap, error) {
+			return readPredefinedCMap(path.Join("cmaps", e.Name()))
+		})
+	}
+}
+
+// readPredefinedCMap parses a bundled CMap file and the chain of CMaps it
+// extends.
+func readPredefinedCMap(file string) (*CMap, error) {
+	data, err := readGzipFile(cmapFiles, file)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := ParseCMap(data)
+	if err != nil {
+		return nil, err
+	}
+	if c.useCMap != "" {
+		parent, err := PredefinedCMap(c.useCMap)
+		if err != nil {
+			return nil, err
+		}
+		c.setParent(parent)
+	}
+	return c, nil
+}
+
+// PredefinedCMap returns the predefined CMap with the given name.
+func PredefinedCMap(name string) (*CMap, error) {
+	load, ok := predefinedCMaps[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown CMap %s", name)
+	}
+	return load()
+}
+
+// maxCMapDepth bounds the chain of embedded CMaps extending each other,
+// which a file could make circular.
+const maxCMapDepth = 8
+
+// loadCMap returns the CMap that the /Encoding entry of a Type0 font names
+// or embeds as a stream. An embedded CMap extends the CMap given by the
+// /UseCMap entry of its stream or by its usecmap operator.
+func loadCMap(v model.PDFValue, r model.Resolver, depth int) (*CMap, error) {
+	if depth > maxCMapDepth {
+		return nil, fmt.Errorf("CMaps nested too deeply")
+	}
+
+	switch e := r.Resolve(v).(type) {
+	case model.PDFName:
+		return PredefinedCMap(string(e))
+	case model.PDFStream:
+		data, err := parser.DecodeStream(e, r)
+		if err != nil {
+			return nil, err
+		}
+		c, err := ParseCMap(data)
+		if err != nil {
+			return nil, err
+		}
+
+		var parent *CMap
+		if use, ok := e.Dict["UseCMap"]; ok {
+			parent, err = loadCMap(use, r, depth+1)
+		} else if c.useCMap != "" {
+			parent, err = PredefinedCMap(c.useCMap)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent != nil {
+			c.setParent(parent)
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("invalid CMap %v", e)
+	}
+}
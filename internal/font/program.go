@@ -0,0 +1,55 @@
+package font
+
+import (
+	"github.com/Kantha2004/go-pdfviewer/internal/font/cff"
+	"github.com/Kantha2004/go-pdfviewer/internal/font/truetype"
+	"github.com/Kantha2004/go-pdfviewer/internal/font/type1"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+// loadFontFile parses the font program that a font descriptor embeds:
+// Type 1 in /FontFile, TrueType in /FontFile2, or CFF or OpenType in
+// /FontFile3. The outlines of OpenType programs with a CFF or CFF2 table
+// come from that table. A program that cannot be read leaves the font
+// without outlines, as if it were not embedded.
+func (f *Font) loadFontFile(fd model.PDFDict, r model.Resolver) {
+	for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		s, ok := r.Resolve(fd[key]).(model.PDFStream)
+		if !ok {
+			continue
+		}
+		subtype, _ := r.Resolve(s.Dict["Subtype"]).(model.PDFName)
+		if key == "FontFile3" && subtype != "OpenType" && subtype != "Type1C" && subtype != "CIDFontType0C" {
+			continue
+		}
+
+		data, err := parser.DecodeStream(s, r)
+		if err != nil {
+			return
+		}
+		switch {
+		case key == "FontFile":
+			if t1, err := type1.Parse(data); err == nil {
+				f.t1 = t1
+			}
+		case key == "FontFile3" && subtype != "OpenType":
+			if c, err := cff.Parse(data); err == nil {
+				f.cff = c
+			}
+		default:
+			tt, err := truetype.Parse(data)
+			if err != nil {
+				return
+			}
+			f.tt = tt
+			for _, tag := range []string{"CFF ", "CFF2"} {
+				if c, err := cff.Parse(tt.Table(tag)); err == nil {
+					f.cff = c
+					break
+				}
+			}
+		}
+		return
+	}
+}
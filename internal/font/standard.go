@@ -0,0 +1,114 @@
+package font
+
+import (
+	"embed"
+	"strings"
+	"sync"
+)
+
+// The metrics of the standard 14 fonts (PDF 32000-1 9.6.2.2), which files
+// may use without embedding them or giving their widths. The AFM files are
+// Adobe's Core 14 set, distributed with its MustRead.html.
+//
+//go:embed afm/*.afm
+var afmFiles embed.FS
+
+// standardStyles lists the standard fonts of each family by style: regular,
+// bold, italic and bold italic.
+var standardStyles = map[string][4]string{
+	"Courier":      {"Courier", "Courier-Bold", "Courier-Oblique", "Courier-BoldOblique"},
+	"Helvetica":    {"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique"},
+	"Times":        {"Times-Roman", "Times-Bold", "Times-Italic", "Times-BoldItalic"},
+	"Symbol":       {"Symbol", "Symbol", "Symbol", "Symbol"},
+	"ZapfDingbats": {"ZapfDingbats", "ZapfDingbats", "ZapfDingbats", "ZapfDingbats"},
+}
+
+// standardFamilies maps family names that files use for the standard fonts,
+// including the metric-compatible fonts shipped with Windows, to the
+// families of standardStyles.
+var standardFamilies = map[string]string{
+	"Courier":           "Courier",
+	"CourierNew":        "Courier",
+	"CourierNewPS":      "Courier",
+	"CourierNewPSMT":    "Courier",
+	"Helvetica":         "Helvetica",
+	"Arial":             "Helvetica",
+	"ArialMT":           "Helvetica",
+	"Times":             "Times",
+	"TimesNewRoman":     "Times",
+	"TimesNewRomanPS":   "Times",
+	"TimesNewRomanPSMT": "Times",
+	"Symbol":            "Symbol",
+	"ZapfDingbats":      "ZapfDingbats",
+}
+
+// standardMetrics holds the parsed AFM file of each standard font, loaded on
+// first use.
+var standardMetrics = make(map[string]func() (*afmMetrics, error))
+
+func init() {
+	for _, styles := range standardStyles {
+		for _, name := range styles {
+			standardMetrics[name] = sync.OnceValues(func() (*afmMetrics, error) {
+				data, err := afmFiles.ReadFile("afm/" + name + ".afm")
+				if err != nil {
+					return nil, err
+				}
+				return parseAFM(data)
+			})
+		}
+	}
+}
+
+// StandardName returns the standard font that a base font name refers to,
+// such as Helvetica-Bold for "Arial,Bold" or "ABCDEF+Arial-BoldMT". The
+// second result is false for other fonts.
+func StandardName(name string) (string, bool) {
+	name = strings.ReplaceAll(stripSubsetTag(name), " ", "")
+	if _, ok := standardMetrics[name]; ok {
+		return name, true
+	}
+
+	family, style := name, ""
+	if i := strings.IndexAny(name, ",-"); i >= 0 {
+		family, style = name[:i], name[i+1:]
+	}
+	family, ok := standardFamilies[family]
+	if !ok {
+		return "", false
+	}
+
+	i := 0
+	if strings.Contains(style, "Bold") {
+		i |= 1
+	}
+	if strings.Contains(style, "Italic") || strings.Contains(style, "Oblique") {
+		i |= 2
+	}
+	return standardStyles[family][i], true
+}
+
+// stripSubsetTag removes the tag of six capital letters and a plus sign that
+// prefixes the names of font subsets.
+func stripSubsetTag(name string) string {
+	if len(name) < 7 || name[6] != '+' {
+		return name
+	}
+	for _, c := range name[:6] {
+		if c < 'A' || c > 'Z' {
+			return name
+		}
+	}
+	return name[7:]
+}
+
+// standardFont returns the metrics of the standard font a base font name
+// refers to, or nil. The bundled files are tested to parse.
+func standardFont(name string) *afmMetrics {
+	std, ok := StandardName(name)
+	if !ok {
+		return nil
+	}
+	m, _ := standardMetrics[std]()
+	return m
+}
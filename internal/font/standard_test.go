@@ -0,0 +1,122 @@
+package font
+
+import (
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func TestStandardMetrics(t *testing.T) {
+	for name, load := range standardMetrics {
+		m, err := load()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if m.fontName != name {
+			t.Errorf("%s: FontName = %s", name, m.fontName)
+		}
+		if len(m.widths) == 0 {
+			t.Errorf("%s: no character metrics", name)
+		}
+	}
+	if len(standardMetrics) != 14 {
+		t.Errorf("%d standard fonts, expected 14", len(standardMetrics))
+	}
+}
+
+func TestParseAFM(t *testing.T) {
+	data := []byte("StartFontMetrics 4.1\r\nFontName Test\r\nStartCharMetrics 2\r\n" +
+		"C 65 ; WX 600 ; N A ; B 0 0 600 700 ;\r\nC -1 ; WX 500 ; N Aacute ;\r\nEndCharMetrics\r\n" +
+		"StartKernPairs 1\r\nKPX A Aacute -20\r\nEndKernPairs\r\nEndFontMetrics\r\n")
+
+	m, err := parseAFM(data)
+	if err != nil {
+		t.Fatalf("parseAFM() error = %v", err)
+	}
+	if m.encoding['A'] != "A" || m.widths["A"] != 600 || m.widths["Aacute"] != 500 {
+		t.Errorf("parseAFM() metrics = %v, encoding of 65 = %q", m.widths, m.encoding['A'])
+	}
+	if k := m.kern[[2]string{"A", "Aacute"}]; k != -20 {
+		t.Errorf("kerning = %v, expected -20", k)
+	}
+
+	for _, bad := range []string{"C 65 ; WX 600 ;\n", "FontName X\nC x ; N A ;\n", "FontName X\nKPX A\n", "C 65 ; N A ;\n"} {
+		if _, err := parseAFM([]byte(bad)); err == nil {
+			t.Errorf("parseAFM(%q) expected an error", bad)
+		}
+	}
+}
+
+func TestStandardName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Helvetica", "Helvetica"},
+		{"Times-BoldItalic", "Times-BoldItalic"},
+		{"Arial", "Helvetica"},
+		{"ArialMT", "Helvetica"},
+		{"Arial,Bold", "Helvetica-Bold"},
+		{"Arial-BoldItalicMT", "Helvetica-BoldOblique"},
+		{"ABCDEF+Arial-ItalicMT", "Helvetica-Oblique"},
+		{"TimesNewRoman", "Times-Roman"},
+		{"TimesNewRoman,Bold", "Times-Bold"},
+		{"TimesNewRomanPS-ItalicMT", "Times-Italic"},
+		{"Times New Roman,BoldItalic", "Times-BoldItalic"},
+		{"CourierNewPSMT", "Courier"},
+		{"Courier,Italic", "Courier-Oblique"},
+		{"Symbol,Bold", "Symbol"},
+	}
+	for _, tc := range tests {
+		if got, ok := StandardName(tc.name); !ok || got != tc.expected {
+			t.Errorf("StandardName(%q) = %q, %v, expected %q", tc.name, got, ok, tc.expected)
+		}
+	}
+
+	for _, name := range []string{"Verdana", "Calibri-Bold", "abcdef+Arial"} {
+		if got, ok := StandardName(name); ok {
+			t.Errorf("StandardName(%q) = %q, expected no standard font", name, got)
+		}
+	}
+}
+
+func TestStandardWidths(t *testing.T) {
+	r := parser.NewObjectTable()
+	helvetica := model.PDFDict{"Subtype": model.PDFName("Type1"), "BaseFont": model.PDFName("Helvetica")}
+
+	f, err := Load(helvetica, r)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for code, w := range map[int]float64{'H': 722, 'e': 556, ' ': 278, 0: 0} {
+		if got := f.Width(code); got != w {
+			t.Errorf("Width(%q) = %v, expected %v", code, got, w)
+		}
+	}
+	if k := f.Kern('A', 'V'); k != -70 {
+		t.Errorf("Kern(A, V) = %v, expected -70", k)
+	}
+
+	// Widths in the font dictionary take precedence.
+	helvetica["FirstChar"] = model.PDFNumber('H')
+	helvetica["Widths"] = model.PDFArray{model.PDFNumber(700)}
+	if f, err = Load(helvetica, r); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := f.Width('H'); got != 700 {
+		t.Errorf("Width(H) = %v, expected 700", got)
+	}
+	if got := f.Width('e'); got != 0 {
+		t.Errorf("Width(e) outside /Widths = %v, expected 0", got)
+	}
+
+	symbol, err := Load(model.PDFDict{"Subtype": model.PDFName("Type1"), "BaseFont": model.PDFName("Symbol")}, r)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := symbol.Width('a'); got != 631 {
+		t.Errorf("Symbol Width(a) = %v, expected 631 (alpha)", got)
+	}
+}
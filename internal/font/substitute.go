@@ -0,0 +1,181 @@
+package font
+
+import (
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/font/truetype"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// The fonts that stand in for fonts whose programs are not embedded:
+// DejaVu Serif and the Go fonts, gzipped, with their licences in
+// fallback/LICENSE.md.
+//
+//go:embed fallback/*.gz
+var fallbackFiles embed.FS
+
+// The font descriptor flags (PDF 32000-1 9.8.2) that choose a fallback.
+const (
+	flagFixedPitch = 1 << 0
+	flagSerif      = 1 << 1
+	flagItalic     = 1 << 6
+	flagForceBold  = 1 << 18
+)
+
+// fallbackFaces lists the bundled faces of each family by style: regular,
+// bold, italic and bold italic, as standardStyles does.
+var fallbackFaces = map[string][4]string{
+	"serif": {"DejaVuSerif", "DejaVuSerif-Bold", "DejaVuSerif-Italic", "DejaVuSerif-BoldItalic"},
+	"sans":  {"Go-Regular", "Go-Bold", "Go-Italic", "Go-Bold-Italic"},
+	"mono":  {"Go-Mono", "Go-Mono-Bold", "Go-Mono-Italic", "Go-Mono-Bold-Italic"},
+}
+
+// The words of font names that choose a family or style, in lower case.
+var (
+	monoWords   = []string{"mono", "courier", "consolas", "typewriter"}
+	serifWords  = []string{"serif", "times", "georgia", "garamond", "cambria", "palatino", "baskerville", "minion", "century"}
+	boldWords   = []string{"bold", "black", "heavy", "demi"}
+	italicWords = []string{"italic", "oblique"}
+)
+
+// fallbackFonts holds each bundled face, parsed on first use.
+var fallbackFonts = make(map[string]func() (*truetype.Font, error))
+
+func init() {
+	for _, faces := range fallbackFaces {
+		for _, name := range faces {
+			fallbackFonts[name] = sync.OnceValues(func() (*truetype.Font, error) {
+				data, err := readGzipFile(fallbackFiles, "fallback/"+name+".ttf.gz")
+				if err != nil {
+					return nil, err
+				}
+				return truetype.Parse(data)
+			})
+		}
+	}
+}
+
+// readGzipFile reads a gzipped file of an embedded file system.
+func readGzipFile(fsys embed.FS, file string) ([]byte, error) {
+	data, err := fsys.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+// substitute is a bundled face standing in for a simple font that embeds
+// no program.
+type substitute struct {
+	tt *truetype.Font
+
+	// glyphs maps the codes of the font to glyphs of the face, with 0 for
+	// codes it has no glyph for.
+	glyphs [256]int
+}
+
+// chooseFallback returns the bundled face that stands in for a font, from
+// the flags, weight and italic angle of its font descriptor and the words
+// of its name.
+func chooseFallback(name string, flags int, weight, angle float64) string {
+	// Monotype is a foundry, not a fixed-pitch font.
+	lower := strings.ReplaceAll(strings.ToLower(stripSubsetTag(name)), "monotype", "")
+	family := "sans"
+	switch {
+	case flags&flagFixedPitch != 0 || containsAny(lower, monoWords):
+		family = "mono"
+	case flags&flagSerif != 0 || containsAny(lower, serifWords) && !strings.Contains(lower, "sans"):
+		family = "serif"
+	}
+	bold := flags&flagForceBold != 0 || weight >= 600 || containsAny(lower, boldWords)
+	italic := flags&flagItalic != 0 || angle != 0 || containsAny(lower, italicWords)
+
+	i := 0
+	if bold {
+		i |= 1
+	}
+	if italic {
+		i |= 2
+	}
+	return fallbackFaces[family][i]
+}
+
+// containsAny reports whether s contains any of words.
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSubstitute chooses the bundled face that stands in for a simple font
+// without a program, which includes the standard fonts, and maps its codes
+// to the face's glyphs through the face's Unicode character map: by the
+// text of their glyph names, or the text the font gives them. Codes
+// without either, as in symbolic fonts without an encoding, are looked up
+// as they are. A face that cannot be read leaves the font without outlines.
+func (f *Font) loadSubstitute(fd model.PDFDict, r model.Resolver) {
+	name := f.BaseFont
+	if f.std != nil {
+		name = f.std.fontName
+	}
+	flags, _ := r.Resolve(fd["Flags"]).(model.PDFNumber)
+	weight, _ := r.Resolve(fd["FontWeight"]).(model.PDFNumber)
+	angle, _ := r.Resolve(fd["ItalicAngle"]).(model.PDFNumber)
+
+	face := chooseFallback(name, int(flags), float64(weight), float64(angle))
+	tt, err := fallbackFonts[face]()
+	if err != nil {
+		return
+	}
+	unicode, ok := tt.CMap(truetype.PlatformMicrosoft, truetype.EncodingUnicode)
+	if !ok {
+		return
+	}
+
+	s := &substitute{tt: tt}
+	for code := range s.glyphs {
+		text := []rune(GlyphUnicode(f.encoding[code]))
+		if len(text) != 1 {
+			text = []rune(f.Unicode(code, 1))
+		}
+		if len(text) != 1 {
+			text = []rune{rune(code)}
+		}
+		s.glyphs[code], _ = unicode.Lookup(uint32(text[0]))
+	}
+	f.subst = s
+}
+
+// substituteOutline returns the outline of the substitute glyph of a
+// character code in glyph space, scaled horizontally to the code's width
+// if it has one, or nil if the face has no glyph for it.
+func (f *Font) substituteOutline(code int) *render.Path {
+	if code < 0 || code >= len(f.subst.glyphs) || f.subst.glyphs[code] == 0 {
+		return nil
+	}
+	gid := f.subst.glyphs[code]
+	p, err := f.subst.tt.Outline(gid)
+	if err != nil {
+		return nil
+	}
+
+	s := 1000 / float64(f.subst.tt.UnitsPerEm)
+	sx := s
+	if adv, w := f.subst.tt.Advance(gid), f.Width(code); adv > 0 && w > 0 {
+		sx = w / float64(adv)
+	}
+	return p.Transform(render.Scale(sx, s))
+}
@@ -0,0 +1,119 @@
+package font
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/font/truetype"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func TestChooseFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		flags  int
+		weight float64
+		angle  float64
+		face   string
+	}{
+		{"ABCDEF+Verdana", 32, 0, 0, "Go-Regular"},
+		{"Verdana", 32 | flagForceBold, 0, 0, "Go-Bold"},
+		{"Verdana", 32, 700, 0, "Go-Bold"},
+		{"Verdana,Italic", 32, 0, 0, "Go-Italic"},
+		{"Verdana,BoldItalic", 32, 0, 0, "Go-Bold-Italic"},
+		{"MicrosoftSansSerif", 32, 0, 0, "Go-Regular"},
+		{"Georgia", 32, 0, 0, "DejaVuSerif"},
+		{"Unknown", flagSerif, 0, 0, "DejaVuSerif"},
+		{"Book Antiqua Demibold", flagSerif, 0, 0, "DejaVuSerif-Bold"},
+		{"Georgia-Italic", 32, 0, 0, "DejaVuSerif-Italic"},
+		{"Unknown", flagSerif | flagItalic, 0, -20, "DejaVuSerif-Italic"},
+		{"Unknown", flagSerif, 700, -12, "DejaVuSerif-BoldItalic"},
+		{"Consolas", 32, 0, 0, "Go-Mono"},
+		{"Unknown", flagFixedPitch | flagItalic, 0, 0, "Go-Mono-Italic"},
+		{"Courier-BoldOblique", 0, 0, 0, "Go-Mono-Bold-Italic"},
+		{"MonotypeCorsiva", 32, 0, -15, "Go-Italic"},
+	}
+	for _, tc := range tests {
+		if face := chooseFallback(tc.name, tc.flags, tc.weight, tc.angle); face != tc.face {
+			t.Errorf("chooseFallback(%q, %d, %v, %v) = %s, expected %s",
+				tc.name, tc.flags, tc.weight, tc.angle, face, tc.face)
+		}
+	}
+}
+
+func TestFallbackFaces(t *testing.T) {
+	for name, load := range fallbackFonts {
+		if _, err := load(); err != nil {
+			t.Errorf("loading %s: %v", name, err)
+		}
+	}
+}
+
+func TestSubstituteOutline(t *testing.T) {
+	r := parser.NewObjectTable()
+	load := func(width, flags float64) *Font {
+		t.Helper()
+		f, err := Load(model.PDFDict{
+			"Subtype":        model.PDFName("TrueType"),
+			"BaseFont":       model.PDFName("Unknown"),
+			"FirstChar":      model.PDFNumber('H'),
+			"Widths":         nums(width),
+			"FontDescriptor": model.PDFDict{"Flags": model.PDFNumber(flags)},
+		}, r)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		return f
+	}
+
+	// Glyphs are scaled horizontally to their widths, but not vertically.
+	narrow, wide := load(500, 32).Outline('H'), load(1000, 32).Outline('H')
+	if narrow == nil || wide == nil {
+		t.Fatalf("Outline('H') = %v, %v", narrow, wide)
+	}
+	nMin, nMax := narrow.Bounds()
+	wMin, wMax := wide.Bounds()
+	if math.Abs(wMin.X-2*nMin.X) > 1e-9 || math.Abs(wMax.X-2*nMax.X) > 1e-9 || wMax.X > 1 || wMax.Y != nMax.Y {
+		t.Errorf("Outline('H') bounds = %v, %v at width 500 and %v, %v at 1000", nMin, nMax, wMin, wMax)
+	}
+
+	// Italic fonts get the italic face of their family.
+	italic := load(1000, flagSerif|flagItalic)
+	if italic.subst == nil || italic.subst.tt != mustFallback(t, "DejaVuSerif-Italic") {
+		t.Errorf("italic serif font does not use DejaVuSerif-Italic")
+	}
+
+	// Standard fonts render with a substitute at their metrics' widths;
+	// symbolic fonts without an encoding look codes up as they are.
+	std, err := Load(model.PDFDict{"Subtype": model.PDFName("Type1"), "BaseFont": model.PDFName("Helvetica")}, r)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if p := std.Outline('H'); p == nil {
+		t.Errorf("Outline('H') of Helvetica = nil")
+	} else if _, max := p.Bounds(); max.X > 0.722 {
+		t.Errorf("Outline('H') of Helvetica reaches %v, past its width", max.X)
+	}
+	if load(1000, flagSymbolic).Outline('H') == nil {
+		t.Errorf("Outline('H') of a symbolic font = nil")
+	}
+
+	// Embedded programs need no substitute.
+	embedded, err := Load(trueTypeFont(t, 32, nil), r)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if embedded.subst != nil {
+		t.Errorf("font with a program has a substitute")
+	}
+}
+
+func mustFallback(t *testing.T, name string) *truetype.Font {
+	t.Helper()
+	tt, err := fallbackFonts[name]()
+	if err != nil {
+		t.Fatalf("loading %s: %v", name, err)
+	}
+	return tt
+}
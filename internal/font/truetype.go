@@ -0,0 +1,82 @@
+package font
+
+import (
+	"github.com/Kantha2004/go-pdfviewer/internal/font/truetype"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// trueTypeGlyphs maps the codes of a simple TrueType font to glyphs through
+// the character map PDF 32000-1 9.6.6.4 selects. Non-symbolic fonts look up
+// the Unicode value or Mac OS Roman code of each code's glyph name;
+// symbolic fonts look up the code itself, which a (3, 0) map may place in
+// the private use range at U+F000, U+F100 or U+F200.
+func (f *Font) trueTypeGlyphs() *[256]int {
+	var glyphs [256]int
+
+	unicode, hasUnicode := f.tt.CMap(truetype.PlatformMicrosoft, truetype.EncodingUnicode)
+	symbol, hasSymbol := f.tt.CMap(truetype.PlatformMicrosoft, truetype.EncodingSymbol)
+	mac, hasMac := f.tt.CMap(truetype.PlatformMac, truetype.EncodingMacRoman)
+	first, hasFirst := f.tt.FirstCMap()
+
+	for code := range glyphs {
+		name := f.encoding[code]
+		var gid int
+		var ok bool
+		switch {
+		case !f.symbolic && hasUnicode && name != "":
+			if s := []rune(GlyphUnicode(name)); len(s) == 1 {
+				gid, ok = unicode.Lookup(uint32(s[0]))
+			}
+		case !f.symbolic && hasMac && name != "":
+			if c, found := macRomanCode(name); found {
+				gid, ok = mac.Lookup(uint32(c))
+			}
+		case hasSymbol:
+			for _, base := range []uint32{0, 0xf000, 0xf100, 0xf200} {
+				if gid, ok = symbol.Lookup(base + uint32(code)); ok {
+					break
+				}
+			}
+		case hasMac:
+			gid, ok = mac.Lookup(uint32(code))
+		}
+
+		// Codes the rules leave unmapped, such as those of names outside
+		// the character map, are looked up as they are.
+		if !ok && hasFirst {
+			gid, ok = first.Lookup(uint32(code))
+		}
+		if !ok && !hasFirst {
+			gid = code
+		}
+		glyphs[code] = gid
+	}
+	return &glyphs
+}
+
+// macRomanCode returns the Mac OS Roman code of a glyph name.
+func macRomanCode(name string) (int, bool) {
+	for c, n := range macRomanEncoding {
+		if n == name {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// trueTypeOutline returns the outline of a glyph of the TrueType program
+// in glyph space.
+func (f *Font) trueTypeOutline(gid int) *render.Path {
+	p, err := f.tt.Outline(gid)
+	if err != nil {
+		return nil
+	}
+	s := 1000 / float64(f.tt.UnitsPerEm)
+	return p.Transform(render.Scale(s, s))
+}
+
+// trueTypeWidth returns the advance width of a glyph of the TrueType
+// program in glyph space.
+func (f *Font) trueTypeWidth(gid int) float64 {
+	return float64(f.tt.Advance(gid)) * 1000 / float64(f.tt.UnitsPerEm)
+}
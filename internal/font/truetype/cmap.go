@@ -0,0 +1,185 @@
+package truetype
+
+import "encoding/binary"
+
+// Platform and encoding IDs of the character maps that PDF 32000-1 9.6.6.4
+// selects from.
+const (
+	PlatformMac       = 1
+	PlatformMicrosoft = 3
+
+	// EncodingMacRoman is an encoding of the Mac platform.
+	EncodingMacRoman = 0
+
+	// EncodingSymbol and EncodingUnicode are encodings of the Microsoft
+	// platform.
+	EncodingSymbol  = 0
+	EncodingUnicode = 1
+)
+
+// cmapSubtable is a character map of the cmap table.
+type cmapSubtable struct {
+	platform, encoding int
+	data               []byte
+}
+
+// readCMapTable reads the encoding records of the cmap table. Subtables are
+// only decoded when looked up.
+func readCMapTable(b []byte) []cmapSubtable {
+	if len(b) < 4 {
+		return nil
+	}
+	n := int(binary.BigEndian.Uint16(b[2:]))
+
+	var subtables []cmapSubtable
+	for i := 0; i < n && 4+8*i+8 <= len(b); i++ {
+		e := b[4+8*i:]
+		off := binary.BigEndian.Uint32(e[4:])
+		if int64(off)+4 > int64(len(b)) {
+			continue
+		}
+		subtables = append(subtables, cmapSubtable{
+			platform: int(binary.BigEndian.Uint16(e)),
+			encoding: int(binary.BigEndian.Uint16(e[2:])),
+			data:     b[off:],
+		})
+	}
+	return subtables
+}
+
+// CMap is a character map, mapping character codes to glyph indices.
+type CMap struct {
+	sub cmapSubtable
+}
+
+// CMap returns the character map for a platform and encoding.
+func (f *Font) CMap(platform, encoding int) (*CMap, bool) {
+	for _, s := range f.cmaps {
+		if s.platform == platform && s.encoding == encoding && supportedFormat(s.data) {
+			return &CMap{s}, true
+		}
+	}
+	return nil, false
+}
+
+// FirstCMap returns the first character map in a format this package reads,
+// which fonts with a single map expect to be used whatever its platform.
+func (f *Font) FirstCMap() (*CMap, bool) {
+	for _, s := range f.cmaps {
+		if supportedFormat(s.data) {
+			return &CMap{s}, true
+		}
+	}
+	return nil, false
+}
+
+func supportedFormat(b []byte) bool {
+	switch binary.BigEndian.Uint16(b) {
+	case 0, 4, 6, 12:
+		return true
+	}
+	return false
+}
+
+// Lookup returns the glyph a character code maps to. The second result is
+// false for codes that map to no glyph.
+func (c *CMap) Lookup(code uint32) (int, bool) {
+	b := c.sub.data
+	var gid int
+	switch binary.BigEndian.Uint16(b) {
+	case 0:
+		if code < 256 && 6+int(code) < len(b) {
+			gid = int(b[6+code])
+		}
+	case 4:
+		gid = lookupFormat4(b, code)
+	case 6:
+		gid = lookupFormat6(b, code)
+	case 12:
+		gid = lookupFormat12(b, code)
+	}
+	return gid, gid != 0
+}
+
+// lookupFormat4 looks a code up in a segment mapping to delta values.
+func lookupFormat4(b []byte, code uint32) int {
+	if code > 0xffff || len(b) < 14 {
+		return 0
+	}
+	segX2 := int(binary.BigEndian.Uint16(b[6:]))
+	ends := 14
+	starts := ends + segX2 + 2
+	deltas := starts + segX2
+	offsets := deltas + segX2
+	if offsets+segX2 > len(b) {
+		return 0
+	}
+
+	for i := 0; i < segX2; i += 2 {
+		end := uint32(binary.BigEndian.Uint16(b[ends+i:]))
+		if code > end {
+			continue
+		}
+		start := uint32(binary.BigEndian.Uint16(b[starts+i:]))
+		if code < start {
+			return 0
+		}
+
+		delta := binary.BigEndian.Uint16(b[deltas+i:])
+		ro := int(binary.BigEndian.Uint16(b[offsets+i:]))
+		if ro == 0 {
+			return int(uint16(code) + delta)
+		}
+
+		// The range offset is relative to its own position in the table.
+		at := offsets + i + ro + 2*int(code-start)
+		if at+2 > len(b) {
+			return 0
+		}
+		gid := binary.BigEndian.Uint16(b[at:])
+		if gid == 0 {
+			return 0
+		}
+		return int(gid + delta)
+	}
+	return 0
+}
+
+// lookupFormat6 looks a code up in a trimmed table.
+func lookupFormat6(b []byte, code uint32) int {
+	if len(b) < 10 {
+		return 0
+	}
+	first := uint32(binary.BigEndian.Uint16(b[6:]))
+	count := uint32(binary.BigEndian.Uint16(b[8:]))
+	if code < first || code-first >= count || 10+2*int(code-first)+2 > len(b) {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(b[10+2*(code-first):]))
+}
+
+// lookupFormat12 looks a code up in segmented coverage groups.
+func lookupFormat12(b []byte, code uint32) int {
+	if len(b) < 16 {
+		return 0
+	}
+	n := int(binary.BigEndian.Uint32(b[12:]))
+	n = min(n, (len(b)-16)/12)
+
+	// Groups are sorted by start code.
+	lo, hi := 0, n
+	for lo < hi {
+		mid := (lo + hi) / 2
+		g := b[16+12*mid:]
+		start, end := binary.BigEndian.Uint32(g), binary.BigEndian.Uint32(g[4:])
+		switch {
+		case code < start:
+			hi = mid
+		case code > end:
+			lo = mid + 1
+		default:
+			return int(binary.BigEndian.Uint32(g[8:]) + code - start)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,302 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// Flags of the points of simple glyphs.
+const (
+	flagOnCurve = 1 << 0
+	flagXShort  = 1 << 1
+	flagYShort  = 1 << 2
+	flagRepeat  = 1 << 3
+	flagXSame   = 1 << 4
+	flagYSame   = 1 << 5
+)
+
+// Flags of the components of composite glyphs.
+const (
+	compArgWords = 1 << 0
+	compArgsXY   = 1 << 1
+	compScale    = 1 << 3
+	compMore     = 1 << 5
+	compXYScale  = 1 << 6
+	compTwoByTwo = 1 << 7
+)
+
+// maxComponentDepth bounds the nesting of composite glyphs, which a damaged
+// font could make circular.
+const maxComponentDepth = 8
+
+// Outline returns the outline of a glyph in font units, built from
+// quadratic Bézier curves. Glyphs without contours, such as the space,
+// have an empty outline.
+func (f *Font) Outline(gid int) (*render.Path, error) {
+	if f.glyf == nil {
+		return nil, fmt.Errorf("font has no glyf table")
+	}
+	p := render.NewPath()
+	if err := f.appendGlyph(p, gid, render.Identity, 0); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// glyphData returns the glyf data of a glyph, which is empty for glyphs
+// without contours.
+func (f *Font) glyphData(gid int) ([]byte, error) {
+	if gid < 0 || gid+1 >= len(f.loca) {
+		return nil, fmt.Errorf("glyph %d out of range", gid)
+	}
+	start, end := f.loca[gid], f.loca[gid+1]
+	if start >= end {
+		return nil, nil
+	}
+	if end > uint32(len(f.glyf)) {
+		return nil, fmt.Errorf("glyph %d extends past the glyf table", gid)
+	}
+	return f.glyf[start:end], nil
+}
+
+// appendGlyph appends the outline of a glyph, mapped through m, to p.
+func (f *Font) appendGlyph(p *render.Path, gid int, m render.Matrix, depth int) error {
+	if depth > maxComponentDepth {
+		return fmt.Errorf("composite glyphs nested too deeply")
+	}
+	b, err := f.glyphData(gid)
+	if err != nil || len(b) == 0 {
+		return err
+	}
+	if len(b) < 10 {
+		return fmt.Errorf("glyph %d: truncated header", gid)
+	}
+
+	n := int(int16(binary.BigEndian.Uint16(b)))
+	if n < 0 {
+		return f.appendComposite(p, b[10:], m, depth)
+	}
+	return appendSimple(p, b[10:], n, m)
+}
+
+// glyphPoint is a point of a simple glyph's contours.
+type glyphPoint struct {
+	render.Point
+	onCurve bool
+}
+
+// appendSimple appends the n contours of a simple glyph to p.
+func appendSimple(p *render.Path, b []byte, n int, m render.Matrix) error {
+	if len(b) < 2*n+2 {
+		return fmt.Errorf("truncated glyph")
+	}
+	ends := make([]int, n)
+	numPoints := 0
+	for i := range ends {
+		ends[i] = int(binary.BigEndian.Uint16(b[2*i:]))
+		if ends[i] < numPoints-1 {
+			return fmt.Errorf("contour end points out of order")
+		}
+		numPoints = ends[i] + 1
+	}
+	b = b[2*n:]
+
+	// Skip the hinting instructions.
+	insLen := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+insLen {
+		return fmt.Errorf("truncated glyph")
+	}
+	b = b[2+insLen:]
+
+	flags := make([]byte, 0, numPoints)
+	for len(flags) < numPoints {
+		if len(b) == 0 {
+			return fmt.Errorf("truncated glyph flags")
+		}
+		fl := b[0]
+		b = b[1:]
+		flags = append(flags, fl)
+		if fl&flagRepeat != 0 {
+			if len(b) == 0 {
+				return fmt.Errorf("truncated glyph flags")
+			}
+			for range min(int(b[0]), numPoints-len(flags)) {
+				flags = append(flags, fl)
+			}
+			b = b[1:]
+		}
+	}
+
+	points := make([]glyphPoint, numPoints)
+	b, err := readCoordinates(b, flags, points, flagXShort, flagXSame, func(pt *glyphPoint, v float64) { pt.X = v })
+	if err != nil {
+		return err
+	}
+	if _, err := readCoordinates(b, flags, points, flagYShort, flagYSame, func(pt *glyphPoint, v float64) { pt.Y = v }); err != nil {
+		return err
+	}
+
+	start := 0
+	for i, fl := range flags {
+		points[i].onCurve = fl&flagOnCurve != 0
+		points[i].Point = m.TransformPoint(points[i].Point)
+	}
+	for _, end := range ends {
+		appendContour(p, points[start:end+1])
+		start = end + 1
+	}
+	return nil
+}
+
+// readCoordinates reads the x or y coordinates of the points of a simple
+// glyph, which are stored as deltas from the previous point.
+func readCoordinates(b []byte, flags []byte, points []glyphPoint, short, same byte, set func(*glyphPoint, float64)) ([]byte, error) {
+	v := 0
+	for i, fl := range flags {
+		switch {
+		case fl&short != 0:
+			if len(b) < 1 {
+				return nil, fmt.Errorf("truncated glyph coordinates")
+			}
+			d := int(b[0])
+			if fl&same == 0 {
+				d = -d
+			}
+			v += d
+			b = b[1:]
+		case fl&same == 0:
+			if len(b) < 2 {
+				return nil, fmt.Errorf("truncated glyph coordinates")
+			}
+			v += int(int16(binary.BigEndian.Uint16(b)))
+			b = b[2:]
+		}
+		set(&points[i], float64(v))
+	}
+	return b, nil
+}
+
+// appendContour appends a closed contour of on- and off-curve points. Two
+// consecutive off-curve points imply an on-curve point midway between them.
+func appendContour(p *render.Path, pts []glyphPoint) {
+	if len(pts) == 0 {
+		return
+	}
+
+	// Start at an on-curve point, or at the point implied between the last
+	// and first points if the contour has none.
+	first := 0
+	for first < len(pts) && !pts[first].onCurve {
+		first++
+	}
+	var start render.Point
+	if first == len(pts) {
+		first = 0
+		start = midpoint(pts[0].Point, pts[len(pts)-1].Point)
+	} else {
+		start = pts[first].Point
+		first++
+	}
+	p.MoveTo(start.X, start.Y)
+
+	var ctrl *render.Point
+	for i := range len(pts) {
+		pt := pts[(first+i)%len(pts)]
+		switch {
+		case pt.onCurve && ctrl == nil:
+			p.LineTo(pt.X, pt.Y)
+		case pt.onCurve:
+			p.QuadTo(ctrl.X, ctrl.Y, pt.X, pt.Y)
+			ctrl = nil
+		case ctrl != nil:
+			mid := midpoint(*ctrl, pt.Point)
+			p.QuadTo(ctrl.X, ctrl.Y, mid.X, mid.Y)
+			ctrl = &pt.Point
+		default:
+			ctrl = &pt.Point
+		}
+	}
+	if ctrl != nil {
+		p.QuadTo(ctrl.X, ctrl.Y, start.X, start.Y)
+	}
+	p.Close()
+}
+
+func midpoint(a, b render.Point) render.Point {
+	return a.Add(b).Mul(0.5)
+}
+
+// appendComposite appends the components of a composite glyph, each mapped
+// through its own transform and then m.
+func (f *Font) appendComposite(p *render.Path, b []byte, m render.Matrix, depth int) error {
+	for {
+		if len(b) < 4 {
+			return fmt.Errorf("truncated composite glyph")
+		}
+		flags := binary.BigEndian.Uint16(b)
+		gid := int(binary.BigEndian.Uint16(b[2:]))
+		b = b[4:]
+
+		var dx, dy float64
+		if flags&compArgWords != 0 {
+			if len(b) < 4 {
+				return fmt.Errorf("truncated composite glyph")
+			}
+			dx, dy = float64(int16(binary.BigEndian.Uint16(b))), float64(int16(binary.BigEndian.Uint16(b[2:])))
+			b = b[4:]
+		} else {
+			if len(b) < 2 {
+				return fmt.Errorf("truncated composite glyph")
+			}
+			dx, dy = float64(int8(b[0])), float64(int8(b[1]))
+			b = b[2:]
+		}
+		// Components positioned by matching points, which are rare, are
+		// placed without an offset.
+		if flags&compArgsXY == 0 {
+			dx, dy = 0, 0
+		}
+
+		t := render.Identity
+		var scales int
+		switch {
+		case flags&compScale != 0:
+			scales = 1
+		case flags&compXYScale != 0:
+			scales = 2
+		case flags&compTwoByTwo != 0:
+			scales = 4
+		}
+		if len(b) < 2*scales {
+			return fmt.Errorf("truncated composite glyph")
+		}
+		s := make([]float64, scales)
+		for i := range s {
+			s[i] = f2dot14(b[2*i:])
+		}
+		b = b[2*scales:]
+		switch scales {
+		case 1:
+			t = render.Scale(s[0], s[0])
+		case 2:
+			t = render.Scale(s[0], s[1])
+		case 4:
+			t = render.Matrix{s[0], s[1], s[2], s[3], 0, 0}
+		}
+		t[4], t[5] = dx, dy
+
+		if err := f.appendGlyph(p, gid, t.Multiply(m), depth+1); err != nil {
+			return err
+		}
+		if flags&compMore == 0 {
+			return nil
+		}
+	}
+}
+
+// f2dot14 reads a signed 2.14 fixed-point number.
+func f2dot14(b []byte) float64 {
+	return float64(int16(binary.BigEndian.Uint16(b))) / (1 << 14)
+}
@@ -0,0 +1,145 @@
+// Package truetype reads TrueType and OpenType font programs embedded in PDF
+// files: their character maps, horizontal metrics and glyph outlines.
+package truetype
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Font is a parsed TrueType or OpenType font program.
+type Font struct {
+	// UnitsPerEm is the size of the em square in font units.
+	UnitsPerEm int
+
+	// NumGlyphs is the number of glyphs in the font.
+	NumGlyphs int
+
+	tables map[string][]byte
+
+	// loca holds the offsets of the glyphs in glyf, with one more entry
+	// marking the end of the last glyph. Both are empty for fonts with
+	// CFF outlines.
+	loca []uint32
+	glyf []byte
+
+	// advances holds the advance widths of the glyphs with metrics of
+	// their own; later glyphs repeat the last advance.
+	advances []uint16
+
+	cmaps []cmapSubtable
+}
+
+// Parse parses a font program. Fonts embedded in PDF files are often
+// subsets lacking tables a standalone font requires, so only head and maxp
+// must be present; without cmap, hmtx or glyf the font has no character
+// maps, metrics or outlines.
+func Parse(data []byte) (*Font, error) {
+	tables, err := readTables(data)
+	if err != nil {
+		return nil, err
+	}
+
+	head, maxp := tables["head"], tables["maxp"]
+	if len(head) < 54 {
+		return nil, fmt.Errorf("missing or short head table")
+	}
+	if len(maxp) < 6 {
+		return nil, fmt.Errorf("missing or short maxp table")
+	}
+
+	f := &Font{
+		UnitsPerEm: int(binary.BigEndian.Uint16(head[18:])),
+		NumGlyphs:  int(binary.BigEndian.Uint16(maxp[4:])),
+		tables:     tables,
+	}
+	if f.UnitsPerEm == 0 {
+		f.UnitsPerEm = 1000
+	}
+
+	longLoca := binary.BigEndian.Uint16(head[50:]) != 0
+	f.glyf = tables["glyf"]
+	f.loca = readLoca(tables["loca"], longLoca, f.NumGlyphs)
+	f.advances = readAdvances(tables["hhea"], tables["hmtx"])
+	f.cmaps = readCMapTable(tables["cmap"])
+	return f, nil
+}
+
+// readTables reads the table directory of a font program.
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("font program too short")
+	}
+	switch v := binary.BigEndian.Uint32(data); v {
+	case 0x00010000, 0x74727565, 0x4f54544f: // 1.0, 'true', 'OTTO'
+	default:
+		return nil, fmt.Errorf("unknown font program version %#08x", v)
+	}
+
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*n {
+		return nil, fmt.Errorf("truncated table directory")
+	}
+
+	tables := make(map[string][]byte, n)
+	for i := range n {
+		e := data[12+16*i:]
+		off := int64(binary.BigEndian.Uint32(e[8:]))
+		size := int64(binary.BigEndian.Uint32(e[12:]))
+		// A table running past the end of the data is cut short rather
+		// than rejected, as damaged subsets often are.
+		if off > int64(len(data)) {
+			continue
+		}
+		tables[string(e[:4])] = data[off:min(off+size, int64(len(data)))]
+	}
+	return tables, nil
+}
+
+// readLoca reads the glyph offsets of the loca table.
+func readLoca(b []byte, long bool, numGlyphs int) []uint32 {
+	size := 2
+	if long {
+		size = 4
+	}
+	n := min(len(b)/size, numGlyphs+1)
+
+	loca := make([]uint32, n)
+	for i := range loca {
+		if long {
+			loca[i] = binary.BigEndian.Uint32(b[4*i:])
+		} else {
+			loca[i] = 2 * uint32(binary.BigEndian.Uint16(b[2*i:]))
+		}
+	}
+	return loca
+}
+
+// readAdvances reads the advance widths of the hmtx table, whose number of
+// long metrics hhea gives.
+func readAdvances(hhea, hmtx []byte) []uint16 {
+	if len(hhea) < 36 {
+		return nil
+	}
+	n := min(int(binary.BigEndian.Uint16(hhea[34:])), len(hmtx)/4)
+
+	advances := make([]uint16, n)
+	for i := range advances {
+		advances[i] = binary.BigEndian.Uint16(hmtx[4*i:])
+	}
+	return advances
+}
+
+// Table returns the raw data of a table, or nil if the font has none.
+func (f *Font) Table(tag string) []byte {
+	return f.tables[tag]
+}
+
+// Advance returns the advance width of a glyph in font units, or 0 if the
+// font has no horizontal metrics.
+func (f *Font) Advance(gid int) int {
+	if len(f.advances) == 0 || gid < 0 || gid >= f.NumGlyphs {
+		return 0
+	}
+	return int(f.advances[min(gid, len(f.advances)-1)])
+}
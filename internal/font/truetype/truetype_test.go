@@ -0,0 +1,135 @@
+package truetype
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// glyphs.ttf has five glyphs: an empty .notdef, a 500 by 500 square from
+// (100, 0), a contour of off-curve points, a composite of a half-size
+// square and glyph 4, and a square stored with short coordinates.
+func loadTestFont(t *testing.T) *Font {
+	t.Helper()
+	data, err := os.ReadFile("../../../testdata/glyphs.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return f
+}
+
+func TestParse(t *testing.T) {
+	f := loadTestFont(t)
+	if f.UnitsPerEm != 1000 || f.NumGlyphs != 5 {
+		t.Errorf("UnitsPerEm = %d, NumGlyphs = %d, expected 1000, 5", f.UnitsPerEm, f.NumGlyphs)
+	}
+
+	for gid, w := range map[int]int{0: 500, 1: 700, 2: 800, 4: 800, 5: 0, -1: 0} {
+		if got := f.Advance(gid); got != w {
+			t.Errorf("Advance(%d) = %d, expected %d", gid, got, w)
+		}
+	}
+
+	errors := [][]byte{
+		nil,
+		[]byte("wOFF\x00\x00\x00\x00\x00\x00\x00\x00"),
+		{0, 1, 0, 0, 0, 9, 0, 0, 0, 0, 0, 0},
+		{0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+	for _, data := range errors {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("Parse(% x) expected an error", data)
+		}
+	}
+}
+
+func TestCMapLookup(t *testing.T) {
+	f := loadTestFont(t)
+
+	tests := []struct {
+		platform, encoding int
+		code               uint32
+		gid                int
+	}{
+		{0, 3, 0x42, 2},
+		{0, 3, 0x44, 0},
+		{PlatformMac, EncodingMacRoman, 'A', 1},
+		{PlatformMac, EncodingMacRoman, 'C', 0},
+		{PlatformMicrosoft, EncodingSymbol, 0xf043, 3},
+		{PlatformMicrosoft, EncodingSymbol, 0x43, 0},
+		{PlatformMicrosoft, EncodingUnicode, 0x44, 4},
+		{PlatformMicrosoft, EncodingUnicode, 0x45, 0},
+		{PlatformMicrosoft, EncodingUnicode, 0x10041, 0},
+		{PlatformMicrosoft, 10, 0x42, 2},
+		{PlatformMicrosoft, 10, 0x1f600, 4},
+		{PlatformMicrosoft, 10, 0x1f601, 0},
+	}
+	for _, tc := range tests {
+		c, ok := f.CMap(tc.platform, tc.encoding)
+		if !ok {
+			t.Fatalf("CMap(%d, %d) not found", tc.platform, tc.encoding)
+		}
+		gid, ok := c.Lookup(tc.code)
+		if gid != tc.gid || ok != (tc.gid != 0) {
+			t.Errorf("CMap(%d, %d).Lookup(%#x) = %d, %v, expected %d", tc.platform, tc.encoding, tc.code, gid, ok, tc.gid)
+		}
+	}
+
+	if _, ok := f.CMap(PlatformMicrosoft, 2); ok {
+		t.Errorf("CMap(3, 2) found, expected none")
+	}
+	if c, ok := f.FirstCMap(); !ok || c.sub.platform != 0 {
+		t.Errorf("FirstCMap() = %v, %v, expected the platform 0 map", c, ok)
+	}
+}
+
+func TestOutline(t *testing.T) {
+	f := loadTestFont(t)
+
+	tests := []struct {
+		gid      int
+		kinds    []render.SegmentKind
+		min, max render.Point
+	}{
+		{0, nil, render.Point{}, render.Point{}},
+		{1, []render.SegmentKind{render.SegMoveTo, render.SegLineTo, render.SegLineTo, render.SegLineTo, render.SegLineTo, render.SegClose},
+			render.Point{X: 100, Y: 0}, render.Point{X: 600, Y: 500}},
+		// Two off-curve points imply an on-curve point between them, and a
+		// contour without on-curve points starts at such a point.
+		{2, []render.SegmentKind{render.SegMoveTo, render.SegQuadTo, render.SegQuadTo, render.SegClose,
+			render.SegMoveTo, render.SegQuadTo, render.SegQuadTo, render.SegQuadTo, render.SegQuadTo, render.SegClose},
+			render.Point{X: 0, Y: 0}, render.Point{X: 500, Y: 700}},
+		{3, []render.SegmentKind{render.SegMoveTo, render.SegLineTo, render.SegLineTo, render.SegLineTo, render.SegLineTo, render.SegClose,
+			render.SegMoveTo, render.SegLineTo, render.SegLineTo, render.SegLineTo, render.SegLineTo, render.SegClose},
+			render.Point{X: 100, Y: 50}, render.Point{X: 400, Y: 300}},
+		{4, []render.SegmentKind{render.SegMoveTo, render.SegLineTo, render.SegLineTo, render.SegLineTo, render.SegLineTo, render.SegClose},
+			render.Point{X: 100, Y: 100}, render.Point{X: 200, Y: 200}},
+	}
+	for _, tc := range tests {
+		p, err := f.Outline(tc.gid)
+		if err != nil {
+			t.Fatalf("Outline(%d) error = %v", tc.gid, err)
+		}
+		if len(p.Segs) != len(tc.kinds) {
+			t.Errorf("Outline(%d) has %d segments, expected %d", tc.gid, len(p.Segs), len(tc.kinds))
+			continue
+		}
+		for i, s := range p.Segs {
+			if s.Kind != tc.kinds[i] {
+				t.Errorf("Outline(%d) segment %d = %v, expected %v", tc.gid, i, s.Kind, tc.kinds[i])
+			}
+		}
+		if min, max := p.Bounds(); len(tc.kinds) > 0 && (min != tc.min || max != tc.max) {
+			t.Errorf("Outline(%d) bounds = %v, %v, expected %v, %v", tc.gid, min, max, tc.min, tc.max)
+		}
+	}
+
+	if _, err := f.Outline(5); err == nil {
+		t.Errorf("Outline(5) expected an error")
+	}
+}
@@ -0,0 +1,117 @@
+package font
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+func trueTypeFont(t *testing.T, flags int, encoding model.PDFValue) model.PDFDict {
+	t.Helper()
+	data, err := os.ReadFile("../../testdata/glyphs.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := model.PDFDict{
+		"Subtype":  model.PDFName("TrueType"),
+		"BaseFont": model.PDFName("ABCDEF+Glyphs"),
+		"FontDescriptor": model.PDFDict{
+			"Flags":     model.PDFNumber(flags),
+			"FontFile2": model.PDFStream{Dict: model.PDFDict{}, Data: data},
+		},
+	}
+	if encoding != nil {
+		dict["Encoding"] = encoding
+	}
+	return dict
+}
+
+func TestTrueTypeGlyphs(t *testing.T) {
+	r := parser.NewObjectTable()
+	diffs := model.PDFDict{"Differences": model.PDFArray{model.PDFNumber(1), model.PDFName("C"), model.PDFName("Euro")}}
+
+	tests := []struct {
+		name     string
+		flags    int
+		encoding model.PDFValue
+		gids     map[int]int
+	}{
+		// Glyph names are looked up in the (3, 1) map, and codes without
+		// one as they are in the first map.
+		{"NonSymbolic", 32, model.PDFName("WinAnsiEncoding"), map[int]int{'A': 1, 'D': 4, 1: 0, 'E': 0}},
+		{"Differences", 32, diffs, map[int]int{1: 3, 2: 0, 'B': 2}},
+		// Symbolic codes are found in the (3, 0) map's U+F000 range.
+		{"Symbolic", 4, nil, map[int]int{'A': 1, 'C': 3, 'D': 0}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Load(trueTypeFont(t, tc.flags, tc.encoding), r)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			for code, gid := range tc.gids {
+				if got := f.GID(code); got != gid {
+					t.Errorf("GID(%d) = %d, expected %d", code, got, gid)
+				}
+			}
+		})
+	}
+}
+
+func TestTrueTypeOutline(t *testing.T) {
+	f, err := Load(trueTypeFont(t, 32, nil), parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Without /Widths the advances come from hmtx.
+	if w := f.Width('B'); w != 800 {
+		t.Errorf("Width('B') = %v, expected 800", w)
+	}
+
+	p := f.Outline('A')
+	if p == nil {
+		t.Fatalf("Outline('A') = nil")
+	}
+	min, max := p.Bounds()
+	if min != (render.Point{X: 0.1, Y: 0}) || max != (render.Point{X: 0.6, Y: 0.5}) {
+		t.Errorf("Outline('A') bounds = %v, %v", min, max)
+	}
+
+	noProgram, err := Load(model.PDFDict{"Subtype": model.PDFName("TrueType")}, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// A substitute face stands in for the program, without a glyph for
+	// control codes.
+	if p := noProgram.Outline('A'); p == nil {
+		t.Errorf("Outline() without a font program = nil")
+	}
+	if p := noProgram.Outline(1); p != nil {
+		t.Errorf("Outline(1) without a font program = %v, expected nil", p)
+	}
+}
+
+func TestCIDFontType2Outline(t *testing.T) {
+	fd := trueTypeFont(t, 4, nil)["FontDescriptor"]
+	f, err := Load(type0Font("Identity-H", model.PDFDict{
+		"Subtype":        model.PDFName("CIDFontType2"),
+		"FontDescriptor": fd,
+		"CIDToGIDMap":    model.PDFStream{Dict: model.PDFDict{}, Data: []byte{0, 0, 0, 4}},
+	}), parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	p := f.Outline(1)
+	if p == nil {
+		t.Fatalf("Outline(1) = nil")
+	}
+	min, max := p.Bounds()
+	if min != (render.Point{X: 0.1, Y: 0.1}) || max != (render.Point{X: 0.2, Y: 0.2}) {
+		t.Errorf("Outline(1) bounds = %v, %v, expected glyph 4", min, max)
+	}
+}
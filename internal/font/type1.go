@@ -0,0 +1,24 @@
+package font
+
+import (
+	"github.com/Kantha2004/go-pdfviewer/internal/font/type1"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// type1Glyph returns the glyph of the Type 1 program that a character code
+// selects by name, in glyph space. Names the program lacks select .notdef.
+func (f *Font) type1Glyph(code int) *type1.Glyph {
+	name := f.GlyphName(code)
+	if !f.t1.Has(name) {
+		name = ".notdef"
+	}
+	g, err := f.t1.Glyph(name)
+	if err != nil {
+		return nil
+	}
+
+	// The font matrix maps charstring space to text space, which is 1000
+	// units of glyph space.
+	m := f.t1.FontMatrix.Multiply(render.Scale(1000, 1000))
+	return &type1.Glyph{Outline: g.Outline.Transform(m), Width: g.Width * m[0]}
+}
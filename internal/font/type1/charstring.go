@@ -0,0 +1,390 @@
+package type1
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// Glyph is a glyph drawn by a charstring, in charstring space.
+type Glyph struct {
+	Outline *render.Path
+	Width   float64
+}
+
+// Limits of the charstring interpreter (Adobe Type 1 Font Format, Appendix
+// B), which damaged programs could otherwise exceed.
+const (
+	maxStack     = 24
+	maxCallDepth = 10
+)
+
+// Charstring commands; those of two bytes are escape (12) commands plus 32.
+const (
+	csHStem           = 1
+	csVStem           = 3
+	csVMoveTo         = 4
+	csRLineTo         = 5
+	csHLineTo         = 6
+	csVLineTo         = 7
+	csRRCurveTo       = 8
+	csClosePath       = 9
+	csCallSubr        = 10
+	csReturn          = 11
+	csEscape          = 12
+	csHSBW            = 13
+	csEndChar         = 14
+	csRMoveTo         = 21
+	csHMoveTo         = 22
+	csVHCurveTo       = 30
+	csHVCurveTo       = 31
+	csDotSection      = 32 + 0
+	csVStem3          = 32 + 1
+	csHStem3          = 32 + 2
+	csSeac            = 32 + 6
+	csSBW             = 32 + 7
+	csDiv             = 32 + 12
+	csCallOtherSubr   = 32 + 16
+	csPop             = 32 + 17
+	csSetCurrentPoint = 32 + 33
+)
+
+// Has reports whether the font has a charstring for a glyph name.
+func (f *Font) Has(name string) bool {
+	_, ok := f.charStrings[name]
+	return ok
+}
+
+// Glyph runs the charstring of a glyph. Hints are read but not applied.
+func (f *Font) Glyph(name string) (*Glyph, error) {
+	cs, ok := f.charStrings[name]
+	if !ok {
+		return nil, fmt.Errorf("no charstring for %s", name)
+	}
+
+	d := &decoder{font: f, path: render.NewPath()}
+	if err := d.run(cs, 0); err != nil && err != errEndChar {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &Glyph{Outline: d.path, Width: d.width}, nil
+}
+
+// errEndChar ends the interpretation of a charstring.
+var errEndChar = fmt.Errorf("endchar")
+
+// decoder is the state of the charstring interpreter.
+type decoder struct {
+	font *Font
+	path *render.Path
+
+	stack []float64
+
+	// psStack holds the results of OtherSubrs, which pop moves to the
+	// charstring stack.
+	psStack []float64
+
+	// x and y are the current point; origin offsets the points of an
+	// accent drawn by seac.
+	x, y   float64
+	origin render.Point
+
+	width float64
+
+	// hasWidth is set once hsbw or sbw gives the glyph's width, which those
+	// of the components of an accented glyph do not change.
+	hasWidth bool
+
+	// flex is set between the OtherSubrs starting and ending a flex
+	// sequence, whose moves only collect the points of two curves.
+	flex    bool
+	flexPts []render.Point
+
+	seac bool
+}
+
+// run interprets a charstring or subroutine.
+func (d *decoder) run(cs []byte, depth int) error {
+	if depth > maxCallDepth {
+		return fmt.Errorf("subroutines nested too deeply")
+	}
+
+	for i := 0; i < len(cs); {
+		b := int(cs[i])
+		i++
+
+		// Bytes from 32 on encode numbers.
+		if b >= 32 {
+			v, n, err := number(cs[i-1:])
+			if err != nil {
+				return err
+			}
+			i += n - 1
+			if len(d.stack) >= maxStack {
+				return fmt.Errorf("stack overflow")
+			}
+			d.stack = append(d.stack, v)
+			continue
+		}
+
+		op := b
+		if op == csEscape {
+			if i >= len(cs) {
+				return fmt.Errorf("truncated escape command")
+			}
+			op = 32 + int(cs[i])
+			i++
+		}
+
+		switch op {
+		case csCallSubr:
+			n, ok := d.pop()
+			if !ok || int(n) < 0 || int(n) >= len(d.font.subrs) {
+				return fmt.Errorf("invalid subroutine %v", n)
+			}
+			if err := d.run(d.font.subrs[int(n)], depth+1); err != nil {
+				return err
+			}
+			continue
+		case csReturn:
+			return nil
+		case csCallOtherSubr:
+			if err := d.callOtherSubr(); err != nil {
+				return err
+			}
+			continue
+		case csPop:
+			v := 0.0
+			if n := len(d.psStack); n > 0 {
+				v, d.psStack = d.psStack[n-1], d.psStack[:n-1]
+			}
+			d.stack = append(d.stack, v)
+			continue
+		case csDiv:
+			if len(d.stack) < 2 {
+				return fmt.Errorf("stack underflow")
+			}
+			n := len(d.stack)
+			d.stack[n-2] /= d.stack[n-1]
+			d.stack = d.stack[:n-1]
+			continue
+		}
+
+		if err := d.command(op); err != nil {
+			return err
+		}
+		d.stack = d.stack[:0]
+	}
+	return nil
+}
+
+// number decodes the number a charstring byte sequence starts with, and
+// returns it with the number of bytes it takes.
+func number(b []byte) (float64, int, error) {
+	switch v := int(b[0]); {
+	case v <= 246:
+		return float64(v - 139), 1, nil
+	case v <= 254:
+		if len(b) < 2 {
+			return 0, 0, fmt.Errorf("truncated number")
+		}
+		if v <= 250 {
+			return float64((v-247)*256 + int(b[1]) + 108), 2, nil
+		}
+		return float64(-(v-251)*256 - int(b[1]) - 108), 2, nil
+	default:
+		if len(b) < 5 {
+			return 0, 0, fmt.Errorf("truncated number")
+		}
+		return float64(int32(binary.BigEndian.Uint32(b[1:]))), 5, nil
+	}
+}
+
+func (d *decoder) pop() (float64, bool) {
+	n := len(d.stack)
+	if n == 0 {
+		return 0, false
+	}
+	v := d.stack[n-1]
+	d.stack = d.stack[:n-1]
+	return v, true
+}
+
+// args returns the operands of a command that takes n of them.
+func (d *decoder) args(n int) ([]float64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("negative argument count %d", n)
+	}
+	if len(d.stack) < n {
+		return nil, fmt.Errorf("stack underflow")
+	}
+	return d.stack[len(d.stack)-n:], nil
+}
+
+// arities gives the number of operands of the commands that take any.
+var arities = map[int]int{
+	csHStem: 2, csVStem: 2, csVMoveTo: 1, csRLineTo: 2, csHLineTo: 1, csVLineTo: 1,
+	csRRCurveTo: 6, csHSBW: 2, csRMoveTo: 2, csHMoveTo: 1, csVHCurveTo: 4, csHVCurveTo: 4,
+	csVStem3: 6, csHStem3: 6, csSeac: 5, csSBW: 4, csSetCurrentPoint: 2,
+}
+
+// command executes a command that clears the stack.
+func (d *decoder) command(op int) error {
+	a, err := d.args(arities[op])
+	if err != nil {
+		return err
+	}
+
+	switch op {
+	case csHStem, csVStem, csVStem3, csHStem3, csDotSection:
+		// Hints only serve rendering at small sizes.
+	case csHSBW:
+		d.setWidth(a[0], 0, a[1])
+	case csSBW:
+		d.setWidth(a[0], a[1], a[2])
+	case csRMoveTo:
+		d.moveTo(a[0], a[1])
+	case csHMoveTo:
+		d.moveTo(a[0], 0)
+	case csVMoveTo:
+		d.moveTo(0, a[0])
+	case csRLineTo:
+		d.lineTo(a[0], a[1])
+	case csHLineTo:
+		d.lineTo(a[0], 0)
+	case csVLineTo:
+		d.lineTo(0, a[0])
+	case csRRCurveTo:
+		d.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+	case csVHCurveTo:
+		d.curveTo(0, a[0], a[1], a[2], a[3], 0)
+	case csHVCurveTo:
+		d.curveTo(a[0], 0, a[1], a[2], 0, a[3])
+	case csClosePath:
+		d.path.Close()
+	case csSetCurrentPoint:
+		d.x, d.y = a[0], a[1]
+	case csSeac:
+		return d.accent(a[0], a[1], a[2], int(a[3]), int(a[4]))
+	case csEndChar:
+		return errEndChar
+	default:
+		return fmt.Errorf("unknown command %d", op)
+	}
+	return nil
+}
+
+// setWidth sets the left side bearing point, which is the starting point,
+// and the width of a glyph.
+func (d *decoder) setWidth(sbx, sby, wx float64) {
+	d.x, d.y = sbx, sby
+	if !d.hasWidth {
+		d.width, d.hasWidth = wx, true
+	}
+}
+
+func (d *decoder) point() render.Point {
+	return render.Point{X: d.x, Y: d.y}.Add(d.origin)
+}
+
+func (d *decoder) moveTo(dx, dy float64) {
+	d.x += dx
+	d.y += dy
+	if d.flex {
+		d.flexPts = append(d.flexPts, d.point())
+		return
+	}
+	p := d.point()
+	d.path.MoveTo(p.X, p.Y)
+}
+
+func (d *decoder) lineTo(dx, dy float64) {
+	d.x += dx
+	d.y += dy
+	p := d.point()
+	d.path.LineTo(p.X, p.Y)
+}
+
+func (d *decoder) curveTo(dx1, dy1, dx2, dy2, dx3, dy3 float64) {
+	d.x += dx1
+	d.y += dy1
+	p1 := d.point()
+	d.x += dx2
+	d.y += dy2
+	p2 := d.point()
+	d.x += dx3
+	d.y += dy3
+	p3 := d.point()
+	d.path.CubeTo(p1.X, p1.Y, p2.X, p2.Y, p3.X, p3.Y)
+}
+
+// callOtherSubr implements the OtherSubrs that charstrings rely on: flex
+// (0 to 2) and hint replacement (3). The arguments of others are left for
+// pop to return, last first, as on the PostScript operand stack.
+func (d *decoder) callOtherSubr() error {
+	a, err := d.args(2)
+	if err != nil {
+		return err
+	}
+	num, n := int(a[1]), int(a[0])
+	d.stack = d.stack[:len(d.stack)-2]
+	args, err := d.args(n)
+	if err != nil {
+		return err
+	}
+	args = append([]float64(nil), args...)
+	d.stack = d.stack[:len(d.stack)-n]
+
+	switch num {
+	case 0:
+		// The reference point and the six points of two curves were
+		// collected; the end point is left for setcurrentpoint.
+		d.flex = false
+		if len(d.flexPts) != 7 {
+			return fmt.Errorf("flex with %d points", len(d.flexPts))
+		}
+		p := d.flexPts
+		d.path.CubeTo(p[1].X, p[1].Y, p[2].X, p[2].Y, p[3].X, p[3].Y)
+		d.path.CubeTo(p[4].X, p[4].Y, p[5].X, p[5].Y, p[6].X, p[6].Y)
+		end := p[6].Sub(d.origin)
+		d.psStack = append(d.psStack[:0], end.Y, end.X)
+	case 1:
+		d.flex, d.flexPts = true, d.flexPts[:0]
+	case 2:
+	default:
+		// Hint replacement returns the subroutine number it was given.
+		d.psStack = append(d.psStack[:0], args...)
+	}
+	return nil
+}
+
+// accent draws an accented glyph, seac, from the glyphs of two codes of
+// StandardEncoding: a base glyph and an accent whose origin lies at
+// (adx - asb, ady) from that of the base.
+func (d *decoder) accent(asb, adx, ady float64, base, accent int) error {
+	if d.seac {
+		return fmt.Errorf("nested seac")
+	}
+	if base < 0 || base > 255 || accent < 0 || accent > 255 {
+		return fmt.Errorf("invalid seac codes")
+	}
+	d.seac = true
+
+	for _, c := range []struct {
+		code   int
+		origin render.Point
+	}{
+		{base, render.Point{}},
+		{accent, render.Point{X: adx - asb, Y: ady}},
+	} {
+		cs, ok := d.font.charStrings[StandardEncoding[c.code]]
+		if !ok {
+			return fmt.Errorf("seac component %d missing", c.code)
+		}
+		d.stack, d.origin, d.x, d.y = d.stack[:0], c.origin, 0, 0
+		if err := d.run(cs, 1); err != nil && err != errEndChar {
+			return err
+		}
+	}
+	return errEndChar
+}
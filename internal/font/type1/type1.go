@@ -0,0 +1,370 @@
+// Package type1 reads Type 1 font programs embedded in PDF files with
+// /FontFile: their built-in encoding, and the outlines and widths their
+// charstrings draw.
+package type1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// Font is a parsed Type 1 font program.
+type Font struct {
+	FontName string
+
+	// FontMatrix maps charstring space to text space.
+	FontMatrix render.Matrix
+
+	// Encoding is the built-in encoding, mapping codes to glyph names.
+	// Unencoded codes map to "", as do all codes of fonts that use
+	// StandardEncoding.
+	Encoding [256]string
+
+	// charStrings and subrs hold decrypted charstrings.
+	charStrings map[string][]byte
+	subrs       [][]byte
+}
+
+// Keys of the eexec and charstring encryption (Adobe Type 1 Font Format
+// 7.1).
+const (
+	eexecKey      = 55665
+	charStringKey = 4330
+)
+
+// Parse parses a font program: a clear-text portion defining the font
+// dictionary, followed by the private dictionary and charstrings encrypted
+// with eexec in binary or hexadecimal form. Programs in the segmented PFB
+// format are also accepted.
+func Parse(data []byte) (*Font, error) {
+	data = unwrapPFB(data)
+
+	i := bytes.Index(data, []byte("eexec"))
+	if i < 0 {
+		return nil, fmt.Errorf("no eexec section")
+	}
+	clear, encrypted := data[:i], data[i+len("eexec"):]
+
+	f := &Font{FontMatrix: render.Scale(0.001, 0.001), charStrings: make(map[string][]byte)}
+	if err := f.parseClearText(clear); err != nil {
+		return nil, err
+	}
+
+	encrypted = bytes.TrimLeft(encrypted, " \t\r\n")
+	if isHex(encrypted) {
+		encrypted = decodeHex(encrypted)
+	}
+	if len(encrypted) < 4 {
+		return nil, fmt.Errorf("eexec section too short")
+	}
+	if err := f.parsePrivate(decrypt(encrypted, eexecKey)[4:]); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// unwrapPFB joins the data of the segments of a PFB file. Other data is
+// returned as it is.
+func unwrapPFB(data []byte) []byte {
+	if len(data) < 6 || data[0] != 0x80 {
+		return data
+	}
+
+	var out []byte
+	for len(data) >= 6 && data[0] == 0x80 && data[1] != 3 {
+		n := int(binary.LittleEndian.Uint32(data[2:]))
+		data = data[6:]
+		n = min(n, len(data))
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return out
+}
+
+// isHex reports whether an eexec section is in hexadecimal form, which its
+// first four bytes tell.
+func isHex(b []byte) bool {
+	if len(b) < 4 {
+		return false
+	}
+	for _, c := range b[:4] {
+		if !isHexDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// decodeHex decodes hexadecimal data, skipping white space and stopping at
+// any other byte.
+func decodeHex(b []byte) []byte {
+	digits := make([]byte, 0, len(b))
+	for _, c := range b {
+		if isHexDigit(c) {
+			digits = append(digits, c)
+		} else if !isSpace(c) {
+			break
+		}
+	}
+	out := make([]byte, len(digits)/2)
+	hex.Decode(out, digits[:2*len(out)])
+	return out
+}
+
+// decrypt decrypts eexec or charstring data with the given key.
+func decrypt(b []byte, key uint16) []byte {
+	out := make([]byte, len(b))
+	r := key
+	for i, c := range b {
+		out[i] = c ^ byte(r>>8)
+		r = (uint16(c)+r)*52845 + 22719
+	}
+	return out
+}
+
+// parseClearText reads the font name, matrix and built-in encoding from the
+// clear-text portion.
+func (f *Font) parseClearText(b []byte) error {
+	s := &scanner{b: b}
+	for {
+		tok := s.token()
+		switch tok {
+		case "":
+			return nil
+		case "/FontName":
+			if name := s.token(); len(name) > 1 && name[0] == '/' {
+				f.FontName = name[1:]
+			}
+		case "/FontMatrix":
+			m, err := s.numbers()
+			if err != nil || len(m) != 6 {
+				return fmt.Errorf("invalid /FontMatrix")
+			}
+			f.FontMatrix = render.Matrix(m)
+		case "/Encoding":
+			f.parseEncoding(s)
+		}
+	}
+}
+
+// parseEncoding reads an encoding array defined by "dup code /name put"
+// sequences. StandardEncoding leaves the encoding empty.
+func (f *Font) parseEncoding(s *scanner) {
+	if s.token() == "StandardEncoding" {
+		return
+	}
+
+	for {
+		switch tok := s.token(); tok {
+		case "", "def", "readonly":
+			return
+		case "dup":
+			code, err := strconv.Atoi(s.token())
+			name := s.token()
+			if err != nil || code < 0 || code > 255 || len(name) < 2 || name[0] != '/' || s.token() != "put" {
+				continue
+			}
+			f.Encoding[code] = name[1:]
+		}
+	}
+}
+
+// parsePrivate reads the subroutines and charstrings of the decrypted
+// private portion. Each is a length, a token such as RD and a space, and
+// that many bytes of encrypted charstring.
+func (f *Font) parsePrivate(b []byte) error {
+	s := &scanner{b: b}
+	lenIV := 4
+
+	for {
+		switch tok := s.token(); tok {
+		case "":
+			if len(f.charStrings) == 0 {
+				return fmt.Errorf("font has no charstrings")
+			}
+			return nil
+
+		case "/lenIV":
+			if n, err := strconv.Atoi(s.token()); err == nil {
+				lenIV = n
+			}
+
+		case "/Subrs":
+			n, err := strconv.Atoi(s.token())
+			if err != nil || n < 0 || n > len(b) {
+				return fmt.Errorf("invalid /Subrs")
+			}
+			f.subrs = make([][]byte, n)
+			if s.peek() == "array" {
+				s.token()
+			}
+			// Entries read "dup index length RD data NP".
+			for s.peek() == "dup" {
+				s.token()
+				i, err := strconv.Atoi(s.token())
+				data, ok := s.binary()
+				if err != nil || !ok {
+					return fmt.Errorf("invalid subroutine")
+				}
+				if i >= 0 && i < n {
+					f.subrs[i] = decryptCharString(data, lenIV)
+				}
+				s.token()
+			}
+
+		case "/CharStrings":
+			// Entries read "/name length RD data ND" up to "end".
+			for {
+				name := s.token()
+				if len(name) < 2 || name[0] != '/' {
+					if name == "end" || name == "" {
+						break
+					}
+					continue
+				}
+				data, ok := s.binary()
+				if !ok {
+					return fmt.Errorf("invalid charstring %s", name)
+				}
+				f.charStrings[name[1:]] = decryptCharString(data, lenIV)
+				s.token()
+			}
+		}
+	}
+}
+
+// decryptCharString decrypts a charstring, dropping the lenIV random bytes
+// it starts with. A lenIV of -1 marks charstrings that are not encrypted.
+func decryptCharString(b []byte, lenIV int) []byte {
+	if lenIV < 0 {
+		return b
+	}
+	if len(b) < lenIV {
+		return nil
+	}
+	return decrypt(b, charStringKey)[lenIV:]
+}
+
+// scanner splits PostScript source into tokens. It only distinguishes what
+// reading a font program needs: names keep their slash, and strings,
+// procedures and arrays are returned one delimiter at a time.
+type scanner struct {
+	b   []byte
+	pos int
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return isSpace(c) || bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+// token returns the next token, or "" at the end of the data. Strings are
+// skipped whole and returned as "()".
+func (s *scanner) token() string {
+	for s.pos < len(s.b) {
+		c := s.b[s.pos]
+		switch {
+		case isSpace(c):
+			s.pos++
+		case c == '%':
+			for s.pos < len(s.b) && s.b[s.pos] != '\n' && s.b[s.pos] != '\r' {
+				s.pos++
+			}
+		case c == '(':
+			s.skipString()
+			return "()"
+		case bytes.IndexByte([]byte("[]{}<>"), c) >= 0:
+			s.pos++
+			return string(c)
+		default:
+			start := s.pos
+			s.pos++
+			for s.pos < len(s.b) && !isDelimiter(s.b[s.pos]) {
+				s.pos++
+			}
+			return string(s.b[start:s.pos])
+		}
+	}
+	return ""
+}
+
+// peek returns the next token without consuming it.
+func (s *scanner) peek() string {
+	pos := s.pos
+	tok := s.token()
+	s.pos = pos
+	return tok
+}
+
+// skipString skips a literal string, whose parentheses nest.
+func (s *scanner) skipString() {
+	depth := 0
+	for ; s.pos < len(s.b); s.pos++ {
+		switch s.b[s.pos] {
+		case '\\':
+			s.pos++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				s.pos++
+				return
+			}
+		}
+	}
+}
+
+// numbers reads an array or procedure of numbers.
+func (s *scanner) numbers() ([]float64, error) {
+	open := s.token()
+	if open != "[" && open != "{" {
+		return nil, fmt.Errorf("expected an array")
+	}
+
+	var out []float64
+	for {
+		tok := s.token()
+		switch tok {
+		case "]", "}":
+			return out, nil
+		case "":
+			return nil, fmt.Errorf("unterminated array")
+		}
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+}
+
+// binary reads a length, the token that reads the data, such as RD or -|,
+// and the data, which follows that token after a single space.
+func (s *scanner) binary() ([]byte, bool) {
+	n, err := strconv.Atoi(s.token())
+	if err != nil || n < 0 {
+		return nil, false
+	}
+	if s.token() == "" || s.pos >= len(s.b) {
+		return nil, false
+	}
+	start := s.pos + 1
+	if start+n > len(s.b) {
+		return nil, false
+	}
+	s.pos = start + n
+	return s.b[start:s.pos], true
+}
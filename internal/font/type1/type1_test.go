@@ -0,0 +1,139 @@
+package type1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// glyphs.t1 has a square A, a B of a curve and a line from a subroutine, an
+// F drawn with flex and hint replacement, an Aacute built with seac, and a
+// slash whose width comes from div.
+func readTestFont(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../../../testdata/glyphs.t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParse(t *testing.T) {
+	data := readTestFont(t)
+	i := bytes.Index(data, []byte("eexec")) + len("eexec\n")
+
+	// The eexec section may be hexadecimal, and the program in PFB
+	// segments.
+	hexForm := append(append([]byte(nil), data[:i]...), hex.EncodeToString(data[i:])...)
+	var pfb []byte
+	for j, seg := range [][]byte{data[:i], data[i:]} {
+		pfb = append(pfb, 0x80, byte(j+1))
+		pfb = binary.LittleEndian.AppendUint32(pfb, uint32(len(seg)))
+		pfb = append(pfb, seg...)
+	}
+	pfb = append(pfb, 0x80, 3)
+
+	for name, data := range map[string][]byte{"Binary": data, "Hex": hexForm, "PFB": pfb} {
+		t.Run(name, func(t *testing.T) {
+			f, err := Parse(data)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if f.FontName != "Glyphs" {
+				t.Errorf("FontName = %q, expected Glyphs", f.FontName)
+			}
+			if f.FontMatrix != render.Scale(0.001, 0.001) {
+				t.Errorf("FontMatrix = %v", f.FontMatrix)
+			}
+			if f.Encoding['A'] != "A" || f.Encoding[200] != "Aacute" || f.Encoding['C'] != "" {
+				t.Errorf("Encoding = %q, %q, %q", f.Encoding['A'], f.Encoding[200], f.Encoding['C'])
+			}
+			for _, name := range []string{".notdef", "A", "B", "F", "acute", "Aacute", "slash"} {
+				if !f.Has(name) {
+					t.Errorf("Has(%q) = false", name)
+				}
+			}
+		})
+	}
+
+	errors := [][]byte{
+		[]byte("%!PS-AdobeFont-1.0\n/FontName /X def\n"),
+		[]byte("/FontMatrix [1 0 0] def currentfile eexec\n"),
+		[]byte("currentfile eexec\n00"),
+	}
+	for _, data := range errors {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("Parse(%q) expected an error", data)
+		}
+	}
+}
+
+func TestGlyph(t *testing.T) {
+	f, err := Parse(readTestFont(t))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	line, cube, move, close := render.SegLineTo, render.SegCubeTo, render.SegMoveTo, render.SegClose
+	tests := []struct {
+		name     string
+		width    float64
+		kinds    []render.SegmentKind
+		min, max render.Point
+	}{
+		{"A", 600, []render.SegmentKind{move, line, line, line, close}, render.Point{X: 100}, render.Point{X: 600, Y: 500}},
+		{"B", 700, []render.SegmentKind{move, cube, line, close}, render.Point{}, render.Point{X: 300, Y: 300}},
+		{"F", 600, []render.SegmentKind{move, cube, cube, line, close}, render.Point{}, render.Point{X: 500, Y: 200}},
+		// The accent lies at adx - asb = 100 from the base glyph.
+		{"Aacute", 600, []render.SegmentKind{move, line, line, line, close, move, line, line, close},
+			render.Point{X: 100}, render.Point{X: 600, Y: 700}},
+		{"slash", 278, []render.SegmentKind{move, line, line, close}, render.Point{}, render.Point{X: 500, Y: 300}},
+	}
+	for _, tc := range tests {
+		g, err := f.Glyph(tc.name)
+		if err != nil {
+			t.Errorf("Glyph(%q) error = %v", tc.name, err)
+			continue
+		}
+		if g.Width != tc.width {
+			t.Errorf("Glyph(%q) width = %v, expected %v", tc.name, g.Width, tc.width)
+		}
+		var kinds []render.SegmentKind
+		for _, s := range g.Outline.Segs {
+			kinds = append(kinds, s.Kind)
+		}
+		if !slices.Equal(kinds, tc.kinds) {
+			t.Errorf("Glyph(%q) segments = %v, expected %v", tc.name, kinds, tc.kinds)
+		}
+		if min, max := g.Outline.Bounds(); min != tc.min || max != tc.max {
+			t.Errorf("Glyph(%q) bounds = %v, %v, expected %v, %v", tc.name, min, max, tc.min, tc.max)
+		}
+	}
+
+	if _, err := f.Glyph("missing"); err == nil {
+		t.Errorf("Glyph() of a missing glyph expected an error")
+	}
+}
+
+func TestCharStringErrors(t *testing.T) {
+	f := &Font{charStrings: map[string][]byte{
+		"underflow": {139, 5},
+		"subr":      {139 + 7, 10},
+		"recursive": {139, 10},
+		"escape":    {12},
+		"number":    {255, 0},
+		// -1 3 callothersubr
+		"othersubr": {138, 142, 12, 16},
+	}}
+	f.subrs = [][]byte{{139, 10}}
+	for name := range f.charStrings {
+		if _, err := f.Glyph(name); err == nil {
+			t.Errorf("Glyph(%q) expected an error", name)
+		}
+	}
+}
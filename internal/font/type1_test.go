@@ -0,0 +1,51 @@
+package font
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+func TestType1Program(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/glyphs.t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(model.PDFDict{
+		"Subtype":  model.PDFName("Type1"),
+		"BaseFont": model.PDFName("ABCDEF+Glyphs"),
+		"FontDescriptor": model.PDFDict{
+			"Flags":    model.PDFNumber(4),
+			"FontFile": model.PDFStream{Dict: model.PDFDict{}, Data: data},
+		},
+	}, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Without /Encoding the program's built-in encoding applies.
+	if name := f.GlyphName(200); name != "Aacute" {
+		t.Errorf("GlyphName(200) = %q, expected Aacute", name)
+	}
+	// Without /Widths the advances come from the charstrings.
+	if w := f.Width('A'); w != 600 {
+		t.Errorf("Width('A') = %v, expected 600", w)
+	}
+
+	p := f.Outline('A')
+	if p == nil {
+		t.Fatalf("Outline('A') = nil")
+	}
+	min, max := p.Bounds()
+	if min != (render.Point{X: 0.1, Y: 0}) || max != (render.Point{X: 0.6, Y: 0.5}) {
+		t.Errorf("Outline('A') bounds = %v, %v", min, max)
+	}
+
+	// Codes the program has no glyph for draw .notdef.
+	if p := f.Outline('C'); p == nil {
+		t.Errorf("Outline('C') = nil, expected .notdef")
+	}
+}
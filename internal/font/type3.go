@@ -0,0 +1,41 @@
+package font
+
+import (
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+// loadType3 reads the glyph descriptions of a Type 3 font, the resources
+// they use and the font's bounding box. Entries of /CharProcs that are not
+// streams are left out.
+func (f *Font) loadType3(dict model.PDFDict, r model.Resolver) {
+	procs, _ := r.Resolve(dict["CharProcs"]).(model.PDFDict)
+	f.charProcs = make(map[string]model.PDFStream, len(procs))
+	for name, v := range procs {
+		if s, ok := r.Resolve(v).(model.PDFStream); ok {
+			f.charProcs[name] = s
+		}
+	}
+	f.resources, _ = r.Resolve(dict["Resources"]).(model.PDFDict)
+	f.bbox, _ = parser.ParseRectangle(dict["FontBBox"], r)
+}
+
+// CharProc returns the glyph description of a character code of a Type 3
+// font: the content stream that /CharProcs holds under its glyph name.
+func (f *Font) CharProc(code int) (model.PDFStream, bool) {
+	s, ok := f.charProcs[f.GlyphName(code)]
+	return s, ok
+}
+
+// Resources returns the resources of the glyph descriptions of a Type 3
+// font, or nil if it has none and those of the content stream showing the
+// text apply.
+func (f *Font) Resources() model.PDFDict {
+	return f.resources
+}
+
+// BBox returns the /FontBBox of a Type 3 font in glyph space, which is
+// empty if the font has none.
+func (f *Font) BBox() model.Rectangle {
+	return f.bbox
+}
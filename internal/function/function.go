@@ -0,0 +1,161 @@
+// Package function evaluates PDF function objects, which map m input values
+// to n output values and drive tint transforms, shadings and transfer
+// functions.
+package function
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// Function is a parsed PDF function.
+type Function interface {
+	// NumInputs returns the number of input values m.
+	NumInputs() int
+
+	// NumOutputs returns the number of output values n.
+	NumOutputs() int
+
+	// Evaluate computes the outputs for in, which is clipped to the domain.
+	// Outputs are clipped to the range when one is defined. Evaluation never
+	// fails: a PostScript program that errors at run time yields the lower
+	// bound of each output's range.
+	Evaluate(in []float64) []float64
+}
+
+// Parse returns the function described by a dictionary or stream. An array
+// of functions, as allowed for shadings, is combined into one function whose
+// outputs are those of each element in turn.
+func Parse(v model.PDFValue, r model.Resolver) (Function, error) {
+	return parse(v, r, 0)
+}
+
+// maxParseDepth bounds the nesting of stitching functions.
+const maxParseDepth = 8
+
+func parse(v model.PDFValue, r model.Resolver, depth int) (Function, error) {
+	if depth > maxParseDepth {
+		return nil, fmt.Errorf("functions nested too deeply")
+	}
+
+	var dict model.PDFDict
+	var stream *model.PDFStream
+
+	switch f := r.Resolve(v).(type) {
+	case model.PDFDict:
+		dict = f
+	case model.PDFStream:
+		dict, stream = f.Dict, &f
+	case model.PDFArray:
+		return parseArray(f, r, depth)
+	default:
+		return nil, fmt.Errorf("invalid function %v", f)
+	}
+
+	domain, ok := model.ResolveNumbers(dict["Domain"], r)
+	if !ok || len(domain) == 0 || len(domain)%2 != 0 {
+		return nil, fmt.Errorf("invalid function /Domain")
+	}
+
+	rng, _ := model.ResolveNumbers(dict["Range"], r)
+	if len(rng)%2 != 0 {
+		return nil, fmt.Errorf("invalid function /Range")
+	}
+
+	b := base{domain: domain, rng: rng}
+
+	typ, _ := r.Resolve(dict["FunctionType"]).(model.PDFNumber)
+	switch typ {
+	case 0:
+		if stream == nil {
+			return nil, fmt.Errorf("sampled function is not a stream")
+		}
+		return parseSampled(b, *stream, r)
+	case 2:
+		return parseExponential(b, dict, r)
+	case 3:
+		return parseStitching(b, dict, r, depth)
+	case 4:
+		if stream == nil {
+			return nil, fmt.Errorf("PostScript function is not a stream")
+		}
+		return parsePostScript(b, *stream, r)
+	default:
+		return nil, fmt.Errorf("unsupported function type %v", dict["FunctionType"])
+	}
+}
+
+// base holds the entries common to every function type.
+type base struct {
+	domain []float64
+	rng    []float64
+}
+
+func (b base) NumInputs() int { return len(b.domain) / 2 }
+
+// clipInputs returns in clipped to the domain, padding missing inputs with
+// the domain's lower bounds.
+func (b base) clipInputs(in []float64) []float64 {
+	out := make([]float64, len(b.domain)/2)
+	for i := range out {
+		v := b.domain[2*i]
+		if i < len(in) {
+			v = in[i]
+		}
+		out[i] = util.Clamp(v, b.domain[2*i], b.domain[2*i+1])
+	}
+	return out
+}
+
+// clipOutputs clips out to the range, if there is one.
+func (b base) clipOutputs(out []float64) []float64 {
+	for i := range out {
+		if 2*i+1 < len(b.rng) {
+			out[i] = util.Clamp(out[i], b.rng[2*i], b.rng[2*i+1])
+		}
+	}
+	return out
+}
+
+// combined evaluates several single-input functions side by side.
+type combined []Function
+
+func parseArray(arr model.PDFArray, r model.Resolver, depth int) (Function, error) {
+	if len(arr) == 0 {
+		return nil, fmt.Errorf("empty function array")
+	}
+
+	fns := make(combined, len(arr))
+	for i, v := range arr {
+		f, err := parse(v, r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && f.NumInputs() != fns[0].NumInputs() {
+			return nil, fmt.Errorf("functions in array have different input counts")
+		}
+		fns[i] = f
+	}
+
+	return fns, nil
+}
+
+func (c combined) NumInputs() int { return c[0].NumInputs() }
+
+func (c combined) NumOutputs() int {
+	n := 0
+	for _, f := range c {
+		n += f.NumOutputs()
+	}
+	return n
+}
+
+func (c combined) Evaluate(in []float64) []float64 {
+	out := make([]float64, 0, c.NumOutputs())
+	for _, f := range c {
+		out = append(out, f.Evaluate(in)...)
+	}
+	return out
+}
@@ -0,0 +1,205 @@
+package function
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func nums(v ...float64) model.PDFArray {
+	arr := make(model.PDFArray, len(v))
+	for i, x := range v {
+		arr[i] = model.PDFNumber(x)
+	}
+	return arr
+}
+
+func mustParse(t *testing.T, v model.PDFValue) Function {
+	t.Helper()
+
+	f, err := Parse(v, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return f
+}
+
+func checkOutputs(t *testing.T, f Function, in, expected []float64) {
+	t.Helper()
+
+	got := f.Evaluate(in)
+	if len(got) != len(expected) {
+		t.Fatalf("Evaluate(%v) = %v, expected %v", in, got, expected)
+	}
+	for i := range got {
+		if math.Abs(got[i]-expected[i]) > 1e-6 {
+			t.Errorf("Evaluate(%v) = %v, expected %v", in, got, expected)
+			return
+		}
+	}
+}
+
+func TestExponential(t *testing.T) {
+	f := mustParse(t, model.PDFDict{
+		"FunctionType": model.PDFNumber(2),
+		"Domain":       nums(0, 1),
+		"C0":           nums(1, 0),
+		"C1":           nums(0, 1),
+		"N":            model.PDFNumber(2),
+	})
+
+	if f.NumInputs() != 1 || f.NumOutputs() != 2 {
+		t.Fatalf("dimensions = %d -> %d, expected 1 -> 2", f.NumInputs(), f.NumOutputs())
+	}
+
+	checkOutputs(t, f, []float64{0}, []float64{1, 0})
+	checkOutputs(t, f, []float64{0.5}, []float64{0.75, 0.25})
+	// Inputs are clipped to the domain.
+	checkOutputs(t, f, []float64{3}, []float64{0, 1})
+}
+
+func TestExponentialRange(t *testing.T) {
+	f := mustParse(t, model.PDFDict{
+		"FunctionType": model.PDFNumber(2),
+		"Domain":       nums(0, 10),
+		"Range":        nums(0, 4),
+		"N":            model.PDFNumber(1),
+	})
+
+	checkOutputs(t, f, []float64{2}, []float64{2})
+	checkOutputs(t, f, []float64{8}, []float64{4})
+}
+
+func TestSampled(t *testing.T) {
+	tests := []struct {
+		name     string
+		dict     model.PDFDict
+		data     []byte
+		in       []float64
+		expected []float64
+	}{
+		{
+			name: "Linear",
+			dict: model.PDFDict{
+				"Domain": nums(0, 1), "Range": nums(0, 1),
+				"Size": nums(3), "BitsPerSample": model.PDFNumber(8),
+			},
+			data:     []byte{0, 255, 0},
+			in:       []float64{0.25},
+			expected: []float64{0.5},
+		},
+		{
+			name: "Bilinear",
+			dict: model.PDFDict{
+				"Domain": nums(0, 1, 0, 1), "Range": nums(0, 1),
+				"Size": nums(2, 2), "BitsPerSample": model.PDFNumber(8),
+			},
+			// Samples vary fastest in the first input.
+			data:     []byte{0, 255, 0, 255},
+			in:       []float64{0.5, 0.9},
+			expected: []float64{0.5},
+		},
+		{
+			name: "Decode",
+			dict: model.PDFDict{
+				"Domain": nums(0, 1), "Range": nums(0, 10),
+				"Decode": nums(10, 0),
+				"Size":   nums(2), "BitsPerSample": model.PDFNumber(4),
+			},
+			data:     []byte{0x0f},
+			in:       []float64{1},
+			expected: []float64{0},
+		},
+		{
+			name: "Encode",
+			dict: model.PDFDict{
+				"Domain": nums(0, 1), "Range": nums(0, 1),
+				"Encode": nums(1, 1),
+				"Size":   nums(2), "BitsPerSample": model.PDFNumber(8),
+			},
+			data:     []byte{0, 255},
+			in:       []float64{0},
+			expected: []float64{1},
+		},
+		{
+			name: "MultipleOutputs16",
+			dict: model.PDFDict{
+				"Domain": nums(0, 1), "Range": nums(0, 1, 0, 1),
+				"Size": nums(2), "BitsPerSample": model.PDFNumber(16),
+			},
+			data:     []byte{0, 0, 0xff, 0xff, 0xff, 0xff, 0, 0},
+			in:       []float64{0.5},
+			expected: []float64{0.5, 0.5},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.dict["FunctionType"] = model.PDFNumber(0)
+			f := mustParse(t, model.PDFStream{Dict: tc.dict, Data: tc.data})
+			checkOutputs(t, f, tc.in, tc.expected)
+		})
+	}
+}
+
+func TestStitching(t *testing.T) {
+	linear := func(c0, c1 float64) model.PDFDict {
+		return model.PDFDict{
+			"FunctionType": model.PDFNumber(2), "Domain": nums(0, 1),
+			"C0": nums(c0), "C1": nums(c1), "N": model.PDFNumber(1),
+		}
+	}
+
+	f := mustParse(t, model.PDFDict{
+		"FunctionType": model.PDFNumber(3),
+		"Domain":       nums(0, 1),
+		"Functions":    model.PDFArray{linear(0, 1), linear(1, 0)},
+		"Bounds":       nums(0.5),
+		"Encode":       nums(0, 1, 0, 1),
+	})
+
+	checkOutputs(t, f, []float64{0.25}, []float64{0.5})
+	checkOutputs(t, f, []float64{0.5}, []float64{1})
+	checkOutputs(t, f, []float64{0.75}, []float64{0.5})
+	checkOutputs(t, f, []float64{1}, []float64{0})
+}
+
+func TestFunctionArray(t *testing.T) {
+	f := mustParse(t, model.PDFArray{
+		model.PDFDict{"FunctionType": model.PDFNumber(2), "Domain": nums(0, 1), "N": model.PDFNumber(1)},
+		model.PDFDict{"FunctionType": model.PDFNumber(2), "Domain": nums(0, 1), "C0": nums(1), "C1": nums(0), "N": model.PDFNumber(1)},
+	})
+
+	if f.NumOutputs() != 2 {
+		t.Fatalf("NumOutputs() = %d, expected 2", f.NumOutputs())
+	}
+	checkOutputs(t, f, []float64{0.25}, []float64{0.25, 0.75})
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    model.PDFValue
+	}{
+		{"NoDomain", model.PDFDict{"FunctionType": model.PDFNumber(2), "N": model.PDFNumber(1)}},
+		{"UnknownType", model.PDFDict{"FunctionType": model.PDFNumber(7), "Domain": nums(0, 1)}},
+		{"SampledNotStream", model.PDFDict{"FunctionType": model.PDFNumber(0), "Domain": nums(0, 1), "Range": nums(0, 1)}},
+		{"SampledTooLarge", model.PDFStream{Dict: model.PDFDict{
+			"FunctionType": model.PDFNumber(0), "Domain": nums(0, 1, 0, 1), "Range": nums(0, 1),
+			"Size": nums(1e5, 1e5), "BitsPerSample": model.PDFNumber(8),
+		}}},
+		{"StitchingBounds", model.PDFDict{
+			"FunctionType": model.PDFNumber(3), "Domain": nums(0, 1),
+			"Functions": model.PDFArray{model.PDFDict{"FunctionType": model.PDFNumber(2), "Domain": nums(0, 1), "N": model.PDFNumber(1)}},
+			"Bounds":    nums(0.5), "Encode": nums(0, 1),
+		}},
+	}
+
+	for _, tc := range tests {
+		if _, err := Parse(tc.v, parser.NewObjectTable()); err == nil {
+			t.Errorf("%s: Parse() expected an error", tc.name)
+		}
+	}
+}
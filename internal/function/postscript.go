@@ -0,0 +1,600 @@
+package function
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+// psOp identifies an instruction of a PostScript calculator program.
+type psOp int
+
+const (
+	psPush psOp = iota
+	psPushBool
+	psProc // procedure literal, only valid as the operand of if/ifelse
+	psIf
+	psIfElse
+
+	psAbs
+	psAdd
+	psAtan
+	psCeiling
+	psCos
+	psCvi
+	psCvr
+	psDiv
+	psExp
+	psFloor
+	psIdiv
+	psLn
+	psLog
+	psMod
+	psMul
+	psNeg
+	psRound
+	psSin
+	psSqrt
+	psSub
+	psTruncate
+
+	psAnd
+	psBitshift
+	psEq
+	psGe
+	psGt
+	psLe
+	psLt
+	psNe
+	psNot
+	psOr
+	psXor
+
+	psCopy
+	psDup
+	psExch
+	psIndex
+	psPop
+	psRoll
+)
+
+var psOperators = map[string]psOp{
+	"abs": psAbs, "add": psAdd, "atan": psAtan, "ceiling": psCeiling,
+	"cos": psCos, "cvi": psCvi, "cvr": psCvr, "div": psDiv, "exp": psExp,
+	"floor": psFloor, "idiv": psIdiv, "ln": psLn, "log": psLog, "mod": psMod,
+	"mul": psMul, "neg": psNeg, "round": psRound, "sin": psSin,
+	"sqrt": psSqrt, "sub": psSub, "truncate": psTruncate,
+
+	"and": psAnd, "bitshift": psBitshift, "eq": psEq, "ge": psGe, "gt": psGt,
+	"le": psLe, "lt": psLt, "ne": psNe, "not": psNot, "or": psOr, "xor": psXor,
+
+	"copy": psCopy, "dup": psDup, "exch": psExch, "index": psIndex,
+	"pop": psPop, "roll": psRoll,
+}
+
+type psInstr struct {
+	op   psOp
+	num  float64
+	then []psInstr // body of psProc, psIf and psIfElse
+	els  []psInstr // else branch of psIfElse
+}
+
+// postScript is a Type 4 function, a program in a subset of PostScript.
+type postScript struct {
+	base
+	prog []psInstr
+}
+
+func parsePostScript(b base, stream model.PDFStream, r model.Resolver) (Function, error) {
+	if len(b.rng) == 0 {
+		return nil, fmt.Errorf("PostScript function without /Range")
+	}
+
+	data, err := parser.DecodeStream(stream, r)
+	if err != nil {
+		return nil, fmt.Errorf("PostScript function: %w", err)
+	}
+
+	prog, err := compilePostScript(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postScript{base: b, prog: prog}, nil
+}
+
+// maxProcDepth bounds the nesting of procedures in a program.
+const maxProcDepth = 32
+
+// compilePostScript parses a calculator program, a single procedure in
+// braces, into a tree of instructions.
+func compilePostScript(data []byte) ([]psInstr, error) {
+	lex := parser.NewLexer(bytes.NewReader(data))
+
+	tok, err := lex.NextToken()
+	if err != nil {
+		return nil, err
+	}
+	if tok.Type != model.TokProcStart {
+		return nil, fmt.Errorf("PostScript function does not start with '{'")
+	}
+
+	prog, err := compileProc(lex, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, err = lex.NextToken(); err != nil || tok.Type != model.TokEOF {
+		return nil, fmt.Errorf("unexpected data after PostScript procedure")
+	}
+
+	return prog, nil
+}
+
+// compileProc compiles the body of a procedure whose opening brace has been
+// read, up to and including the closing brace.
+func compileProc(lex *parser.Lexer, depth int) ([]psInstr, error) {
+	if depth > maxProcDepth {
+		return nil, fmt.Errorf("PostScript procedures nested too deeply")
+	}
+
+	var prog []psInstr
+
+	// proc returns the procedure literal n instructions from the end.
+	proc := func(n int) ([]psInstr, bool) {
+		if len(prog) < n || prog[len(prog)-n].op != psProc {
+			return nil, false
+		}
+		return prog[len(prog)-n].then, true
+	}
+
+	for {
+		tok, err := lex.NextToken()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok.Type {
+		case model.TokProcEnd:
+			for _, in := range prog {
+				if in.op == psProc {
+					return nil, fmt.Errorf("procedure not followed by if or ifelse")
+				}
+			}
+			return prog, nil
+
+		case model.TokProcStart:
+			body, err := compileProc(lex, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			prog = append(prog, psInstr{op: psProc, then: body})
+
+		case model.TokNumber:
+			v, err := strconv.ParseFloat(tok.Value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", tok.Value)
+			}
+			prog = append(prog, psInstr{op: psPush, num: v})
+
+		case model.TokKeyword:
+			switch tok.Value {
+			case "true", "false":
+				prog = append(prog, psInstr{op: psPushBool, num: boolNum(tok.Value == "true")})
+
+			case "if":
+				then, ok := proc(1)
+				if !ok {
+					return nil, fmt.Errorf("if without procedure")
+				}
+				prog[len(prog)-1] = psInstr{op: psIf, then: then}
+
+			case "ifelse":
+				then, ok1 := proc(2)
+				els, ok2 := proc(1)
+				if !ok1 || !ok2 {
+					return nil, fmt.Errorf("ifelse without two procedures")
+				}
+				prog = append(prog[:len(prog)-2], psInstr{op: psIfElse, then: then, els: els})
+
+			default:
+				op, ok := psOperators[tok.Value]
+				if !ok {
+					return nil, fmt.Errorf("unknown PostScript operator %q", tok.Value)
+				}
+				prog = append(prog, psInstr{op: op})
+			}
+
+		case model.TokEOF:
+			return nil, fmt.Errorf("unterminated PostScript procedure")
+
+		default:
+			return nil, fmt.Errorf("unexpected %v in PostScript function", tok.Type)
+		}
+	}
+}
+
+func (f *postScript) NumOutputs() int { return len(f.rng) / 2 }
+
+func (f *postScript) Evaluate(in []float64) []float64 {
+	n := f.NumOutputs()
+	out := make([]float64, n)
+
+	vm := &psMachine{}
+	for _, v := range f.clipInputs(in) {
+		vm.stack = append(vm.stack, psValue{num: v})
+	}
+
+	if err := vm.run(f.prog); err != nil || len(vm.stack) < n {
+		for i := range out {
+			out[i] = f.rng[2*i]
+		}
+		return out
+	}
+
+	for i, v := range vm.stack[len(vm.stack)-n:] {
+		out[i] = v.num
+	}
+	return f.clipOutputs(out)
+}
+
+// psValue is a number or, if boolean is set, a boolean stored as 0 or 1.
+type psValue struct {
+	num     float64
+	boolean bool
+}
+
+func boolNum(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// psStackLimit is the operand stack depth required of Type 4 functions.
+const psStackLimit = 100
+
+type psMachine struct {
+	stack []psValue
+}
+
+func (vm *psMachine) push(v psValue) error {
+	if len(vm.stack) >= psStackLimit {
+		return fmt.Errorf("stack overflow")
+	}
+	vm.stack = append(vm.stack, v)
+	return nil
+}
+
+func (vm *psMachine) pushNum(v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("undefined result")
+	}
+	return vm.push(psValue{num: v})
+}
+
+func (vm *psMachine) pushBool(b bool) error {
+	return vm.push(psValue{num: boolNum(b), boolean: true})
+}
+
+func (vm *psMachine) pop() (psValue, error) {
+	n := len(vm.stack)
+	if n == 0 {
+		return psValue{}, fmt.Errorf("stack underflow")
+	}
+	v := vm.stack[n-1]
+	vm.stack = vm.stack[:n-1]
+	return v, nil
+}
+
+func (vm *psMachine) popNum() (float64, error) {
+	v, err := vm.pop()
+	if err == nil && v.boolean {
+		err = fmt.Errorf("type check: expected a number")
+	}
+	return v.num, err
+}
+
+func (vm *psMachine) popNum2() (float64, float64, error) {
+	b, err := vm.popNum()
+	if err != nil {
+		return 0, 0, err
+	}
+	a, err := vm.popNum()
+	return a, b, err
+}
+
+func (vm *psMachine) popInt() (int64, error) {
+	v, err := vm.popNum()
+	if err == nil && (v != math.Trunc(v) || math.Abs(v) > 1<<53) {
+		err = fmt.Errorf("type check: expected an integer")
+	}
+	return int64(v), err
+}
+
+func (vm *psMachine) popInt2() (int64, int64, error) {
+	b, err := vm.popInt()
+	if err != nil {
+		return 0, 0, err
+	}
+	a, err := vm.popInt()
+	return a, b, err
+}
+
+func (vm *psMachine) run(prog []psInstr) error {
+	for _, in := range prog {
+		if err := vm.step(in); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vm *psMachine) step(in psInstr) error {
+	switch in.op {
+	case psPush:
+		return vm.pushNum(in.num)
+	case psPushBool:
+		return vm.pushBool(in.num != 0)
+
+	case psIf, psIfElse:
+		c, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		if !c.boolean {
+			return fmt.Errorf("type check: expected a boolean")
+		}
+		if c.num != 0 {
+			return vm.run(in.then)
+		}
+		return vm.run(in.els)
+
+	case psAbs, psCeiling, psCos, psCvi, psCvr, psFloor, psLn, psLog, psNeg, psRound, psSin, psSqrt, psTruncate:
+		x, err := vm.popNum()
+		if err != nil {
+			return err
+		}
+		return vm.pushNum(unaryOp(in.op, x))
+
+	case psAdd, psAtan, psDiv, psExp, psMul, psSub:
+		a, b, err := vm.popNum2()
+		if err != nil {
+			return err
+		}
+		return vm.pushNum(binaryOp(in.op, a, b))
+
+	case psIdiv, psMod:
+		a, b, err := vm.popInt2()
+		if err != nil {
+			return err
+		}
+		if b == 0 {
+			return fmt.Errorf("division by zero")
+		}
+		if in.op == psIdiv {
+			return vm.pushNum(float64(a / b))
+		}
+		return vm.pushNum(float64(a % b))
+
+	case psBitshift:
+		a, shift, err := vm.popInt2()
+		if err != nil {
+			return err
+		}
+		v := int32(a)
+		switch {
+		case shift >= 32 || shift <= -32:
+			v = 0
+		case shift >= 0:
+			v <<= shift
+		default:
+			v = int32(uint32(v) >> -shift)
+		}
+		return vm.pushNum(float64(v))
+
+	case psEq, psNe:
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		eq := a == b
+		return vm.pushBool(eq == (in.op == psEq))
+
+	case psGe, psGt, psLe, psLt:
+		a, b, err := vm.popNum2()
+		if err != nil {
+			return err
+		}
+		return vm.pushBool(compare(in.op, a, b))
+
+	case psAnd, psOr, psXor:
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		if a.boolean != b.boolean {
+			return fmt.Errorf("type check: mixed boolean and integer operands")
+		}
+		if a.num != math.Trunc(a.num) || b.num != math.Trunc(b.num) {
+			return fmt.Errorf("type check: expected integers")
+		}
+		v := float64(bitwise(in.op, int64(a.num), int64(b.num)))
+		return vm.push(psValue{num: v, boolean: a.boolean})
+
+	case psNot:
+		v, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		if v.boolean {
+			return vm.pushBool(v.num == 0)
+		}
+		if v.num != math.Trunc(v.num) {
+			return fmt.Errorf("type check: expected an integer")
+		}
+		return vm.pushNum(float64(^int64(v.num)))
+
+	case psCopy:
+		n, err := vm.popInt()
+		if err != nil {
+			return err
+		}
+		if n < 0 || int(n) > len(vm.stack) {
+			return fmt.Errorf("range check: copy %d", n)
+		}
+		for _, v := range vm.stack[len(vm.stack)-int(n):] {
+			if err := vm.push(v); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case psDup:
+		v, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		vm.stack = append(vm.stack, v)
+		return vm.push(v)
+
+	case psExch:
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		vm.stack = append(vm.stack, b, a)
+		return nil
+
+	case psIndex:
+		n, err := vm.popInt()
+		if err != nil {
+			return err
+		}
+		if n < 0 || int(n) >= len(vm.stack) {
+			return fmt.Errorf("range check: index %d", n)
+		}
+		return vm.push(vm.stack[len(vm.stack)-1-int(n)])
+
+	case psPop:
+		_, err := vm.pop()
+		return err
+
+	case psRoll:
+		n, j, err := vm.popInt2()
+		if err != nil {
+			return err
+		}
+		if n < 0 || int(n) > len(vm.stack) {
+			return fmt.Errorf("range check: roll %d", n)
+		}
+		if n == 0 {
+			return nil
+		}
+		top := vm.stack[len(vm.stack)-int(n):]
+		j = (j%n + n) % n
+		rolled := append(append([]psValue(nil), top[n-j:]...), top[:n-j]...)
+		copy(top, rolled)
+		return nil
+
+	default:
+		return fmt.Errorf("invalid instruction %d", in.op)
+	}
+}
+
+func unaryOp(op psOp, x float64) float64 {
+	switch op {
+	case psAbs:
+		return math.Abs(x)
+	case psCeiling:
+		return math.Ceil(x)
+	case psCos:
+		return math.Cos(x * math.Pi / 180)
+	case psCvi, psTruncate:
+		return math.Trunc(x)
+	case psCvr:
+		return x
+	case psFloor:
+		return math.Floor(x)
+	case psLn:
+		return math.Log(x)
+	case psLog:
+		return math.Log10(x)
+	case psNeg:
+		return -x
+	case psRound:
+		// PostScript rounds halves up, towards positive infinity.
+		return math.Floor(x + 0.5)
+	case psSin:
+		return math.Sin(x * math.Pi / 180)
+	case psSqrt:
+		return math.Sqrt(x)
+	}
+	return math.NaN()
+}
+
+func binaryOp(op psOp, a, b float64) float64 {
+	switch op {
+	case psAdd:
+		return a + b
+	case psAtan:
+		// The angle in degrees of the vector (b, a), in [0, 360).
+		if a == 0 && b == 0 {
+			return math.NaN()
+		}
+		d := math.Atan2(a, b) * 180 / math.Pi
+		if d < 0 {
+			d += 360
+		}
+		return d
+	case psDiv:
+		return a / b
+	case psExp:
+		return math.Pow(a, b)
+	case psMul:
+		return a * b
+	case psSub:
+		return a - b
+	}
+	return math.NaN()
+}
+
+func compare(op psOp, a, b float64) bool {
+	switch op {
+	case psGe:
+		return a >= b
+	case psGt:
+		return a > b
+	case psLe:
+		return a <= b
+	default:
+		return a < b
+	}
+}
+
+func bitwise(op psOp, a, b int64) int64 {
+	switch op {
+	case psAnd:
+		return a & b
+	case psOr:
+		return a | b
+	default:
+		return a ^ b
+	}
+}
@@ -0,0 +1,148 @@
+package function
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func postScriptFunction(program string, domain, rng []float64) (Function, error) {
+	return Parse(model.PDFStream{
+		Dict: model.PDFDict{
+			"FunctionType": model.PDFNumber(4),
+			"Domain":       nums(domain...),
+			"Range":        nums(rng...),
+		},
+		Data: []byte(program),
+	}, parser.NewObjectTable())
+}
+
+func TestPostScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  string
+		in       []float64
+		expected []float64
+	}{
+		{"Identity", "{ }", []float64{0.3}, []float64{0.3}},
+		{"Arithmetic", "{ 2 mul 1 add 3 div }", []float64{4}, []float64{3}},
+		{"Sub", "{ 10 exch sub }", []float64{4}, []float64{6}},
+		{"IdivMod", "{ dup 3 idiv exch 3 mod add }", []float64{7}, []float64{3}},
+		{"Round", "{ 2.5 round -2.5 round add }", []float64{0}, []float64{1}},
+		{"Trig", "{ 90 sin 0 cos add }", []float64{0}, []float64{2}},
+		{"Atan", "{ 1 -1 atan }", []float64{0}, []float64{135}},
+		{"IfTrue", "{ dup 5 gt { pop 1 } if }", []float64{6}, []float64{1}},
+		{"IfFalse", "{ dup 5 gt { pop 1 } if }", []float64{4}, []float64{4}},
+		{"IfElse", "{ 0.5 lt { 0 } { 1 } ifelse }", []float64{0.7}, []float64{1}},
+		{"Nested", "{ dup 0.5 lt { 0.25 lt { 1 } { 2 } ifelse } { pop 3 } ifelse }", []float64{0.3}, []float64{2}},
+		{"Boolean", "{ true false or { 7 } { 8 } ifelse exch pop }", []float64{0}, []float64{7}},
+		{"Bitwise", "{ pop 12 10 and 1 bitshift }", []float64{0}, []float64{16}},
+		{"Roll", "{ 1 2 3 3 1 roll pop pop }", []float64{0}, []float64{3}},
+		{"RollNegative", "{ pop 1 2 3 3 -1 roll pop pop }", []float64{0}, []float64{2}},
+		{"IndexCopy", "{ 5 1 index 2 copy pop pop pop pop }", []float64{9}, []float64{9}},
+		{"Eq", "{ 1 eq { 1 } { 0 } ifelse }", []float64{1}, []float64{1}},
+		{"OutputClipped", "{ 100 mul }", []float64{5}, []float64{200}},
+		{"InputClipped", "{ 20 add }", []float64{-50}, []float64{10}},
+		{"RuntimeError", "{ 0 div }", []float64{1}, []float64{0}},
+		{"Underflow", "{ pop pop }", []float64{1}, []float64{0}},
+		{"TypeError", "{ true add }", []float64{1}, []float64{0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := postScriptFunction(tc.program, []float64{-10, 10}, []float64{0, 200})
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			checkOutputs(t, f, tc.in, tc.expected)
+		})
+	}
+}
+
+func TestPostScriptMultipleOutputs(t *testing.T) {
+	// A typical tint transform from one tint to CMYK.
+	f, err := postScriptFunction("{ dup 0.5 mul exch 0 exch 0 }", []float64{0, 1}, []float64{0, 1, 0, 1, 0, 1, 0, 1})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	checkOutputs(t, f, []float64{0.8}, []float64{0.4, 0, 0.8, 0})
+}
+
+func TestPostScriptSyntaxErrors(t *testing.T) {
+	programs := []string{
+		"",
+		"1 add",
+		"{ 1 add",
+		"{ foo }",
+		"{ { 1 } }",
+		"{ 1 if }",
+		"{ { 1 } ifelse }",
+		"{ (string) }",
+		"{ } extra",
+	}
+
+	for _, p := range programs {
+		if _, err := postScriptFunction(p, []float64{0, 1}, []float64{0, 1}); err == nil {
+			t.Errorf("Parse(%q) expected an error", p)
+		}
+	}
+}
+
+func FuzzPostScript(f *testing.F) {
+	seeds := []string{
+		"{ }",
+		"{ 2 mul 1 add }",
+		"{ dup 0.5 gt { 1 sub } { 2 mul } ifelse }",
+		"{ 3 1 roll exch 2 index copy }",
+		"{ 7 3 idiv 7 3 mod bitshift not }",
+		"{ 1 0 atan ln log sqrt exp }",
+	}
+	for _, s := range seeds {
+		f.Add(s, 0.5)
+	}
+
+	f.Fuzz(func(t *testing.T, program string, x float64) {
+		fn, err := postScriptFunction(program, []float64{0, 1}, []float64{-1, 1, -1, 1})
+		if err != nil {
+			return
+		}
+
+		out := fn.Evaluate([]float64{x})
+		if len(out) != 2 {
+			t.Fatalf("Evaluate() returned %d outputs, expected 2", len(out))
+		}
+		for _, v := range out {
+			if math.IsNaN(v) || v < -1 || v > 1 {
+				t.Fatalf("Evaluate() = %v, outside the range", out)
+			}
+		}
+	})
+}
+
+func FuzzSampled(f *testing.F) {
+	f.Add([]byte{0, 128, 255}, uint8(3), uint8(8), 0.5)
+	f.Add([]byte{0x5a}, uint8(4), uint8(2), 0.1)
+
+	f.Fuzz(func(t *testing.T, data []byte, size, bps uint8, x float64) {
+		fn, err := Parse(model.PDFStream{
+			Dict: model.PDFDict{
+				"FunctionType":  model.PDFNumber(0),
+				"Domain":        nums(0, 1),
+				"Range":         nums(0, 1),
+				"Size":          nums(float64(size)),
+				"BitsPerSample": model.PDFNumber(bps),
+			},
+			Data: data,
+		}, parser.NewObjectTable())
+		if err != nil {
+			return
+		}
+
+		out := fn.Evaluate([]float64{x})
+		if len(out) != 1 || math.IsNaN(out[0]) || out[0] < 0 || out[0] > 1 {
+			t.Fatalf("Evaluate(%v) = %v, outside the range", x, out)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package function
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// maxSamples bounds the size of a sample table, which is otherwise only
+// limited by the /Size entry of the file.
+const maxSamples = 1 << 24
+
+// sampled is a Type 0 function: a table of samples with multilinear
+// interpolation between them.
+type sampled struct {
+	base
+	size    []int
+	encode  []float64
+	samples []float64 // decoded, n values per table entry
+	n       int
+}
+
+func parseSampled(b base, stream model.PDFStream, r model.Resolver) (Function, error) {
+	if len(b.rng) == 0 {
+		return nil, fmt.Errorf("sampled function without /Range")
+	}
+
+	m, n := b.NumInputs(), len(b.rng)/2
+
+	sz, ok := model.ResolveNumbers(stream.Dict["Size"], r)
+	if !ok || len(sz) != m {
+		return nil, fmt.Errorf("invalid sampled function /Size")
+	}
+
+	f := &sampled{base: b, size: make([]int, m), n: n}
+	total := n
+	for i, s := range sz {
+		if s < 1 || s > maxSamples {
+			return nil, fmt.Errorf("invalid sampled function size %v", s)
+		}
+		f.size[i] = int(s)
+		if total *= int(s); total > maxSamples {
+			return nil, fmt.Errorf("sampled function too large")
+		}
+	}
+
+	bps, _ := r.Resolve(stream.Dict["BitsPerSample"]).(model.PDFNumber)
+	switch bps {
+	case 1, 2, 4, 8, 12, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("invalid sampled function /BitsPerSample %v", stream.Dict["BitsPerSample"])
+	}
+
+	f.encode = make([]float64, 2*m)
+	for i := range m {
+		f.encode[2*i+1] = float64(f.size[i] - 1)
+	}
+	if v, ok := model.ResolveNumbers(stream.Dict["Encode"], r); ok && len(v) == 2*m {
+		f.encode = v
+	}
+
+	decode := b.rng
+	if v, ok := model.ResolveNumbers(stream.Dict["Decode"], r); ok && len(v) == 2*n {
+		decode = v
+	}
+
+	data, err := parser.DecodeStream(stream, r)
+	if err != nil {
+		return nil, fmt.Errorf("sampled function: %w", err)
+	}
+
+	// Missing samples at the end of a short stream read as zero.
+	br := util.NewBitReader(data)
+	maxVal := math.Exp2(float64(bps)) - 1
+	f.samples = make([]float64, total)
+	for i := range f.samples {
+		s, _ := br.ReadBits(int(bps))
+		j := i % n
+		f.samples[i] = util.Interpolate(float64(s), 0, maxVal, decode[2*j], decode[2*j+1])
+	}
+
+	return f, nil
+}
+
+func (f *sampled) NumOutputs() int { return f.n }
+
+// Evaluate interpolates multilinearly between the 2^m samples surrounding the
+// encoded input. Cubic (/Order 3) tables are interpolated the same way.
+func (f *sampled) Evaluate(in []float64) []float64 {
+	x := f.clipInputs(in)
+	m := len(x)
+
+	idx := make([]int, m)
+	frac := make([]float64, m)
+	for i, v := range x {
+		e := util.Interpolate(v, f.domain[2*i], f.domain[2*i+1], f.encode[2*i], f.encode[2*i+1])
+		e = util.Clamp(e, 0, float64(f.size[i]-1))
+
+		idx[i] = min(int(e), f.size[i]-1)
+		frac[i] = e - float64(idx[i])
+	}
+
+	out := make([]float64, f.n)
+	for corner := range 1 << m {
+		w := 1.0
+		offset, stride := 0, 1
+		for i := range m {
+			k := idx[i]
+			if corner&(1<<i) != 0 {
+				if frac[i] == 0 {
+					w = 0
+					break
+				}
+				k++
+				w *= frac[i]
+			} else {
+				w *= 1 - frac[i]
+			}
+			offset += k * stride
+			stride *= f.size[i]
+		}
+		if w == 0 {
+			continue
+		}
+
+		for j := range out {
+			out[j] += w * f.samples[offset*f.n+j]
+		}
+	}
+
+	return f.clipOutputs(out)
+}
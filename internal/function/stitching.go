@@ -0,0 +1,79 @@
+package function
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// stitching is a Type 3 function, which divides its domain into subdomains
+// each handled by one of a list of single-input functions.
+type stitching struct {
+	base
+	fns    []Function
+	bounds []float64
+	encode []float64
+}
+
+func parseStitching(b base, dict model.PDFDict, r model.Resolver, depth int) (Function, error) {
+	if b.NumInputs() != 1 {
+		return nil, fmt.Errorf("stitching function has %d inputs, expected 1", b.NumInputs())
+	}
+
+	arr, ok := r.Resolve(dict["Functions"]).(model.PDFArray)
+	if !ok || len(arr) == 0 {
+		return nil, fmt.Errorf("stitching function without /Functions")
+	}
+
+	f := &stitching{base: b, fns: make([]Function, len(arr))}
+	for i, v := range arr {
+		fn, err := parse(v, r, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("stitched function %d: %w", i, err)
+		}
+		if fn.NumInputs() != 1 || (i > 0 && fn.NumOutputs() != f.fns[0].NumOutputs()) {
+			return nil, fmt.Errorf("stitched function %d has mismatched dimensions", i)
+		}
+		f.fns[i] = fn
+	}
+
+	if f.bounds, ok = model.ResolveNumbers(dict["Bounds"], r); !ok || len(f.bounds) != len(arr)-1 {
+		return nil, fmt.Errorf("stitching function /Bounds has wrong length")
+	}
+	for i, v := range f.bounds {
+		if v < b.domain[0] || v > b.domain[1] || (i > 0 && v < f.bounds[i-1]) {
+			return nil, fmt.Errorf("stitching function /Bounds out of order")
+		}
+	}
+
+	if f.encode, ok = model.ResolveNumbers(dict["Encode"], r); !ok || len(f.encode) != 2*len(arr) {
+		return nil, fmt.Errorf("stitching function /Encode has wrong length")
+	}
+
+	return f, nil
+}
+
+func (f *stitching) NumOutputs() int { return f.fns[0].NumOutputs() }
+
+func (f *stitching) Evaluate(in []float64) []float64 {
+	x := f.clipInputs(in)[0]
+
+	// Subdomain k spans [bounds[k-1], bounds[k]), the last one including
+	// the upper end of the domain.
+	k := 0
+	for k < len(f.bounds) && x >= f.bounds[k] {
+		k++
+	}
+
+	lo, hi := f.domain[0], f.domain[1]
+	if k > 0 {
+		lo = f.bounds[k-1]
+	}
+	if k < len(f.bounds) {
+		hi = f.bounds[k]
+	}
+
+	e := util.Interpolate(x, lo, hi, f.encode[2*k], f.encode[2*k+1])
+	return f.clipOutputs(f.fns[k].Evaluate([]float64{e}))
+}
@@ -0,0 +1,135 @@
+package graphics
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// colorSpace resolves the operand of cs or CS: either a device family name or
+// the name of an entry in the /ColorSpace resource dictionary.
+func (in *Interpreter) colorSpace(v model.PDFValue) (colorspace.ColorSpace, error) {
+	name, ok := v.(model.PDFName)
+	if !ok {
+		return nil, fmt.Errorf("colour space operand is not a name: %v", v)
+	}
+
+	if colorspace.IsDeviceName(string(name)) {
+		return colorspace.Parse(name, in.r)
+	}
+
+	def, ok := model.LookupResource(in.res, in.r, model.ResColorSpace, string(name))
+	if !ok {
+		return nil, fmt.Errorf("colour space resource %s not found", name)
+	}
+
+	return colorspace.Parse(def, in.r)
+}
+
+// setFillColor sets the fill colour, and the pattern when cs is a pattern
+// space.
+func (in *Interpreter) setFillColor(cs colorspace.ColorSpace, comps []float64, pat *Pattern) {
+	in.gs.FillSpace = cs
+	in.gs.FillComps = comps
+	in.gs.FillColor = colorspace.ToRGBA(cs, comps)
+	in.gs.FillPattern = pat
+}
+
+func (in *Interpreter) setStrokeColor(cs colorspace.ColorSpace, comps []float64, pat *Pattern) {
+	in.gs.StrokeSpace = cs
+	in.gs.StrokeComps = comps
+	in.gs.StrokeColor = colorspace.ToRGBA(cs, comps)
+	in.gs.StrokePattern = pat
+}
+
+// deviceColorOp returns the implementation of g, rg, k and their stroking
+// counterparts, which select a device space and colour in one step.
+func deviceColorOp(cs colorspace.ColorSpace, stroke bool) operatorFunc {
+	return func(in *Interpreter, args []model.PDFValue) error {
+		comps, err := numberArgs(args, cs.NComponents())
+		if err != nil {
+			return err
+		}
+
+		if stroke {
+			in.setStrokeColor(cs, comps, nil)
+		} else {
+			in.setFillColor(cs, comps, nil)
+		}
+		return nil
+	}
+}
+
+func opSetFillColorSpace(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 operand, got %d", len(args))
+	}
+
+	cs, err := in.colorSpace(args[0])
+	if err != nil {
+		return err
+	}
+
+	in.setFillColor(cs, cs.InitialColor(), nil)
+	return nil
+}
+
+func opSetStrokeColorSpace(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 operand, got %d", len(args))
+	}
+
+	cs, err := in.colorSpace(args[0])
+	if err != nil {
+		return err
+	}
+
+	in.setStrokeColor(cs, cs.InitialColor(), nil)
+	return nil
+}
+
+// colorOperands parses the operands of sc, scn, SC and SCN: components of
+// the current colour space followed, in a pattern space, by a pattern name.
+func (in *Interpreter) colorOperands(cs colorspace.ColorSpace, args []model.PDFValue) ([]float64, *Pattern, error) {
+	if _, ok := cs.(*colorspace.PatternSpace); !ok {
+		comps, err := numberArgs(args, cs.NComponents())
+		return comps, nil, err
+	}
+
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("missing pattern name")
+	}
+	name, ok := args[len(args)-1].(model.PDFName)
+	if !ok {
+		return nil, nil, fmt.Errorf("pattern operand is not a name: %v", args[len(args)-1])
+	}
+
+	comps, err := numberArgs(args[:len(args)-1], cs.NComponents())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pat, err := in.pattern(name)
+	return comps, pat, err
+}
+
+func opSetFillColor(in *Interpreter, args []model.PDFValue) error {
+	comps, pat, err := in.colorOperands(in.gs.FillSpace, args)
+	if err != nil {
+		return err
+	}
+
+	in.setFillColor(in.gs.FillSpace, comps, pat)
+	return nil
+}
+
+func opSetStrokeColor(in *Interpreter, args []model.PDFValue) error {
+	comps, pat, err := in.colorOperands(in.gs.StrokeSpace, args)
+	if err != nil {
+		return err
+	}
+
+	in.setStrokeColor(in.gs.StrokeSpace, comps, pat)
+	return nil
+}
@@ -0,0 +1,254 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// opInlineImage implements an inline image, which the content parser
+// returns as a BI operation with the image as its operand.
+func opInlineImage(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 operand, got %d", len(args))
+	}
+
+	stream, ok := args[0].(model.PDFStream)
+	if !ok {
+		return fmt.Errorf("inline image operand is not a stream: %v", args[0])
+	}
+	return in.drawImage(stream)
+}
+
+// drawImage paints an image XObject or inline image into the unit square of
+// user space. Stencil masks are painted with the current fill colour.
+func (in *Interpreter) drawImage(stream model.PDFStream) error {
+	img, err := in.parseImage(stream)
+	if err != nil {
+		return err
+	}
+	space := img.Space
+	if space == nil {
+		space = in.gs.FillSpace
+	}
+	if colorspace.PaintsNothing(space) {
+		return nil
+	}
+
+	in.setCompositing(false)
+	if img.Space != nil {
+		// A soft-mask image overrides the soft mask of the graphics state
+		// and any colour key (PDF 32000-1 11.6.5.3).
+		if smask, ok := in.r.Resolve(stream.Dict["SMask"]).(model.PDFStream); ok {
+			if img.SoftMask, err = in.imageSoftMask(smask); err != nil {
+				return fmt.Errorf("/SMask: %w", err)
+			}
+			img.ColorKey = nil
+		}
+		if img.Alpha != nil || img.SoftMask != nil {
+			in.canvas.SoftMask = nil
+		}
+
+		rgba, err := img.RGBA()
+		if err != nil {
+			return err
+		}
+		in.canvas.DrawImage(rgba, in.gs.CTM, in.gs.Clip)
+		return nil
+	}
+
+	mask, err := img.Stencil()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := in.gs.FillSpace.(*colorspace.PatternSpace); !ok {
+		in.canvas.DrawStencil(mask, in.gs.CTM, in.gs.Clip, in.gs.FillColor)
+		return nil
+	}
+
+	bounds := in.canvas.Bounds().Intersect(in.gs.Clip.Bounds())
+	if cov := render.ResampleMask(mask, in.gs.CTM, bounds); cov != nil && in.gs.FillPattern != nil {
+		in.paintPattern(in.gs.FillPattern, in.gs.FillColor, cov)
+	}
+	return nil
+}
+
+// parseImage reads the dictionary of an image and decodes its data.
+func (in *Interpreter) parseImage(stream model.PDFStream) (*render.Image, error) {
+	dict := stream.Dict
+	integer := func(key string) (int, bool) {
+		n, ok := in.r.Resolve(dict[key]).(model.PDFNumber)
+		return int(n), ok
+	}
+
+	img := &render.Image{}
+	var ok bool
+	if img.Width, ok = integer("Width"); !ok {
+		return nil, fmt.Errorf("image without /Width")
+	}
+	if img.Height, ok = integer("Height"); !ok {
+		return nil, fmt.Errorf("image without /Height")
+	}
+
+	specs, err := parser.StreamFilters(dict, in.r)
+	if err != nil {
+		return nil, err
+	}
+
+	mask, _ := in.r.Resolve(dict["ImageMask"]).(model.PDFBoolean)
+	if n := len(specs); n > 0 && specs[n-1].Name == "JPXDecode" && !mask {
+		return in.parseJPXImage(stream, specs, img)
+	}
+
+	if mask {
+		img.BitsPerComponent = 1
+	} else {
+		if img.BitsPerComponent, ok = integer("BitsPerComponent"); !ok {
+			return nil, fmt.Errorf("image without /BitsPerComponent")
+		}
+
+		cs, err := in.imageColorSpace(dict["ColorSpace"])
+		if err != nil {
+			return nil, err
+		}
+		img.Space = cs
+
+		if key, ok := in.r.Resolve(dict["Mask"]).(model.PDFArray); ok {
+			for _, v := range key {
+				n, _ := number(in.r.Resolve(v))
+				img.ColorKey = append(img.ColorKey, int(n))
+			}
+		}
+	}
+
+	if arr, ok := in.r.Resolve(dict["Decode"]).(model.PDFArray); ok {
+		for _, v := range arr {
+			n, _ := number(in.r.Resolve(v))
+			img.Decode = append(img.Decode, n)
+		}
+	}
+
+	data, err := parser.ApplyFilters(stream.Data, specs)
+	if err != nil {
+		return nil, err
+	}
+	img.Data = data
+
+	return img, nil
+}
+
+// parseJPXImage decodes a JPEG 2000 image. Its samples are 8-bit whatever
+// the dictionary says, its colour space defaults to the one the data
+// declares, and its /Decode array is ignored.
+func (in *Interpreter) parseJPXImage(stream model.PDFStream, specs []parser.FilterSpec, img *render.Image) (*render.Image, error) {
+	dict := stream.Dict
+	data, err := parser.ApplyFilters(stream.Data, specs[:len(specs)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	smaskInData, _ := in.r.Resolve(dict["SMaskInData"]).(model.PDFNumber)
+	jpx, err := parser.DecodeJPX(data, smaskInData != 0)
+	if err != nil {
+		return nil, fmt.Errorf("JPXDecode: %w", err)
+	}
+
+	img.Width, img.Height = jpx.Width, jpx.Height
+	img.BitsPerComponent = 8
+	img.Data = jpx.Samples
+	if smaskInData != 0 {
+		img.Alpha = jpx.Alpha
+	}
+
+	if v := dict["ColorSpace"]; v != nil {
+		if img.Space, err = in.imageColorSpace(v); err != nil {
+			return nil, err
+		}
+	}
+	if img.Space == nil || img.Space.NComponents() != jpx.NComponents {
+		if img.Space, err = in.jpxColorSpace(jpx); err != nil {
+			return nil, err
+		}
+	}
+
+	if key, ok := in.r.Resolve(dict["Mask"]).(model.PDFArray); ok {
+		for _, v := range key {
+			n, _ := number(in.r.Resolve(v))
+			img.ColorKey = append(img.ColorKey, int(n))
+		}
+	}
+
+	return img, nil
+}
+
+// jpxColorSpace returns the colour space a JPEG 2000 image declares: its
+// ICC profile, or else its enumerated space, or else the device space with
+// as many components.
+func (in *Interpreter) jpxColorSpace(jpx *parser.JPXImage) (colorspace.ColorSpace, error) {
+	n := jpx.NComponents
+	if jpx.ICCProfile != nil {
+		icc := model.PDFArray{model.PDFName("ICCBased"), model.PDFStream{
+			Dict: model.PDFDict{"N": model.PDFNumber(n)},
+			Data: jpx.ICCProfile,
+		}}
+		if cs, err := colorspace.Parse(icc, in.r); err == nil && cs.NComponents() == n {
+			return cs, nil
+		}
+	}
+
+	if jpx.ColorSpace != "" {
+		if cs, err := colorspace.Parse(model.PDFName(jpx.ColorSpace), in.r); err == nil && cs.NComponents() == n {
+			return cs, nil
+		}
+	}
+
+	switch n {
+	case 1:
+		return colorspace.DeviceGray, nil
+	case 3:
+		return colorspace.DeviceRGB, nil
+	case 4:
+		return colorspace.DeviceCMYK, nil
+	}
+	return nil, fmt.Errorf("JPEG 2000 image with %d colour components", n)
+}
+
+// imageSoftMask decodes the soft-mask image of an image.
+func (in *Interpreter) imageSoftMask(stream model.PDFStream) (*image.Gray, error) {
+	mask, err := in.parseImage(stream)
+	if err != nil {
+		return nil, err
+	}
+	if mask.Space == nil || mask.Space.NComponents() != 1 {
+		return nil, fmt.Errorf("soft mask is not a greyscale image")
+	}
+	gray, err := mask.RGBA()
+	if err != nil {
+		return nil, err
+	}
+
+	out := image.NewGray(gray.Rect)
+	for i := range out.Pix {
+		out.Pix[i] = gray.Pix[4*i]
+	}
+	return out, nil
+}
+
+// imageColorSpace resolves the /ColorSpace of an image. Inline images may
+// name an entry of the /ColorSpace resources.
+func (in *Interpreter) imageColorSpace(v model.PDFValue) (colorspace.ColorSpace, error) {
+	v = in.r.Resolve(v)
+	if v == nil {
+		return nil, fmt.Errorf("image without /ColorSpace")
+	}
+
+	if _, ok := v.(model.PDFName); ok {
+		return in.colorSpace(v)
+	}
+	return colorspace.Parse(v, in.r)
+}
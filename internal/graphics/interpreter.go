@@ -1 +1,241 @@
 package graphics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/font"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// Interpreter executes content stream operators against a canvas.
+type Interpreter struct {
+	canvas *render.Canvas
+
+	// r resolves indirect references; res is the resource dictionary of the
+	// content stream being run.
+	r   model.Resolver
+	res model.PDFDict
+
+	gs    *State
+	stack []*State
+
+	// stackFloor is the number of saved states that belong to enclosing
+	// content streams and may not be restored by the current one.
+	stackFloor int
+
+	// baseCTM maps the default coordinate space of the content stream being
+	// run to the device; pattern matrices are relative to it.
+	baseCTM render.Matrix
+
+	// path is the current path in user space. The CTM may not change while a
+	// path is under construction, so it is only transformed when painted.
+	path *render.Path
+
+	// clipPending is set by W and W*; the clip is applied by the next
+	// path-painting operator.
+	clipPending bool
+	clipRule    render.FillRule
+
+	// textClip collects the glyphs shown in a clipping mode in the current
+	// text object.
+	textClip *render.Path
+
+	// tm and tlm are the text matrix and text line matrix of the current
+	// text object, which text operators outside one also use.
+	tm, tlm render.Matrix
+
+	// depth counts the nested interpreters run for patterns and forms.
+	depth int
+
+	// forms holds the forms stored as indirect objects that are running,
+	// which may not invoke themselves. It is shared with nested
+	// interpreters.
+	forms map[model.PDFIndirectRef]bool
+
+	// patterns caches parsed pattern objects, and with them their rendered
+	// tiles, for the page. It is shared with nested interpreters.
+	patterns map[model.PDFIndirectRef]*Pattern
+
+	// fonts caches loaded fonts for the page. It is shared with nested
+	// interpreters.
+	fonts map[model.PDFIndirectRef]*font.Font
+
+	// glyphs caches flattened glyph outlines for the page. It is shared
+	// with nested interpreters.
+	glyphs map[glyphKey]*render.Path
+
+	// masks caches rendered soft masks for the page. It is shared with
+	// nested interpreters.
+	masks map[softMaskKey]*image.Alpha
+
+	// charProcs caches the decoded glyph descriptions of Type 3 fonts, and
+	// glyphMasks the coverage of their stencil glyphs, for the page. Both
+	// are shared with nested interpreters.
+	charProcs  map[charProcKey]*charProc
+	glyphMasks map[glyphKey]*image.Alpha
+
+	// stencil is set while running the description of a Type 3 stencil
+	// glyph, whose colour operators are ignored.
+	stencil bool
+}
+
+// NewInterpreter returns an interpreter painting onto canvas, with ctm mapping
+// the default user space to device pixels. Indirect references in resources
+// are resolved through r.
+func NewInterpreter(canvas *render.Canvas, ctm render.Matrix, r model.Resolver) *Interpreter {
+	b := canvas.Bounds()
+	clip := render.NewRectClip(render.Rect{
+		X0: float64(b.Min.X), Y0: float64(b.Min.Y),
+		X1: float64(b.Max.X), Y1: float64(b.Max.Y),
+	})
+
+	return &Interpreter{
+		canvas:   canvas,
+		r:        r,
+		gs:       NewState(ctm, clip),
+		baseCTM:  ctm,
+		path:     render.NewPath(),
+		patterns: make(map[model.PDFIndirectRef]*Pattern),
+		fonts:    make(map[model.PDFIndirectRef]*font.Font),
+		forms:    make(map[model.PDFIndirectRef]bool),
+		glyphs:   make(map[glyphKey]*render.Path),
+		masks:    make(map[softMaskKey]*image.Alpha),
+
+		charProcs:  make(map[charProcKey]*charProc),
+		glyphMasks: make(map[glyphKey]*image.Alpha),
+	}
+}
+
+// maxNesting bounds the depth of nested content streams, which a
+// self-referencing pattern or form would otherwise make unbounded.
+const maxNesting = 16
+
+// nested returns an interpreter for a pattern cell or form painting onto
+// canvas, sharing the page-level caches of in.
+func (in *Interpreter) nested(canvas *render.Canvas, ctm render.Matrix) *Interpreter {
+	sub := NewInterpreter(canvas, ctm, in.r)
+	sub.depth = in.depth + 1
+	sub.patterns = in.patterns
+	sub.fonts = in.fonts
+	sub.forms = in.forms
+	sub.glyphs = in.glyphs
+	sub.masks = in.masks
+	sub.charProcs = in.charProcs
+	sub.glyphMasks = in.glyphMasks
+	return sub
+}
+
+// State returns the current graphics state.
+func (in *Interpreter) State() *State {
+	return in.gs
+}
+
+// Run interprets a decoded content stream whose named resources are looked up
+// in resources. An operator that fails is skipped and the stream goes on, as
+// files often hold operators that are invalid but harmless; the errors are
+// returned together once the stream ends. A syntax error ends it early.
+func (in *Interpreter) Run(content []byte, resources model.PDFDict) error {
+	saved := in.res
+	in.res = resources
+	defer func() { in.res = saved }()
+
+	p := parser.NewParser(parser.NewLexer(bytes.NewReader(content)))
+
+	var errs []error
+	for {
+		op, err := p.ParseOperation()
+		if err == io.EOF {
+			return errors.Join(errs...)
+		}
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		if err := in.execute(op); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", op.Operator, err))
+		}
+	}
+}
+
+func (in *Interpreter) execute(op *parser.Operation) error {
+	fn, ok := operators[op.Operator]
+	if !ok {
+		// Unknown operators are ignored, as is required inside BX/EX
+		// compatibility sections and harmless elsewhere.
+		return nil
+	}
+	if in.stencil && colorOperators[op.Operator] {
+		return nil
+	}
+
+	return fn(in, op.Operands)
+}
+
+func (in *Interpreter) save() {
+	in.stack = append(in.stack, in.gs.Clone())
+}
+
+// restore pops the graphics state saved last. A Q without its q, which
+// files often have, does nothing.
+func (in *Interpreter) restore() {
+	n := len(in.stack)
+	if n <= in.stackFloor {
+		return
+	}
+
+	in.gs = in.stack[n-1]
+	in.stack = in.stack[:n-1]
+}
+
+func (in *Interpreter) fillPath(rule render.FillRule) {
+	in.fillArea(in.path.Transform(in.gs.CTM), rule)
+}
+
+// fillArea paints a device-space area with the fill color or pattern.
+func (in *Interpreter) fillArea(area *render.Path, rule render.FillRule) {
+	if colorspace.PaintsNothing(in.gs.FillSpace) {
+		return
+	}
+	in.setCompositing(false)
+	if _, ok := in.gs.FillSpace.(*colorspace.PatternSpace); ok {
+		in.fillPattern(in.gs.FillPattern, in.gs.FillColor, area, rule)
+		return
+	}
+	in.canvas.Fill(area, rule, in.gs.Clip, in.gs.FillColor)
+}
+
+func (in *Interpreter) strokePath() {
+	in.strokeOutline(in.path)
+}
+
+// strokeOutline strokes a user-space path with the stroke color or pattern.
+func (in *Interpreter) strokeOutline(p *render.Path) {
+	if colorspace.PaintsNothing(in.gs.StrokeSpace) {
+		return
+	}
+	in.setCompositing(true)
+	outline := render.Stroke(p, in.gs.Stroke, in.gs.CTM)
+	if _, ok := in.gs.StrokeSpace.(*colorspace.PatternSpace); ok {
+		in.fillPattern(in.gs.StrokePattern, in.gs.StrokeColor, outline, render.NonZero)
+		return
+	}
+	in.canvas.Fill(outline, render.NonZero, in.gs.Clip, in.gs.StrokeColor)
+}
+
+// endPath finishes the current path object. A clip requested by W or W* takes
+// effect here, after the path has been painted.
+func (in *Interpreter) endPath() {
+	if in.clipPending {
+		in.gs.Clip = in.gs.Clip.IntersectPath(in.path.Transform(in.gs.CTM), in.clipRule)
+		in.clipPending = false
+	}
+
+	in.path = render.NewPath()
+}
@@ -0,0 +1,850 @@
+package graphics
+
+import (
+	"image/color"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+func newTestInterpreter() *Interpreter {
+	return NewInterpreter(render.NewCanvas(20, 20), render.Identity, parser.NewObjectTable())
+}
+
+func alphaAt(in *Interpreter, x, y int) uint8 {
+	return in.canvas.Img.RGBAAt(x, y).A
+}
+
+type pixel struct {
+	x, y    int
+	painted bool
+}
+
+func checkPixels(t *testing.T, in *Interpreter, pixels []pixel) {
+	t.Helper()
+
+	for _, px := range pixels {
+		if got := alphaAt(in, px.x, px.y) != 0; got != px.painted {
+			t.Errorf("pixel (%d, %d) painted = %v, expected %v", px.x, px.y, got, px.painted)
+		}
+	}
+}
+
+func TestClipPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		pixels  []pixel
+	}{
+		{
+			name:    "Rectangle",
+			content: "0 0 10 10 re W n 0 0 20 20 re f",
+			pixels:  []pixel{{5, 5, true}, {15, 5, false}, {5, 15, false}},
+		},
+		{
+			name:    "NestedIntersection",
+			content: "0 0 10 20 re W n q 0 5 20 10 re W n 0 0 20 20 re f Q",
+			pixels:  []pixel{{5, 7, true}, {5, 2, false}, {15, 7, false}},
+		},
+		{
+			name:    "RestoredByQ",
+			content: "q 0 0 5 5 re W n Q 0 0 20 20 re f",
+			pixels:  []pixel{{2, 2, true}, {15, 15, true}},
+		},
+		{
+			name:    "EvenOdd",
+			content: "0 0 20 20 re 5 5 10 10 re W* n 0 0 20 20 re f",
+			pixels:  []pixel{{2, 2, true}, {10, 10, false}},
+		},
+		{
+			name:    "NonZero",
+			content: "0 0 20 20 re 5 5 10 10 re W n 0 0 20 20 re f",
+			pixels:  []pixel{{2, 2, true}, {10, 10, true}},
+		},
+		{
+			name:    "AppliedAfterPainting",
+			content: "0 0 20 20 re W f 0 0 0 0 re W n",
+			pixels:  []pixel{{15, 15, true}},
+		},
+		{
+			name:    "Triangle",
+			content: "0 0 m 20 0 l 0 20 l h W n 0 0 20 20 re f",
+			pixels:  []pixel{{2, 2, true}, {17, 17, false}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := newTestInterpreter()
+			if err := in.Run([]byte(tc.content), nil); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			checkPixels(t, in, tc.pixels)
+		})
+	}
+}
+
+func TestTextClipModes(t *testing.T) {
+	in := newTestInterpreter()
+
+	if err := in.Run([]byte("BT 7 Tr"), nil); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	glyph := render.NewPath()
+	glyph.Rect(2, 2, 6, 6)
+	in.clipGlyph(glyph)
+
+	if err := in.Run([]byte("ET 0 0 20 20 re f"), nil); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	checkPixels(t, in, []pixel{{4, 4, true}, {12, 12, false}})
+}
+
+func TestTextClipIgnoredForFillMode(t *testing.T) {
+	in := newTestInterpreter()
+
+	if err := in.Run([]byte("BT 0 Tr"), nil); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	glyph := render.NewPath()
+	glyph.Rect(2, 2, 6, 6)
+	in.clipGlyph(glyph)
+
+	if err := in.Run([]byte("ET 0 0 20 20 re f"), nil); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	checkPixels(t, in, []pixel{{4, 4, true}, {12, 12, true}})
+}
+
+func TestRestoreUnderflow(t *testing.T) {
+	in := newTestInterpreter()
+
+	if err := in.Run([]byte("q 2 w Q Q 5 w"), nil); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if in.gs.Stroke.Width != 5 || len(in.stack) != 0 {
+		t.Errorf("after an extra Q: line width %v, %d saved states", in.gs.Stroke.Width, len(in.stack))
+	}
+}
+
+func TestRunSkipsFailingOperators(t *testing.T) {
+	in := newTestInterpreter()
+
+	// Invalid styles and modes are ignored, and operators that fail are
+	// skipped, the stream going on after them.
+	err := in.Run([]byte("1 J 7 J 1 j 5 j 9 Tr /Missing cs 1 2 3 4 rg 1 0 0 rg 0 0 10 10 re f"), nil)
+	if err == nil || !strings.Contains(err.Error(), "cs:") {
+		t.Errorf("Run() error = %v, expected the failure of cs", err)
+	}
+	if in.gs.Stroke.Cap != render.RoundCap || in.gs.Stroke.Join != render.RoundJoin || in.gs.Text.RenderMode != TextFill {
+		t.Errorf("cap %v, join %v, render mode %v", in.gs.Stroke.Cap, in.gs.Stroke.Join, in.gs.Text.RenderMode)
+	}
+	if got := in.canvas.Img.RGBAAt(5, 5); got != (color.RGBA{0xff, 0, 0, 0xff}) {
+		t.Errorf("pixel (5, 5) = %v, expected red", got)
+	}
+	// Text objects may nest or be missing; positioning goes on regardless.
+	in = newTestInterpreter()
+	if err := in.Run([]byte("BT BT 5 6 Td ET ET 1 2 Td"), nil); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if in.tm != render.Translate(6, 8) {
+		t.Errorf("text matrix = %v, expected a translation by (6, 8)", in.tm)
+	}
+}
+
+func TestColorOperators(t *testing.T) {
+	resources := model.PDFDict{
+		"ColorSpace": model.PDFDict{
+			"CS0":  model.PDFName("DeviceRGB"),
+			"None": model.PDFArray{model.PDFName("Separation"), model.PDFName("None"), model.PDFName("DeviceGray"), model.PDFDict{}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected color.RGBA
+	}{
+		{"Gray", "0.5 g 0 0 20 20 re f", color.RGBA{0x80, 0x80, 0x80, 0xff}},
+		{"RGB", "1 0 0 rg 0 0 20 20 re f", color.RGBA{0xff, 0, 0, 0xff}},
+		{"CMYKWhite", "0 0 0 0 k 0 0 20 20 re f", color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{"StrokeRGB", "0 0 1 RG 20 w 0 10 m 20 10 l S", color.RGBA{0, 0, 0xff, 0xff}},
+		{"NamedSpace", "/CS0 cs 0 1 0 sc 0 0 20 20 re f", color.RGBA{0, 0xff, 0, 0xff}},
+		{"DeviceSpace", "/DeviceGray cs 1 scn 0 0 20 20 re f", color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{"RestoredByQ", "q 1 0 0 rg Q 0 0 20 20 re f", color.RGBA{0, 0, 0, 0xff}},
+		// The None colourant paints nothing, filled or stroked.
+		{"None", "/None cs 1 sc /None CS 1 SC 0 0 20 20 re B", color.RGBA{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := newTestInterpreter()
+			if err := in.Run([]byte(tc.content), resources); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := in.canvas.Img.RGBAAt(10, 10); got != tc.expected {
+				t.Errorf("pixel = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestColorSpaceResourceMissing(t *testing.T) {
+	in := newTestInterpreter()
+
+	if err := in.Run([]byte("/Missing cs"), nil); err == nil {
+		t.Errorf("Run() with an unknown colour space expected an error")
+	}
+}
+
+func TestShadingOperators(t *testing.T) {
+	// A horizontal gradient from red at x = 0 to blue at x = 20.
+	shading := model.PDFDict{
+		"ShadingType": model.PDFNumber(2),
+		"ColorSpace":  model.PDFName("DeviceRGB"),
+		"Coords":      model.PDFArray{model.PDFNumber(0), model.PDFNumber(0), model.PDFNumber(20), model.PDFNumber(0)},
+		"Function": model.PDFDict{
+			"FunctionType": model.PDFNumber(2),
+			"Domain":       model.PDFArray{model.PDFNumber(0), model.PDFNumber(1)},
+			"C0":           model.PDFArray{model.PDFNumber(1), model.PDFNumber(0), model.PDFNumber(0)},
+			"C1":           model.PDFArray{model.PDFNumber(0), model.PDFNumber(0), model.PDFNumber(1)},
+			"N":            model.PDFNumber(1),
+		},
+	}
+	resources := model.PDFDict{
+		"Shading": model.PDFDict{"Sh0": shading},
+		"Pattern": model.PDFDict{"P0": model.PDFDict{
+			"PatternType": model.PDFNumber(2),
+			"Shading":     shading,
+		}},
+	}
+
+	in := newTestInterpreter()
+	if err := in.Run([]byte("0 0 10 20 re W n /Sh0 sh"), resources); err != nil {
+		t.Fatalf("Run(sh) error = %v", err)
+	}
+	if got := in.canvas.Img.RGBAAt(1, 5); got.R < 0xe0 || got.B > 0x20 {
+		t.Errorf("sh left pixel = %v, expected red", got)
+	}
+	checkPixels(t, in, []pixel{{15, 5, false}})
+
+	in = newTestInterpreter()
+	if err := in.Run([]byte("/Pattern cs /P0 scn 10 0 10 20 re f"), resources); err != nil {
+		t.Fatalf("Run(pattern fill) error = %v", err)
+	}
+	if got := in.canvas.Img.RGBAAt(18, 5); got.B < 0xe0 || got.R > 0x20 {
+		t.Errorf("pattern right pixel = %v, expected blue", got)
+	}
+	checkPixels(t, in, []pixel{{5, 5, false}})
+
+	// Selecting a pattern space without a pattern paints nothing.
+	in = newTestInterpreter()
+	if err := in.Run([]byte("/Pattern cs 0 0 20 20 re f"), resources); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	checkPixels(t, in, []pixel{{10, 10, false}})
+
+	if err := in.Run([]byte("/Pattern cs /Missing scn"), resources); err == nil {
+		t.Errorf("Run() with an unknown pattern expected an error")
+	}
+}
+
+// tilingPattern returns a 10×10 tiling pattern cell whose content paints
+// the lower left quarter.
+func tilingPattern(paintType float64, content string, matrix ...float64) model.PDFStream {
+	dict := model.PDFDict{
+		"PatternType": model.PDFNumber(1),
+		"PaintType":   model.PDFNumber(paintType),
+		"TilingType":  model.PDFNumber(1),
+		"BBox":        model.PDFArray{model.PDFNumber(0), model.PDFNumber(0), model.PDFNumber(10), model.PDFNumber(10)},
+		"XStep":       model.PDFNumber(10),
+		"YStep":       model.PDFNumber(10),
+		"Resources":   model.PDFDict{},
+	}
+	if matrix != nil {
+		arr := make(model.PDFArray, len(matrix))
+		for i, v := range matrix {
+			arr[i] = model.PDFNumber(v)
+		}
+		dict["Matrix"] = arr
+	}
+	return model.PDFStream{Dict: dict, Data: []byte(content)}
+}
+
+func TestTilingPatterns(t *testing.T) {
+	resources := model.PDFDict{
+		"ColorSpace": model.PDFDict{
+			"PRGB": model.PDFArray{model.PDFName("Pattern"), model.PDFName("DeviceRGB")},
+		},
+		"Pattern": model.PDFDict{
+			"Colored":   tilingPattern(1, "1 0 0 rg 0 0 5 5 re f"),
+			"Uncolored": tilingPattern(2, "0 0 5 5 re f"),
+			"Scaled":    tilingPattern(1, "1 0 0 rg 0 0 5 5 re f", 2, 0, 0, 2, 0, 0),
+		},
+	}
+
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	none := color.RGBA{}
+
+	tests := []struct {
+		name    string
+		content string
+		pixels  map[[2]int]color.RGBA
+	}{
+		{
+			name:    "Colored",
+			content: "/Pattern cs /Colored scn 0 0 20 20 re f",
+			pixels:  map[[2]int]color.RGBA{{2, 2}: red, {7, 7}: none, {12, 12}: red, {12, 2}: red, {7, 2}: none},
+		},
+		{
+			name:    "Uncolored",
+			content: "/PRGB cs 0 0 1 /Uncolored scn 0 0 20 20 re f",
+			pixels:  map[[2]int]color.RGBA{{2, 2}: blue, {7, 7}: none, {17, 12}: none, {12, 12}: blue},
+		},
+		{
+			name:    "Matrix",
+			content: "/Pattern cs /Scaled scn 0 0 20 20 re f",
+			pixels:  map[[2]int]color.RGBA{{5, 5}: red, {9, 9}: red, {15, 15}: none},
+		},
+		{
+			name:    "Stroke",
+			content: "/Pattern CS /Colored SCN 4 w 0 2 m 20 2 l S",
+			pixels:  map[[2]int]color.RGBA{{2, 2}: red, {7, 2}: none, {2, 10}: none},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := newTestInterpreter()
+			if err := in.Run([]byte(tc.content), resources); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for p, expected := range tc.pixels {
+				if got := in.canvas.Img.RGBAAt(p[0], p[1]); got != expected {
+					t.Errorf("pixel %v = %v, expected %v", p, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestTilingPatternCache(t *testing.T) {
+	in := newTestInterpreter()
+
+	ref := model.PDFIndirectRef{ObjectNumber: 5}
+	in.r.(*parser.ObjectTable).Add(&model.PDFObject{Number: 5, Value: tilingPattern(1, "0 0 5 5 re f")})
+	resources := model.PDFDict{"Pattern": model.PDFDict{"P": ref}}
+
+	if err := in.Run([]byte("/Pattern cs /P scn 0 0 10 10 re f"), resources); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	first := in.patterns[ref].Tiling.tile
+
+	if err := in.Run([]byte("/Pattern cs /P scn 10 10 10 10 re f"), resources); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if first == nil || in.patterns[ref].Tiling.tile != first {
+		t.Errorf("tile was not reused across fills")
+	}
+}
+
+func TestTilingPatternRecursion(t *testing.T) {
+	in := newTestInterpreter()
+
+	// A pattern whose overlapping cell copies are filled with itself.
+	ref := model.PDFIndirectRef{ObjectNumber: 6}
+	pat := tilingPattern(1, "/Pattern cs /P scn 0 0 10 10 re f")
+	pat.Dict["XStep"], pat.Dict["YStep"] = model.PDFNumber(3), model.PDFNumber(3)
+	pat.Dict["Resources"] = model.PDFDict{"Pattern": model.PDFDict{"P": ref}}
+	in.r.(*parser.ObjectTable).Add(&model.PDFObject{Number: 6, Value: pat})
+
+	resources := model.PDFDict{"Pattern": model.PDFDict{"P": ref}}
+	if err := in.Run([]byte("/Pattern cs /P scn 0 0 20 20 re f"), resources); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func formXObject(content string, extra model.PDFDict) model.PDFStream {
+	dict := model.PDFDict{
+		"Type":    model.PDFName("XObject"),
+		"Subtype": model.PDFName("Form"),
+		"BBox":    model.PDFArray{model.PDFNumber(0), model.PDFNumber(0), model.PDFNumber(10), model.PDFNumber(10)},
+	}
+	for k, v := range extra {
+		dict[k] = v
+	}
+	return model.PDFStream{Dict: dict, Data: []byte(content)}
+}
+
+func TestFormXObjects(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	green := color.RGBA{0, 0xff, 0, 0xff}
+	none := color.RGBA{}
+
+	resources := model.PDFDict{
+		"ColorSpace": model.PDFDict{"Page": model.PDFName("DeviceRGB")},
+		"XObject": model.PDFDict{
+			"Plain":    formXObject("1 0 0 rg 0 0 20 20 re f", nil),
+			"Moved":    formXObject("1 0 0 rg 0 0 10 10 re f", model.PDFDict{"Matrix": model.PDFArray{model.PDFNumber(1), model.PDFNumber(0), model.PDFNumber(0), model.PDFNumber(1), model.PDFNumber(10), model.PDFNumber(10)}}),
+			"Fallback": formXObject("/Page cs 0 1 0 sc 0 0 5 5 re f", nil),
+			"OwnRes": formXObject("/Own cs 0 1 0 sc 0 0 5 5 re f", model.PDFDict{
+				"Resources": model.PDFDict{"ColorSpace": model.PDFDict{"Own": model.PDFName("DeviceRGB")}},
+			}),
+			"Unbalanced": formXObject("q q 1 0 0 rg", nil),
+			"Underflow":  formXObject("Q 0 1 0 rg", nil),
+		},
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		pixels  map[[2]int]color.RGBA
+	}{
+		{"ClippedToBBox", "/Plain Do", map[[2]int]color.RGBA{{5, 5}: red, {15, 15}: none}},
+		{"Matrix", "/Moved Do", map[[2]int]color.RGBA{{15, 15}: red, {5, 5}: none}},
+		{"CTM", "1 0 0 1 10 0 cm /Plain Do", map[[2]int]color.RGBA{{15, 5}: red, {5, 5}: none}},
+		{"FallbackResources", "/Fallback Do", map[[2]int]color.RGBA{{2, 2}: green}},
+		{"OwnResources", "/OwnRes Do", map[[2]int]color.RGBA{{2, 2}: green}},
+		{"StateRestored", "/Unbalanced Do 0 0 20 20 re f", map[[2]int]color.RGBA{{15, 15}: {0, 0, 0, 0xff}}},
+		// A form cannot restore its caller's states.
+		{"Underflow", "q 1 0 0 rg /Underflow Do Q 0 0 20 20 re f", map[[2]int]color.RGBA{{15, 15}: {0, 0, 0, 0xff}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := newTestInterpreter()
+			if err := in.Run([]byte(tc.content), resources); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for p, expected := range tc.pixels {
+				if got := in.canvas.Img.RGBAAt(p[0], p[1]); got != expected {
+					t.Errorf("pixel %v = %v, expected %v", p, got, expected)
+				}
+			}
+		})
+	}
+
+	in := newTestInterpreter()
+	if err := in.Run([]byte("/Missing Do"), resources); err == nil {
+		t.Errorf("Run() with an unknown XObject expected an error")
+	}
+}
+
+func TestFormRecursion(t *testing.T) {
+	in := newTestInterpreter()
+
+	ref := model.PDFIndirectRef{ObjectNumber: 7}
+	form := formXObject("/Self Do 0 0 5 5 re f", nil)
+	form.Dict["Resources"] = model.PDFDict{"XObject": model.PDFDict{"Self": ref}}
+	in.r.(*parser.ObjectTable).Add(&model.PDFObject{Number: 7, Value: form})
+
+	resources := model.PDFDict{"XObject": model.PDFDict{"Self": ref}}
+	if err := in.Run([]byte("/Self Do"), resources); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	checkPixels(t, in, []pixel{{2, 2, true}, {7, 7, false}})
+
+	if in.depth != 0 || len(in.stack) != 0 || len(in.forms) != 0 {
+		t.Errorf("after Do depth = %d, stack = %d, forms = %d, expected 0, 0, 0", in.depth, len(in.stack), len(in.forms))
+	}
+
+	// A form invoking itself several times would run exponentially often
+	// up to the nesting limit.
+	fanOut := formXObject("/Self Do /Self Do /Self Do 0 0 5 5 re f", nil)
+	fanOut.Dict["Resources"] = form.Dict["Resources"]
+	in = newTestInterpreter()
+	in.r.(*parser.ObjectTable).Add(&model.PDFObject{Number: 7, Value: fanOut})
+	if err := in.Run([]byte("/Self Do /Self Do"), resources); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	checkPixels(t, in, []pixel{{2, 2, true}, {7, 7, false}})
+}
+
+func TestImages(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	none := color.RGBA{}
+
+	resources := model.PDFDict{
+		"ColorSpace": model.PDFDict{"Two": model.PDFArray{
+			model.PDFName("Indexed"), model.PDFName("DeviceRGB"), model.PDFNumber(1),
+			model.PDFString("\xff\x00\x00\x00\x00\xff"),
+		}},
+		"XObject": model.PDFDict{
+			"Im0": model.PDFStream{Dict: model.PDFDict{
+				"Subtype":          model.PDFName("Image"),
+				"Width":            model.PDFNumber(2),
+				"Height":           model.PDFNumber(1),
+				"BitsPerComponent": model.PDFNumber(8),
+				"ColorSpace":       model.PDFName("DeviceRGB"),
+			}, Data: []byte{0xff, 0, 0, 0, 0, 0xff}},
+			"Mask": model.PDFStream{Dict: model.PDFDict{
+				"Subtype":   model.PDFName("Image"),
+				"Width":     model.PDFNumber(2),
+				"Height":    model.PDFNumber(1),
+				"ImageMask": model.PDFBoolean(true),
+			}, Data: []byte{0x40}},
+			"Keyed": model.PDFStream{Dict: model.PDFDict{
+				"Subtype":          model.PDFName("Image"),
+				"Width":            model.PDFNumber(2),
+				"Height":           model.PDFNumber(1),
+				"BitsPerComponent": model.PDFNumber(8),
+				"ColorSpace":       model.PDFName("DeviceGray"),
+				"Mask":             model.PDFArray{model.PDFNumber(0), model.PDFNumber(0)},
+			}, Data: []byte{0, 0xff}},
+			// A JPEG 2000 image of two black pixels, the first opaque and
+			// the second transparent.
+			"JPX": model.PDFStream{Dict: model.PDFDict{
+				"Subtype":     model.PDFName("Image"),
+				"Width":       model.PDFNumber(2),
+				"Height":      model.PDFNumber(1),
+				"Filter":      model.PDFName("JPXDecode"),
+				"SMaskInData": model.PDFNumber(1),
+			}, Data: []byte("\xff\x4f\xff\x51\x00\x2c\x00\x00\x00\x00\x00\x02\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00" +
+				"\x00\x00\x00\x02\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x07\x01\x01\x07\x01\x01" +
+				"\xff\x52\x00\x0c\x00\x00\x00\x01\x00\x00\x00\x00\x00\x01\xff\x5c\x00\x04\x40\x48" +
+				"\xff\x90\x00\x0a\x00\x00\x00\x00\x00\x1a\x00\x01\xff\x93" +
+				"\xcf\xc0\x08\x09\x3f\xcf\xc0\x10\x0b\xb2\x8a\x7f\xff\xd9")},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		pixels  map[[2]int]color.RGBA
+	}{
+		{"XObject", "20 0 0 20 0 0 cm /Im0 Do", map[[2]int]color.RGBA{{2, 10}: red, {17, 10}: blue}},
+		{"Placed", "10 0 0 10 0 0 cm /Im0 Do", map[[2]int]color.RGBA{{2, 5}: red, {2, 15}: none, {15, 5}: none}},
+		{"StencilMask", "0 0 1 rg 20 0 0 20 0 0 cm /Mask Do", map[[2]int]color.RGBA{{2, 10}: blue, {17, 10}: none}},
+		{"ColorKey", "20 0 0 20 0 0 cm /Keyed Do", map[[2]int]color.RGBA{{2, 10}: none, {17, 10}: {0xff, 0xff, 0xff, 0xff}}},
+		{"JPXSMaskInData", "20 0 0 20 0 0 cm /JPX Do", map[[2]int]color.RGBA{{2, 10}: {0, 0, 0, 0xff}, {17, 10}: none}},
+		{"Inline", "20 0 0 20 0 0 cm BI /W 2 /H 1 /CS /RGB /BPC 8 ID \x00\x00\xff\xff\x00\x00\nEI", map[[2]int]color.RGBA{{2, 10}: blue, {17, 10}: red}},
+		{"InlineResourceSpace", "20 0 0 20 0 0 cm BI /W 2 /H 1 /CS /Two /BPC 1 ID \x40\nEI", map[[2]int]color.RGBA{{2, 10}: red, {17, 10}: blue}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := newTestInterpreter()
+			if err := in.Run([]byte(tc.content), resources); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for p, expected := range tc.pixels {
+				if got := in.canvas.Img.RGBAAt(p[0], p[1]); got != expected {
+					t.Errorf("pixel %v = %v, expected %v", p, got, expected)
+				}
+			}
+		})
+	}
+
+	in := newTestInterpreter()
+	if err := in.Run([]byte("BI /H 1 /CS /G /BPC 8 ID \x00\nEI"), resources); err == nil {
+		t.Errorf("Run() of an image without /Width expected an error")
+	}
+}
+
+func TestTransparency(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	none := color.RGBA{}
+
+	nums := func(v ...float64) model.PDFArray {
+		arr := model.PDFArray{}
+		for _, n := range v {
+			arr = append(arr, model.PDFNumber(n))
+		}
+		return arr
+	}
+	page := model.PDFDict{"BBox": nums(0, 0, 20, 20)}
+	group := func(content string, attrs model.PDFDict) model.PDFStream {
+		attrs["S"] = model.PDFName("Transparency")
+		return formXObject(content, model.PDFDict{"BBox": page["BBox"], "Group": attrs})
+	}
+	mask := func(kind, content string, extra model.PDFDict) model.PDFDict {
+		m := model.PDFDict{"S": model.PDFName(kind), "G": group(content, model.PDFDict{"CS": model.PDFName("DeviceGray")})}
+		for k, v := range extra {
+			m[k] = v
+		}
+		return model.PDFDict{"SMask": m}
+	}
+	leftHalf := "1 g 0 0 10 20 re f"
+
+	resources := model.PDFDict{
+		"ExtGState": model.PDFDict{
+			"Half":      model.PDFDict{"CA": model.PDFNumber(0.5), "ca": model.PDFNumber(0.5)},
+			"Wide":      model.PDFDict{"LW": model.PDFNumber(4)},
+			"Multiply":  model.PDFDict{"BM": model.PDFName("Multiply")},
+			"Screen":    model.PDFDict{"BM": model.PDFArray{model.PDFName("Dissolve"), model.PDFName("Screen")}},
+			"Lum":       mask("Luminosity", leftHalf, nil),
+			"AlphaMask": mask("Alpha", "0 0 10 20 re f", nil),
+			"Backdrop":  mask("Luminosity", "", model.PDFDict{"BC": nums(1)}),
+			"Inverted": mask("Luminosity", leftHalf, model.PDFDict{"TR": model.PDFDict{
+				"FunctionType": model.PDFNumber(2), "Domain": nums(0, 1), "C0": nums(1), "C1": nums(0), "N": model.PDFNumber(1),
+			}}),
+			"NoMask": model.PDFDict{"SMask": model.PDFName("None")},
+		},
+		"XObject": model.PDFDict{
+			"Group":    group("1 0 0 rg 0 0 15 20 re f 5 0 15 20 re f", model.PDFDict{}),
+			"Knockout": group("/Half gs 1 0 0 rg 0 0 15 20 re f 0 0 1 rg 5 0 15 20 re f", model.PDFDict{"K": model.PDFBoolean(true)}),
+			"Masked": model.PDFStream{Dict: model.PDFDict{
+				"Subtype":          model.PDFName("Image"),
+				"Width":            model.PDFNumber(2),
+				"Height":           model.PDFNumber(1),
+				"BitsPerComponent": model.PDFNumber(8),
+				"ColorSpace":       model.PDFName("DeviceRGB"),
+				"SMask": model.PDFStream{Dict: model.PDFDict{
+					"Subtype":          model.PDFName("Image"),
+					"Width":            model.PDFNumber(2),
+					"Height":           model.PDFNumber(1),
+					"BitsPerComponent": model.PDFNumber(8),
+					"ColorSpace":       model.PDFName("DeviceGray"),
+				}, Data: []byte{0xff, 0}},
+			}, Data: []byte{0xff, 0, 0, 0xff, 0, 0}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		pixels  map[[2]int]color.RGBA
+	}{
+		{"FillAlpha", "1 g 0 0 20 20 re f /Half gs 0 g 0 0 20 20 re f", map[[2]int]color.RGBA{{5, 5}: {0x80, 0x80, 0x80, 0xff}}},
+		{"StrokeAlpha", "/Half gs /Wide gs 1 0 0 RG 0 10 m 20 10 l S", map[[2]int]color.RGBA{{5, 10}: {0x80, 0, 0, 0x80}, {5, 5}: none}},
+		{"BlendMode", "1 1 0 rg 0 0 20 20 re f /Multiply gs 0 1 1 rg 0 0 20 20 re f", map[[2]int]color.RGBA{{5, 5}: {0, 0xff, 0, 0xff}}},
+		{"BlendModeArray", "1 1 0 rg 0 0 20 20 re f /Screen gs 0 0 1 rg 0 0 20 20 re f", map[[2]int]color.RGBA{{5, 5}: {0xff, 0xff, 0xff, 0xff}}},
+		{"LuminosityMask", "/Lum gs 1 0 0 rg 0 0 20 20 re f", map[[2]int]color.RGBA{{5, 5}: red, {15, 5}: none}},
+		{"AlphaMask", "/AlphaMask gs 1 0 0 rg 0 0 20 20 re f", map[[2]int]color.RGBA{{5, 5}: red, {15, 5}: none}},
+		{"MaskBackdrop", "/Backdrop gs 1 0 0 rg 0 0 20 20 re f", map[[2]int]color.RGBA{{15, 5}: red}},
+		{"MaskTransfer", "/Inverted gs 1 0 0 rg 0 0 20 20 re f", map[[2]int]color.RGBA{{5, 5}: none, {15, 5}: red}},
+		{"MaskNone", "/Lum gs /NoMask gs 1 0 0 rg 0 0 20 20 re f", map[[2]int]color.RGBA{{15, 5}: red}},
+		{"MaskRestored", "q /Lum gs Q 1 0 0 rg 0 0 20 20 re f", map[[2]int]color.RGBA{{15, 5}: red}},
+		{"Group", "1 g 0 0 20 20 re f /Half gs /Group Do", map[[2]int]color.RGBA{
+			{2, 5}: {0xff, 0x80, 0x80, 0xff}, {10, 5}: {0xff, 0x80, 0x80, 0xff}, {17, 5}: {0xff, 0x80, 0x80, 0xff},
+		}},
+		{"KnockoutGroup", "1 g 0 0 20 20 re f /Knockout Do", map[[2]int]color.RGBA{
+			{2, 5}: {0xff, 0x80, 0x80, 0xff}, {10, 5}: {0x80, 0x80, 0xff, 0xff},
+		}},
+		{"ImageSMask", "/Lum gs 20 0 0 20 0 0 cm /Masked Do", map[[2]int]color.RGBA{{2, 10}: red, {17, 10}: none}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := newTestInterpreter()
+			if err := in.Run([]byte(tc.content), resources); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for p, expected := range tc.pixels {
+				got := in.canvas.Img.RGBAAt(p[0], p[1])
+				d := func(a, b uint8) bool { return int(a)-int(b) > 1 || int(b)-int(a) > 1 }
+				if d(got.R, expected.R) || d(got.G, expected.G) || d(got.B, expected.B) || d(got.A, expected.A) {
+					t.Errorf("pixel %v = %v, expected %v", p, got, expected)
+				}
+			}
+		})
+	}
+
+	in := newTestInterpreter()
+	if err := in.Run([]byte("/Missing gs"), resources); err == nil {
+		t.Errorf("Run() with an unknown ExtGState expected an error")
+	}
+}
+
+func TestTextOperators(t *testing.T) {
+	widths := make(model.PDFArray, 35)
+	for i := range widths {
+		widths[i] = model.PDFNumber(0)
+	}
+	widths[0], widths[33], widths[34] = model.PDFNumber(250), model.PDFNumber(500), model.PDFNumber(1000)
+	resources := model.PDFDict{"Font": model.PDFDict{"F1": model.PDFDict{
+		"Type":      model.PDFName("Font"),
+		"Subtype":   model.PDFName("Type1"),
+		"BaseFont":  model.PDFName("Test"),
+		"FirstChar": model.PDFNumber(32),
+		"Widths":    widths,
+	}}}
+
+	tests := []struct {
+		name    string
+		content string
+		tm, tlm render.Matrix
+	}{
+		{"Td", "5 6 Td", render.Translate(5, 6), render.Translate(5, 6)},
+		{"ShowText", "5 6 Td (AB) Tj", render.Translate(20, 6), render.Translate(5, 6)},
+		{"Spacing", "2 Tc 3 Tw (A B) Tj", render.Translate(26.5, 0), render.Identity},
+		{"HorizontalScaling", "50 Tz 1 Tc (A) Tj", render.Translate(3, 0), render.Identity},
+		{"HexString", "<4142> Tj", render.Translate(15, 0), render.Identity},
+		{"ShowTextArray", "[(A) -500 (B) 1000] TJ", render.Translate(10, 0), render.Identity},
+		{"TD", "1 -12 TD T*", render.Translate(1, -24), render.Translate(1, -24)},
+		{"Tm", "2 0 0 2 10 10 Tm (A) Tj", render.Matrix{2, 0, 0, 2, 20, 10}, render.Matrix{2, 0, 0, 2, 10, 10}},
+		{"TmReplaces", "5 5 Td 1 0 0 1 0 0 Tm 0 2 Td", render.Translate(0, 2), render.Translate(0, 2)},
+		{"Quote", "14 TL 0 100 Td (A) '", render.Translate(5, 86), render.Translate(0, 86)},
+		{"DoubleQuote", "14 TL 4 1 (A B) \"", render.Translate(24.5, -14), render.Translate(0, -14)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := newTestInterpreter()
+			if err := in.Run([]byte("BT /F1 10 Tf "+tc.content), resources); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if in.tm != tc.tm || in.tlm != tc.tlm {
+				t.Errorf("text matrix = %v, line matrix = %v, expected %v, %v", in.tm, in.tlm, tc.tm, tc.tlm)
+			}
+		})
+	}
+
+	t.Run("Vertical", func(t *testing.T) {
+		resources := model.PDFDict{"Font": model.PDFDict{"F2": model.PDFDict{
+			"Subtype":  model.PDFName("Type0"),
+			"Encoding": model.PDFName("Identity-V"),
+			"DescendantFonts": model.PDFArray{model.PDFDict{
+				"Subtype": model.PDFName("CIDFontType0"),
+				"W2":      model.PDFArray{model.PDFNumber(1), model.PDFArray{model.PDFNumber(-500), model.PDFNumber(0), model.PDFNumber(0)}},
+			}},
+		}}}
+
+		// Horizontal scaling does not apply, and TJ numbers move down.
+		in := newTestInterpreter()
+		content := "BT /F2 10 Tf 50 Tz 1 Tc [<00010002> 500] TJ"
+		if err := in.Run([]byte(content), resources); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if expected := render.Translate(0, -18); in.tm != expected {
+			t.Errorf("text matrix = %v, expected %v", in.tm, expected)
+		}
+	})
+
+	t.Run("State", func(t *testing.T) {
+		in := newTestInterpreter()
+		content := "q /F1 12 Tf 1 Tc 2 Tw 80 Tz 14 TL 3 Ts"
+		if err := in.Run([]byte(content), resources); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		ts := in.gs.Text
+		if ts.Font == nil || ts.Font.BaseFont != "Test" || ts.Size != 12 || ts.CharSpace != 1 ||
+			ts.WordSpace != 2 || ts.Scale != 0.8 || ts.Leading != 14 || ts.Rise != 3 {
+			t.Errorf("text state = %+v", ts)
+		}
+		if err := in.Run([]byte("Q"), resources); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if in.gs.Text != NewTextState() {
+			t.Errorf("text state after Q = %+v, expected the initial state", in.gs.Text)
+		}
+	})
+
+	errors := []string{
+		"BT (A) Tj",
+		"BT /F2 10 Tf",
+		"BT /F1 Tf",
+		"BT /F1 10 Tf [(A) /B] TJ",
+		"BT /F1 10 Tf 5 Tj",
+		"BT /F1 10 Tf 5 (A) \"",
+	}
+	for _, content := range errors {
+		in := newTestInterpreter()
+		if err := in.Run([]byte(content), resources); err == nil {
+			t.Errorf("Run(%q) expected an error", content)
+		}
+	}
+}
+
+func TestGlyphRendering(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/glyphs.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Glyph A is the square from (0.1, 0) to (0.6, 0.5) of the em.
+	resources := model.PDFDict{"Font": model.PDFDict{"F1": model.PDFDict{
+		"Subtype":  model.PDFName("TrueType"),
+		"BaseFont": model.PDFName("Glyphs"),
+		"FontDescriptor": model.PDFDict{
+			"Flags":     model.PDFNumber(32),
+			"FontFile2": model.PDFStream{Dict: model.PDFDict{}, Data: data},
+		},
+	}}}
+
+	tests := []struct {
+		name    string
+		content string
+		pixels  []pixel
+	}{
+		{"Fill", "BT /F1 20 Tf (A) Tj ET", []pixel{{5, 5, true}, {15, 5, false}, {5, 15, false}}},
+		{"Rise", "BT /F1 20 Tf 5 Ts (A) Tj ET", []pixel{{5, 2, false}, {5, 12, true}}},
+		{"Advance", "BT /F1 20 Tf (AA) Tj ET", []pixel{{16, 5, true}, {13, 5, false}}},
+		{"Stroke", "BT /F1 20 Tf 1 Tr (A) Tj ET", []pixel{{2, 5, true}, {7, 5, false}}},
+		{"Invisible", "BT /F1 20 Tf 3 Tr (A) Tj ET", []pixel{{5, 5, false}}},
+		{"Clip", "BT /F1 20 Tf 7 Tr (A) Tj ET 0 0 20 20 re f", []pixel{{5, 5, true}, {15, 15, false}}},
+		{"Missing", "BT /F1 20 Tf (Z) Tj ET", []pixel{{5, 5, false}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := newTestInterpreter()
+			if err := in.Run([]byte(tc.content), resources); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			checkPixels(t, in, tc.pixels)
+		})
+	}
+
+	// Glyphs are flattened once per font, glyph and size.
+	in := newTestInterpreter()
+	if err := in.Run([]byte("BT /F1 10 Tf (AAA) Tj /F1 5 Tf (A) Tj ET"), resources); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(in.glyphs) != 2 {
+		t.Errorf("glyph cache holds %d outlines, expected 2", len(in.glyphs))
+	}
+}
+
+func TestType3Glyphs(t *testing.T) {
+	// The square is a stencil whose colour operator is ignored; the red
+	// square sets its own colour.
+	resources := model.PDFDict{"Font": model.PDFDict{"F3": model.PDFDict{
+		"Subtype":    model.PDFName("Type3"),
+		"FontMatrix": model.PDFArray{model.PDFNumber(0.001), model.PDFNumber(0), model.PDFNumber(0), model.PDFNumber(0.001), model.PDFNumber(0), model.PDFNumber(0)},
+		"FirstChar":  model.PDFNumber(65),
+		"Widths":     model.PDFArray{model.PDFNumber(1000), model.PDFNumber(1000)},
+		"Encoding": model.PDFDict{"Differences": model.PDFArray{
+			model.PDFNumber(65), model.PDFName("square"), model.PDFName("red"),
+		}},
+		"CharProcs": model.PDFDict{
+			"square": model.PDFStream{Dict: model.PDFDict{}, Data: []byte("1000 0 0 0 500 500 d1 0 1 0 rg 0 0 500 500 re f")},
+			"red":    model.PDFStream{Dict: model.PDFDict{}, Data: []byte("1000 0 d0 1 0 0 rg 0 0 500 500 re f")},
+		},
+	}}}
+
+	in := newTestInterpreter()
+	if err := in.Run([]byte("0 0 1 rg BT /F3 10 Tf (ABC) Tj ET"), resources); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	checkPixels(t, in, []pixel{{2, 2, true}, {7, 2, false}, {12, 2, true}, {2, 7, false}})
+	if c := in.canvas.Img.RGBAAt(2, 2); c != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("stencil glyph colour = %v, expected the fill colour", c)
+	}
+	if c := in.canvas.Img.RGBAAt(12, 2); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("coloured glyph colour = %v, expected its own", c)
+	}
+	// The fill colour is unchanged by the glyphs.
+	if c := in.gs.FillColor; c != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("fill colour after the text = %v", c)
+	}
+
+	// Stencil glyphs are rendered once per font, glyph and size.
+	in = newTestInterpreter()
+	if err := in.Run([]byte("BT /F3 4 Tf (AAA) Tj 200 Tz (A) Tj ET"), resources); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(in.glyphMasks) != 2 || len(in.charProcs) != 1 {
+		t.Errorf("caches hold %d masks and %d descriptions, expected 2 and 1", len(in.glyphMasks), len(in.charProcs))
+	}
+	checkPixels(t, in, []pixel{{1, 1, true}, {5, 1, true}, {9, 1, true}, {15, 1, true}, {3, 1, false}, {15, 3, false}})
+}
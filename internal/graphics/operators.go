@@ -1 +1,369 @@
 package graphics
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+type operatorFunc func(in *Interpreter, args []model.PDFValue) error
+
+var operators map[string]operatorFunc
+
+func init() {
+	operators = map[string]operatorFunc{
+		// ---- graphics state ----
+		"q":  opSave,
+		"Q":  opRestore,
+		"cm": opConcat,
+		"w":  opSetLineWidth,
+		"J":  opSetLineCap,
+		"j":  opSetLineJoin,
+		"M":  opSetMiterLimit,
+		"d":  opSetDash,
+		"gs": opSetExtGState,
+
+		// ---- path construction ----
+		"m":  opMoveTo,
+		"l":  opLineTo,
+		"c":  opCurveTo,
+		"v":  opCurveToV,
+		"y":  opCurveToY,
+		"h":  opClosePath,
+		"re": opRect,
+
+		// ---- path painting ----
+		"S":  opStroke,
+		"s":  opCloseStroke,
+		"f":  opFill,
+		"F":  opFill,
+		"f*": opFillEvenOdd,
+		"B":  opFillStroke,
+		"B*": opFillStrokeEvenOdd,
+		"b":  opCloseFillStroke,
+		"b*": opCloseFillStrokeEvenOdd,
+		"n":  opEndPath,
+
+		// ---- colour ----
+		"g":   deviceColorOp(colorspace.DeviceGray, false),
+		"G":   deviceColorOp(colorspace.DeviceGray, true),
+		"rg":  deviceColorOp(colorspace.DeviceRGB, false),
+		"RG":  deviceColorOp(colorspace.DeviceRGB, true),
+		"k":   deviceColorOp(colorspace.DeviceCMYK, false),
+		"K":   deviceColorOp(colorspace.DeviceCMYK, true),
+		"cs":  opSetFillColorSpace,
+		"CS":  opSetStrokeColorSpace,
+		"sc":  opSetFillColor,
+		"scn": opSetFillColor,
+		"SC":  opSetStrokeColor,
+		"SCN": opSetStrokeColor,
+
+		// ---- shading ----
+		"sh": opShade,
+
+		// ---- external objects ----
+		"Do": opDo,
+
+		// ---- inline images ----
+		"BI": opInlineImage,
+
+		// ---- clipping ----
+		"W":  opClip,
+		"W*": opClipEvenOdd,
+
+		// ---- text objects ----
+		"BT": opBeginText,
+		"ET": opEndText,
+
+		// ---- text state ----
+		"Tc": textParamOp(func(ts *TextState) *float64 { return &ts.CharSpace }),
+		"Tw": textParamOp(func(ts *TextState) *float64 { return &ts.WordSpace }),
+		"Tz": opSetHorizontalScaling,
+		"TL": textParamOp(func(ts *TextState) *float64 { return &ts.Leading }),
+		"Tf": opSetFont,
+		"Tr": opSetTextRenderMode,
+		"Ts": textParamOp(func(ts *TextState) *float64 { return &ts.Rise }),
+
+		// ---- text positioning ----
+		"Td": opMoveText,
+		"TD": opMoveTextSetLeading,
+		"Tm": opSetTextMatrix,
+		"T*": opNextLine,
+
+		// ---- text showing ----
+		"Tj": opShowText,
+		"TJ": opShowTextArray,
+		"'":  opNextLineShowText,
+		"\"": opSpacedNextLineShowText,
+
+		// ---- Type 3 fonts ----
+		"d0": opSetCharWidth,
+		"d1": opSetCharWidth,
+	}
+}
+
+// number converts a numeric operand to float64.
+func number(v model.PDFValue) (float64, bool) {
+	n, ok := v.(model.PDFNumber)
+	return float64(n), ok
+}
+
+// numberArgs returns the last n operands of args, which must be numbers.
+// Operands before them, which files sometimes leave, are ignored.
+func numberArgs(args []model.PDFValue, n int) ([]float64, error) {
+	if len(args) < n {
+		return nil, fmt.Errorf("expected %d operands, got %d", n, len(args))
+	}
+	args = args[len(args)-n:]
+
+	out := make([]float64, n)
+	for i, a := range args {
+		v, ok := number(a)
+		if !ok {
+			return nil, fmt.Errorf("operand %d is not a number: %v", i, a)
+		}
+		out[i] = v
+	}
+
+	return out, nil
+}
+
+// ---- graphics state ----
+
+func opSave(in *Interpreter, args []model.PDFValue) error {
+	in.save()
+	return nil
+}
+
+func opRestore(in *Interpreter, args []model.PDFValue) error {
+	in.restore()
+	return nil
+}
+
+func opConcat(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 6)
+	if err != nil {
+		return err
+	}
+
+	m := render.Matrix{v[0], v[1], v[2], v[3], v[4], v[5]}
+	in.gs.CTM = m.Multiply(in.gs.CTM)
+	return nil
+}
+
+func opSetLineWidth(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 1)
+	if err != nil {
+		return err
+	}
+
+	in.gs.Stroke.Width = v[0]
+	return nil
+}
+
+func opSetLineCap(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 1)
+	if err != nil {
+		return err
+	}
+
+	// Unknown styles are ignored.
+	if v[0] >= 0 && v[0] <= 2 {
+		in.gs.Stroke.Cap = render.LineCap(v[0])
+	}
+	return nil
+}
+
+func opSetLineJoin(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 1)
+	if err != nil {
+		return err
+	}
+
+	// Unknown styles are ignored.
+	if v[0] >= 0 && v[0] <= 2 {
+		in.gs.Stroke.Join = render.LineJoin(v[0])
+	}
+	return nil
+}
+
+func opSetMiterLimit(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 1)
+	if err != nil {
+		return err
+	}
+
+	in.gs.Stroke.MiterLimit = v[0]
+	return nil
+}
+
+func opSetDash(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected 2 operands, got %d", len(args))
+	}
+
+	arr, ok := args[0].(model.PDFArray)
+	if !ok {
+		return fmt.Errorf("dash array is not an array: %v", args[0])
+	}
+
+	phase, ok := number(args[1])
+	if !ok {
+		return fmt.Errorf("dash phase is not a number: %v", args[1])
+	}
+
+	dash := make([]float64, len(arr))
+	for i, a := range arr {
+		if dash[i], ok = number(a); !ok {
+			return fmt.Errorf("dash element is not a number: %v", a)
+		}
+	}
+
+	in.gs.Stroke.Dash = dash
+	in.gs.Stroke.DashPhase = phase
+	return nil
+}
+
+// ---- path construction ----
+
+func opMoveTo(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 2)
+	if err != nil {
+		return err
+	}
+
+	in.path.MoveTo(v[0], v[1])
+	return nil
+}
+
+func opLineTo(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 2)
+	if err != nil {
+		return err
+	}
+
+	in.path.LineTo(v[0], v[1])
+	return nil
+}
+
+func opCurveTo(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 6)
+	if err != nil {
+		return err
+	}
+
+	in.path.CubeTo(v[0], v[1], v[2], v[3], v[4], v[5])
+	return nil
+}
+
+// opCurveToV implements v, whose first control point is the current point.
+func opCurveToV(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 4)
+	if err != nil {
+		return err
+	}
+
+	cur, ok := in.path.CurrentPoint()
+	if !ok {
+		return fmt.Errorf("no current point")
+	}
+
+	in.path.CubeTo(cur.X, cur.Y, v[0], v[1], v[2], v[3])
+	return nil
+}
+
+// opCurveToY implements y, whose second control point is the end point.
+func opCurveToY(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 4)
+	if err != nil {
+		return err
+	}
+
+	in.path.CubeTo(v[0], v[1], v[2], v[3], v[2], v[3])
+	return nil
+}
+
+func opClosePath(in *Interpreter, args []model.PDFValue) error {
+	in.path.Close()
+	return nil
+}
+
+func opRect(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 4)
+	if err != nil {
+		return err
+	}
+
+	in.path.Rect(v[0], v[1], v[2], v[3])
+	return nil
+}
+
+// ---- path painting ----
+
+func opStroke(in *Interpreter, args []model.PDFValue) error {
+	in.strokePath()
+	in.endPath()
+	return nil
+}
+
+func opCloseStroke(in *Interpreter, args []model.PDFValue) error {
+	in.path.Close()
+	return opStroke(in, args)
+}
+
+func opFill(in *Interpreter, args []model.PDFValue) error {
+	in.fillPath(render.NonZero)
+	in.endPath()
+	return nil
+}
+
+func opFillEvenOdd(in *Interpreter, args []model.PDFValue) error {
+	in.fillPath(render.EvenOdd)
+	in.endPath()
+	return nil
+}
+
+func opFillStroke(in *Interpreter, args []model.PDFValue) error {
+	in.fillPath(render.NonZero)
+	in.strokePath()
+	in.endPath()
+	return nil
+}
+
+func opFillStrokeEvenOdd(in *Interpreter, args []model.PDFValue) error {
+	in.fillPath(render.EvenOdd)
+	in.strokePath()
+	in.endPath()
+	return nil
+}
+
+func opCloseFillStroke(in *Interpreter, args []model.PDFValue) error {
+	in.path.Close()
+	return opFillStroke(in, args)
+}
+
+func opCloseFillStrokeEvenOdd(in *Interpreter, args []model.PDFValue) error {
+	in.path.Close()
+	return opFillStrokeEvenOdd(in, args)
+}
+
+func opEndPath(in *Interpreter, args []model.PDFValue) error {
+	in.endPath()
+	return nil
+}
+
+// ---- clipping ----
+
+func opClip(in *Interpreter, args []model.PDFValue) error {
+	in.clipPending = true
+	in.clipRule = render.NonZero
+	return nil
+}
+
+func opClipEvenOdd(in *Interpreter, args []model.PDFValue) error {
+	in.clipPending = true
+	in.clipRule = render.EvenOdd
+	return nil
+}
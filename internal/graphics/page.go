@@ -0,0 +1,74 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// RenderOptions controls how a page is rasterized.
+type RenderOptions struct {
+	// DPI is the output resolution; PDF user space has 72 units per inch.
+	DPI float64
+
+	// Background is painted before the page contents. A nil background
+	// leaves unpainted areas transparent.
+	Background color.Color
+}
+
+// PageTransform returns the matrix that maps the default user space of page to
+// device pixels at the given resolution, together with the size of the
+// raster. The visible area is the crop box, scaled by /UserUnit and turned
+// clockwise by /Rotate.
+func PageTransform(page *model.Page, dpi float64) (render.Matrix, int, int) {
+	box := page.CropBox
+	s := dpi / 72 * page.UserUnit
+
+	w, h := box.Width()*s, box.Height()*s
+
+	var m render.Matrix
+	switch page.Rotate {
+	case 90:
+		m = render.Matrix{0, s, s, 0, -box.LLY * s, -box.LLX * s}
+		w, h = h, w
+	case 180:
+		m = render.Matrix{-s, 0, 0, s, box.URX * s, -box.LLY * s}
+	case 270:
+		m = render.Matrix{0, -s, -s, 0, box.URY * s, box.URX * s}
+		w, h = h, w
+	default:
+		// Device space has its origin at the top left with y pointing down.
+		m = render.Matrix{s, 0, 0, -s, -box.LLX * s, box.URY * s}
+	}
+
+	return m, int(math.Ceil(w - 1e-9)), int(math.Ceil(h - 1e-9))
+}
+
+// RenderPage rasterizes a page, resolving indirect references through r.
+// Operators of the contents that fail, such as those naming missing
+// resources, are skipped: the page is returned painted without them, along
+// with their errors.
+func RenderPage(page *model.Page, r model.Resolver, opts RenderOptions) (*image.RGBA, error) {
+	if opts.DPI <= 0 {
+		return nil, fmt.Errorf("invalid resolution %v dpi", opts.DPI)
+	}
+
+	ctm, w, h := PageTransform(page, opts.DPI)
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("empty page area")
+	}
+
+	canvas := render.NewCanvas(w, h)
+	if opts.Background != nil {
+		draw.Draw(canvas.Img, canvas.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	}
+
+	in := NewInterpreter(canvas, ctm, r)
+	err := in.Run(page.Contents, page.Resources)
+	return canvas.Img, err
+}
@@ -0,0 +1,119 @@
+package graphics
+
+import (
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func TestPageTransform(t *testing.T) {
+	page := &model.Page{
+		CropBox:  model.Rectangle{LLX: 0, LLY: 0, URX: 200, URY: 100},
+		UserUnit: 1,
+	}
+
+	// Where the lower-left and upper-right corners of the crop box land for
+	// each rotation.
+	tests := []struct {
+		rotate     int
+		w, h       int
+		expectLL   [2]float64
+		expectURxy [2]float64
+	}{
+		{rotate: 0, w: 200, h: 100, expectLL: [2]float64{0, 100}, expectURxy: [2]float64{200, 0}},
+		{rotate: 90, w: 100, h: 200, expectLL: [2]float64{0, 0}, expectURxy: [2]float64{100, 200}},
+		{rotate: 180, w: 200, h: 100, expectLL: [2]float64{200, 0}, expectURxy: [2]float64{0, 100}},
+		{rotate: 270, w: 100, h: 200, expectLL: [2]float64{100, 200}, expectURxy: [2]float64{0, 0}},
+	}
+
+	for _, tc := range tests {
+		page.Rotate = tc.rotate
+		m, w, h := PageTransform(page, 72)
+
+		if w != tc.w || h != tc.h {
+			t.Errorf("rotate %d: size = %dx%d, expected %dx%d", tc.rotate, w, h, tc.w, tc.h)
+		}
+
+		if x, y := m.Transform(0, 0); x != tc.expectLL[0] || y != tc.expectLL[1] {
+			t.Errorf("rotate %d: lower-left maps to (%v, %v), expected %v", tc.rotate, x, y, tc.expectLL)
+		}
+
+		if x, y := m.Transform(200, 100); x != tc.expectURxy[0] || y != tc.expectURxy[1] {
+			t.Errorf("rotate %d: upper-right maps to (%v, %v), expected %v", tc.rotate, x, y, tc.expectURxy)
+		}
+	}
+}
+
+func TestPageTransformUserUnit(t *testing.T) {
+	page := &model.Page{
+		CropBox:  model.Rectangle{LLX: 0, LLY: 0, URX: 100, URY: 50},
+		UserUnit: 2,
+	}
+
+	if _, w, h := PageTransform(page, 144); w != 400 || h != 200 {
+		t.Errorf("size = %dx%d, expected 400x200", w, h)
+	}
+}
+
+func TestRenderMinimalPDF(t *testing.T) {
+	f, err := os.Open("../../testdata/minimal.pdf")
+	if err != nil {
+		t.Fatalf("opening test file: %v", err)
+	}
+	defer f.Close()
+
+	doc, err := parser.NewParser(parser.NewLexer(f)).ParseDocument()
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if err := doc.ResolveCatalog(); err != nil {
+		t.Fatalf("ResolveCatalog() error = %v", err)
+	}
+	if err := doc.ResolvePages(); err != nil {
+		t.Fatalf("ResolvePages() error = %v", err)
+	}
+
+	page, err := doc.Page(0)
+	if err != nil {
+		t.Fatalf("Page(0) error = %v", err)
+	}
+
+	img, err := RenderPage(page, doc, RenderOptions{DPI: 72, Background: color.White})
+	if err != nil {
+		t.Fatalf("RenderPage() error = %v", err)
+	}
+
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Fatalf("image size = %v, expected 300x300", b)
+	}
+
+	// The page strokes a line from (72, 72) to (200, 200) in PDF space.
+	if c := img.RGBAAt(136, 300-136); c.R > 0xc0 {
+		t.Errorf("pixel on the line = %v, expected painted", c)
+	}
+	if c := img.RGBAAt(136, 300-180); c != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("pixel off the line = %v, expected white", c)
+	}
+}
+
+func TestRenderPageSkipsFailingOperators(t *testing.T) {
+	page := &model.Page{
+		CropBox:  model.Rectangle{URX: 20, URY: 20},
+		UserUnit: 1,
+		Contents: []byte("Q 3 J /CS9 cs BT /F9 12 Tf (A) Tj ET 1 0 0 rg 0 0 20 20 re f"),
+	}
+
+	img, err := RenderPage(page, parser.NewObjectTable(), RenderOptions{DPI: 72})
+	if err == nil {
+		t.Errorf("RenderPage() expected the errors of cs, Tf and Tj")
+	}
+	if img == nil {
+		t.Fatalf("RenderPage() returned no page")
+	}
+	if c := img.RGBAAt(10, 10); c != (color.RGBA{0xff, 0, 0, 0xff}) {
+		t.Errorf("pixel = %v, expected red", c)
+	}
+}
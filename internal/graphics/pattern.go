@@ -0,0 +1,182 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// Pattern is a parsed pattern resource selected as the current colour.
+type Pattern struct {
+	// Matrix maps pattern space to the default coordinate space of the
+	// content stream that uses the pattern.
+	Matrix render.Matrix
+
+	// Shading is the gradient of a shading pattern (PatternType 2).
+	Shading *render.Shading
+
+	// Tiling is the cell of a tiling pattern (PatternType 1).
+	Tiling *Tiling
+}
+
+// pattern looks up and parses a pattern resource. Patterns stored as
+// indirect objects are parsed once per page so that their tiles are cached
+// across fills.
+func (in *Interpreter) pattern(name model.PDFName) (*Pattern, error) {
+	v, ok := model.LookupResource(in.res, in.r, model.ResPattern, string(name))
+	if !ok {
+		return nil, fmt.Errorf("pattern resource %s not found", name)
+	}
+
+	sub, _ := in.r.Resolve(in.res[model.ResPattern]).(model.PDFDict)
+	ref, indirect := sub[string(name)].(model.PDFIndirectRef)
+	if pat, ok := in.patterns[ref]; indirect && ok {
+		return pat, nil
+	}
+
+	pat, err := in.parsePattern(name, v)
+	if err == nil && indirect {
+		in.patterns[ref] = pat
+	}
+	return pat, err
+}
+
+func (in *Interpreter) parsePattern(name model.PDFName, v model.PDFValue) (*Pattern, error) {
+	var dict model.PDFDict
+	var stream *model.PDFStream
+	switch p := v.(type) {
+	case model.PDFDict:
+		dict = p
+	case model.PDFStream:
+		dict, stream = p.Dict, &p
+	default:
+		return nil, fmt.Errorf("pattern %s is not a dictionary", name)
+	}
+
+	pat := &Pattern{Matrix: render.Identity}
+	if m, ok := in.matrix(dict["Matrix"]); ok {
+		pat.Matrix = m
+	}
+
+	typ, _ := in.r.Resolve(dict["PatternType"]).(model.PDFNumber)
+	switch typ {
+	case 1:
+		if stream == nil {
+			return nil, fmt.Errorf("tiling pattern %s is not a stream", name)
+		}
+		t, err := in.parseTiling(*stream)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", name, err)
+		}
+		pat.Tiling = t
+		return pat, nil
+	case 2:
+		sh, err := render.ParseShading(dict["Shading"], in.r)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", name, err)
+		}
+		pat.Shading = sh
+		return pat, nil
+	default:
+		return nil, fmt.Errorf("pattern %s has unknown type %v", name, dict["PatternType"])
+	}
+}
+
+// matrix resolves a six-element array of numbers.
+func (in *Interpreter) matrix(v model.PDFValue) (render.Matrix, bool) {
+	n, ok := model.ResolveNumbers(v, in.r)
+	if !ok || len(n) != 6 {
+		return render.Matrix{}, false
+	}
+	return render.Matrix(n), true
+}
+
+// fillPattern paints the device-space area of a path with a pattern. col is
+// the colour of an uncoloured tiling pattern.
+func (in *Interpreter) fillPattern(pat *Pattern, col color.Color, area *render.Path, rule render.FillRule) {
+	if pat == nil {
+		return
+	}
+
+	bounds := in.canvas.Bounds().Intersect(in.gs.Clip.Bounds())
+	lo, hi := area.Bounds()
+	bounds = bounds.Intersect(pixelBounds(lo, hi))
+	if bounds.Empty() {
+		return
+	}
+
+	in.paintPattern(pat, col, render.Rasterize(area, rule, bounds))
+}
+
+// paintPattern paints a pattern through the device-space coverage in mask.
+func (in *Interpreter) paintPattern(pat *Pattern, col color.Color, mask *image.Alpha) {
+	ctm := pat.Matrix.Multiply(in.baseCTM)
+	if pat.Tiling != nil {
+		in.paintTiling(pat.Tiling, ctm, col, mask)
+		return
+	}
+	in.paintShading(pat.Shading, ctm, mask, true)
+}
+
+// paintShading composites a shading through mask, or the whole clip if mask
+// is nil, with ctm mapping shading space to the device.
+func (in *Interpreter) paintShading(sh *render.Shading, ctm render.Matrix, mask *image.Alpha, background bool) {
+	if colorspace.PaintsNothing(sh.Space) {
+		return
+	}
+	clip := in.gs.Clip
+	if sh.HasBBox {
+		clip = clip.IntersectPath(sh.BBoxPath().Transform(ctm), render.NonZero)
+	}
+
+	bounds := in.canvas.Bounds().Intersect(clip.Bounds())
+	if mask != nil {
+		bounds = bounds.Intersect(mask.Rect)
+	}
+	if bounds.Empty() {
+		return
+	}
+
+	in.canvas.Composite(sh.Render(ctm, bounds, background), mask, clip)
+}
+
+// pixelBounds returns the pixel rectangle covering the points lo to hi.
+func pixelBounds(lo, hi render.Point) image.Rectangle {
+	return image.Rect(
+		int(math.Floor(lo.X)), int(math.Floor(lo.Y)),
+		int(math.Ceil(hi.X)), int(math.Ceil(hi.Y)),
+	)
+}
+
+// opShade implements sh, which paints a shading over the current clip.
+func opShade(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 operand, got %d", len(args))
+	}
+
+	name, ok := args[0].(model.PDFName)
+	if !ok {
+		return fmt.Errorf("shading operand is not a name: %v", args[0])
+	}
+
+	v, ok := model.LookupResource(in.res, in.r, model.ResShading, string(name))
+	if !ok {
+		return fmt.Errorf("shading resource %s not found", name)
+	}
+
+	sh, err := render.ParseShading(v, in.r)
+	if err != nil {
+		return err
+	}
+
+	in.setCompositing(false)
+
+	// The background only applies when the shading is used as a pattern.
+	in.paintShading(sh, in.gs.CTM, nil, false)
+	return nil
+}
@@ -1 +1,69 @@
 package graphics
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// State is the device-independent graphics state saved and restored by the q
+// and Q operators.
+type State struct {
+	CTM    render.Matrix
+	Clip   *render.Clip
+	Stroke render.StrokeStyle
+
+	// The current colours are kept both as components in their colour
+	// space and converted for the rasterizer. Component slices are replaced,
+	// never modified in place, so saved states may share them.
+	FillSpace   colorspace.ColorSpace
+	FillComps   []float64
+	FillColor   color.Color
+	StrokeSpace colorspace.ColorSpace
+	StrokeComps []float64
+	StrokeColor color.Color
+
+	// The patterns selected when the corresponding colour space is a
+	// pattern space; nil paints nothing.
+	FillPattern   *Pattern
+	StrokePattern *Pattern
+
+	Text TextState
+
+	// The transparency parameters set by the gs operator. SoftMask is the
+	// opacity of each device pixel, or nil for none; like clips, masks are
+	// shared between saved states.
+	FillAlpha   float64
+	StrokeAlpha float64
+	BlendMode   render.BlendMode
+	SoftMask    *image.Alpha
+}
+
+// NewState returns the initial graphics state of a page whose default user
+// space is mapped to the device by ctm and which is clipped to clip.
+func NewState(ctm render.Matrix, clip *render.Clip) *State {
+	return &State{
+		CTM:         ctm,
+		Clip:        clip,
+		Stroke:      render.DefaultStrokeStyle(),
+		FillSpace:   colorspace.DeviceGray,
+		FillComps:   []float64{0},
+		FillColor:   color.Black,
+		StrokeSpace: colorspace.DeviceGray,
+		StrokeComps: []float64{0},
+		StrokeColor: color.Black,
+		Text:        NewTextState(),
+		FillAlpha:   1,
+		StrokeAlpha: 1,
+	}
+}
+
+// Clone returns a copy of s that can be modified without affecting s. Clips
+// and soft masks are immutable and therefore shared.
+func (s *State) Clone() *State {
+	c := *s
+	c.Stroke.Dash = append([]float64(nil), s.Stroke.Dash...)
+	return &c
+}
@@ -1 +1,390 @@
 package graphics
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/font"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// TextRenderMode is the operand of the Tr operator.
+type TextRenderMode int
+
+// TextRenderMode values.
+const (
+	TextFill TextRenderMode = iota
+	TextStroke
+	TextFillStroke
+	TextInvisible
+	TextFillClip
+	TextStrokeClip
+	TextFillStrokeClip
+	TextClip
+)
+
+// Clips reports whether glyphs shown in mode m are added to the clipping path.
+func (m TextRenderMode) Clips() bool {
+	return m >= TextFillClip && m <= TextClip
+}
+
+// Fills reports whether glyphs shown in mode m are filled.
+func (m TextRenderMode) Fills() bool {
+	return m == TextFill || m == TextFillStroke || m == TextFillClip || m == TextFillStrokeClip
+}
+
+// Strokes reports whether glyphs shown in mode m are stroked.
+func (m TextRenderMode) Strokes() bool {
+	return m == TextStroke || m == TextFillStroke || m == TextStrokeClip || m == TextFillStrokeClip
+}
+
+// TextState holds the text parameters of the graphics state (PDF 32000-1
+// 9.3). Spacings, leading and rise are in unscaled text space units.
+type TextState struct {
+	CharSpace float64
+	WordSpace float64
+
+	// Scale is the horizontal scaling as a fraction; Tz sets it in percent.
+	Scale float64
+
+	Leading float64
+	Font    *font.Font
+	Size    float64
+	Rise    float64
+
+	RenderMode TextRenderMode
+}
+
+// NewTextState returns the initial text state.
+func NewTextState() TextState {
+	return TextState{Scale: 1, RenderMode: TextFill}
+}
+
+// clipGlyph accumulates the device-space outline of a glyph shown in one of
+// the clipping render modes. The accumulated outlines are intersected with the
+// clip at the end of the text object.
+func (in *Interpreter) clipGlyph(outline *render.Path) {
+	if !in.gs.Text.RenderMode.Clips() {
+		return
+	}
+	if in.textClip == nil {
+		in.textClip = render.NewPath()
+	}
+	in.textClip.Append(outline)
+}
+
+// opBeginText implements BT. A BT inside a text object starts it over.
+func opBeginText(in *Interpreter, args []model.PDFValue) error {
+	in.textClip = nil
+	in.tm, in.tlm = render.Identity, render.Identity
+	return nil
+}
+
+// opEndText implements ET. An ET outside a text object does nothing.
+func opEndText(in *Interpreter, args []model.PDFValue) error {
+	// Glyphs shown in a clipping mode jointly form one clipping path.
+	if in.textClip != nil {
+		in.gs.Clip = in.gs.Clip.IntersectPath(in.textClip, render.NonZero)
+		in.textClip = nil
+	}
+
+	return nil
+}
+
+func opSetTextRenderMode(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 1)
+	if err != nil {
+		return err
+	}
+
+	// Unknown modes are ignored.
+	if mode := TextRenderMode(v[0]); mode >= TextFill && mode <= TextClip {
+		in.gs.Text.RenderMode = mode
+	}
+	return nil
+}
+
+// ---- text state ----
+
+// textParamOp returns an operator setting one numeric text state parameter.
+func textParamOp(field func(*TextState) *float64) operatorFunc {
+	return func(in *Interpreter, args []model.PDFValue) error {
+		v, err := numberArgs(args, 1)
+		if err != nil {
+			return err
+		}
+
+		*field(&in.gs.Text) = v[0]
+		return nil
+	}
+}
+
+func opSetHorizontalScaling(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 1)
+	if err != nil {
+		return err
+	}
+
+	in.gs.Text.Scale = v[0] / 100
+	return nil
+}
+
+func opSetFont(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected 2 operands, got %d", len(args))
+	}
+
+	name, ok := args[0].(model.PDFName)
+	if !ok {
+		return fmt.Errorf("font operand is not a name: %v", args[0])
+	}
+	size, ok := number(args[1])
+	if !ok {
+		return fmt.Errorf("font size is not a number: %v", args[1])
+	}
+
+	f, err := in.font(name)
+	if err != nil {
+		return err
+	}
+
+	in.gs.Text.Font, in.gs.Text.Size = f, size
+	return nil
+}
+
+// font looks up and loads a font resource. Fonts stored as indirect objects
+// are loaded once per page.
+func (in *Interpreter) font(name model.PDFName) (*font.Font, error) {
+	v, ok := model.LookupResource(in.res, in.r, model.ResFont, string(name))
+	if !ok {
+		return nil, fmt.Errorf("font resource %s not found", name)
+	}
+
+	sub, _ := in.r.Resolve(in.res[model.ResFont]).(model.PDFDict)
+	ref, indirect := sub[string(name)].(model.PDFIndirectRef)
+	if f, ok := in.fonts[ref]; indirect && ok {
+		return f, nil
+	}
+
+	f, err := font.Load(v, in.r)
+	if err != nil {
+		return nil, fmt.Errorf("font %s: %w", name, err)
+	}
+	if indirect {
+		in.fonts[ref] = f
+	}
+	return f, nil
+}
+
+// ---- text positioning ----
+
+// moveLine starts a new line offset by (tx, ty) from the start of the
+// current one.
+func (in *Interpreter) moveLine(tx, ty float64) {
+	in.tlm = render.Translate(tx, ty).Multiply(in.tlm)
+	in.tm = in.tlm
+}
+
+func opMoveText(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 2)
+	if err != nil {
+		return err
+	}
+
+	in.moveLine(v[0], v[1])
+	return nil
+}
+
+func opMoveTextSetLeading(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 2)
+	if err != nil {
+		return err
+	}
+
+	in.gs.Text.Leading = -v[1]
+	in.moveLine(v[0], v[1])
+	return nil
+}
+
+func opSetTextMatrix(in *Interpreter, args []model.PDFValue) error {
+	v, err := numberArgs(args, 6)
+	if err != nil {
+		return err
+	}
+
+	in.tm = render.Matrix{v[0], v[1], v[2], v[3], v[4], v[5]}
+	in.tlm = in.tm
+	return nil
+}
+
+func opNextLine(in *Interpreter, args []model.PDFValue) error {
+
+	in.moveLine(0, -in.gs.Text.Leading)
+	return nil
+}
+
+// ---- text showing ----
+
+// showText shows the glyphs of a string, advancing the text matrix past
+// each one (9.4.4).
+func (in *Interpreter) showText(v model.PDFValue) error {
+	s, ok := parser.StringBytes(v)
+	if !ok {
+		return fmt.Errorf("text operand is not a string: %v", v)
+	}
+
+	ts := &in.gs.Text
+	if ts.Font == nil {
+		return fmt.Errorf("no font selected")
+	}
+
+	for len(s) > 0 {
+		code, n := ts.Font.NextCode(s)
+		s = s[n:]
+		in.showGlyph(code)
+
+		// Word spacing applies to the single-byte code 32 only.
+		d := ts.Font.Advance(code)*ts.Size + ts.CharSpace
+		if n == 1 && code == ' ' {
+			d += ts.WordSpace
+		}
+		in.advance(d)
+	}
+	return nil
+}
+
+// glyphKey identifies a glyph outline flattened in device space by its font,
+// the character code selecting the glyph, and the linear part of the matrix
+// mapping it to the device, which carries the font size.
+type glyphKey struct {
+	font *font.Font
+	code int
+	m    [4]float64
+}
+
+// showGlyph paints the glyph of a character code at the current text
+// position as the text render mode asks.
+func (in *Interpreter) showGlyph(code int) {
+	ts := &in.gs.Text
+	mode := ts.RenderMode
+	if !mode.Fills() && !mode.Strokes() && !mode.Clips() {
+		return
+	}
+
+	// m maps the text space of a font size of 1 to user space: it is the
+	// text rendering matrix (9.4.4) without the CTM. Vertical glyphs are
+	// placed by their vertical origin.
+	m := render.Matrix{ts.Size * ts.Scale, 0, 0, ts.Size, 0, ts.Rise}
+	if ts.Font.Vertical() {
+		vx, vy := ts.Font.VerticalOrigin(code)
+		m = render.Translate(-vx, -vy).Multiply(m)
+	}
+	m = m.Multiply(in.tm)
+
+	if ts.Font.Subtype == "Type3" {
+		in.showType3Glyph(code, m)
+		return
+	}
+
+	if mode.Fills() || mode.Clips() {
+		area := in.glyphArea(code, m.Multiply(in.gs.CTM))
+		if area == nil {
+			return
+		}
+		if mode.Fills() {
+			in.fillArea(area, render.NonZero)
+		}
+		in.clipGlyph(area)
+	}
+	if mode.Strokes() {
+		if outline := ts.Font.Outline(code); outline != nil {
+			in.strokeOutline(outline.Transform(m))
+		}
+	}
+}
+
+// glyphArea returns the flattened device-space outline of the glyph of a
+// character code shown through m, or nil if the font has no outline for
+// it. Outlines are flattened at the origin, once per key.
+func (in *Interpreter) glyphArea(code int, m render.Matrix) *render.Path {
+	key := glyphKey{in.gs.Text.Font, code, [4]float64{m[0], m[1], m[2], m[3]}}
+	area, ok := in.glyphs[key]
+	if !ok {
+		if outline := in.gs.Text.Font.Outline(code); outline != nil {
+			area = render.FlattenFill(outline.Transform(render.Matrix{m[0], m[1], m[2], m[3], 0, 0}))
+		}
+		in.glyphs[key] = area
+	}
+
+	if area == nil {
+		return nil
+	}
+	return area.Transform(render.Translate(m[4], m[5]))
+}
+
+// advance moves the text matrix along the writing direction by d in
+// unscaled text space. Horizontal scaling does not apply to vertical
+// writing.
+func (in *Interpreter) advance(d float64) {
+	ts := &in.gs.Text
+	if ts.Font != nil && ts.Font.Vertical() {
+		in.tm = render.Translate(0, d).Multiply(in.tm)
+		return
+	}
+	in.tm = render.Translate(d*ts.Scale, 0).Multiply(in.tm)
+}
+
+func opShowText(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 operand, got %d", len(args))
+	}
+
+	return in.showText(args[0])
+}
+
+// opShowTextArray implements TJ, whose numbers move the next glyph left, or
+// down for vertical fonts, by thousandths of the font size.
+func opShowTextArray(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 operand, got %d", len(args))
+	}
+
+	arr, ok := args[0].(model.PDFArray)
+	if !ok {
+		return fmt.Errorf("operand is not an array: %v", args[0])
+	}
+
+	for _, e := range arr {
+		if adj, ok := number(e); ok {
+			in.advance(-adj / 1000 * in.gs.Text.Size)
+			continue
+		}
+		if err := in.showText(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func opNextLineShowText(in *Interpreter, args []model.PDFValue) error {
+	if err := opNextLine(in, nil); err != nil {
+		return err
+	}
+	return opShowText(in, args)
+}
+
+// opSpacedNextLineShowText implements ", which sets the word and character
+// spacing before moving to the next line and showing a string.
+func opSpacedNextLineShowText(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 3 {
+		return fmt.Errorf("expected 3 operands, got %d", len(args))
+	}
+	v, err := numberArgs(args[:2], 2)
+	if err != nil {
+		return err
+	}
+
+	in.gs.Text.WordSpace, in.gs.Text.CharSpace = v[0], v[1]
+	return opNextLineShowText(in, args[2:])
+}
@@ -0,0 +1,189 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// Tiling is the cell of a tiling pattern, repeated at XStep and YStep
+// intervals in pattern space.
+type Tiling struct {
+	// Colored is set for PaintType 1, whose cell specifies its own colours.
+	// The cell of an uncoloured pattern (PaintType 2) is a stencil painted
+	// in the colour given with the pattern.
+	Colored bool
+
+	BBox         model.Rectangle
+	XStep, YStep float64
+	Resources    model.PDFDict
+	Content      []byte
+
+	// tile is the most recently rendered cell. It is reused while the
+	// pattern is painted at the same device scale.
+	tile *tile
+
+	// rendering is set while the cell is being rendered, so that a pattern
+	// that paints with itself draws nothing instead of recursing.
+	rendering bool
+}
+
+// tile is a rasterized pattern cell. Pixel (x, y) of img covers pattern
+// space point (BBox.LLX + x/kx, BBox.LLY + y/ky).
+type tile struct {
+	scale  render.Matrix // linear part of the pattern-to-device matrix
+	img    *image.RGBA
+	kx, ky float64
+}
+
+// maxTileSize bounds the width and height of a rendered cell in pixels.
+const maxTileSize = 2048
+
+func (in *Interpreter) parseTiling(stream model.PDFStream) (*Tiling, error) {
+	num := func(key string) float64 {
+		n, _ := number(in.r.Resolve(stream.Dict[key]))
+		return n
+	}
+
+	bbox, ok := parser.ParseRectangle(stream.Dict["BBox"], in.r)
+	if !ok {
+		return nil, fmt.Errorf("tiling pattern without /BBox")
+	}
+
+	t := &Tiling{
+		Colored: num("PaintType") != 2,
+		BBox:    bbox,
+		XStep:   math.Abs(num("XStep")),
+		YStep:   math.Abs(num("YStep")),
+	}
+	if t.XStep == 0 || t.YStep == 0 {
+		return nil, fmt.Errorf("tiling pattern has a zero step")
+	}
+
+	t.Resources, _ = in.r.Resolve(stream.Dict["Resources"]).(model.PDFDict)
+
+	var err error
+	if t.Content, err = parser.DecodeStream(stream, in.r); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// tileFor returns the cell of t rendered at the device resolution of ctm,
+// the matrix from pattern space to the device, or nil if the cell is
+// already being rendered.
+func (in *Interpreter) tileFor(t *Tiling, ctm render.Matrix) *tile {
+	scale := render.Matrix{ctm[0], ctm[1], ctm[2], ctm[3], 0, 0}
+	if t.tile != nil && t.tile.scale == scale {
+		return t.tile
+	}
+	if t.rendering {
+		return nil
+	}
+	t.rendering = true
+	defer func() { t.rendering = false }()
+
+	// Size the cell so that one cell pixel is about one device pixel, and
+	// round it so that the step is a whole number of pixels.
+	w := min(max(1, math.Ceil(t.XStep*math.Hypot(ctm[0], ctm[1]))), maxTileSize)
+	h := min(max(1, math.Ceil(t.YStep*math.Hypot(ctm[2], ctm[3]))), maxTileSize)
+
+	tl := &tile{scale: scale, kx: w / t.XStep, ky: h / t.YStep}
+	canvas := render.NewCanvas(int(w), int(h))
+	tl.img = canvas.Img
+
+	cellCTM := render.Matrix{tl.kx, 0, 0, tl.ky, -t.BBox.LLX * tl.kx, -t.BBox.LLY * tl.ky}
+
+	// A box wider than the step makes copies further left and down
+	// overlap the cell, so every copy that reaches it is drawn.
+	i0 := max(-int(t.BBox.Width()/t.XStep), -8)
+	j0 := max(-int(t.BBox.Height()/t.YStep), -8)
+
+	box := render.NewPath()
+	box.Rect(t.BBox.LLX, t.BBox.LLY, t.BBox.Width(), t.BBox.Height())
+
+	for i := i0; i <= 0; i++ {
+		for j := j0; j <= 0; j++ {
+			ctm := render.Translate(float64(i)*t.XStep, float64(j)*t.YStep).Multiply(cellCTM)
+
+			sub := in.nested(canvas, ctm)
+			sub.gs.Clip = sub.gs.Clip.IntersectPath(box.Transform(ctm), render.NonZero)
+			if sub.gs.Clip.Bounds().Empty() {
+				continue
+			}
+
+			// Errors end the cell early, like a truncated page.
+			_ = sub.Run(t.Content, t.Resources)
+		}
+	}
+
+	t.tile = tl
+	return tl
+}
+
+// paintTiling fills the device pixels in mask with a tiling pattern. ctm maps
+// pattern space to the device and col colours an uncoloured pattern.
+func (in *Interpreter) paintTiling(t *Tiling, ctm render.Matrix, col color.Color, mask *image.Alpha) {
+	if in.depth >= maxNesting {
+		return
+	}
+
+	inv, ok := ctm.Invert()
+	if !ok {
+		return
+	}
+
+	tl := in.tileFor(t, ctm)
+	if tl == nil {
+		return
+	}
+	tw, th := tl.img.Rect.Dx(), tl.img.Rect.Dy()
+
+	cr, cg, cb, ca := col.RGBA()
+
+	src := image.NewRGBA(mask.Rect)
+	b := mask.Rect
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if mask.Pix[mask.PixOffset(x, y)] == 0 {
+				continue
+			}
+
+			u, v := inv.Transform(float64(x)+0.5, float64(y)+0.5)
+			tx := int(math.Floor(mod(u-t.BBox.LLX, t.XStep) * tl.kx))
+			ty := int(math.Floor(mod(v-t.BBox.LLY, t.YStep) * tl.ky))
+			tx, ty = min(max(tx, 0), tw-1), min(max(ty, 0), th-1)
+
+			s := tl.img.Pix[tl.img.PixOffset(tx, ty):]
+			d := src.Pix[src.PixOffset(x, y):]
+			if t.Colored {
+				copy(d[:4], s[:4])
+				continue
+			}
+
+			// The cell's coverage stencils the pattern colour.
+			a := uint32(s[3])
+			d[0] = uint8(cr * a / 0xffff)
+			d[1] = uint8(cg * a / 0xffff)
+			d[2] = uint8(cb * a / 0xffff)
+			d[3] = uint8(ca * a / 0xffff)
+		}
+	}
+
+	in.canvas.Composite(src, mask, in.gs.Clip)
+}
+
+// mod returns x modulo m in [0, m).
+func mod(x, m float64) float64 {
+	r := math.Mod(x, m)
+	if r < 0 {
+		r += m
+	}
+	return r
+}
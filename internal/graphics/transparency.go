@@ -0,0 +1,264 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/function"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// extGStateLineParams maps the line parameters of an ExtGState to the
+// operators that set them, which check their values.
+var extGStateLineParams = []struct {
+	key string
+	op  operatorFunc
+}{
+	{"LW", opSetLineWidth},
+	{"LC", opSetLineCap},
+	{"LJ", opSetLineJoin},
+	{"ML", opSetMiterLimit},
+}
+
+// opSetExtGState implements gs, which sets graphics state parameters from
+// an ExtGState resource. Parameters the renderer does not use are ignored.
+func opSetExtGState(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 operand, got %d", len(args))
+	}
+
+	name, ok := args[0].(model.PDFName)
+	if !ok {
+		return fmt.Errorf("ExtGState operand is not a name: %v", args[0])
+	}
+
+	v, ok := model.LookupResource(in.res, in.r, model.ResExtGState, string(name))
+	if !ok {
+		return fmt.Errorf("ExtGState resource %s not found", name)
+	}
+	dict, ok := v.(model.PDFDict)
+	if !ok {
+		return fmt.Errorf("ExtGState %s is not a dictionary", name)
+	}
+
+	for _, p := range extGStateLineParams {
+		if v, ok := dict[p.key]; ok {
+			if err := p.op(in, []model.PDFValue{in.r.Resolve(v)}); err != nil {
+				return fmt.Errorf("/%s: %w", p.key, err)
+			}
+		}
+	}
+	if d, ok := in.r.Resolve(dict["D"]).(model.PDFArray); ok && len(d) == 2 {
+		if err := opSetDash(in, []model.PDFValue{in.r.Resolve(d[0]), in.r.Resolve(d[1])}); err != nil {
+			return fmt.Errorf("/D: %w", err)
+		}
+	}
+
+	if a, ok := number(in.r.Resolve(dict["CA"])); ok {
+		in.gs.StrokeAlpha = util.Clamp(a, 0, 1)
+	}
+	if a, ok := number(in.r.Resolve(dict["ca"])); ok {
+		in.gs.FillAlpha = util.Clamp(a, 0, 1)
+	}
+	if v, ok := dict["BM"]; ok {
+		in.gs.BlendMode = in.blendMode(v)
+	}
+	if v, ok := dict["SMask"]; ok {
+		mask, err := in.softMask(v)
+		if err != nil {
+			return fmt.Errorf("/SMask: %w", err)
+		}
+		in.gs.SoftMask = mask
+	}
+
+	return nil
+}
+
+// blendMode resolves a /BM entry: a name, or an array of names of which the
+// first known one applies. Unknown modes fall back to Normal.
+func (in *Interpreter) blendMode(v model.PDFValue) render.BlendMode {
+	names, ok := in.r.Resolve(v).(model.PDFArray)
+	if !ok {
+		names = model.PDFArray{v}
+	}
+	for _, n := range names {
+		if name, ok := in.r.Resolve(n).(model.PDFName); ok {
+			if m, ok := render.ParseBlendMode(string(name)); ok {
+				return m
+			}
+		}
+	}
+	return render.BlendNormal
+}
+
+// softMaskKey identifies a rendered soft mask. The same mask dictionary
+// renders differently under another CTM or onto another canvas.
+type softMaskKey struct {
+	ref    model.PDFIndirectRef
+	ctm    render.Matrix
+	bounds image.Rectangle
+}
+
+// softMask renders the soft mask dictionary of an ExtGState (PDF 32000-1
+// 11.6.5.2) in device space, with its group placed by the current CTM. It
+// returns nil for /None.
+func (in *Interpreter) softMask(v model.PDFValue) (*image.Alpha, error) {
+	dict, ok := in.r.Resolve(v).(model.PDFDict)
+	if !ok {
+		return nil, nil
+	}
+
+	ref, indirect := v.(model.PDFIndirectRef)
+	key := softMaskKey{ref, in.gs.CTM, in.canvas.Bounds()}
+	if m, ok := in.masks[key]; indirect && ok {
+		return m, nil
+	}
+
+	group, ok := in.r.Resolve(dict["G"]).(model.PDFStream)
+	if !ok {
+		return nil, fmt.Errorf("soft mask without a /G group")
+	}
+
+	subtype, _ := in.r.Resolve(dict["S"]).(model.PDFName)
+	luminosity := false
+	switch subtype {
+	case "Luminosity":
+		luminosity = true
+	case "Alpha":
+	default:
+		return nil, fmt.Errorf("unknown soft mask type %v", dict["S"])
+	}
+
+	var tr function.Function
+	if name, _ := in.r.Resolve(dict["TR"]).(model.PDFName); dict["TR"] != nil && name != "Identity" {
+		f, err := function.Parse(dict["TR"], in.r)
+		if err != nil {
+			return nil, fmt.Errorf("/TR: %w", err)
+		}
+		tr = f
+	}
+
+	bounds := in.canvas.Bounds()
+	canvas := in.canvas.NewGroup(bounds, true, false)
+
+	// A luminosity mask is the group composited over an opaque backdrop of
+	// the colour /BC, in the group's colour space, which is black by
+	// default.
+	if luminosity {
+		area := render.NewPath()
+		area.Rect(float64(bounds.Min.X), float64(bounds.Min.Y), float64(bounds.Dx()), float64(bounds.Dy()))
+		canvas.Fill(area, render.NonZero, nil, in.maskBackdrop(group, dict["BC"]))
+	}
+
+	// Errors end the group early, like a truncated page.
+	_ = in.nested(canvas, in.gs.CTM).runForm(group)
+
+	mask := image.NewAlpha(bounds)
+	img := canvas.Img
+	for i := range mask.Pix {
+		px := img.Pix[4*i : 4*i+4 : 4*i+4]
+		m := float64(px[3]) / 0xff
+		if luminosity {
+			m = (0.3*float64(px[0]) + 0.59*float64(px[1]) + 0.11*float64(px[2])) / 0xff
+		}
+		if tr != nil {
+			if out := tr.Evaluate([]float64{m}); len(out) > 0 {
+				m = out[0]
+			}
+		}
+		mask.Pix[i] = util.ToByte(m)
+	}
+
+	if indirect {
+		in.masks[key] = mask
+	}
+	return mask, nil
+}
+
+// maskBackdrop returns the /BC colour of a luminosity soft mask, given in
+// the colour space of its group.
+func (in *Interpreter) maskBackdrop(group model.PDFStream, bc model.PDFValue) color.Color {
+	arr, ok := in.r.Resolve(bc).(model.PDFArray)
+	if !ok {
+		return color.Black
+	}
+
+	attrs, _ := in.r.Resolve(group.Dict["Group"]).(model.PDFDict)
+	cs, err := colorspace.Parse(attrs["CS"], in.r)
+	if attrs["CS"] == nil || err != nil || cs.NComponents() != len(arr) {
+		return color.Black
+	}
+
+	comps := make([]float64, len(arr))
+	for i, v := range arr {
+		comps[i], _ = number(in.r.Resolve(v))
+	}
+	return colorspace.ToRGBA(cs, comps)
+}
+
+// transparencyGroup holds the attributes of a transparency group XObject.
+type transparencyGroup struct {
+	isolated, knockout bool
+}
+
+// groupAttributes returns the /Group attributes of a form if it is a
+// transparency group.
+func (in *Interpreter) groupAttributes(dict model.PDFDict) (transparencyGroup, bool) {
+	attrs, ok := in.r.Resolve(dict["Group"]).(model.PDFDict)
+	if !ok {
+		return transparencyGroup{}, false
+	}
+	if s, _ := in.r.Resolve(attrs["S"]).(model.PDFName); s != "Transparency" {
+		return transparencyGroup{}, false
+	}
+
+	isolated, _ := in.r.Resolve(attrs["I"]).(model.PDFBoolean)
+	knockout, _ := in.r.Resolve(attrs["K"]).(model.PDFBoolean)
+	return transparencyGroup{isolated: bool(isolated), knockout: bool(knockout)}, true
+}
+
+// runGroup runs the content of a transparency group on a canvas of its
+// own and composites the result as a single object with the current
+// transparency parameters, which are reset within the group (11.6.6).
+func (in *Interpreter) runGroup(content []byte, res model.PDFDict, g transparencyGroup) error {
+	alpha, blend, mask := in.gs.FillAlpha, in.gs.BlendMode, in.gs.SoftMask
+
+	// A group that is neither isolated nor knockout and is composited
+	// plainly looks the same as its objects painted directly.
+	if !g.isolated && !g.knockout && alpha == 1 && blend == render.BlendNormal && mask == nil {
+		return in.Run(content, res)
+	}
+
+	bounds := in.canvas.Bounds().Intersect(in.gs.Clip.Bounds())
+	if bounds.Empty() {
+		return nil
+	}
+
+	parent := in.canvas
+	in.canvas = parent.NewGroup(bounds, g.isolated, g.knockout)
+	in.gs.FillAlpha, in.gs.StrokeAlpha = 1, 1
+	in.gs.BlendMode, in.gs.SoftMask = render.BlendNormal, nil
+
+	err := in.Run(content, res)
+
+	result := in.canvas.GroupResult()
+	in.canvas = parent
+	parent.Alpha, parent.Blend, parent.SoftMask = alpha, blend, mask
+	parent.Composite(result, nil, nil)
+	return err
+}
+
+// setCompositing sets the canvas to paint with the transparency parameters
+// of the graphics state, with the stroking alpha if stroke is set.
+func (in *Interpreter) setCompositing(stroke bool) {
+	in.canvas.Alpha = in.gs.FillAlpha
+	if stroke {
+		in.canvas.Alpha = in.gs.StrokeAlpha
+	}
+	in.canvas.Blend = in.gs.BlendMode
+	in.canvas.SoftMask = in.gs.SoftMask
+}
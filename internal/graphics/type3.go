@@ -0,0 +1,179 @@
+package graphics
+
+import (
+	"bytes"
+	"image"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/font"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// charProc is the decoded glyph description of a Type 3 font.
+type charProc struct {
+	content []byte
+
+	// stencil is set for glyphs begun with d1, which only give a shape
+	// for the fill colour to paint; bbox is their bounding box in glyph
+	// space.
+	stencil bool
+	bbox    model.Rectangle
+}
+
+// charProcKey identifies the glyph description of a character code.
+type charProcKey struct {
+	font *font.Font
+	code int
+}
+
+// maxGlyphMaskSize bounds the side in pixels of the cached coverage of a
+// stencil glyph. Larger glyphs are painted directly.
+const maxGlyphMaskSize = 1024
+
+// colorOperators are the operators that set colours, which glyph
+// descriptions begun with d1 may not use (PDF 32000-1 9.6.5). They are
+// ignored there.
+var colorOperators = map[string]bool{
+	"g": true, "G": true, "rg": true, "RG": true, "k": true, "K": true,
+	"cs": true, "CS": true, "sc": true, "scn": true, "SC": true, "SCN": true,
+}
+
+// opSetCharWidth implements d0 and d1, which begin the glyph descriptions
+// of Type 3 fonts. They are read before a description runs, and the
+// font's /Widths give the advance, so running them does nothing.
+func opSetCharWidth(in *Interpreter, args []model.PDFValue) error {
+	return nil
+}
+
+// showType3Glyph paints the glyph of a character code of a Type 3 font by
+// running its description, with m mapping text space to user space. The
+// glyphs paint themselves, so every visible render mode shows them as
+// described, and they add nothing to the clip.
+func (in *Interpreter) showType3Glyph(code int, m render.Matrix) {
+	mode := in.gs.Text.RenderMode
+	if !mode.Fills() && !mode.Strokes() || in.depth >= maxNesting {
+		return
+	}
+	cp := in.charProc(code)
+	if cp == nil {
+		return
+	}
+
+	f := in.gs.Text.Font
+	ctm := f.Matrix.Multiply(m).Multiply(in.gs.CTM)
+	if !cp.stencil || cp.bbox.Width() == 0 || cp.bbox.Height() == 0 {
+		in.runCharProc(cp, in.canvas, ctm, true)
+		return
+	}
+
+	mask := in.glyphMask(code, cp, ctm)
+	if mask == nil {
+		in.runCharProc(cp, in.canvas, ctm, true)
+		return
+	}
+
+	// The coverage is rendered once per size, for a glyph origin on a
+	// whole pixel, to which the origin is rounded.
+	off := image.Pt(int(math.Round(ctm[4])), int(math.Round(ctm[5])))
+	mask = &image.Alpha{Pix: mask.Pix, Stride: mask.Stride, Rect: mask.Rect.Add(off)}
+
+	if colorspace.PaintsNothing(in.gs.FillSpace) {
+		return
+	}
+	in.setCompositing(false)
+	if _, ok := in.gs.FillSpace.(*colorspace.PatternSpace); ok {
+		if in.gs.FillPattern != nil {
+			in.paintPattern(in.gs.FillPattern, in.gs.FillColor, mask)
+		}
+		return
+	}
+	in.canvas.FillMask(mask, in.gs.Clip, in.gs.FillColor)
+}
+
+// charProc returns the decoded glyph description of a character code of
+// the current Type 3 font, or nil if the font has none for it. The first
+// operator tells coloured glyphs from stencils and gives the bounding box
+// of the latter, or the font's if that is empty.
+func (in *Interpreter) charProc(code int) *charProc {
+	f := in.gs.Text.Font
+	key := charProcKey{f, code}
+	if cp, ok := in.charProcs[key]; ok {
+		return cp
+	}
+
+	var cp *charProc
+	if s, ok := f.CharProc(code); ok {
+		if content, err := parser.DecodeStream(s, in.r); err == nil {
+			cp = &charProc{content: content}
+			p := parser.NewParser(parser.NewLexer(bytes.NewReader(content)))
+			if op, err := p.ParseOperation(); err == nil && op.Operator == "d1" {
+				if v, err := numberArgs(op.Operands, 6); err == nil {
+					cp.stencil = true
+					cp.bbox = model.Rectangle{
+						LLX: min(v[2], v[4]), LLY: min(v[3], v[5]),
+						URX: max(v[2], v[4]), URY: max(v[3], v[5]),
+					}
+					if cp.bbox.Width() == 0 || cp.bbox.Height() == 0 {
+						cp.bbox = f.BBox()
+					}
+				}
+			}
+		}
+	}
+	in.charProcs[key] = cp
+	return cp
+}
+
+// runCharProc runs a glyph description onto canvas, with ctm mapping glyph
+// space to the device. Like a form, it starts from the current graphics
+// state if inherit is set, and from the initial one otherwise. The font's
+// resources apply, or those of the content stream showing the glyph.
+func (in *Interpreter) runCharProc(cp *charProc, canvas *render.Canvas, ctm render.Matrix, inherit bool) {
+	sub := in.nested(canvas, ctm)
+	if inherit {
+		sub.gs = in.gs.Clone()
+		sub.gs.CTM = ctm
+	}
+	sub.stencil = cp.stencil
+
+	res := in.gs.Text.Font.Resources()
+	if res == nil {
+		res = in.res
+	}
+
+	// Errors end the glyph early, like a truncated page.
+	_ = sub.Run(cp.content, res)
+}
+
+// glyphMask returns the coverage of a stencil glyph shown through ctm, for
+// a glyph origin at the device origin, or nil if it is too large to keep.
+// Glyphs are rendered once per key.
+func (in *Interpreter) glyphMask(code int, cp *charProc, ctm render.Matrix) *image.Alpha {
+	key := glyphKey{in.gs.Text.Font, code, [4]float64{ctm[0], ctm[1], ctm[2], ctm[3]}}
+	if mask, ok := in.glyphMasks[key]; ok {
+		return mask
+	}
+
+	m := render.Matrix{ctm[0], ctm[1], ctm[2], ctm[3], 0, 0}
+	box := render.NewPath()
+	box.Rect(cp.bbox.LLX, cp.bbox.LLY, cp.bbox.Width(), cp.bbox.Height())
+	r := pixelBounds(box.Transform(m).Bounds()).Inset(-1)
+
+	var mask *image.Alpha
+	if r.Dx() <= maxGlyphMaskSize && r.Dy() <= maxGlyphMaskSize {
+		canvas := render.NewCanvas(r.Dx(), r.Dy())
+		in.runCharProc(cp, canvas, m.Multiply(render.Translate(float64(-r.Min.X), float64(-r.Min.Y))), false)
+
+		mask = image.NewAlpha(r)
+		for y := range r.Dy() {
+			for x := range r.Dx() {
+				mask.Pix[y*mask.Stride+x] = canvas.Img.Pix[canvas.Img.PixOffset(x, y)+3]
+			}
+		}
+	}
+	in.glyphMasks[key] = mask
+	return mask
+}
@@ -0,0 +1,114 @@
+package graphics
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/render"
+)
+
+// opDo implements Do, which paints the named external object.
+func opDo(in *Interpreter, args []model.PDFValue) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 operand, got %d", len(args))
+	}
+
+	name, ok := args[0].(model.PDFName)
+	if !ok {
+		return fmt.Errorf("XObject operand is not a name: %v", args[0])
+	}
+
+	v, ok := model.LookupResource(in.res, in.r, model.ResXObject, string(name))
+	if !ok {
+		return fmt.Errorf("XObject resource %s not found", name)
+	}
+
+	stream, ok := v.(model.PDFStream)
+	if !ok {
+		return fmt.Errorf("XObject %s is not a stream", name)
+	}
+
+	subtype, _ := in.r.Resolve(stream.Dict["Subtype"]).(model.PDFName)
+	switch subtype {
+	case "Form":
+		// A form that invokes itself, even several times over, would paint
+		// without end, so it paints nothing where it does.
+		sub, _ := in.r.Resolve(in.res[model.ResXObject]).(model.PDFDict)
+		ref, indirect := sub[string(name)].(model.PDFIndirectRef)
+		if indirect {
+			if in.forms[ref] {
+				return nil
+			}
+			in.forms[ref] = true
+			defer delete(in.forms, ref)
+		}
+		if err := in.runForm(stream); err != nil {
+			return fmt.Errorf("form %s: %w", name, err)
+		}
+	case "Image":
+		if err := in.drawImage(stream); err != nil {
+			return fmt.Errorf("image %s: %w", name, err)
+		}
+	}
+
+	// PostScript XObjects are not rendered, as the specification allows.
+	return nil
+}
+
+// runForm paints a form XObject. The form's /Matrix is concatenated with the
+// CTM and its /BBox clips it; it runs with its own /Resources, or with those
+// of the invoking content stream if it has none. A form that is a
+// transparency group is composited as a whole.
+func (in *Interpreter) runForm(stream model.PDFStream) error {
+	// Forms invoking one another, or stored directly, could still nest
+	// without end; beyond the nesting limit forms paint nothing.
+	if in.depth >= maxNesting {
+		return nil
+	}
+
+	bbox, ok := parser.ParseRectangle(stream.Dict["BBox"], in.r)
+	if !ok {
+		return fmt.Errorf("form without /BBox")
+	}
+
+	content, err := parser.DecodeStream(stream, in.r)
+	if err != nil {
+		return err
+	}
+
+	res, ok := in.r.Resolve(stream.Dict["Resources"]).(model.PDFDict)
+	if !ok {
+		res = in.res
+	}
+
+	// The form may not restore states saved outside it, and states it
+	// leaves saved are discarded when it ends.
+	in.save()
+	n := len(in.stack)
+	savedFloor, savedBase, savedPath := in.stackFloor, in.baseCTM, in.path
+	in.stackFloor = n
+	in.depth++
+	defer func() {
+		in.depth--
+		in.gs, in.stack = in.stack[n-1], in.stack[:n-1]
+		in.stackFloor, in.baseCTM, in.path = savedFloor, savedBase, savedPath
+	}()
+
+	if m, ok := in.matrix(stream.Dict["Matrix"]); ok {
+		in.gs.CTM = m.Multiply(in.gs.CTM)
+	}
+
+	box := render.NewPath()
+	box.Rect(bbox.LLX, bbox.LLY, bbox.Width(), bbox.Height())
+	in.gs.Clip = in.gs.Clip.IntersectPath(box.Transform(in.gs.CTM), render.NonZero)
+
+	// Patterns used by the form are relative to form space.
+	in.baseCTM = in.gs.CTM
+	in.path = render.NewPath()
+
+	if g, ok := in.groupAttributes(stream.Dict); ok {
+		return in.runGroup(content, res, g)
+	}
+	return in.Run(content, res)
+}
@@ -1 +1,65 @@
 package model
+
+// Resolver replaces indirect references with the objects they refer to.
+// Values that are not references are returned unchanged, and references to
+// missing objects resolve to PDFNull.
+type Resolver interface {
+	Resolve(v PDFValue) PDFValue
+}
+
+// ResolveNumbers resolves an array of numbers. The second result is false
+// if v is not an array or holds anything but numbers.
+func ResolveNumbers(v PDFValue, r Resolver) ([]float64, bool) {
+	arr, ok := r.Resolve(v).(PDFArray)
+	if !ok {
+		return nil, false
+	}
+
+	out := make([]float64, len(arr))
+	for i, e := range arr {
+		n, ok := r.Resolve(e).(PDFNumber)
+		if !ok {
+			return nil, false
+		}
+		out[i] = float64(n)
+	}
+	return out, true
+}
+
+// Rectangle is a PDF rectangle normalised so that LLX <= URX and LLY <= URY.
+type Rectangle struct {
+	LLX, LLY, URX, URY float64
+}
+
+// Width returns the horizontal extent of r.
+func (r Rectangle) Width() float64 {
+	return r.URX - r.LLX
+}
+
+// Height returns the vertical extent of r.
+func (r Rectangle) Height() float64 {
+	return r.URY - r.LLY
+}
+
+// Intersect returns the intersection of r and s.
+func (r Rectangle) Intersect(s Rectangle) Rectangle {
+	out := Rectangle{
+		max(r.LLX, s.LLX), max(r.LLY, s.LLY),
+		min(r.URX, s.URX), min(r.URY, s.URY),
+	}
+	if out.URX < out.LLX || out.URY < out.LLY {
+		return Rectangle{}
+	}
+	return out
+}
+
+// Page holds the attributes of a page object after inheritance from the page
+// tree has been applied.
+type Page struct {
+	MediaBox  Rectangle
+	CropBox   Rectangle
+	Rotate    int
+	UserUnit  float64
+	Resources PDFDict
+	Contents  []byte
+}
@@ -1 +1,38 @@
 package model
+
+// Resource categories of a resource dictionary.
+const (
+	ResColorSpace = "ColorSpace"
+	ResExtGState  = "ExtGState"
+	ResFont       = "Font"
+	ResPattern    = "Pattern"
+	ResShading    = "Shading"
+	ResXObject    = "XObject"
+	ResProperties = "Properties"
+)
+
+// LookupResource returns the resolved resource called name in the given
+// category of a resource dictionary. The second result is false if the
+// resource does not exist.
+func LookupResource(res PDFDict, r Resolver, category, name string) (PDFValue, bool) {
+	if res == nil {
+		return nil, false
+	}
+
+	sub, ok := r.Resolve(res[category]).(PDFDict)
+	if !ok {
+		return nil, false
+	}
+
+	v, ok := sub[name]
+	if !ok {
+		return nil, false
+	}
+
+	v = r.Resolve(v)
+	if _, isNull := v.(PDFNull); isNull {
+		return nil, false
+	}
+
+	return v, true
+}
@@ -15,6 +15,8 @@ const (
 	TokDictStart
 	TokDictEnd
 	TokKeyword
+	TokProcStart
+	TokProcEnd
 )
 
 // Delimiter and punctuation bytes used by the lexer.
@@ -77,6 +79,10 @@ func (t TokenType) String() string {
 		return "DictEnd"
 	case TokKeyword:
 		return "Keyword"
+	case TokProcStart:
+		return "ProcStart"
+	case TokProcEnd:
+		return "ProcEnd"
 	default:
 		return "UnknownTokenType"
 	}
@@ -0,0 +1,378 @@
+package parser
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// decodeCCITTFax decodes Group 3 (one- and two-dimensional) and Group 4
+// facsimile data into rows of 1-bit samples, each padded to a whole byte.
+// Unless /BlackIs1 is true, black pixels are 0 bits.
+//
+// Damaged data ends the image: the rows decoded before the error are
+// returned, as truncated images are common.
+func decodeCCITTFax(data []byte, params model.PDFDict) ([]byte, error) {
+	d := ccittDecoder{
+		br:        util.NewBitReader(data),
+		k:         intParam(params, "K", 0),
+		columns:   intParam(params, "Columns", 1728),
+		byteAlign: boolParam(params, "EncodedByteAlign", false),
+		endOfLine: boolParam(params, "EndOfLine", false),
+	}
+	rows := intParam(params, "Rows", 0)
+	blackIs1 := boolParam(params, "BlackIs1", false)
+	endOfBlock := boolParam(params, "EndOfBlock", true)
+
+	if d.columns <= 0 {
+		return nil, fmt.Errorf("invalid /Columns %d", d.columns)
+	}
+
+	var (
+		out []byte
+		ref []int // changing elements of the reference line
+		err error
+	)
+
+	for n := 0; rows <= 0 || n < rows; n++ {
+		if d.br.BitsLeft() == 0 {
+			break
+		}
+
+		eol := d.startLine()
+		if eol && endOfBlock && d.endOfBlock() {
+			break
+		}
+
+		twoD := d.k < 0
+		if d.k > 0 {
+			tag, ok := d.br.ReadBit()
+			if !ok {
+				break
+			}
+			twoD = tag == 0
+		}
+
+		var line []int
+		if twoD {
+			line, err = d.decode2D(ref)
+		} else {
+			line, err = d.decode1D()
+		}
+		if err != nil {
+			break
+		}
+
+		out = appendCCITTRow(out, line, d.columns, blackIs1)
+		ref = line
+	}
+
+	if len(out) == 0 && err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ccittDecoder holds the state shared by the lines of a CCITT image. A line
+// is decoded to its changing elements: the positions at which the colour
+// differs from the pixel to the left, starting from white. A line always
+// ends with a change at or past the last column.
+type ccittDecoder struct {
+	br        *util.BitReader
+	k         int
+	columns   int
+	byteAlign bool
+	endOfLine bool
+}
+
+// startLine skips the fill bits and EOL code that may precede a line and
+// reports whether there was an EOL.
+func (d *ccittDecoder) startLine() bool {
+	// Aligned lines without EOLs start on a byte boundary. Otherwise the
+	// fill bits come before the EOL and are taken as part of it.
+	if d.byteAlign && (d.k < 0 || !d.endOfLine) {
+		d.br.Align()
+	}
+	return d.skipEOL()
+}
+
+// skipEOL consumes an EOL code, along with any zero fill bits before it, if
+// one is next.
+func (d *ccittDecoder) skipEOL() bool {
+	zeros := bits.LeadingZeros32(d.br.PeekBits(32))
+	if zeros < 11 || zeros == 32 {
+		return false
+	}
+
+	d.br.Skip(zeros + 1)
+	return true
+}
+
+// endOfBlock reports whether the EOL just read starts the end-of-block
+// sequence: EOFB in Group 4 data and RTC in Group 3 data, where the EOLs of
+// two-dimensional data are followed by a 1 tag bit.
+func (d *ccittDecoder) endOfBlock() bool {
+	if d.k > 0 {
+		return d.br.PeekBits(13) == 1<<12|1
+	}
+	return d.br.PeekBits(12) == 1
+}
+
+// decode1D decodes a line coded as alternating white and black runs.
+func (d *ccittDecoder) decode1D() ([]int, error) {
+	var line []int
+
+	for pos, black := 0, false; pos < d.columns; black = !black {
+		run, err := d.readRun(black)
+		if err != nil {
+			return nil, err
+		}
+		pos = min(pos+run, d.columns)
+		line = append(line, pos)
+	}
+
+	return line, nil
+}
+
+// decode2D decodes a line coded relative to the reference line ref.
+func (d *ccittDecoder) decode2D(ref []int) ([]int, error) {
+	var line []int
+
+	a0, black := -1, false
+	i := 0
+	for a0 < d.columns {
+		// b1 is the first change on the reference line to the right of
+		// a0 to the colour opposite a0's; b2 is the change after it.
+		// Vertical modes may move a0 left of the previous b1.
+		for i > 0 && ref[i-1] > a0 {
+			i--
+		}
+		for i < len(ref) && (ref[i] <= a0 || (i%2 == 1) != black) {
+			i++
+		}
+		b1, b2 := d.columns, d.columns
+		if i < len(ref) {
+			b1 = ref[i]
+		}
+		if i+1 < len(ref) {
+			b2 = ref[i+1]
+		}
+
+		mode, ok := ccittModes.read(d.br)
+		if !ok {
+			return nil, fmt.Errorf("invalid mode code")
+		}
+
+		switch mode {
+		case ccittPass:
+			a0 = b2
+
+		case ccittHorizontal:
+			r1, err := d.readRun(black)
+			if err != nil {
+				return nil, err
+			}
+			r2, err := d.readRun(!black)
+			if err != nil {
+				return nil, err
+			}
+			a1 := min(max(a0, 0)+r1, d.columns)
+			a0 = min(a1+r2, d.columns)
+			line = append(line, a1, a0)
+
+		case ccittExtension:
+			return nil, fmt.Errorf("unsupported extension code")
+
+		default:
+			a1 := min(b1+mode, d.columns)
+			if a1 < max(a0, 0) {
+				return nil, fmt.Errorf("vertical mode moves left of a0")
+			}
+			a0 = a1
+			line = append(line, a1)
+			black = !black
+		}
+	}
+
+	return line, nil
+}
+
+// readRun reads a run length: any makeup codes followed by a terminating
+// code.
+func (d *ccittDecoder) readRun(black bool) (int, error) {
+	table := ccittWhite
+	if black {
+		table = ccittBlack
+	}
+
+	total := 0
+	for {
+		run, ok := table.read(d.br)
+		if !ok {
+			return 0, fmt.Errorf("invalid run length code")
+		}
+		total += run
+		if run < 64 {
+			return total, nil
+		}
+	}
+}
+
+// appendCCITTRow appends the samples of a decoded line to out.
+func appendCCITTRow(out []byte, line []int, columns int, blackIs1 bool) []byte {
+	row := make([]byte, (columns+7)/8)
+	if !blackIs1 {
+		for i := range row {
+			row[i] = 0xff
+		}
+	}
+
+	for i := 0; i < len(line); i += 2 {
+		end := columns
+		if i+1 < len(line) {
+			end = line[i+1]
+		}
+		for x := line[i]; x < end; x++ {
+			row[x>>3] ^= 0x80 >> (x & 7)
+		}
+	}
+
+	return append(out, row...)
+}
+
+// ccittCode is a code of a CCITT code table: its n low bits, most
+// significant first.
+type ccittCode struct {
+	bits uint16
+	n    uint8
+}
+
+// ccittTable maps the codes of a CCITT code table to their values.
+type ccittTable map[ccittCode]int
+
+// read reads the next code of the table from br.
+func (t ccittTable) read(br *util.BitReader) (int, bool) {
+	var c ccittCode
+	for c.n < 13 {
+		b, ok := br.ReadBit()
+		if !ok {
+			return 0, false
+		}
+		c.bits = c.bits<<1 | uint16(b)
+		c.n++
+
+		if v, ok := t[c]; ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// Two-dimensional mode codes. Vertical modes are their offset from b1.
+const (
+	ccittPass = iota + 4
+	ccittHorizontal
+	ccittExtension
+)
+
+var ccittModes = ccittTable{
+	{0b1, 1}:       0,
+	{0b011, 3}:     1,
+	{0b000011, 6}:  2,
+	{0b0000011, 7}: 3,
+	{0b010, 3}:     -1,
+	{0b000010, 6}:  -2,
+	{0b0000010, 7}: -3,
+	{0b0001, 4}:    ccittPass,
+	{0b001, 3}:     ccittHorizontal,
+	{0b0000001, 7}: ccittExtension,
+}
+
+var (
+	ccittWhite = buildCCITTRunTable(ccittWhiteTerminating[:], ccittWhiteMakeup[:])
+	ccittBlack = buildCCITTRunTable(ccittBlackTerminating[:], ccittBlackMakeup[:])
+)
+
+// buildCCITTRunTable assembles the run length table of one colour from its
+// terminating codes for runs 0-63, its makeup codes for multiples of 64 up
+// to 1728 and the extended makeup codes shared by both colours.
+func buildCCITTRunTable(terminating, makeup []ccittCode) ccittTable {
+	t := ccittTable{}
+	for run, c := range terminating {
+		t[c] = run
+	}
+	for i, c := range makeup {
+		t[c] = 64 * (i + 1)
+	}
+	for i, c := range ccittExtendedMakeup {
+		t[c] = 1792 + 64*i
+	}
+	return t
+}
+
+var ccittWhiteTerminating = [64]ccittCode{
+	{0b00110101, 8}, {0b000111, 6}, {0b0111, 4}, {0b1000, 4},
+	{0b1011, 4}, {0b1100, 4}, {0b1110, 4}, {0b1111, 4},
+	{0b10011, 5}, {0b10100, 5}, {0b00111, 5}, {0b01000, 5},
+	{0b001000, 6}, {0b000011, 6}, {0b110100, 6}, {0b110101, 6},
+	{0b101010, 6}, {0b101011, 6}, {0b0100111, 7}, {0b0001100, 7},
+	{0b0001000, 7}, {0b0010111, 7}, {0b0000011, 7}, {0b0000100, 7},
+	{0b0101000, 7}, {0b0101011, 7}, {0b0010011, 7}, {0b0100100, 7},
+	{0b0011000, 7}, {0b00000010, 8}, {0b00000011, 8}, {0b00011010, 8},
+	{0b00011011, 8}, {0b00010010, 8}, {0b00010011, 8}, {0b00010100, 8},
+	{0b00010101, 8}, {0b00010110, 8}, {0b00010111, 8}, {0b00101000, 8},
+	{0b00101001, 8}, {0b00101010, 8}, {0b00101011, 8}, {0b00101100, 8},
+	{0b00101101, 8}, {0b00000100, 8}, {0b00000101, 8}, {0b00001010, 8},
+	{0b00001011, 8}, {0b01010010, 8}, {0b01010011, 8}, {0b01010100, 8},
+	{0b01010101, 8}, {0b00100100, 8}, {0b00100101, 8}, {0b01011000, 8},
+	{0b01011001, 8}, {0b01011010, 8}, {0b01011011, 8}, {0b01001010, 8},
+	{0b01001011, 8}, {0b00110010, 8}, {0b00110011, 8}, {0b00110100, 8},
+}
+
+var ccittWhiteMakeup = [27]ccittCode{
+	{0b11011, 5}, {0b10010, 5}, {0b010111, 6}, {0b0110111, 7},
+	{0b00110110, 8}, {0b00110111, 8}, {0b01100100, 8}, {0b01100101, 8},
+	{0b01101000, 8}, {0b01100111, 8}, {0b011001100, 9}, {0b011001101, 9},
+	{0b011010010, 9}, {0b011010011, 9}, {0b011010100, 9}, {0b011010101, 9},
+	{0b011010110, 9}, {0b011010111, 9}, {0b011011000, 9}, {0b011011001, 9},
+	{0b011011010, 9}, {0b011011011, 9}, {0b010011000, 9}, {0b010011001, 9},
+	{0b010011010, 9}, {0b011000, 6}, {0b010011011, 9},
+}
+
+var ccittBlackTerminating = [64]ccittCode{
+	{0b0000110111, 10}, {0b010, 3}, {0b11, 2}, {0b10, 2},
+	{0b011, 3}, {0b0011, 4}, {0b0010, 4}, {0b00011, 5},
+	{0b000101, 6}, {0b000100, 6}, {0b0000100, 7}, {0b0000101, 7},
+	{0b0000111, 7}, {0b00000100, 8}, {0b00000111, 8}, {0b000011000, 9},
+	{0b0000010111, 10}, {0b0000011000, 10}, {0b0000001000, 10}, {0b00001100111, 11},
+	{0b00001101000, 11}, {0b00001101100, 11}, {0b00000110111, 11}, {0b00000101000, 11},
+	{0b00000010111, 11}, {0b00000011000, 11}, {0b000011001010, 12}, {0b000011001011, 12},
+	{0b000011001100, 12}, {0b000011001101, 12}, {0b000001101000, 12}, {0b000001101001, 12},
+	{0b000001101010, 12}, {0b000001101011, 12}, {0b000011010010, 12}, {0b000011010011, 12},
+	{0b000011010100, 12}, {0b000011010101, 12}, {0b000011010110, 12}, {0b000011010111, 12},
+	{0b000001101100, 12}, {0b000001101101, 12}, {0b000011011010, 12}, {0b000011011011, 12},
+	{0b000001010100, 12}, {0b000001010101, 12}, {0b000001010110, 12}, {0b000001010111, 12},
+	{0b000001100100, 12}, {0b000001100101, 12}, {0b000001010010, 12}, {0b000001010011, 12},
+	{0b000000100100, 12}, {0b000000110111, 12}, {0b000000111000, 12}, {0b000000100111, 12},
+	{0b000000101000, 12}, {0b000001011000, 12}, {0b000001011001, 12}, {0b000000101011, 12},
+	{0b000000101100, 12}, {0b000001011010, 12}, {0b000001100110, 12}, {0b000001100111, 12},
+}
+
+var ccittBlackMakeup = [27]ccittCode{
+	{0b0000001111, 10}, {0b000011001000, 12}, {0b000011001001, 12}, {0b000001011011, 12},
+	{0b000000110011, 12}, {0b000000110100, 12}, {0b000000110101, 12}, {0b0000001101100, 13},
+	{0b0000001101101, 13}, {0b0000001001010, 13}, {0b0000001001011, 13}, {0b0000001001100, 13},
+	{0b0000001001101, 13}, {0b0000001110010, 13}, {0b0000001110011, 13}, {0b0000001110100, 13},
+	{0b0000001110101, 13}, {0b0000001110110, 13}, {0b0000001110111, 13}, {0b0000001010010, 13},
+	{0b0000001010011, 13}, {0b0000001010100, 13}, {0b0000001010101, 13}, {0b0000001011010, 13},
+	{0b0000001011011, 13}, {0b0000001100100, 13}, {0b0000001100101, 13},
+}
+
+var ccittExtendedMakeup = [13]ccittCode{
+	{0b00000001000, 11}, {0b00000001100, 11}, {0b00000001101, 11}, {0b000000010010, 12},
+	{0b000000010011, 12}, {0b000000010100, 12}, {0b000000010101, 12}, {0b000000010110, 12},
+	{0b000000010111, 12}, {0b000000011100, 12}, {0b000000011101, 12}, {0b000000011110, 12},
+	{0b000000011111, 12},
+}
@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// ccittBits packs a string of '0' and '1' digits, padded with zero bits to a
+// whole byte. Other characters are ignored so that codes can be spaced out.
+func ccittBits(s string) []byte {
+	var out []byte
+	n := 0
+	for _, c := range s {
+		if c != '0' && c != '1' {
+			continue
+		}
+		if n%8 == 0 {
+			out = append(out, 0)
+		}
+		if c == '1' {
+			out[n/8] |= 0x80 >> (n % 8)
+		}
+		n++
+	}
+	return out
+}
+
+func TestDecodeCCITTFax(t *testing.T) {
+	const (
+		eol  = "000000000001"
+		eofb = eol + eol
+	)
+
+	tests := []struct {
+		name     string
+		params   model.PDFDict
+		data     []byte
+		expected []byte
+	}{
+		{
+			// White 2, black 3, white 3; then white 8.
+			name:     "OneDimensional",
+			params:   model.PDFDict{"Columns": model.PDFNumber(8)},
+			data:     ccittBits("0111 10 1000  10011"),
+			expected: []byte{0xc7, 0xff},
+		},
+		{
+			name:     "BlackIs1",
+			params:   model.PDFDict{"Columns": model.PDFNumber(8), "BlackIs1": model.PDFBoolean(true)},
+			data:     ccittBits("0111 10 1000  10011"),
+			expected: []byte{0x38, 0x00},
+		},
+		{
+			// White 64+0, black 36.
+			name:     "MakeupCodes",
+			params:   model.PDFDict{"Columns": model.PDFNumber(100)},
+			data:     ccittBits("11011 00110101 000011010100"),
+			expected: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x0f},
+		},
+		{
+			// White 1792+8.
+			name:     "ExtendedMakeupCodes",
+			params:   model.PDFDict{"Columns": model.PDFNumber(1800)},
+			data:     ccittBits("00000001000 10011"),
+			expected: bytes.Repeat([]byte{0xff}, 225),
+		},
+		{
+			name:     "EndOfLine",
+			params:   model.PDFDict{"Columns": model.PDFNumber(8), "EndOfLine": model.PDFBoolean(true)},
+			data:     ccittBits(eol + "0111 10 1000" + eol + "10011" + eol + eol + eol + eol + eol + eol),
+			expected: []byte{0xc7, 0xff},
+		},
+		{
+			name:     "EncodedByteAlign",
+			params:   model.PDFDict{"Columns": model.PDFNumber(8), "EncodedByteAlign": model.PDFBoolean(true)},
+			data:     ccittBits("0111 10 1000 000000  10011 000"),
+			expected: []byte{0xc7, 0xff},
+		},
+		{
+			// Horizontal; vertical; pass; horizontal; VL1, VR1, V0.
+			name:   "Group4",
+			params: model.PDFDict{"K": model.PDFNumber(-1), "Columns": model.PDFNumber(8)},
+			data: ccittBits("001 0111 10 1" + "1 1 1" + "0001 1" + "001 1000 11 1" + "010 011 1" +
+				eofb + "1 1 1"),
+			expected: []byte{0xc7, 0xc7, 0xff, 0xe7, 0xc3},
+		},
+		{
+			// Horizontal; VL2, VR2; VR3, VR3; V0, VL3.
+			name:   "Group4LongVertical",
+			params: model.PDFDict{"K": model.PDFNumber(-1), "Columns": model.PDFNumber(16)},
+			data: ccittBits("001 0111 11 1" + "000010 000011 1" + "0000011 0000011 1" + "1 0000010 1" +
+				eofb),
+			expected: []byte{0xcf, 0xff, 0x03, 0xff, 0xe0, 0x7f, 0xe3, 0xff},
+		},
+		{
+			name:     "Group4EncodedByteAlign",
+			params:   model.PDFDict{"K": model.PDFNumber(-1), "Columns": model.PDFNumber(8), "EncodedByteAlign": model.PDFBoolean(true)},
+			data:     ccittBits("001 0111 10 1 000000" + "1 1 1 00000" + eofb),
+			expected: []byte{0xc7, 0xc7},
+		},
+		{
+			// A one-dimensional line and a two-dimensional one, each
+			// after an EOL and tag bit, then RTC.
+			name:   "MixedDimensions",
+			params: model.PDFDict{"K": model.PDFNumber(2), "Columns": model.PDFNumber(8)},
+			data: ccittBits(eol + "1 0111 10 1000" + eol + "0 1 1 1" +
+				eol + "1" + eol + "1" + eol + "1" + eol + "1" + eol + "1" + eol + "1"),
+			expected: []byte{0xc7, 0xc7},
+		},
+		{
+			name:     "Rows",
+			params:   model.PDFDict{"Columns": model.PDFNumber(8), "Rows": model.PDFNumber(1)},
+			data:     ccittBits("0111 10 1000  10011"),
+			expected: []byte{0xc7},
+		},
+		{
+			// Without /EndOfBlock decoding continues past the EOFB.
+			name:     "NoEndOfBlock",
+			params:   model.PDFDict{"K": model.PDFNumber(-1), "Columns": model.PDFNumber(8), "EndOfBlock": model.PDFBoolean(false), "Rows": model.PDFNumber(2)},
+			data:     ccittBits("1" + eol + "1"),
+			expected: []byte{0xff, 0xff},
+		},
+		{
+			// The rows before damaged data are kept.
+			name:     "Damaged",
+			params:   model.PDFDict{"Columns": model.PDFNumber(8)},
+			data:     ccittBits("0111 10 1000  00000000 11111"),
+			expected: []byte{0xc7},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dict := model.PDFDict{"Filter": model.PDFName("CCITTFaxDecode"), "DecodeParms": tc.params}
+
+			got, err := DecodeStream(model.PDFStream{Dict: dict, Data: tc.data}, NewObjectTable())
+			if err != nil {
+				t.Fatalf("DecodeStream() error = %v", err)
+			}
+			if !bytes.Equal(got, tc.expected) {
+				t.Errorf("DecodeStream() = %x, expected %x", got, tc.expected)
+			}
+		})
+	}
+
+	s := model.PDFStream{
+		Dict: model.PDFDict{"Filter": model.PDFName("CCITTFaxDecode"), "DecodeParms": model.PDFDict{"Columns": model.PDFNumber(8)}},
+		Data: []byte{0, 0, 0},
+	}
+	if _, err := DecodeStream(s, NewObjectTable()); err == nil {
+		t.Errorf("DecodeStream() of invalid data expected an error")
+	}
+}
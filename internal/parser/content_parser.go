@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"io"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// Operation is a single content stream operator together with the operands
+// that precede it.
+type Operation struct {
+	Operator string
+	Operands []model.PDFValue
+}
+
+// ParseOperation reads the next operation from a content stream. It returns
+// io.EOF once the stream is exhausted; operands left without an operator at
+// the end of the stream are discarded. An inline image is returned as a
+// single BI operation carrying the image as a stream.
+func (p *Parser) ParseOperation() (*Operation, error) {
+
+	var operands []model.PDFValue
+
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if tok.Type == model.TokEOF {
+			return nil, io.EOF
+		}
+
+		if tok.Type == model.TokKeyword && tok.Value == "BI" {
+			return p.parseInlineImage()
+		}
+
+		if tok.Type == model.TokKeyword && !isValueKeyword(tok.Value) {
+			return &Operation{Operator: tok.Value, Operands: operands}, nil
+		}
+
+		p.unread(tok)
+
+		val, err := p.Parse()
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, val)
+	}
+
+}
+
+func isValueKeyword(s string) bool {
+	return s == "true" || s == "false" || s == "null"
+}
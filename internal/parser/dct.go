@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// decodeDCT decodes a baseline or progressive JPEG into interleaved 8-bit
+// samples: one per pixel for greyscale, RGB for three-component images and
+// CMYK for four-component ones.
+//
+// Four-component samples are returned as stored in the file, matching
+// Acrobat. Adobe applications store CMYK JPEGs inverted and flag them with
+// an APP14 marker; PDFs embedding them carry /Decode [1 0 1 0 1 0 1 0],
+// which the image layer applies. YCCK data is converted to CMYK in the same
+// inverted form.
+func decodeDCT(data []byte, params model.PDFDict) ([]byte, error) {
+	info := scanJPEG(data)
+
+	// /ColorTransform overrides the colour transform implied by the file.
+	// The Go decoder rejects four-component images without an APP14
+	// marker, so one stating untransformed CMYK is supplied.
+	transform := -1
+	if t, ok := params["ColorTransform"].(model.PDFNumber); ok {
+		transform = min(int(t), 1)
+		if transform == 1 && info.components == 4 {
+			transform = 2
+		}
+	} else if info.components == 4 && info.adobe < 0 {
+		transform = 0
+	}
+	if transform >= 0 {
+		data = withAdobeTransform(data, info, byte(transform))
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	switch m := img.(type) {
+	case *image.Gray:
+		out := make([]byte, 0, w*h)
+		for y := 0; y < h; y++ {
+			out = append(out, m.Pix[y*m.Stride:y*m.Stride+w]...)
+		}
+		return out, nil
+
+	case *image.YCbCr:
+		out := make([]byte, 0, w*h*3)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				yi, ci := m.YOffset(x, y), m.COffset(x, y)
+				r, g, bl := color.YCbCrToRGB(m.Y[yi], m.Cb[ci], m.Cr[ci])
+				out = append(out, r, g, bl)
+			}
+		}
+		return out, nil
+
+	case *image.RGBA:
+		out := make([]byte, 0, w*h*3)
+		for y := 0; y < h; y++ {
+			row := m.Pix[y*m.Stride : y*m.Stride+4*w]
+			for x := 0; x < len(row); x += 4 {
+				out = append(out, row[x], row[x+1], row[x+2])
+			}
+		}
+		return out, nil
+
+	case *image.CMYK:
+		// The decoder undoes the Adobe inversion, which is restored here.
+		out := make([]byte, 0, w*h*4)
+		for y := 0; y < h; y++ {
+			for _, v := range m.Pix[y*m.Stride : y*m.Stride+4*w] {
+				out = append(out, 255-v)
+			}
+		}
+		return out, nil
+	}
+
+	return nil, fmt.Errorf("unsupported JPEG colour model %T", img)
+}
+
+// jpegInfo holds what decodeDCT needs from the markers of a JPEG file.
+type jpegInfo struct {
+	components int
+
+	// adobe is the offset of the colour transform byte of the APP14
+	// marker, or -1 if there is none.
+	adobe int
+}
+
+// scanJPEG reads the markers of a JPEG file up to its first scan.
+func scanJPEG(data []byte) jpegInfo {
+	info := jpegInfo{adobe: -1}
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return info
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return info
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xff:
+			// Fill bytes may precede a marker.
+			i++
+			continue
+		case marker == 0x01 || marker >= 0xd0 && marker <= 0xd8:
+			// Markers without a segment.
+			i += 2
+			continue
+		case marker == 0xd9 || marker == 0xda:
+			return info
+		}
+
+		// Segment lengths count their own two bytes.
+		length := int(data[i+2])<<8 | int(data[i+3])
+		if length < 2 {
+			return info
+		}
+		seg := data[i+4 : min(i+2+length, len(data))]
+
+		switch {
+		case marker == 0xee && len(seg) >= 12 && bytes.HasPrefix(seg, []byte("Adobe")):
+			info.adobe = i + 4 + 11
+		case marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc:
+			if len(seg) >= 6 {
+				info.components = int(seg[5])
+			}
+		}
+
+		i += 2 + length
+	}
+
+	return info
+}
+
+// withAdobeTransform returns data with the APP14 colour transform set to t,
+// adding the marker after SOI if the file has none.
+func withAdobeTransform(data []byte, info jpegInfo, t byte) []byte {
+	if info.adobe >= 0 {
+		out := append([]byte(nil), data...)
+		out[info.adobe] = t
+		return out
+	}
+	if len(data) < 2 {
+		return data
+	}
+
+	app14 := []byte{
+		0xff, 0xee, 0x00, 0x0e,
+		'A', 'd', 'o', 'b', 'e',
+		0x00, 0x64, // version
+		0x00, 0x00, 0x00, 0x00, // flags
+		t,
+	}
+
+	out := make([]byte, 0, len(data)+len(app14))
+	out = append(out, data[:2]...)
+	out = append(out, app14...)
+	return append(out, data[2:]...)
+}
@@ -0,0 +1,151 @@
+package parser
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// solidJPEG builds a baseline 8x8 JPEG whose components have the given
+// constant values. The standard library cannot write four-component files,
+// so the few segments needed are assembled by hand: unit quantization, a DC
+// table coding each size category in four bits and an AC table holding only
+// end-of-block. adobe is the APP14 colour transform, or -1 for no marker.
+func solidJPEG(values []int, adobe int) []byte {
+	var out bytes.Buffer
+	segment := func(marker byte, payload ...byte) {
+		out.Write([]byte{0xff, marker, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)})
+		out.Write(payload)
+	}
+
+	out.Write([]byte{0xff, 0xd8})
+	if adobe >= 0 {
+		segment(0xee, 'A', 'd', 'o', 'b', 'e', 0, 100, 0, 0, 0, 0, byte(adobe))
+	}
+
+	segment(0xdb, append([]byte{0}, bytes.Repeat([]byte{1}, 64)...)...)
+
+	sof := []byte{8, 0, 8, 0, 8, byte(len(values))}
+	sos := []byte{byte(len(values))}
+	for i := range values {
+		sof = append(sof, byte(i+1), 0x11, 0)
+		sos = append(sos, byte(i+1), 0x00)
+	}
+	segment(0xc0, sof...)
+
+	dc := append([]byte{0x00, 0, 0, 0, 12, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11)
+	segment(0xc4, dc...)
+	segment(0xc4, 0x10, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x00)
+	segment(0xda, append(sos, 0, 63, 0)...)
+
+	var bits []byte
+	put := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, byte(v>>i&1))
+		}
+	}
+	for _, v := range values {
+		// A constant block has only a DC coefficient of 8 times its
+		// level-shifted value.
+		diff := 8 * (v - 128)
+		size, mag := 0, diff
+		if mag < 0 {
+			mag = -mag
+		}
+		for mag>>size != 0 {
+			size++
+		}
+		put(size, 4)
+		if diff < 0 {
+			diff += 1<<size - 1
+		}
+		put(diff, size)
+		put(0, 1)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, 1)
+	}
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for _, bit := range bits[i : i+8] {
+			b = b<<1 | bit
+		}
+		out.WriteByte(b)
+		if b == 0xff {
+			out.WriteByte(0)
+		}
+	}
+
+	out.Write([]byte{0xff, 0xd9})
+	return out.Bytes()
+}
+
+func TestDecodeDCT(t *testing.T) {
+	var gray, rgb bytes.Buffer
+	gimg := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range gimg.Pix {
+		gimg.Pix[i] = 0x40
+	}
+	jpeg.Encode(&gray, gimg, &jpeg.Options{Quality: 100})
+
+	cimg := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			cimg.SetRGBA(x, y, color.RGBA{0xff, 0x80, 0x00, 0xff})
+		}
+	}
+	jpeg.Encode(&rgb, cimg, &jpeg.Options{Quality: 100})
+
+	tests := []struct {
+		name     string
+		data     []byte
+		params   model.PDFDict
+		expected []int
+	}{
+		{"Gray", gray.Bytes(), nil, []int{0x40}},
+		{"YCbCr", rgb.Bytes(), nil, []int{0xff, 0x80, 0x00}},
+		{"CMYK", solidJPEG([]int{0x10, 0x20, 0x30, 0x40}, -1), nil, []int{0x10, 0x20, 0x30, 0x40}},
+		// The Adobe convention stores inverted samples; they are returned
+		// as stored, for /Decode to invert.
+		{"AdobeCMYK", solidJPEG([]int{0xef, 0xdf, 0xcf, 0xbf}, 0), nil, []int{0xef, 0xdf, 0xcf, 0xbf}},
+		// YCCK with Y=0x80 and neutral chroma is inverted CMY of 0x7f.
+		{"YCCK", solidJPEG([]int{0x80, 0x80, 0x80, 0xc0}, 2), nil, []int{0x7f, 0x7f, 0x7f, 0xc0}},
+		{"ColorTransform", solidJPEG([]int{0x80, 0x80, 0x80, 0xc0}, -1), model.PDFDict{"ColorTransform": model.PDFNumber(1)}, []int{0x7f, 0x7f, 0x7f, 0xc0}},
+		{"NoColorTransform", solidJPEG([]int{0x80, 0x80, 0x80, 0xc0}, 2), model.PDFDict{"ColorTransform": model.PDFNumber(0)}, []int{0x80, 0x80, 0x80, 0xc0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dict := model.PDFDict{"Filter": model.PDFName("DCTDecode")}
+			if tc.params != nil {
+				dict["DecodeParms"] = tc.params
+			}
+
+			got, err := DecodeStream(model.PDFStream{Dict: dict, Data: tc.data}, NewObjectTable())
+			if err != nil {
+				t.Fatalf("DecodeStream() error = %v", err)
+			}
+
+			n := len(tc.expected)
+			if len(got) != 64*n {
+				t.Fatalf("DecodeStream() returned %d bytes, expected %d", len(got), 64*n)
+			}
+			for i, v := range got {
+				if d := int(v) - tc.expected[i%n]; d < -2 || d > 2 {
+					t.Fatalf("sample %d = %#x, expected %#x", i, v, tc.expected[i%n])
+				}
+			}
+		})
+	}
+
+	for _, data := range []string{"not a JPEG", "\xff\xd8\xff0\x00\x00"} {
+		s := model.PDFStream{Dict: model.PDFDict{"Filter": model.PDFName("DCTDecode")}, Data: []byte(data)}
+		if _, err := DecodeStream(s, NewObjectTable()); err == nil {
+			t.Errorf("DecodeStream(%q) expected an error", data)
+		}
+	}
+}
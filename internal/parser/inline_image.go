@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// inlineImageKeys maps the abbreviated keys allowed in inline image
+// dictionaries to the keys of image XObjects.
+var inlineImageKeys = map[string]string{
+	"BPC": "BitsPerComponent",
+	"CS":  "ColorSpace",
+	"D":   "Decode",
+	"DP":  "DecodeParms",
+	"F":   "Filter",
+	"H":   "Height",
+	"IM":  "ImageMask",
+	"I":   "Interpolate",
+	"L":   "Length",
+	"W":   "Width",
+}
+
+// inlineColorSpaces maps the abbreviated colour space names allowed in
+// inline images to their full names.
+var inlineColorSpaces = map[model.PDFName]model.PDFName{
+	"G":    "DeviceGray",
+	"RGB":  "DeviceRGB",
+	"CMYK": "DeviceCMYK",
+	"I":    "Indexed",
+}
+
+// parseInlineImage reads an inline image after its BI operator. The image
+// is returned as a BI operation whose single operand is a stream holding the
+// image dictionary, with abbreviations expanded, and the raw image data; the
+// ID and EI operators are consumed.
+func (p *Parser) parseInlineImage() (*Operation, error) {
+	dict := make(model.PDFDict)
+
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if tok.Type == model.TokKeyword && tok.Value == "ID" {
+			break
+		}
+		if tok.Type == model.TokEOF {
+			return nil, fmt.Errorf("unterminated inline image dictionary")
+		}
+		if tok.Type != model.TokName {
+			return nil, fmt.Errorf("inline image key must be name, got %v", tok)
+		}
+
+		val, err := p.Parse()
+		if err != nil {
+			return nil, err
+		}
+
+		key := tok.Value
+		if long, ok := inlineImageKeys[key]; ok {
+			key = long
+		}
+		dict[key] = val
+	}
+	expandInlineColorSpace(dict)
+
+	// A single white-space character separates ID from the data.
+	if b, err := p.l.ReadByte(); err == nil && !IsWhiteSpace(b) {
+		p.l.UnReadByte()
+	}
+
+	data, err := p.readInlineImageData(dict)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := model.PDFStream{Dict: dict, Data: data}
+	return &Operation{Operator: "BI", Operands: []model.PDFValue{stream}}, nil
+}
+
+// readInlineImageData reads the data of an inline image up to its EI
+// operator. The length is taken from /Length when present; otherwise the
+// data ends at the first EI that is delimited by white space, since filtered
+// data may contain the bytes EI anywhere.
+func (p *Parser) readInlineImageData(dict model.PDFDict) ([]byte, error) {
+	if n, ok := dict["Length"].(model.PDFNumber); ok && n >= 0 {
+		data := make([]byte, int(n))
+		if _, err := io.ReadFull(p.l.r, data); err != nil {
+			return nil, fmt.Errorf("inline image data: %w", err)
+		}
+
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Type != model.TokKeyword || tok.Value != "EI" {
+			return nil, fmt.Errorf("expected EI after inline image data, got %v", tok)
+		}
+		return data, nil
+	}
+
+	var data []byte
+	for {
+		b, err := p.l.ReadByte()
+		if err == io.EOF {
+			return nil, fmt.Errorf("inline image without EI")
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b)
+
+		n := len(data)
+		if n < 2 || data[n-2] != 'E' || data[n-1] != 'I' {
+			continue
+		}
+		if n > 2 && !IsWhiteSpace(data[n-3]) {
+			continue
+		}
+
+		next, err := p.l.ReadByte()
+		if err == nil {
+			p.l.UnReadByte()
+			if !IsWhiteSpace(next) && !IsDelimiter(next) {
+				continue
+			}
+		}
+
+		// The white space before EI separates it from the data.
+		return data[:max(n-3, 0)], nil
+	}
+}
+
+// expandInlineColorSpace replaces abbreviated colour space names in the
+// /ColorSpace entry of an inline image dictionary.
+func expandInlineColorSpace(dict model.PDFDict) {
+	switch cs := dict["ColorSpace"].(type) {
+	case model.PDFName:
+		if long, ok := inlineColorSpaces[cs]; ok {
+			dict["ColorSpace"] = long
+		}
+	case model.PDFArray:
+		for i, v := range cs {
+			name, ok := v.(model.PDFName)
+			if long, abbr := inlineColorSpaces[name]; ok && abbr {
+				cs[i] = long
+			}
+		}
+	}
+}
@@ -0,0 +1,674 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// decodeJBIG2 decodes an embedded JBIG2 stream (ITU T.88) into rows of 1-bit
+// samples, each padded to a whole byte, with 0 for black. Segments of the
+// /JBIG2Globals stream, which StreamFilters replaces with a stream of its
+// decoded data, are processed first.
+//
+// Generic, generic refinement and text regions are decoded along with the
+// symbol dictionaries and tables they use. Halftone regions and pattern
+// dictionaries are skipped. A damaged segment ends decoding and the page is
+// returned as composed so far.
+func decodeJBIG2(data []byte, params model.PDFDict) ([]byte, error) {
+	var segs []*jbig2Segment
+	if g, ok := params["JBIG2Globals"].(model.PDFStream); ok {
+		gs, err := parseJBIG2Segments(g.Data)
+		if err != nil {
+			return nil, fmt.Errorf("globals: %w", err)
+		}
+		segs = gs
+	}
+
+	ps, perr := parseJBIG2Segments(data)
+	segs = append(segs, ps...)
+
+	doc := jbig2Document{segments: map[uint32]*jbig2Segment{}}
+	var err error
+	for _, seg := range segs {
+		doc.segments[seg.number] = seg
+		if err = doc.process(seg); err != nil {
+			err = fmt.Errorf("segment %d: %w", seg.number, err)
+			break
+		}
+		if seg.kind == jbig2EndOfPage || seg.kind == jbig2EndOfFile {
+			break
+		}
+	}
+	if err == nil {
+		err = perr
+	}
+
+	if doc.page == nil {
+		if err == nil {
+			err = fmt.Errorf("no page information segment")
+		}
+		return nil, err
+	}
+	return doc.page.packed(), nil
+}
+
+// Segment types (T.88 7.3).
+const (
+	jbig2SymbolDict            = 0
+	jbig2IntermediateText      = 4
+	jbig2ImmediateText         = 6
+	jbig2ImmediateLosslessText = 7
+	jbig2IntermediateGeneric   = 36
+	jbig2ImmediateGeneric      = 38
+	jbig2ImmediateLossless     = 39
+	jbig2IntermediateRefine    = 40
+	jbig2ImmediateRefine       = 42
+	jbig2ImmediateLosslessRef  = 43
+	jbig2PageInfo              = 48
+	jbig2EndOfPage             = 49
+	jbig2EndOfStripe           = 50
+	jbig2EndOfFile             = 51
+	jbig2Tables                = 53
+)
+
+// jbig2FileHeader starts a JBIG2 file; embedded streams normally omit it.
+var jbig2FileHeader = []byte{0x97, 'J', 'B', '2', 0x0d, 0x0a, 0x1a, 0x0a}
+
+// jbig2Segment is a segment with the results later segments may refer to.
+type jbig2Segment struct {
+	number uint32
+	kind   int
+	refs   []uint32
+	data   []byte
+
+	// unknownLength is set for an immediate generic region whose data
+	// ends with its row count.
+	unknownLength bool
+
+	symbols []*jbig2Bitmap
+	table   huffmanTable
+	region  *jbig2Bitmap
+}
+
+// parseJBIG2Segments splits data, in the sequential organization, into
+// segments. The segments read before an error are returned with it.
+func parseJBIG2Segments(data []byte) ([]*jbig2Segment, error) {
+	if bytes.HasPrefix(data, jbig2FileHeader) && len(data) > 8 {
+		flags := data[8]
+		if flags&1 == 0 {
+			return nil, fmt.Errorf("random-access organization is not supported")
+		}
+		data = data[9:]
+		if flags&2 == 0 && len(data) >= 4 {
+			// The number of pages is known and given.
+			data = data[4:]
+		}
+	}
+
+	var segs []*jbig2Segment
+	for pos := 0; pos < len(data); {
+		seg, length, n, err := parseJBIG2SegmentHeader(data[pos:])
+		if err != nil {
+			return segs, err
+		}
+		pos += n
+
+		if length == 0xffffffff {
+			if seg.kind != jbig2ImmediateGeneric && seg.kind != jbig2ImmediateLossless {
+				return segs, fmt.Errorf("segment %d of unknown length", seg.number)
+			}
+			length = genericRegionLength(data[pos:])
+			seg.unknownLength = true
+		}
+
+		end := min(pos+int(length), len(data))
+		seg.data = data[pos:end]
+		pos = end
+
+		segs = append(segs, seg)
+		if seg.kind == jbig2EndOfFile {
+			break
+		}
+	}
+
+	return segs, nil
+}
+
+// parseJBIG2SegmentHeader parses the segment header at the start of data
+// (T.88 7.2), returning the segment, its data length and the header size.
+func parseJBIG2SegmentHeader(data []byte) (*jbig2Segment, uint32, int, error) {
+	errTruncated := fmt.Errorf("truncated segment header")
+	if len(data) < 6 {
+		return nil, 0, 0, errTruncated
+	}
+
+	seg := &jbig2Segment{
+		number: be32(data),
+		kind:   int(data[4] & 0x3f),
+	}
+	longPage := data[4]&0x40 != 0
+
+	count := int(data[5] >> 5)
+	pos := 6
+	if count == 7 {
+		if len(data) < 9 {
+			return nil, 0, 0, errTruncated
+		}
+		count = int(be32(data[5:]) & 0x1fffffff)
+		pos = 9 + (count+8)/8
+	}
+
+	refSize := 1
+	if seg.number > 65536 {
+		refSize = 4
+	} else if seg.number > 256 {
+		refSize = 2
+	}
+
+	pageSize := 1
+	if longPage {
+		pageSize = 4
+	}
+	if count > len(data) || pos+count*refSize+pageSize+4 > len(data) {
+		return nil, 0, 0, errTruncated
+	}
+
+	for i := 0; i < count; i++ {
+		var ref uint32
+		for _, b := range data[pos : pos+refSize] {
+			ref = ref<<8 | uint32(b)
+		}
+		seg.refs = append(seg.refs, ref)
+		pos += refSize
+	}
+	pos += pageSize
+
+	return seg, be32(data[pos:]), pos + 4, nil
+}
+
+// genericRegionLength finds the end of the data of an immediate generic
+// region of unknown length: the end marker for its coding, followed by its
+// row count (T.88 7.2.7).
+func genericRegionLength(data []byte) uint32 {
+	if len(data) < 18 {
+		return uint32(len(data))
+	}
+
+	marker := []byte{0xff, 0xac}
+	if data[17]&1 != 0 {
+		marker = []byte{0x00, 0x00}
+	}
+
+	for i := 18; i+6 <= len(data); i++ {
+		if bytes.Equal(data[i:i+2], marker) {
+			return uint32(i + 6)
+		}
+	}
+	return uint32(len(data))
+}
+
+// jbig2Document is the decoding state of a JBIG2 stream.
+type jbig2Document struct {
+	segments map[uint32]*jbig2Segment
+	page     *jbig2Page
+
+	// pixels counts the pixels of the page and the regions decoded so far.
+	pixels int
+}
+
+// reserve checks the size of a page or region bitmap of w by h pixels and
+// counts it against the combined size they may take.
+func (doc *jbig2Document) reserve(w, h int) error {
+	if err := checkJBIG2Size(w, h); err != nil {
+		return err
+	}
+	if doc.pixels+w*h > maxJBIG2PagePixels {
+		return fmt.Errorf("page and regions exceed %d pixels", maxJBIG2PagePixels)
+	}
+	doc.pixels += w * h
+	return nil
+}
+
+// jbig2Page is the page buffer regions are composed into.
+type jbig2Page struct {
+	bitmap *jbig2Bitmap
+
+	// unknownHeight is set for striped pages whose height grows as
+	// stripes end.
+	unknownHeight bool
+	defPixel      byte
+}
+
+// growPage extends a page of unknown height to h rows.
+func (doc *jbig2Document) growPage(h int) {
+	p := doc.page
+	if !p.unknownHeight || h <= p.bitmap.height || checkJBIG2Size(p.bitmap.width, h) != nil ||
+		doc.reserve(p.bitmap.width, h-p.bitmap.height) != nil {
+		return
+	}
+
+	b := newJBIG2Bitmap(p.bitmap.width, h)
+	copy(b.pix, p.bitmap.pix)
+	b.fillRows(p.bitmap.height, p.defPixel)
+	p.bitmap = b
+}
+
+// packed returns the page as rows of 1-bit samples with 0 for black.
+func (p *jbig2Page) packed() []byte {
+	out := make([]byte, len(p.bitmap.pix))
+	for i, v := range p.bitmap.pix {
+		out[i] = ^v
+	}
+	return out
+}
+
+// jbig2RegionInfo is the region segment information field (T.88 7.4.1).
+type jbig2RegionInfo struct {
+	width, height int
+	x, y          int
+	combOp        int
+}
+
+func parseRegionInfo(data []byte) (jbig2RegionInfo, error) {
+	if len(data) < 17 {
+		return jbig2RegionInfo{}, fmt.Errorf("truncated region segment")
+	}
+
+	info := jbig2RegionInfo{
+		width:  int(be32(data)),
+		height: int(be32(data[4:])),
+		x:      int(int32(be32(data[8:]))),
+		y:      int(int32(be32(data[12:]))),
+		combOp: int(data[16] & 7),
+	}
+	return info, nil
+}
+
+// parseAT reads n adaptive template pixels as pairs of signed bytes.
+func parseAT(data []byte, n int) ([]jbig2Pixel, error) {
+	if len(data) < 2*n {
+		return nil, fmt.Errorf("truncated adaptive template")
+	}
+
+	at := make([]jbig2Pixel, n)
+	for i := range at {
+		at[i] = jbig2Pixel{int(int8(data[2*i])), int(int8(data[2*i+1]))}
+	}
+	return at, nil
+}
+
+// process decodes a segment, composing immediate regions into the page.
+func (doc *jbig2Document) process(seg *jbig2Segment) error {
+	switch seg.kind {
+	case jbig2PageInfo:
+		return doc.pageInfo(seg.data)
+
+	case jbig2EndOfStripe:
+		if doc.page != nil && len(seg.data) >= 4 {
+			doc.growPage(int(be32(seg.data)) + 1)
+		}
+		return nil
+
+	case jbig2Tables:
+		t, err := parseHuffmanTable(seg.data)
+		seg.table = t
+		return err
+
+	case jbig2SymbolDict:
+		return doc.symbolDict(seg)
+
+	case jbig2IntermediateText, jbig2ImmediateText, jbig2ImmediateLosslessText:
+		info, bm, err := doc.textRegion(seg)
+		if err != nil {
+			return err
+		}
+		return doc.placeRegion(seg, info, bm)
+
+	case jbig2IntermediateGeneric, jbig2ImmediateGeneric, jbig2ImmediateLossless:
+		info, bm, err := doc.genericRegion(seg)
+		if err != nil {
+			return err
+		}
+		return doc.placeRegion(seg, info, bm)
+
+	case jbig2IntermediateRefine, jbig2ImmediateRefine, jbig2ImmediateLosslessRef:
+		return doc.refinementRegion(seg)
+	}
+
+	return nil
+}
+
+func (doc *jbig2Document) pageInfo(data []byte) error {
+	if doc.page != nil {
+		return nil
+	}
+	if len(data) < 19 {
+		return fmt.Errorf("truncated page information")
+	}
+
+	w, h := int(be32(data)), be32(data[4:])
+	p := &jbig2Page{defPixel: data[16] >> 2 & 1}
+	if h == 0xffffffff {
+		p.unknownHeight = true
+		h = 0
+	}
+	if err := doc.reserve(w, int(h)); err != nil {
+		return err
+	}
+
+	p.bitmap = newJBIG2Bitmap(w, int(h))
+	p.bitmap.fillRows(0, p.defPixel)
+	doc.page = p
+	return nil
+}
+
+// placeRegion keeps an intermediate region for later refinement or
+// composes an immediate one into the page.
+func (doc *jbig2Document) placeRegion(seg *jbig2Segment, info jbig2RegionInfo, bm *jbig2Bitmap) error {
+	switch seg.kind {
+	case jbig2IntermediateText, jbig2IntermediateGeneric, jbig2IntermediateRefine:
+		seg.region = bm
+		return nil
+	}
+
+	if doc.page == nil {
+		return fmt.Errorf("region before page information")
+	}
+	doc.growPage(info.y + bm.height)
+	doc.page.bitmap.compose(bm, info.x, info.y, info.combOp)
+	return nil
+}
+
+// referred returns the referred-to segments of seg of the given kind.
+func (doc *jbig2Document) referred(seg *jbig2Segment, kinds ...int) []*jbig2Segment {
+	var out []*jbig2Segment
+	for _, n := range seg.refs {
+		r, ok := doc.segments[n]
+		if !ok {
+			continue
+		}
+		for _, k := range kinds {
+			if r.kind == k {
+				out = append(out, r)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// jbig2TableSelector hands out the Huffman tables chosen by a segment's
+// flags: standard ones, or its referred-to tables segments in order.
+type jbig2TableSelector struct {
+	custom []*jbig2Segment
+}
+
+// pick returns standard table std[sel], or the next custom table when sel
+// is custom.
+func (s *jbig2TableSelector) pick(sel, custom int, std ...int) (huffmanTable, error) {
+	if sel == custom {
+		if len(s.custom) == 0 {
+			return nil, fmt.Errorf("missing custom Huffman table")
+		}
+		t := s.custom[0].table
+		s.custom = s.custom[1:]
+		return t, nil
+	}
+	if sel >= len(std) {
+		return nil, fmt.Errorf("invalid Huffman table selection %d", sel)
+	}
+	return standardHuffmanTables[std[sel]], nil
+}
+
+func (doc *jbig2Document) symbolDict(seg *jbig2Segment) error {
+	data := seg.data
+	if len(data) < 2 {
+		return fmt.Errorf("truncated symbol dictionary")
+	}
+
+	flags := int(data[0])<<8 | int(data[1])
+	p := &symbolDict{
+		huffman:   flags&1 != 0,
+		refAgg:    flags&2 != 0,
+		template:  flags >> 10 & 3,
+		rtemplate: flags >> 12 & 1,
+	}
+	data = data[2:]
+
+	if p.huffman {
+		sel := jbig2TableSelector{custom: doc.referred(seg, jbig2Tables)}
+		var err error
+		if p.dh, err = sel.pick(flags>>2&3, 3, 4, 5); err != nil {
+			return err
+		}
+		if p.dw, err = sel.pick(flags>>4&3, 3, 2, 3); err != nil {
+			return err
+		}
+		if p.bmSize, err = sel.pick(flags>>6&1, 1, 1); err != nil {
+			return err
+		}
+	} else {
+		n := 4
+		if p.template != 0 {
+			n = 1
+		}
+		at, err := parseAT(data, n)
+		if err != nil {
+			return err
+		}
+		p.at = at
+		data = data[2*n:]
+	}
+
+	if p.refAgg && p.rtemplate == 0 {
+		rat, err := parseAT(data, 2)
+		if err != nil {
+			return err
+		}
+		p.rat = rat
+		data = data[4:]
+	}
+
+	if len(data) < 8 {
+		return fmt.Errorf("truncated symbol dictionary")
+	}
+	p.numExported = int(be32(data))
+	p.numNew = int(be32(data[4:]))
+	data = data[8:]
+
+	var in []*jbig2Bitmap
+	for _, r := range doc.referred(seg, jbig2SymbolDict) {
+		in = append(in, r.symbols...)
+	}
+
+	syms, err := decodeSymbolDict(p, in, data)
+	seg.symbols = syms
+	return err
+}
+
+func (doc *jbig2Document) textRegion(seg *jbig2Segment) (jbig2RegionInfo, *jbig2Bitmap, error) {
+	info, err := parseRegionInfo(seg.data)
+	if err != nil {
+		return info, nil, err
+	}
+	data := seg.data[17:]
+	if len(data) < 2 {
+		return info, nil, fmt.Errorf("truncated text region")
+	}
+	if err := doc.reserve(info.width, info.height); err != nil {
+		return info, nil, err
+	}
+
+	flags := int(data[0])<<8 | int(data[1])
+	p := &textRegion{
+		width:      info.width,
+		height:     info.height,
+		huffman:    flags&1 != 0,
+		refine:     flags&2 != 0,
+		logStrips:  flags >> 2 & 3,
+		refCorner:  flags >> 4 & 3,
+		transposed: flags&0x40 != 0,
+		combOp:     flags >> 7 & 3,
+		defPixel:   byte(flags >> 9 & 1),
+		dsOffset:   flags >> 10 & 0x1f,
+		rtemplate:  flags >> 15 & 1,
+	}
+	if p.dsOffset >= 16 {
+		p.dsOffset -= 32
+	}
+	data = data[2:]
+
+	if p.huffman {
+		if len(data) < 2 {
+			return info, nil, fmt.Errorf("truncated text region")
+		}
+		hflags := int(data[0])<<8 | int(data[1])
+		data = data[2:]
+
+		sel := jbig2TableSelector{custom: doc.referred(seg, jbig2Tables)}
+		picks := []struct {
+			t      *huffmanTable
+			shift  int
+			custom int
+			std    []int
+		}{
+			{&p.fs, 0, 3, []int{6, 7}},
+			{&p.ds, 2, 3, []int{8, 9, 10}},
+			{&p.dt, 4, 3, []int{11, 12, 13}},
+			{&p.rdw, 6, 3, []int{14, 15}},
+			{&p.rdh, 8, 3, []int{14, 15}},
+			{&p.rdx, 10, 3, []int{14, 15}},
+			{&p.rdy, 12, 3, []int{14, 15}},
+		}
+		for _, pk := range picks {
+			if *pk.t, err = sel.pick(hflags>>pk.shift&3, pk.custom, pk.std...); err != nil {
+				return info, nil, err
+			}
+		}
+	}
+
+	if p.refine && p.rtemplate == 0 {
+		if p.rat, err = parseAT(data, 2); err != nil {
+			return info, nil, err
+		}
+		data = data[4:]
+	}
+
+	if len(data) < 4 {
+		return info, nil, fmt.Errorf("truncated text region")
+	}
+	p.numInstances = int(be32(data))
+	data = data[4:]
+
+	for _, r := range doc.referred(seg, jbig2SymbolDict) {
+		p.symbols = append(p.symbols, r.symbols...)
+	}
+	p.symCodeLen = symbolCodeLength(len(p.symbols))
+
+	if p.huffman {
+		br := util.NewBitReader(data)
+		if p.symCodes, err = parseSymbolCodes(br, len(p.symbols)); err != nil {
+			return info, nil, err
+		}
+		bm, err := decodeTextRegion(p, &jbig2Arith{}, br)
+		return info, bm, err
+	}
+
+	bm, err := decodeTextRegion(p, newJBIG2Arith(data, p.symCodeLen), nil)
+	return info, bm, err
+}
+
+func (doc *jbig2Document) genericRegion(seg *jbig2Segment) (jbig2RegionInfo, *jbig2Bitmap, error) {
+	info, err := parseRegionInfo(seg.data)
+	if err != nil {
+		return info, nil, err
+	}
+	data := seg.data[17:]
+	if len(data) < 1 {
+		return info, nil, fmt.Errorf("truncated generic region")
+	}
+
+	flags := data[0]
+	mmr := flags&1 != 0
+	template := int(flags >> 1 & 3)
+	tpgdon := flags&8 != 0
+	data = data[1:]
+
+	if seg.unknownLength && len(data) >= 6 {
+		// The data ends with the end marker and the actual row count.
+		info.height = int(be32(data[len(data)-4:]))
+		data = data[:len(data)-6]
+	}
+	if err := doc.reserve(info.width, info.height); err != nil {
+		return info, nil, err
+	}
+
+	if mmr {
+		bm, err := decodeMMRRegion(data, info.width, info.height)
+		return info, bm, err
+	}
+
+	n := 4
+	if template != 0 {
+		n = 1
+	}
+	at, err := parseAT(data, n)
+	if err != nil {
+		return info, nil, err
+	}
+	data = data[2*n:]
+
+	d := newMQDecoder(data)
+	bm := decodeGenericRegion(d, make([]mqContext, 1<<16), info.width, info.height, template, tpgdon, at)
+	return info, bm, nil
+}
+
+func (doc *jbig2Document) refinementRegion(seg *jbig2Segment) error {
+	info, err := parseRegionInfo(seg.data)
+	if err != nil {
+		return err
+	}
+	data := seg.data[17:]
+	if len(data) < 1 {
+		return fmt.Errorf("truncated refinement region")
+	}
+
+	if err := doc.reserve(info.width, info.height); err != nil {
+		return err
+	}
+
+	template := int(data[0] & 1)
+	tpgron := data[0]&2 != 0
+	data = data[1:]
+
+	var at []jbig2Pixel
+	if template == 0 {
+		if at, err = parseAT(data, 2); err != nil {
+			return err
+		}
+		data = data[4:]
+	}
+
+	// Without a referred-to intermediate region the page itself is
+	// refined, and the result replaces the area it was taken from.
+	var ref *jbig2Bitmap
+	op := info.combOp
+	if rs := doc.referred(seg, jbig2IntermediateText, jbig2IntermediateGeneric, jbig2IntermediateRefine); len(rs) > 0 && rs[0].region != nil {
+		ref = rs[0].region
+	} else {
+		if doc.page == nil {
+			return fmt.Errorf("region before page information")
+		}
+		ref = doc.page.bitmap.sub(info.x, info.y, info.width, info.height)
+		op = jbig2Replace
+	}
+
+	d := newMQDecoder(data)
+	bm := decodeRefinementRegion(d, make([]mqContext, 1<<13), info.width, info.height, template, ref, 0, 0, tpgron, at)
+
+	info.combOp = op
+	return doc.placeRegion(seg, info, bm)
+}
@@ -0,0 +1,184 @@
+package parser
+
+// mqState is one entry of the MQ coder's probability estimation table.
+type mqState struct {
+	qe         uint32
+	nmps, nlps uint8
+	switchMPS  bool
+}
+
+var mqStates = [47]mqState{
+	{0x5601, 1, 1, true}, {0x3401, 2, 6, false}, {0x1801, 3, 9, false}, {0x0ac1, 4, 12, false},
+	{0x0521, 5, 29, false}, {0x0221, 38, 33, false}, {0x5601, 7, 6, true}, {0x5401, 8, 14, false},
+	{0x4801, 9, 14, false}, {0x3801, 10, 14, false}, {0x3001, 11, 17, false}, {0x2401, 12, 18, false},
+	{0x1c01, 13, 20, false}, {0x1601, 29, 21, false}, {0x5601, 15, 14, true}, {0x5401, 16, 14, false},
+	{0x5101, 17, 15, false}, {0x4801, 18, 16, false}, {0x3801, 19, 17, false}, {0x3401, 20, 18, false},
+	{0x3001, 21, 19, false}, {0x2801, 22, 19, false}, {0x2401, 23, 20, false}, {0x2201, 24, 21, false},
+	{0x1c01, 25, 22, false}, {0x1801, 26, 23, false}, {0x1601, 27, 24, false}, {0x1401, 28, 25, false},
+	{0x1201, 29, 26, false}, {0x1101, 30, 27, false}, {0x0ac1, 31, 28, false}, {0x09c1, 32, 29, false},
+	{0x08a1, 33, 30, false}, {0x0521, 34, 31, false}, {0x0441, 35, 32, false}, {0x02a1, 36, 33, false},
+	{0x0221, 37, 34, false}, {0x0141, 38, 35, false}, {0x0111, 39, 36, false}, {0x0085, 40, 37, false},
+	{0x0049, 41, 38, false}, {0x0025, 42, 39, false}, {0x0015, 43, 40, false}, {0x0009, 44, 41, false},
+	{0x0005, 45, 42, false}, {0x0001, 45, 43, false}, {0x5601, 46, 46, false},
+}
+
+// mqContext is the adaptive state of one context: an index into mqStates
+// shifted left by one, with the more probable symbol in the low bit.
+type mqContext uint8
+
+// mqDecoder is the MQ arithmetic decoder of JBIG2 (ITU T.88 Annex E),
+// also used by JPEG 2000.
+type mqDecoder struct {
+	data []byte
+	pos  int
+	c    uint32
+	a    uint32
+	ct   int
+}
+
+func newMQDecoder(data []byte) *mqDecoder {
+	d := &mqDecoder{data: data}
+	d.c = uint32(d.byteAt(0)) << 16
+	d.byteIn()
+	d.c <<= 7
+	d.ct -= 7
+	d.a = 0x8000
+	return d
+}
+
+// byteAt returns the byte at i, reading past the end of the data as 0xff.
+func (d *mqDecoder) byteAt(i int) byte {
+	if i < len(d.data) {
+		return d.data[i]
+	}
+	return 0xff
+}
+
+func (d *mqDecoder) byteIn() {
+	if d.byteAt(d.pos) == 0xff {
+		if d.byteAt(d.pos+1) > 0x8f {
+			// A marker: feed 1 bits without advancing.
+			d.c += 0xff00
+			d.ct = 8
+			return
+		}
+		d.pos++
+		d.c += uint32(d.byteAt(d.pos)) << 9
+		d.ct = 7
+		return
+	}
+
+	d.pos++
+	d.c += uint32(d.byteAt(d.pos)) << 8
+	d.ct = 8
+}
+
+// decode decodes one bit in context cx.
+func (d *mqDecoder) decode(cx *mqContext) int {
+	st := &mqStates[*cx>>1]
+	mps := int(*cx & 1)
+
+	var bit int
+	d.a -= st.qe
+	if d.c>>16 < st.qe {
+		// LPS exchange.
+		if d.a < st.qe {
+			bit = mps
+			*cx = mqContext(st.nmps<<1) | mqContext(mps)
+		} else {
+			bit = 1 - mps
+			if st.switchMPS {
+				mps = bit
+			}
+			*cx = mqContext(st.nlps<<1) | mqContext(mps)
+		}
+		d.a = st.qe
+	} else {
+		d.c -= st.qe << 16
+		if d.a&0x8000 != 0 {
+			return mps
+		}
+
+		// MPS exchange.
+		if d.a < st.qe {
+			bit = 1 - mps
+			if st.switchMPS {
+				mps = bit
+			}
+			*cx = mqContext(st.nlps<<1) | mqContext(mps)
+		} else {
+			bit = mps
+			*cx = mqContext(st.nmps<<1) | mqContext(mps)
+		}
+	}
+
+	for d.a&0x8000 == 0 {
+		if d.ct == 0 {
+			d.byteIn()
+		}
+		d.a <<= 1
+		d.c <<= 1
+		d.ct--
+	}
+
+	return bit
+}
+
+// jbig2IntContexts holds the contexts of one integer arithmetic decoding
+// procedure (T.88 Annex A.2), such as IADT or IAFS.
+type jbig2IntContexts [512]mqContext
+
+// decode decodes an integer. The second result is false for the
+// out-of-band value.
+func (cx *jbig2IntContexts) decode(d *mqDecoder) (int, bool) {
+	prev := 1
+	bits := func(n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			bit := d.decode(&cx[prev])
+			if prev < 256 {
+				prev = prev<<1 | bit
+			} else {
+				prev = (prev<<1|bit)&511 | 256
+			}
+			v = v<<1 | bit
+		}
+		return v
+	}
+
+	sign := bits(1)
+
+	var v int
+	switch {
+	case bits(1) == 0:
+		v = bits(2)
+	case bits(1) == 0:
+		v = bits(4) + 4
+	case bits(1) == 0:
+		v = bits(6) + 20
+	case bits(1) == 0:
+		v = bits(8) + 84
+	case bits(1) == 0:
+		v = bits(12) + 340
+	default:
+		v = bits(32) + 4436
+	}
+
+	if sign == 1 {
+		if v == 0 {
+			return 0, false
+		}
+		return -v, true
+	}
+	return v, true
+}
+
+// decodeIAID decodes a symbol ID of n bits (T.88 Annex A.3). cx must hold
+// 1<<(n+1) contexts.
+func decodeIAID(d *mqDecoder, cx []mqContext, n int) int {
+	prev := 1
+	for i := 0; i < n; i++ {
+		prev = prev<<1 | d.decode(&cx[prev])
+	}
+	return prev - 1<<n
+}
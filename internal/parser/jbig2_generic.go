@@ -0,0 +1,296 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// jbig2Bitmap is a bilevel image of rows of stride bytes, with one bit per
+// pixel, most significant first, and 1 for black. The bits that pad rows
+// to whole bytes are 0.
+type jbig2Bitmap struct {
+	width, height int
+	stride        int
+	pix           []byte
+}
+
+func newJBIG2Bitmap(width, height int) *jbig2Bitmap {
+	stride := (width + 7) / 8
+	return &jbig2Bitmap{width: width, height: height, stride: stride, pix: make([]byte, stride*height)}
+}
+
+// at returns the pixel at (x, y); pixels outside the bitmap are 0.
+func (b *jbig2Bitmap) at(x, y int) int {
+	if x < 0 || y < 0 || x >= b.width || y >= b.height {
+		return 0
+	}
+	return b.bit(x, y)
+}
+
+// bit returns the pixel at (x, y), which must lie within the bitmap.
+func (b *jbig2Bitmap) bit(x, y int) int {
+	return int(b.pix[y*b.stride+x>>3] >> (7 - x&7) & 1)
+}
+
+// set sets the pixel at (x, y), which must lie within the bitmap, to v.
+func (b *jbig2Bitmap) set(x, y, v int) {
+	mask := byte(0x80) >> (x & 7)
+	if v != 0 {
+		b.pix[y*b.stride+x>>3] |= mask
+	} else {
+		b.pix[y*b.stride+x>>3] &^= mask
+	}
+}
+
+// fillRows sets the pixels of the rows from y on to v.
+func (b *jbig2Bitmap) fillRows(y int, v byte) {
+	if v == 0 {
+		clear(b.pix[y*b.stride:])
+		return
+	}
+	for ; y < b.height; y++ {
+		row := b.pix[y*b.stride : (y+1)*b.stride]
+		for i := range row {
+			row[i] = 0xff
+		}
+		b.clearPadding(row)
+	}
+}
+
+// clearPadding clears the bits past the width of a row.
+func (b *jbig2Bitmap) clearPadding(row []byte) {
+	if n := b.width & 7; n != 0 {
+		row[len(row)-1] &= 0xff << (8 - n)
+	}
+}
+
+// Combination operators of region segments and text regions.
+const (
+	jbig2OR = iota
+	jbig2AND
+	jbig2XOR
+	jbig2XNOR
+	jbig2Replace
+)
+
+// compose combines src into b with its top-left corner at (x, y).
+func (b *jbig2Bitmap) compose(src *jbig2Bitmap, x, y, op int) {
+	for sy := max(0, -y); sy < src.height && y+sy < b.height; sy++ {
+		for sx := max(0, -x); sx < src.width && x+sx < b.width; sx++ {
+			s, d := src.bit(sx, sy), b.bit(x+sx, y+sy)
+			switch op {
+			case jbig2OR:
+				d |= s
+			case jbig2AND:
+				d &= s
+			case jbig2XOR:
+				d ^= s
+			case jbig2XNOR:
+				d = 1 ^ d ^ s
+			default:
+				d = s
+			}
+			b.set(x+sx, y+sy, d)
+		}
+	}
+}
+
+// sub returns a copy of the w by h area of b at (x, y).
+func (b *jbig2Bitmap) sub(x, y, w, h int) *jbig2Bitmap {
+	out := newJBIG2Bitmap(w, h)
+	for sy := 0; sy < h; sy++ {
+		for sx := 0; sx < w; sx++ {
+			out.set(sx, sy, b.at(x+sx, y+sy))
+		}
+	}
+	return out
+}
+
+// jbig2Pixel is a template pixel position relative to the current pixel.
+type jbig2Pixel struct {
+	x, y int
+}
+
+// genericTemplate returns the context template of GBTEMPLATE t with its
+// adaptive pixels at, most significant context bit first (T.88 6.2.5.3).
+func genericTemplate(t int, at []jbig2Pixel) []jbig2Pixel {
+	switch t {
+	case 0:
+		return []jbig2Pixel{
+			at[3], {-1, -2}, {0, -2}, {1, -2}, at[2],
+			at[1], {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {2, -1}, at[0],
+			{-4, 0}, {-3, 0}, {-2, 0}, {-1, 0},
+		}
+	case 1:
+		return []jbig2Pixel{
+			{-1, -2}, {0, -2}, {1, -2}, {2, -2},
+			{-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {2, -1}, at[0],
+			{-3, 0}, {-2, 0}, {-1, 0},
+		}
+	case 2:
+		return []jbig2Pixel{
+			{-1, -2}, {0, -2}, {1, -2},
+			{-2, -1}, {-1, -1}, {0, -1}, {1, -1}, at[0],
+			{-2, 0}, {-1, 0},
+		}
+	default:
+		return []jbig2Pixel{
+			{-3, -1}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, at[0],
+			{-4, 0}, {-3, 0}, {-2, 0}, {-1, 0},
+		}
+	}
+}
+
+// genericSLTP is the context in which the typical prediction flag of each
+// row is decoded, by template.
+var genericSLTP = [4]int{0x9b25, 0x0795, 0x00e5, 0x0195}
+
+// nominalGenericAT is the default position of the adaptive pixels, as
+// used by symbol dictionaries coded with Huffman tables.
+var nominalGenericAT = []jbig2Pixel{{3, -1}, {-3, -1}, {2, -2}, {-2, -2}}
+
+// decodeGenericRegion decodes an arithmetically coded generic region
+// (T.88 6.2.5.7). cx holds the GB contexts, 1<<16 of them.
+func decodeGenericRegion(d *mqDecoder, cx []mqContext, w, h, template int, tpgdon bool, at []jbig2Pixel) *jbig2Bitmap {
+	tmpl := genericTemplate(template, at)
+	bm := newJBIG2Bitmap(w, h)
+
+	var minX, maxX, minY int
+	for _, p := range tmpl {
+		minX, maxX, minY = min(minX, p.x), max(maxX, p.x), min(minY, p.y)
+	}
+	s := bm.stride
+
+	ltp := 0
+	for y := 0; y < h; y++ {
+		if tpgdon {
+			ltp ^= d.decode(&cx[genericSLTP[template]])
+			if ltp == 1 {
+				if y > 0 {
+					copy(bm.pix[y*s:(y+1)*s], bm.pix[(y-1)*s:y*s])
+				}
+				continue
+			}
+		}
+
+		for x := 0; x < w; x++ {
+			ctx := 0
+			if x >= -minX && x < w-maxX && y >= -minY {
+				// The template lies within the bitmap.
+				for _, p := range tmpl {
+					ctx = ctx<<1 | bm.bit(x+p.x, y+p.y)
+				}
+			} else {
+				for _, p := range tmpl {
+					ctx = ctx<<1 | bm.at(x+p.x, y+p.y)
+				}
+			}
+			bm.set(x, y, d.decode(&cx[ctx]))
+		}
+	}
+
+	return bm
+}
+
+// decodeMMRRegion decodes a generic region coded with Group 4 facsimile
+// coding.
+func decodeMMRRegion(data []byte, w, h int) (*jbig2Bitmap, error) {
+	bm := newJBIG2Bitmap(w, h)
+	if w == 0 || h == 0 {
+		return bm, nil
+	}
+
+	rows, err := decodeCCITTFax(data, model.PDFDict{
+		"K":        model.PDFNumber(-1),
+		"Columns":  model.PDFNumber(w),
+		"Rows":     model.PDFNumber(h),
+		"BlackIs1": model.PDFBoolean(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("MMR region: %w", err)
+	}
+
+	bm.setPacked(rows)
+	return bm, nil
+}
+
+// setPacked fills b from rows of 1-bit samples padded to whole bytes.
+func (b *jbig2Bitmap) setPacked(rows []byte) {
+	for y := 0; y < b.height && (y+1)*b.stride <= len(rows); y++ {
+		row := b.pix[y*b.stride : (y+1)*b.stride]
+		copy(row, rows[y*b.stride:])
+		b.clearPadding(row)
+	}
+}
+
+// refinementTemplate returns the context templates of GRTEMPLATE t for
+// the region being decoded and for the reference bitmap, most significant
+// context bit first (T.88 6.3.5.3).
+func refinementTemplate(t int, at []jbig2Pixel) (coding, reference []jbig2Pixel) {
+	if t == 0 {
+		return []jbig2Pixel{{0, -1}, {1, -1}, at[0], {-1, 0}},
+			[]jbig2Pixel{at[1], {0, -1}, {1, -1}, {-1, 0}, {0, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+	}
+	return []jbig2Pixel{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}},
+		[]jbig2Pixel{{0, -1}, {-1, 0}, {0, 0}, {1, 0}, {0, 1}, {1, 1}}
+}
+
+// decodeRefinementRegion decodes a generic refinement region of w by h
+// pixels relative to ref, whose pixel (x-dx, y-dy) is aligned with pixel
+// (x, y) of the region (T.88 6.3.5.6). cx holds the GR contexts, 1<<13 of
+// them.
+func decodeRefinementRegion(d *mqDecoder, cx []mqContext, w, h, template int, ref *jbig2Bitmap, dx, dy int, tpgron bool, at []jbig2Pixel) *jbig2Bitmap {
+	coding, reference := refinementTemplate(template, at)
+	bm := newJBIG2Bitmap(w, h)
+
+	// Typical prediction uses the context in which only the reference
+	// pixel aligned with the current one is set.
+	sltp := 0
+	for i, p := range reference {
+		if p == (jbig2Pixel{}) {
+			sltp = 1 << (len(reference) - 1 - i)
+		}
+	}
+
+	ltp := 0
+	for y := 0; y < h; y++ {
+		if tpgron {
+			ltp ^= d.decode(&cx[sltp])
+		}
+
+		for x := 0; x < w; x++ {
+			rx, ry := x-dx, y-dy
+
+			if ltp == 1 {
+				// Pixels whose reference neighbourhood is uniform are
+				// predicted rather than coded.
+				v := ref.at(rx-1, ry-1)
+				uniform := true
+				for j := -1; j <= 1 && uniform; j++ {
+					for i := -1; i <= 1; i++ {
+						if ref.at(rx+i, ry+j) != v {
+							uniform = false
+							break
+						}
+					}
+				}
+				if uniform {
+					bm.set(x, y, v)
+					continue
+				}
+			}
+
+			ctx := 0
+			for _, p := range coding {
+				ctx = ctx<<1 | bm.at(x+p.x, y+p.y)
+			}
+			for _, p := range reference {
+				ctx = ctx<<1 | ref.at(rx+p.x, ry+p.y)
+			}
+			bm.set(x, y, d.decode(&cx[ctx]))
+		}
+	}
+
+	return bm
+}
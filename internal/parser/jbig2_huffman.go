@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// huffmanLine is one line of a JBIG2 Huffman table (T.88 Annex B): a
+// prefix of preflen bits followed by rangelen bits added to low. The lower
+// range line subtracts them instead.
+type huffmanLine struct {
+	low      int
+	preflen  int
+	rangelen int
+	kind     huffmanLineKind
+}
+
+type huffmanLineKind uint8
+
+const (
+	huffmanNormal huffmanLineKind = iota
+	huffmanLower
+	huffmanOOB
+)
+
+// huffmanCode is a prefix code: its n low bits, most significant first.
+type huffmanCode struct {
+	bits uint32
+	n    int
+}
+
+// huffmanTable decodes values coded with a JBIG2 Huffman table.
+type huffmanTable map[huffmanCode]huffmanLine
+
+// newHuffmanTable assigns prefix codes to the lines of a table as in T.88
+// B.3. Lines with a zero prefix length get no code.
+func newHuffmanTable(lines []huffmanLine) huffmanTable {
+	maxLen := 0
+	for _, l := range lines {
+		maxLen = max(maxLen, l.preflen)
+	}
+
+	count := make([]uint32, maxLen+1)
+	for _, l := range lines {
+		count[l.preflen]++
+	}
+	count[0] = 0
+
+	t := huffmanTable{}
+	first := uint32(0)
+	for n := 1; n <= maxLen; n++ {
+		first = (first + count[n-1]) << 1
+		code := first
+		for _, l := range lines {
+			if l.preflen == n {
+				t[huffmanCode{code, n}] = l
+				code++
+			}
+		}
+	}
+
+	return t
+}
+
+// decode reads a value from br. The second result is false for the
+// out-of-band value.
+func (t huffmanTable) decode(br *util.BitReader) (int, bool, error) {
+	var c huffmanCode
+	for c.n < 32 {
+		b, ok := br.ReadBit()
+		if !ok {
+			return 0, false, fmt.Errorf("unexpected end of Huffman data")
+		}
+		c.bits = c.bits<<1 | b
+		c.n++
+
+		l, ok := t[c]
+		if !ok {
+			continue
+		}
+
+		if l.kind == huffmanOOB {
+			return 0, false, nil
+		}
+		r, ok := br.ReadBits(l.rangelen)
+		if !ok {
+			return 0, false, fmt.Errorf("unexpected end of Huffman data")
+		}
+		if l.kind == huffmanLower {
+			return l.low - int(r), true, nil
+		}
+		return l.low + int(r), true, nil
+	}
+
+	return 0, false, fmt.Errorf("invalid Huffman code")
+}
+
+// huffmanLines builds the lines of a table from its normal lines, given as
+// (low, preflen, rangelen) triples, and its optional range and OOB lines.
+// A zero prefix length omits a line.
+func huffmanLines(normal [][3]int, lower, upper [2]int, oob int) []huffmanLine {
+	var lines []huffmanLine
+	for _, l := range normal {
+		lines = append(lines, huffmanLine{low: l[0], preflen: l[1], rangelen: l[2]})
+	}
+	if lower[1] > 0 {
+		lines = append(lines, huffmanLine{low: lower[0], preflen: lower[1], rangelen: 32, kind: huffmanLower})
+	}
+	if upper[1] > 0 {
+		lines = append(lines, huffmanLine{low: upper[0], preflen: upper[1], rangelen: 32})
+	}
+	if oob > 0 {
+		lines = append(lines, huffmanLine{preflen: oob, kind: huffmanOOB})
+	}
+	return lines
+}
+
+// standardHuffmanTables holds tables B.1 to B.15 of T.88 at their number.
+var standardHuffmanTables = [16]huffmanTable{
+	1: newHuffmanTable(huffmanLines(
+		[][3]int{{0, 1, 4}, {16, 2, 8}, {272, 3, 16}},
+		[2]int{}, [2]int{65808, 3}, 0)),
+	2: newHuffmanTable(huffmanLines(
+		[][3]int{{0, 1, 0}, {1, 2, 0}, {2, 3, 0}, {3, 4, 3}, {11, 5, 6}},
+		[2]int{}, [2]int{75, 6}, 6)),
+	3: newHuffmanTable(huffmanLines(
+		[][3]int{{-256, 8, 8}, {0, 1, 0}, {1, 2, 0}, {2, 3, 0}, {3, 4, 3}, {11, 5, 6}},
+		[2]int{-257, 8}, [2]int{75, 7}, 6)),
+	4: newHuffmanTable(huffmanLines(
+		[][3]int{{1, 1, 0}, {2, 2, 0}, {3, 3, 0}, {4, 4, 3}, {12, 5, 6}},
+		[2]int{}, [2]int{76, 5}, 0)),
+	5: newHuffmanTable(huffmanLines(
+		[][3]int{{-255, 7, 8}, {1, 1, 0}, {2, 2, 0}, {3, 3, 0}, {4, 4, 3}, {12, 5, 6}},
+		[2]int{-256, 7}, [2]int{76, 6}, 0)),
+	6: newHuffmanTable(huffmanLines(
+		[][3]int{{-2048, 5, 10}, {-1024, 4, 9}, {-512, 4, 8}, {-256, 4, 7}, {-128, 5, 6}, {-64, 5, 5},
+			{-32, 4, 5}, {0, 2, 7}, {128, 3, 7}, {256, 3, 8}, {512, 4, 9}, {1024, 4, 10}},
+		[2]int{-2049, 6}, [2]int{2048, 6}, 0)),
+	7: newHuffmanTable(huffmanLines(
+		[][3]int{{-1024, 4, 9}, {-512, 3, 8}, {-256, 4, 7}, {-128, 5, 6}, {-64, 5, 5}, {-32, 4, 5},
+			{0, 4, 5}, {32, 5, 5}, {64, 5, 6}, {128, 4, 7}, {256, 3, 8}, {512, 3, 9}, {1024, 3, 10}},
+		[2]int{-1025, 5}, [2]int{2048, 5}, 0)),
+	8: newHuffmanTable(huffmanLines(
+		[][3]int{{-15, 8, 3}, {-7, 9, 1}, {-5, 8, 1}, {-3, 9, 0}, {-2, 7, 0}, {-1, 4, 0}, {0, 2, 1},
+			{2, 5, 0}, {3, 6, 0}, {4, 3, 4}, {20, 6, 1}, {22, 4, 4}, {38, 4, 5}, {70, 5, 6},
+			{134, 5, 7}, {262, 6, 7}, {390, 7, 8}, {646, 6, 10}},
+		[2]int{-16, 9}, [2]int{1670, 9}, 2)),
+	9: newHuffmanTable(huffmanLines(
+		[][3]int{{-31, 8, 4}, {-15, 9, 2}, {-11, 8, 2}, {-7, 9, 1}, {-5, 7, 1}, {-3, 4, 1}, {-1, 3, 1},
+			{1, 3, 1}, {3, 5, 1}, {5, 6, 1}, {7, 3, 5}, {39, 6, 2}, {43, 4, 5}, {75, 4, 6},
+			{139, 5, 7}, {267, 5, 8}, {523, 6, 8}, {779, 7, 9}, {1291, 6, 11}},
+		[2]int{-32, 9}, [2]int{3339, 9}, 2)),
+	10: newHuffmanTable(huffmanLines(
+		[][3]int{{-21, 7, 4}, {-5, 8, 0}, {-4, 7, 0}, {-3, 5, 0}, {-2, 2, 2}, {2, 5, 0}, {3, 6, 0},
+			{4, 7, 0}, {5, 8, 0}, {6, 2, 6}, {70, 5, 5}, {102, 6, 5}, {134, 6, 6}, {198, 6, 7},
+			{326, 6, 8}, {582, 6, 9}, {1094, 6, 10}, {2118, 7, 11}},
+		[2]int{-22, 8}, [2]int{4166, 8}, 2)),
+	11: newHuffmanTable(huffmanLines(
+		[][3]int{{1, 1, 0}, {2, 2, 1}, {4, 4, 0}, {5, 4, 1}, {7, 5, 1}, {9, 5, 2}, {13, 6, 2},
+			{17, 7, 2}, {21, 7, 3}, {29, 7, 4}, {45, 7, 5}, {77, 7, 6}},
+		[2]int{}, [2]int{141, 7}, 0)),
+	12: newHuffmanTable(huffmanLines(
+		[][3]int{{1, 1, 0}, {2, 2, 0}, {3, 3, 1}, {5, 5, 0}, {6, 5, 1}, {8, 6, 1}, {10, 7, 0},
+			{11, 7, 1}, {13, 7, 2}, {17, 7, 3}, {25, 7, 4}, {41, 8, 5}},
+		[2]int{}, [2]int{73, 8}, 0)),
+	13: newHuffmanTable(huffmanLines(
+		[][3]int{{1, 1, 0}, {2, 3, 0}, {3, 4, 0}, {4, 5, 0}, {5, 4, 1}, {7, 3, 3}, {15, 6, 1},
+			{17, 6, 2}, {21, 6, 3}, {29, 6, 4}, {45, 6, 5}, {77, 7, 6}},
+		[2]int{}, [2]int{141, 7}, 0)),
+	14: newHuffmanTable(huffmanLines(
+		[][3]int{{-2, 3, 0}, {-1, 3, 0}, {0, 1, 0}, {1, 3, 0}, {2, 3, 0}},
+		[2]int{}, [2]int{}, 0)),
+	15: newHuffmanTable(huffmanLines(
+		[][3]int{{-24, 7, 4}, {-8, 6, 2}, {-4, 5, 1}, {-2, 4, 0}, {-1, 3, 0}, {0, 1, 0}, {1, 3, 0},
+			{2, 4, 0}, {3, 5, 1}, {5, 6, 2}, {9, 7, 4}},
+		[2]int{-25, 7}, [2]int{25, 7}, 0)),
+}
+
+// parseHuffmanTable parses the data of a tables segment (T.88 7.4.13).
+func parseHuffmanTable(data []byte) (huffmanTable, error) {
+	if len(data) < 9 {
+		return nil, fmt.Errorf("truncated Huffman table")
+	}
+
+	flags := data[0]
+	oob := flags&1 != 0
+	prefBits := int(flags>>1&7) + 1
+	rangeBits := int(flags>>4&7) + 1
+	low := int(int32(be32(data[1:])))
+	high := int(int32(be32(data[5:])))
+
+	br := util.NewBitReader(data[9:])
+	read := func(n int) (int, error) {
+		v, ok := br.ReadBits(n)
+		if !ok {
+			return 0, fmt.Errorf("truncated Huffman table")
+		}
+		return int(v), nil
+	}
+
+	var lines []huffmanLine
+	for cur := low; cur < high; {
+		preflen, err := read(prefBits)
+		if err != nil {
+			return nil, err
+		}
+		rangelen, err := read(rangeBits)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, huffmanLine{low: cur, preflen: preflen, rangelen: rangelen})
+		cur += 1 << rangelen
+	}
+
+	preflen, err := read(prefBits)
+	if err != nil {
+		return nil, err
+	}
+	lines = append(lines, huffmanLine{low: low - 1, preflen: preflen, rangelen: 32, kind: huffmanLower})
+
+	if preflen, err = read(prefBits); err != nil {
+		return nil, err
+	}
+	lines = append(lines, huffmanLine{low: high, preflen: preflen, rangelen: 32})
+
+	if oob {
+		if preflen, err = read(prefBits); err != nil {
+			return nil, err
+		}
+		lines = append(lines, huffmanLine{preflen: preflen, kind: huffmanOOB})
+	}
+
+	return newHuffmanTable(lines), nil
+}
+
+// be32 reads a big-endian 32-bit integer.
+func be32(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
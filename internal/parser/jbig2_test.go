@@ -0,0 +1,639 @@
+package parser
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// mqEncoder is the MQ arithmetic encoder of T.88 Annex E, used to build
+// fixtures for the decoder.
+type mqEncoder struct {
+	out  []byte // out[0] is the byte before the first output byte
+	a, c uint32
+	ct   int
+}
+
+func newMQEncoder() *mqEncoder {
+	return &mqEncoder{out: []byte{0}, a: 0x8000, ct: 12}
+}
+
+func (e *mqEncoder) encode(cx *mqContext, bit int) {
+	st := &mqStates[*cx>>1]
+	mps := int(*cx & 1)
+
+	e.a -= st.qe
+	if bit == mps {
+		if e.a&0x8000 != 0 {
+			e.c += st.qe
+			return
+		}
+		if e.a < st.qe {
+			e.a = st.qe
+		} else {
+			e.c += st.qe
+		}
+		*cx = mqContext(st.nmps<<1) | mqContext(mps)
+	} else {
+		if e.a < st.qe {
+			e.c += st.qe
+		} else {
+			e.a = st.qe
+		}
+		if st.switchMPS {
+			mps = 1 - mps
+		}
+		*cx = mqContext(st.nlps<<1) | mqContext(mps)
+	}
+
+	for {
+		e.a <<= 1
+		e.c <<= 1
+		e.ct--
+		if e.ct == 0 {
+			e.byteOut()
+		}
+		if e.a&0x8000 != 0 {
+			break
+		}
+	}
+}
+
+func (e *mqEncoder) byteOut() {
+	last := len(e.out) - 1
+	if e.out[last] != 0xff && e.c >= 0x8000000 {
+		e.out[last]++
+		if e.out[last] == 0xff {
+			e.c &= 0x7ffffff
+		} else {
+			e.noCarry()
+			return
+		}
+	} else if e.out[last] != 0xff {
+		e.noCarry()
+		return
+	}
+
+	e.out = append(e.out, byte(e.c>>20))
+	e.c &= 0xfffff
+	e.ct = 7
+}
+
+func (e *mqEncoder) noCarry() {
+	e.out = append(e.out, byte(e.c>>19))
+	e.c &= 0x7ffff
+	e.ct = 8
+}
+
+// flush terminates the code and returns it followed by an end marker.
+func (e *mqEncoder) flush() []byte {
+	temp := e.c + e.a
+	e.c |= 0xffff
+	if e.c >= temp {
+		e.c -= 0x8000
+	}
+	e.c <<= e.ct
+	e.byteOut()
+	e.c <<= e.ct
+	e.byteOut()
+
+	if e.out[len(e.out)-1] != 0xff {
+		e.out = append(e.out, 0xff)
+	}
+	return append(e.out[1:], 0xac)
+}
+
+// encodeInt encodes v, or the out-of-band value if oob is set.
+func (e *mqEncoder) encodeInt(cx *jbig2IntContexts, v int, oob bool) {
+	prev := 1
+	put := func(n, x int) {
+		for i := n - 1; i >= 0; i-- {
+			bit := x >> i & 1
+			e.encode(&cx[prev], bit)
+			if prev < 256 {
+				prev = prev<<1 | bit
+			} else {
+				prev = (prev<<1|bit)&511 | 256
+			}
+		}
+	}
+
+	sign, mag := 0, v
+	if oob {
+		sign, mag = 1, 0
+	} else if v < 0 {
+		sign, mag = 1, -v
+	}
+
+	put(1, sign)
+	switch {
+	case mag < 4:
+		put(1, 0b0)
+		put(2, mag)
+	case mag < 20:
+		put(2, 0b10)
+		put(4, mag-4)
+	case mag < 84:
+		put(3, 0b110)
+		put(6, mag-20)
+	case mag < 340:
+		put(4, 0b1110)
+		put(8, mag-84)
+	case mag < 4436:
+		put(5, 0b11110)
+		put(12, mag-340)
+	default:
+		put(5, 0b11111)
+		put(32, mag-4436)
+	}
+}
+
+func (e *mqEncoder) encodeIAID(cx []mqContext, id, n int) {
+	prev := 1
+	for i := n - 1; i >= 0; i-- {
+		bit := id >> i & 1
+		e.encode(&cx[prev], bit)
+		prev = prev<<1 | bit
+	}
+}
+
+func (e *mqEncoder) encodeGeneric(cx []mqContext, bm *jbig2Bitmap, template int, tpgdon bool, at []jbig2Pixel) {
+	tmpl := genericTemplate(template, at)
+
+	ltp := 0
+	for y := 0; y < bm.height; y++ {
+		if tpgdon {
+			typical := 1
+			for x := 0; x < bm.width; x++ {
+				if bm.at(x, y) != bm.at(x, y-1) {
+					typical = 0
+				}
+			}
+			e.encode(&cx[genericSLTP[template]], typical^ltp)
+			if ltp = typical; ltp == 1 {
+				continue
+			}
+		}
+
+		for x := 0; x < bm.width; x++ {
+			ctx := 0
+			for _, p := range tmpl {
+				ctx = ctx<<1 | bm.at(x+p.x, y+p.y)
+			}
+			e.encode(&cx[ctx], bm.at(x, y))
+		}
+	}
+}
+
+func (e *mqEncoder) encodeRefinement(cx []mqContext, bm *jbig2Bitmap, template int, ref *jbig2Bitmap, dx, dy int, tpgron bool, at []jbig2Pixel) {
+	coding, reference := refinementTemplate(template, at)
+	sltp := 0
+	for i, p := range reference {
+		if p == (jbig2Pixel{}) {
+			sltp = 1 << (len(reference) - 1 - i)
+		}
+	}
+
+	// uniform reports whether the reference neighbourhood of (x, y) is
+	// uniform, and its value.
+	uniform := func(x, y int) (int, bool) {
+		v := ref.at(x-dx-1, y-dy-1)
+		for j := -1; j <= 1; j++ {
+			for i := -1; i <= 1; i++ {
+				if ref.at(x-dx+i, y-dy+j) != v {
+					return 0, false
+				}
+			}
+		}
+		return v, true
+	}
+
+	ltp := 0
+	for y := 0; y < bm.height; y++ {
+		if tpgron {
+			typical := 1
+			for x := 0; x < bm.width; x++ {
+				if v, ok := uniform(x, y); ok && v != bm.at(x, y) {
+					typical = 0
+				}
+			}
+			e.encode(&cx[sltp], typical^ltp)
+			ltp = typical
+		}
+
+		for x := 0; x < bm.width; x++ {
+			if _, ok := uniform(x, y); ok && ltp == 1 {
+				continue
+			}
+			ctx := 0
+			for _, p := range coding {
+				ctx = ctx<<1 | bm.at(x+p.x, y+p.y)
+			}
+			for _, p := range reference {
+				ctx = ctx<<1 | ref.at(x-dx+p.x, y-dy+p.y)
+			}
+			e.encode(&cx[ctx], bm.at(x, y))
+		}
+	}
+}
+
+// textInstance is a symbol placed with its top-left corner at (s, 0).
+type textInstance struct {
+	id, s int
+}
+
+// encodeText encodes a single strip of symbol instances, with refinement
+// flags if refine is set.
+func (e *mqEncoder) encodeText(a *jbig2Arith, syms []*jbig2Bitmap, insts []textInstance, codeLen int, refine bool) {
+	e.encodeInt(&a.iadt, 0, false)
+	e.encodeInt(&a.iadt, 0, false)
+	e.encodeInt(&a.iafs, insts[0].s, false)
+
+	for i, in := range insts {
+		e.encodeIAID(a.iaid, in.id, codeLen)
+		if refine {
+			e.encodeInt(&a.iari, 0, false)
+		}
+		end := in.s + syms[in.id].width - 1
+		if i+1 < len(insts) {
+			e.encodeInt(&a.iads, insts[i+1].s-end, false)
+		}
+	}
+	e.encodeInt(&a.iads, 0, true)
+}
+
+// bitmapOf builds a bitmap from rows of '0' and '1' digits.
+func bitmapOf(rows ...string) *jbig2Bitmap {
+	bm := newJBIG2Bitmap(len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '1' {
+				bm.set(x, y, 1)
+			}
+		}
+	}
+	return bm
+}
+
+func be32Bytes(v int) []byte {
+	return []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+// segmentBytes builds a segment with a short header associated with page 1.
+func segmentBytes(number, kind int, refs []int, data []byte) []byte {
+	out := be32Bytes(number)
+	out = append(out, byte(kind), byte(len(refs)<<5))
+	for _, r := range refs {
+		out = append(out, byte(r))
+	}
+	out = append(out, 1)
+	out = append(out, be32Bytes(len(data))...)
+	return append(out, data...)
+}
+
+func pageInfoBytes(w, h int) []byte {
+	out := append(be32Bytes(w), be32Bytes(h)...)
+	out = append(out, make([]byte, 8)...)
+	return append(out, 0, 0, 0)
+}
+
+func regionInfoBytes(w, h, x, y int) []byte {
+	out := append(be32Bytes(w), be32Bytes(h)...)
+	out = append(out, be32Bytes(x)...)
+	out = append(out, be32Bytes(y)...)
+	return append(out, jbig2OR)
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// testArith returns contexts for encoding with symbol IDs of n bits.
+func testArith(n int) *jbig2Arith {
+	return &jbig2Arith{gb: make([]mqContext, 1<<16), gr: make([]mqContext, 1<<13), iaid: make([]mqContext, 1<<(n+1))}
+}
+
+func TestMQDecoder(t *testing.T) {
+	// The test sequence of T.88 H.2, coded in a single context.
+	in := []byte{
+		0x00, 0x02, 0x00, 0x51, 0x00, 0x00, 0x00, 0xc0, 0x03, 0x52, 0x87, 0x2a, 0xaa, 0xaa, 0xaa, 0xaa,
+		0x82, 0xc0, 0x20, 0x00, 0xfc, 0xd7, 0x9e, 0xf6, 0xbf, 0x7f, 0xed, 0x90, 0x4f, 0x46, 0xa3, 0xbf,
+	}
+	coded := []byte{
+		0x84, 0xc7, 0x3b, 0xfc, 0xe1, 0xa1, 0x43, 0x04, 0x02, 0x20, 0x00, 0x00, 0x41, 0x0d, 0xbb,
+		0x86, 0xf4, 0x31, 0x7f, 0xff, 0x88, 0xff, 0x37, 0x47, 0x1a, 0xdb, 0x6a, 0xdf, 0xff, 0xac,
+	}
+	d := newMQDecoder(coded)
+	var cx mqContext
+	for i := 0; i < len(in)*8; i++ {
+		if got, want := d.decode(&cx), int(in[i/8]>>(7-i%8)&1); got != want {
+			t.Fatalf("H.2 bit %d = %d, expected %d", i, got, want)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	bits := make([]int, 5000)
+	for i := range bits {
+		// Skewed bits in context i%3 exercise both symbol exchanges.
+		if rng.Intn(10) < 2+3*(i%3) {
+			bits[i] = 1
+		}
+	}
+	ints := []int{0, 1, 3, 4, 19, 20, 83, 84, 339, 340, 4435, 4436, 100000, -1, -5, -4436}
+
+	e := newMQEncoder()
+	var ecx [3]mqContext
+	for i, b := range bits {
+		e.encode(&ecx[i%3], b)
+	}
+	var eint jbig2IntContexts
+	for _, v := range ints {
+		e.encodeInt(&eint, v, false)
+	}
+	e.encodeInt(&eint, 0, true)
+	eid := make([]mqContext, 1<<6)
+	e.encodeIAID(eid, 21, 5)
+
+	d = newMQDecoder(e.flush())
+	var dcx [3]mqContext
+	for i, b := range bits {
+		if got := d.decode(&dcx[i%3]); got != b {
+			t.Fatalf("bit %d = %d, expected %d", i, got, b)
+		}
+	}
+	var dint jbig2IntContexts
+	for _, v := range ints {
+		if got, ok := dint.decode(d); got != v || !ok {
+			t.Errorf("decode() = %d, %v, expected %d", got, ok, v)
+		}
+	}
+	if _, ok := dint.decode(d); ok {
+		t.Errorf("decode() expected OOB")
+	}
+	if got := decodeIAID(d, make([]mqContext, 1<<6), 5); got != 21 {
+		t.Errorf("decodeIAID() = %d, expected 21", got)
+	}
+}
+
+func TestDecodeJBIG2(t *testing.T) {
+	nominal := []byte{3, 0xff, 0xfd, 0xff, 2, 0xfe, 0xfe, 0xfe}
+
+	generic := func(template int, tpgdon bool, at []jbig2Pixel, atBytes []byte, bm *jbig2Bitmap) []byte {
+		e := newMQEncoder()
+		e.encodeGeneric(make([]mqContext, 1<<16), bm, template, tpgdon, at)
+		flags := byte(template << 1)
+		if tpgdon {
+			flags |= 8
+		}
+		return concat(regionInfoBytes(bm.width, bm.height, 0, 0), []byte{flags}, atBytes, e.flush())
+	}
+
+	pattern := bitmapOf(
+		"1011001110110",
+		"1011001110110",
+		"0000000000000",
+		"1111100001011",
+		"0110011101100",
+	)
+
+	a0, a1 := bitmapOf("10", "01", "10"), bitmapOf("111", "010", "010")
+
+	type testCase struct {
+		name     string
+		globals  []byte
+		data     []byte
+		expected []string
+	}
+	var tests []testCase
+
+	for _, tc := range []struct {
+		name     string
+		template int
+		at       []jbig2Pixel
+		atBytes  []byte
+	}{
+		{"GenericTemplate0", 0, nominalGenericAT, nominal},
+		{"GenericMovedAT", 0, []jbig2Pixel{{-1, -1}, {-4, -1}, {0, -2}, {-3, 0}}, []byte{0xff, 0xff, 0xfc, 0xff, 0, 0xfe, 0xfd, 0}},
+		{"GenericTemplate1", 1, []jbig2Pixel{{3, -1}}, []byte{3, 0xff}},
+		{"GenericTemplate2", 2, []jbig2Pixel{{2, -1}}, []byte{2, 0xff}},
+		{"GenericTemplate3", 3, []jbig2Pixel{{-5, 0}}, []byte{0xfb, 0}},
+	} {
+		tests = append(tests, testCase{
+			name: tc.name,
+			data: concat(
+				segmentBytes(0, jbig2PageInfo, nil, pageInfoBytes(13, 5)),
+				segmentBytes(1, jbig2ImmediateLossless, nil, generic(tc.template, true, tc.at, tc.atBytes, pattern)),
+			),
+			expected: []string{"1011001110110", "1011001110110", "0000000000000", "1111100001011", "0110011101100"},
+		})
+	}
+
+	// Group 4 coded generic region: black 2-4 on both rows.
+	tests = append(tests, testCase{
+		name: "MMR",
+		data: concat(
+			segmentBytes(0, jbig2PageInfo, nil, pageInfoBytes(8, 2)),
+			segmentBytes(1, jbig2ImmediateGeneric, nil, concat(regionInfoBytes(8, 2, 0, 0), []byte{1},
+				ccittBits("001 0111 10 1"+"1 1 1"+"000000000001 000000000001"))),
+		),
+		expected: []string{"00111000", "00111000"},
+	})
+
+	// Huffman-coded symbol dictionary with an uncompressed collective
+	// bitmap, and a Huffman-coded text region placing both symbols.
+	huffDict := concat(
+		[]byte{0x00, 0x01}, be32Bytes(2), be32Bytes(2),
+		// HCDH 3, DW 2, DW 1, OOB, BMSIZE 0.
+		ccittBits("110 110 10 111111 0 0000"),
+		[]byte{0xb8, 0x50, 0x90},
+		// Export runs 0 and 2.
+		ccittBits("0 0000 0 0010"),
+	)
+	huffText := concat(
+		regionInfoBytes(8, 3, 0, 0),
+		[]byte{0x00, 0x11}, []byte{0x00, 0x00}, be32Bytes(2),
+		// Run code 1 has a one-bit code; both symbols have one-bit IDs.
+		ccittBits("0000 0001"+strings.Repeat("0000", 33)+"0 0"),
+		// DT 1, DT 1, FS 1, ID 0, DS 2, ID 1, OOB.
+		ccittBits("0 0 00 0000001 0 11010 1 01"),
+	)
+	tests = append(tests, testCase{
+		name: "HuffmanText",
+		data: concat(
+			segmentBytes(0, jbig2SymbolDict, nil, huffDict),
+			segmentBytes(1, jbig2PageInfo, nil, pageInfoBytes(8, 3)),
+			segmentBytes(2, jbig2ImmediateText, []int{0}, huffText),
+		),
+		expected: []string{"01001110", "00100100", "01000100"},
+	})
+
+	// Arithmetically coded symbol dictionary, in the globals, and text
+	// region.
+	{
+		e := newMQEncoder()
+		a := testArith(1)
+		e.encodeInt(&a.iadh, 3, false)
+		e.encodeInt(&a.iadw, 2, false)
+		e.encodeGeneric(a.gb, a0, 0, false, nominalGenericAT)
+		e.encodeInt(&a.iadw, 1, false)
+		e.encodeGeneric(a.gb, a1, 0, false, nominalGenericAT)
+		e.encodeInt(&a.iadw, 0, true)
+		e.encodeInt(&a.iaex, 0, false)
+		e.encodeInt(&a.iaex, 2, false)
+		dict := concat([]byte{0x00, 0x00}, nominal, be32Bytes(2), be32Bytes(2), e.flush())
+
+		e = newMQEncoder()
+		e.encodeText(testArith(1), []*jbig2Bitmap{a0, a1}, []textInstance{{0, 1}, {1, 4}}, 1, false)
+		text := concat(regionInfoBytes(8, 3, 0, 0), []byte{0x00, 0x10}, be32Bytes(2), e.flush())
+
+		tests = append(tests, testCase{
+			name:    "ArithmeticText",
+			globals: segmentBytes(0, jbig2SymbolDict, nil, dict),
+			data: concat(
+				segmentBytes(1, jbig2PageInfo, nil, pageInfoBytes(8, 3)),
+				segmentBytes(2, jbig2ImmediateText, []int{0}, text),
+				segmentBytes(3, jbig2EndOfPage, nil, nil),
+			),
+			expected: []string{"01001110", "00100100", "01000100"},
+		})
+	}
+
+	// A dictionary whose symbols refine and aggregate those of another.
+	{
+		e := newMQEncoder()
+		a := testArith(1)
+		e.encodeInt(&a.iadh, 3, false)
+		e.encodeInt(&a.iadw, 2, false)
+		e.encodeGeneric(a.gb, a0, 0, false, nominalGenericAT)
+		e.encodeInt(&a.iadw, 1, false)
+		e.encodeGeneric(a.gb, a1, 0, false, nominalGenericAT)
+		e.encodeInt(&a.iadw, 0, true)
+		e.encodeInt(&a.iaex, 0, false)
+		e.encodeInt(&a.iaex, 2, false)
+		base := concat([]byte{0x00, 0x00}, nominal, be32Bytes(2), be32Bytes(2), e.flush())
+
+		rat := []jbig2Pixel{{-1, -1}, {-1, -1}}
+		b0 := bitmapOf("11", "01", "10")
+		e = newMQEncoder()
+		a = testArith(2)
+		e.encodeInt(&a.iadh, 3, false)
+		e.encodeInt(&a.iadw, 2, false)
+		e.encodeInt(&a.iaai, 1, false)
+		e.encodeIAID(a.iaid, 0, 2)
+		e.encodeInt(&a.iardx, 0, false)
+		e.encodeInt(&a.iardy, 0, false)
+		e.encodeRefinement(a.gr, b0, 0, a0, 0, 0, false, rat)
+		e.encodeInt(&a.iadw, 3, false)
+		e.encodeInt(&a.iaai, 2, false)
+		e.encodeText(a, []*jbig2Bitmap{a0, a1, b0}, []textInstance{{0, 0}, {1, 2}}, 2, true)
+		e.encodeInt(&a.iadw, 0, true)
+		e.encodeInt(&a.iaex, 2, false)
+		e.encodeInt(&a.iaex, 2, false)
+		agg := concat([]byte{0x00, 0x02}, nominal, []byte{0xff, 0xff, 0xff, 0xff}, be32Bytes(2), be32Bytes(2), e.flush())
+
+		e = newMQEncoder()
+		e.encodeText(testArith(1), []*jbig2Bitmap{b0, bitmapOf("10111", "01010", "10010")}, []textInstance{{0, 0}, {1, 3}}, 1, false)
+		text := concat(regionInfoBytes(8, 3, 0, 0), []byte{0x00, 0x10}, be32Bytes(2), e.flush())
+
+		tests = append(tests, testCase{
+			name: "RefinementAggregate",
+			data: concat(
+				segmentBytes(0, jbig2SymbolDict, nil, base),
+				segmentBytes(1, jbig2SymbolDict, []int{0}, agg),
+				segmentBytes(2, jbig2PageInfo, nil, pageInfoBytes(8, 3)),
+				segmentBytes(3, jbig2ImmediateText, []int{1}, text),
+			),
+			expected: []string{"11010111", "01001010", "10010010"},
+		})
+	}
+
+	// An immediate refinement region refining the page, with typical
+	// prediction.
+	{
+		coarse := bitmapOf("11110000", "11110000", "11110000", "00001111")
+		fine := bitmapOf("11110000", "11100000", "11110000", "00001111")
+
+		e := newMQEncoder()
+		e.encodeRefinement(make([]mqContext, 1<<13), fine, 0, coarse, 0, 0, true, []jbig2Pixel{{-1, -1}, {-1, -1}})
+		refine := concat(regionInfoBytes(8, 4, 0, 0), []byte{0x02}, []byte{0xff, 0xff, 0xff, 0xff}, e.flush())
+
+		tests = append(tests, testCase{
+			name: "Refinement",
+			data: concat(
+				segmentBytes(0, jbig2PageInfo, nil, pageInfoBytes(8, 4)),
+				segmentBytes(1, jbig2ImmediateLossless, nil, generic(0, false, nominalGenericAT, nominal, coarse)),
+				segmentBytes(2, jbig2ImmediateLosslessRef, nil, refine),
+			),
+			expected: []string{"11110000", "11100000", "11110000", "00001111"},
+		})
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewObjectTable()
+			dict := model.PDFDict{"Filter": model.PDFName("JBIG2Decode")}
+			if tc.globals != nil {
+				// The globals are themselves compressed, as is usual.
+				r.Add(&model.PDFObject{Number: 5, Value: model.PDFStream{
+					Dict: model.PDFDict{"Filter": model.PDFName("FlateDecode")},
+					Data: deflate(tc.globals),
+				}})
+				dict["DecodeParms"] = model.PDFDict{"JBIG2Globals": model.PDFIndirectRef{ObjectNumber: 5}}
+			}
+
+			got, err := DecodeStream(model.PDFStream{Dict: dict, Data: tc.data}, r)
+			if err != nil {
+				t.Fatalf("DecodeStream() error = %v", err)
+			}
+
+			// JBIG2 codes black as 1, the filter's output as 0.
+			var expected []byte
+			for _, row := range tc.expected {
+				packed := make([]byte, (len(row)+7)/8)
+				for i := range packed {
+					packed[i] = 0xff
+				}
+				for x, c := range row {
+					if c == '1' {
+						packed[x/8] &^= 0x80 >> (x % 8)
+					}
+				}
+				expected = append(expected, packed...)
+			}
+			if !bytes.Equal(got, expected) {
+				t.Errorf("DecodeStream() = %x, expected %x", got, expected)
+			}
+		})
+	}
+
+	s := model.PDFStream{Dict: model.PDFDict{"Filter": model.PDFName("JBIG2Decode")}, Data: []byte("not JBIG2")}
+	if _, err := DecodeStream(s, NewObjectTable()); err == nil {
+		t.Errorf("DecodeStream() of invalid data expected an error")
+	}
+}
+
+func TestJBIG2PixelBudget(t *testing.T) {
+	// A page and a region of the largest size fit; a third bitmap does
+	// not, however small.
+	var doc jbig2Document
+	for i, expectErr := range []bool{false, false, true} {
+		w, h := 1<<14, 1<<14
+		if i == 2 {
+			w, h = 1, 1
+		}
+		if err := doc.reserve(w, h); (err != nil) != expectErr {
+			t.Errorf("reserve(%d, %d) error = %v with %d pixels reserved", w, h, err, doc.pixels)
+		}
+	}
+	if err := doc.reserve(1<<15, 1<<14); err == nil {
+		t.Errorf("reserve() of a bitmap too large expected an error")
+	}
+
+	// Bitmaps take one bit per pixel.
+	if bm := newJBIG2Bitmap(9, 2); len(bm.pix) != 4 {
+		t.Errorf("9x2 bitmap takes %d bytes, expected 4", len(bm.pix))
+	}
+}
@@ -0,0 +1,469 @@
+package parser
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// jbig2Arith holds an arithmetic decoder and the contexts of the decoding
+// procedures that share it within a segment.
+type jbig2Arith struct {
+	d  *mqDecoder
+	gb []mqContext
+	gr []mqContext
+
+	iadh, iadw, iaex, iaai       jbig2IntContexts
+	iadt, iafs, iads, iait, iari jbig2IntContexts
+	iardw, iardh, iardx, iardy   jbig2IntContexts
+	iaid                         []mqContext
+}
+
+func newJBIG2Arith(data []byte, symCodeLen int) *jbig2Arith {
+	return &jbig2Arith{
+		d:    newMQDecoder(data),
+		gb:   make([]mqContext, 1<<16),
+		gr:   make([]mqContext, 1<<13),
+		iaid: make([]mqContext, 1<<(symCodeLen+1)),
+	}
+}
+
+// symbolCodeLength returns the number of bits needed to code n symbol IDs.
+func symbolCodeLength(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len(uint(n - 1))
+}
+
+// maxJBIG2Pixels bounds the size of the bitmaps a segment may declare, and
+// maxJBIG2PagePixels the combined size of a page and its regions.
+const (
+	maxJBIG2Pixels     = 1 << 28
+	maxJBIG2PagePixels = 1 << 29
+)
+
+func checkJBIG2Size(w, h int) error {
+	if w < 0 || h < 0 || h > 0 && w > maxJBIG2Pixels/h {
+		return fmt.Errorf("invalid bitmap size %dx%d", w, h)
+	}
+	return nil
+}
+
+// symbolDict holds the parameters of a symbol dictionary segment.
+type symbolDict struct {
+	huffman   bool
+	refAgg    bool
+	template  int
+	at        []jbig2Pixel
+	rtemplate int
+	rat       []jbig2Pixel
+
+	numExported int
+	numNew      int
+
+	// Tables used when huffman is set.
+	dh, dw, bmSize huffmanTable
+}
+
+// decodeSymbolDict decodes the data of a symbol dictionary whose input
+// symbols are in, returning the exported symbols (T.88 6.5.5).
+func decodeSymbolDict(p *symbolDict, in []*jbig2Bitmap, data []byte) ([]*jbig2Bitmap, error) {
+	if p.huffman && p.refAgg {
+		return nil, fmt.Errorf("Huffman-coded refinement/aggregate symbols are not supported")
+	}
+	if p.numNew < 0 || p.numNew > len(data)*8 {
+		return nil, fmt.Errorf("invalid number of new symbols %d", p.numNew)
+	}
+
+	symCodeLen := symbolCodeLength(len(in) + p.numNew)
+
+	// Huffman-coded dictionaries leave the contexts of a unused.
+	a := &jbig2Arith{}
+	var br *util.BitReader
+	if p.huffman {
+		br = util.NewBitReader(data)
+	} else {
+		a = newJBIG2Arith(data, symCodeLen)
+	}
+
+	readInt := func(cx *jbig2IntContexts, t huffmanTable) (int, bool, error) {
+		if p.huffman {
+			return t.decode(br)
+		}
+		v, ok := cx.decode(a.d)
+		return v, ok, nil
+	}
+
+	syms := append([]*jbig2Bitmap(nil), in...)
+	height := 0
+	for len(syms)-len(in) < p.numNew {
+		dh, _, err := readInt(&a.iadh, p.dh)
+		if err != nil {
+			return nil, err
+		}
+		height += dh
+		if height < 0 {
+			return nil, fmt.Errorf("negative symbol height")
+		}
+
+		var widths []int
+		width, total := 0, 0
+		for {
+			dw, ok, err := readInt(&a.iadw, p.dw)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			if len(syms)-len(in) >= p.numNew {
+				return nil, fmt.Errorf("too many symbols in height class")
+			}
+
+			width += dw
+			total += width
+			if err := checkJBIG2Size(width, height); err != nil {
+				return nil, err
+			}
+
+			switch {
+			case p.huffman:
+				widths = append(widths, width)
+			case !p.refAgg:
+				syms = append(syms, decodeGenericRegion(a.d, a.gb, width, height, p.template, false, p.at))
+			default:
+				bm, err := p.decodeAggregate(a, syms, symCodeLen, width, height)
+				if err != nil {
+					return nil, err
+				}
+				syms = append(syms, bm)
+			}
+		}
+
+		if p.huffman {
+			// The symbols of a height class are coded as one bitmap.
+			if err := checkJBIG2Size(total, height); err != nil {
+				return nil, err
+			}
+			collective, err := p.decodeCollective(br, data, total, height)
+			if err != nil {
+				return nil, err
+			}
+			x := 0
+			for _, w := range widths {
+				syms = append(syms, collective.sub(x, 0, w, height))
+				x += w
+			}
+		}
+	}
+
+	// Runs of alternating export flags select the symbols to export.
+	var out []*jbig2Bitmap
+	export := false
+	for i, n := 0, 0; i < len(syms); n++ {
+		run, _, err := readInt(&a.iaex, standardHuffmanTables[1])
+		if err != nil {
+			return nil, err
+		}
+		if run < 0 || run > len(syms)-i || n > 2*len(syms)+1 {
+			return nil, fmt.Errorf("invalid export flags")
+		}
+		if export {
+			out = append(out, syms[i:i+run]...)
+		}
+		i += run
+		export = !export
+	}
+
+	return out, nil
+}
+
+// decodeAggregate decodes a symbol refined from, or aggregated out of,
+// earlier symbols.
+func (p *symbolDict) decodeAggregate(a *jbig2Arith, syms []*jbig2Bitmap, symCodeLen, width, height int) (*jbig2Bitmap, error) {
+	n, _ := a.iaai.decode(a.d)
+	if n == 1 {
+		id := decodeIAID(a.d, a.iaid, symCodeLen)
+		dx, _ := a.iardx.decode(a.d)
+		dy, _ := a.iardy.decode(a.d)
+		if id >= len(syms) {
+			return nil, fmt.Errorf("invalid symbol ID %d", id)
+		}
+		return decodeRefinementRegion(a.d, a.gr, width, height, p.rtemplate, syms[id], dx, dy, false, p.rat), nil
+	}
+
+	t := &textRegion{
+		width:        width,
+		height:       height,
+		refine:       true,
+		refCorner:    jbig2TopLeft,
+		rtemplate:    p.rtemplate,
+		rat:          p.rat,
+		numInstances: n,
+		symbols:      syms,
+		symCodeLen:   symCodeLen,
+	}
+	return decodeTextRegion(t, a, nil)
+}
+
+// decodeCollective reads the bitmap holding the symbols of a height class
+// of a Huffman-coded symbol dictionary: uncompressed or MMR-coded.
+func (p *symbolDict) decodeCollective(br *util.BitReader, data []byte, w, h int) (*jbig2Bitmap, error) {
+	size, _, err := p.bmSize.decode(br)
+	if err != nil {
+		return nil, err
+	}
+	br.Align()
+
+	start := br.BytePos()
+	if size == 0 {
+		size = (w + 7) / 8 * h
+	}
+	if size < 0 || start+size > len(data) {
+		return nil, fmt.Errorf("truncated symbol bitmap")
+	}
+	br.Skip(size * 8)
+
+	bm := newJBIG2Bitmap(w, h)
+	if size == (w+7)/8*h {
+		bm.setPacked(data[start : start+size])
+		return bm, nil
+	}
+	return decodeMMRRegion(data[start:start+size], w, h)
+}
+
+// Reference corners of text regions.
+const (
+	jbig2BottomLeft = iota
+	jbig2TopLeft
+	jbig2BottomRight
+	jbig2TopRight
+)
+
+// textRegion holds the parameters of a text region.
+type textRegion struct {
+	width, height int
+	huffman       bool
+	refine        bool
+	logStrips     int
+	refCorner     int
+	transposed    bool
+	combOp        int
+	defPixel      byte
+	dsOffset      int
+	rtemplate     int
+	rat           []jbig2Pixel
+	numInstances  int
+
+	symbols    []*jbig2Bitmap
+	symCodeLen int
+
+	// Tables used when huffman is set.
+	fs, ds, dt, rdw, rdh, rdx, rdy huffmanTable
+	symCodes                       huffmanTable
+}
+
+// decodeTextRegion decodes a text region from a, or from br when it is
+// Huffman-coded, in which case the contexts of a are unused (T.88 6.4.5).
+func decodeTextRegion(p *textRegion, a *jbig2Arith, br *util.BitReader) (*jbig2Bitmap, error) {
+	if err := checkJBIG2Size(p.width, p.height); err != nil {
+		return nil, err
+	}
+
+	bm := newJBIG2Bitmap(p.width, p.height)
+	bm.fillRows(0, p.defPixel)
+
+	readInt := func(cx *jbig2IntContexts, t huffmanTable) (int, bool, error) {
+		if p.huffman {
+			return t.decode(br)
+		}
+		v, ok := cx.decode(a.d)
+		return v, ok, nil
+	}
+
+	strips := 1 << p.logStrips
+	stripT, _, err := readInt(&a.iadt, p.dt)
+	if err != nil {
+		return nil, err
+	}
+	stripT *= -strips
+
+	firstS := 0
+	for n := 0; n < p.numInstances; {
+		dt, _, err := readInt(&a.iadt, p.dt)
+		if err != nil {
+			return nil, err
+		}
+		stripT += dt * strips
+
+		dfs, _, err := readInt(&a.iafs, p.fs)
+		if err != nil {
+			return nil, err
+		}
+		firstS += dfs
+		curS := firstS
+
+		// Each strip ends with an out-of-band S offset.
+		for {
+			if n >= p.numInstances {
+				return nil, fmt.Errorf("too many symbol instances")
+			}
+
+			curT := 0
+			if strips > 1 {
+				if p.huffman {
+					v, ok := br.ReadBits(p.logStrips)
+					if !ok {
+						return nil, fmt.Errorf("truncated text region")
+					}
+					curT = int(v)
+				} else {
+					curT, _ = a.iait.decode(a.d)
+				}
+			}
+			t := stripT + curT
+
+			var id int
+			if p.huffman {
+				if id, _, err = p.symCodes.decode(br); err != nil {
+					return nil, err
+				}
+			} else {
+				id = decodeIAID(a.d, a.iaid, p.symCodeLen)
+			}
+			if id < 0 || id >= len(p.symbols) {
+				return nil, fmt.Errorf("invalid symbol ID %d", id)
+			}
+			sym := p.symbols[id]
+
+			if p.refine {
+				ri := 0
+				if p.huffman {
+					v, _ := br.ReadBit()
+					ri = int(v)
+				} else {
+					ri, _ = a.iari.decode(a.d)
+				}
+				if ri != 0 {
+					if p.huffman {
+						return nil, fmt.Errorf("Huffman-coded symbol refinement is not supported")
+					}
+					rdw, _ := a.iardw.decode(a.d)
+					rdh, _ := a.iardh.decode(a.d)
+					rdx, _ := a.iardx.decode(a.d)
+					rdy, _ := a.iardy.decode(a.d)
+
+					w, h := sym.width+rdw, sym.height+rdh
+					if err := checkJBIG2Size(w, h); err != nil {
+						return nil, err
+					}
+					sym = decodeRefinementRegion(a.d, a.gr, w, h, p.rtemplate, sym,
+						floorDiv(rdw, 2)+rdx, floorDiv(rdh, 2)+rdy, false, p.rat)
+				}
+			}
+
+			// curS runs along the strip and t across it; the reference
+			// corner of the symbol is placed at their intersection.
+			right := p.refCorner == jbig2TopRight || p.refCorner == jbig2BottomRight
+			bottom := p.refCorner == jbig2BottomLeft || p.refCorner == jbig2BottomRight
+
+			extent := sym.width
+			if p.transposed {
+				extent = sym.height
+			}
+			if p.transposed && bottom || !p.transposed && right {
+				curS += extent - 1
+			}
+
+			x, y := curS, t
+			if p.transposed {
+				x, y = t, curS
+			}
+			if right {
+				x -= sym.width - 1
+			}
+			if bottom {
+				y -= sym.height - 1
+			}
+			bm.compose(sym, x, y, p.combOp)
+
+			if p.transposed && !bottom || !p.transposed && !right {
+				curS += extent - 1
+			}
+			n++
+
+			ids, ok, err := readInt(&a.iads, p.ds)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			curS += ids + p.dsOffset
+		}
+	}
+
+	return bm, nil
+}
+
+// floorDiv divides rounding towards negative infinity.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// parseSymbolCodes reads the run-length coded lengths of the symbol ID
+// codes of a Huffman-coded text region (T.88 7.4.4.1.7).
+func parseSymbolCodes(br *util.BitReader, numSyms int) (huffmanTable, error) {
+	var runLines []huffmanLine
+	for i := 0; i < 35; i++ {
+		n, ok := br.ReadBits(4)
+		if !ok {
+			return nil, fmt.Errorf("truncated symbol ID table")
+		}
+		runLines = append(runLines, huffmanLine{low: i, preflen: int(n)})
+	}
+	runCodes := newHuffmanTable(runLines)
+
+	lengths := make([]int, 0, numSyms)
+	for len(lengths) < numSyms {
+		code, _, err := runCodes.decode(br)
+		if err != nil {
+			return nil, err
+		}
+
+		var v, repeat int
+		switch {
+		case code < 32:
+			v, repeat = code, 1
+		case code == 32:
+			if len(lengths) == 0 {
+				return nil, fmt.Errorf("invalid symbol ID table")
+			}
+			r, _ := br.ReadBits(2)
+			v, repeat = lengths[len(lengths)-1], 3+int(r)
+		case code == 33:
+			r, _ := br.ReadBits(3)
+			repeat = 3 + int(r)
+		default:
+			r, _ := br.ReadBits(7)
+			repeat = 11 + int(r)
+		}
+
+		for i := 0; i < repeat && len(lengths) < numSyms; i++ {
+			lengths = append(lengths, v)
+		}
+	}
+	br.Align()
+
+	lines := make([]huffmanLine, numSyms)
+	for i, n := range lengths {
+		lines[i] = huffmanLine{low: i, preflen: n}
+	}
+	return newHuffmanTable(lines), nil
+}
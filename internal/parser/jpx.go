@@ -0,0 +1,390 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// decodeJPX decodes JPEG 2000 data into the 8-bit samples of its colour
+// channels, interleaved. Opacity channels are dropped; images with
+// /SMaskInData read them through DecodeJPX.
+func decodeJPX(data []byte, _ model.PDFDict) ([]byte, error) {
+	img, err := DecodeJPX(data, false)
+	if err != nil {
+		return nil, err
+	}
+	return img.Samples, nil
+}
+
+// JPXImage is a decoded JPEG 2000 image.
+type JPXImage struct {
+	Width, Height int
+
+	// NComponents is the number of colour channels and Samples holds
+	// them for each pixel in turn, 8 bits each.
+	NComponents int
+	Samples     []byte
+
+	// Alpha holds the opacity of each pixel, or is nil if the image has
+	// no opacity channel.
+	Alpha []byte
+
+	// ColorSpace names the device space matching the colour space the
+	// file declares, or is empty if it declares none. ICCProfile holds an
+	// embedded ICC profile.
+	ColorSpace string
+	ICCProfile []byte
+}
+
+// JP2 colour space enumerations (ITU T.800 Table I.10 and T.801 Table
+// M.25).
+const (
+	jpxCMYK  = 12
+	jpxSRGB  = 16
+	jpxGray  = 17
+	jpxSYCC  = 18
+	jpxESRGB = 20
+	jpxROMM  = 21
+	jpxESYCC = 24
+)
+
+// jpxBoxes holds what the decoder uses from the boxes of a JP2 or JPX file.
+type jpxBoxes struct {
+	codestream []byte
+
+	enumCS int
+	icc    []byte
+
+	// palette holds the columns of a pclr box, with the bit depth of
+	// each, and mapping the cmap box's (component, type, column) triples.
+	palette  [][]uint16
+	palDepth []int
+	mapping  [][3]int
+
+	// channels holds the (channel, type, association) triples of a cdef
+	// box.
+	channels [][3]int
+}
+
+// DecodeJPX decodes a JPEG 2000 codestream or a JP2 or JPX file holding
+// one. When smaskInData is set and the file does not say which channels
+// are colours, a channel beyond those of its colour space is taken as
+// opacity, as for images with /SMaskInData.
+func DecodeJPX(data []byte, smaskInData bool) (*JPXImage, error) {
+	var boxes jpxBoxes
+	if bytes.HasPrefix(data, []byte{0xff, 0x4f}) {
+		boxes.codestream = data
+	} else if err := boxes.parse(data); err != nil {
+		return nil, err
+	}
+	if boxes.codestream == nil {
+		return nil, fmt.Errorf("no codestream")
+	}
+
+	cs, err := parseJPXCodestream(boxes.codestream)
+	if err != nil {
+		return nil, err
+	}
+	planes, err := cs.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &cs.size
+	img := &JPXImage{Width: s.x1 - s.x0, Height: s.y1 - s.y0, ICCProfile: boxes.icc}
+	channels, err := boxes.channelSamples(planes, s.x0, s.y0, img.Width, img.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	ncolor := 0
+	switch boxes.enumCS {
+	case jpxGray:
+		img.ColorSpace, ncolor = "DeviceGray", 1
+	case jpxSRGB, jpxESRGB, jpxROMM, jpxSYCC, jpxESYCC:
+		img.ColorSpace, ncolor = "DeviceRGB", 3
+	case jpxCMYK:
+		img.ColorSpace, ncolor = "DeviceCMYK", 4
+	}
+	if len(boxes.icc) >= 20 {
+		switch string(boxes.icc[16:20]) {
+		case "GRAY":
+			ncolor = 1
+		case "RGB ":
+			ncolor = 3
+		case "CMYK":
+			ncolor = 4
+		}
+	}
+
+	// Channels are colours in order unless a cdef box says otherwise.
+	var colors [][]byte
+	var alpha []byte
+	premultiplied := false
+	if boxes.channels != nil {
+		colors = make([][]byte, len(channels))
+		for _, ch := range boxes.channels {
+			if ch[0] >= len(channels) {
+				continue
+			}
+			switch ch[1] {
+			case 0:
+				if ch[2] >= 1 && ch[2] <= len(colors) {
+					colors[ch[2]-1] = channels[ch[0]]
+				}
+			case 1, 2:
+				alpha = channels[ch[0]]
+				premultiplied = ch[1] == 2
+			}
+		}
+		colors = compactChannels(colors)
+	} else {
+		colors = channels
+		if ncolor == 0 {
+			ncolor = len(channels)
+			if smaskInData && (ncolor == 2 || ncolor == 4) {
+				ncolor--
+			}
+		}
+		if ncolor < len(channels) {
+			colors = channels[:ncolor]
+			if smaskInData {
+				alpha = channels[ncolor]
+			}
+		}
+	}
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("no colour channels")
+	}
+
+	if premultiplied {
+		for _, c := range colors {
+			for i, a := range alpha {
+				if a != 0 {
+					c[i] = byte(min(255, int(c[i])*255/int(a)))
+				}
+			}
+		}
+	}
+
+	if (boxes.enumCS == jpxSYCC || boxes.enumCS == jpxESYCC) && len(colors) == 3 {
+		yccToRGB(colors[0], colors[1], colors[2])
+	}
+
+	img.NComponents = len(colors)
+	img.Samples = make([]byte, 0, len(colors)*img.Width*img.Height)
+	for i := range img.Width * img.Height {
+		for _, c := range colors {
+			img.Samples = append(img.Samples, c[i])
+		}
+	}
+	img.Alpha = alpha
+
+	return img, nil
+}
+
+// compactChannels drops the colours a cdef box left unassigned.
+func compactChannels(chs [][]byte) [][]byte {
+	out := chs[:0]
+	for _, c := range chs {
+		if c != nil {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// yccToRGB converts sYCC samples to sRGB in place.
+func yccToRGB(y, cb, cr []byte) {
+	clamp := func(v float32) byte { return byte(max(0, min(255, v+0.5))) }
+	for i := range y {
+		l, u, v := float32(y[i]), float32(cb[i])-128, float32(cr[i])-128
+		y[i], cb[i], cr[i] = clamp(l+1.402*v), clamp(l-0.34413*u-0.71414*v), clamp(l+1.772*u)
+	}
+}
+
+// parse reads the boxes of a JP2 or JPX file (T.800 Annex I).
+func (b *jpxBoxes) parse(data []byte) error {
+	for len(data) >= 8 {
+		length := int(be32(data))
+		kind := string(data[4:8])
+		header := 8
+		switch length {
+		case 0:
+			length = len(data)
+		case 1:
+			if len(data) < 16 || be32(data[8:]) != 0 {
+				return fmt.Errorf("invalid box length")
+			}
+			length, header = int(be32(data[12:])), 16
+		}
+		if length < header || length > len(data) {
+			// Tolerate a truncated last box.
+			length = len(data)
+		}
+		body := data[header:length]
+		data = data[length:]
+
+		switch kind {
+		case "jp2h", "jpch":
+			if err := b.parse(body); err != nil {
+				return err
+			}
+		case "jp2c":
+			if b.codestream == nil {
+				b.codestream = body
+			}
+		case "colr":
+			b.parseColor(body)
+		case "pclr":
+			if err := b.parsePalette(body); err != nil {
+				return err
+			}
+		case "cmap":
+			for ; len(body) >= 4; body = body[4:] {
+				b.mapping = append(b.mapping, [3]int{int(body[0])<<8 | int(body[1]), int(body[2]), int(body[3])})
+			}
+		case "cdef":
+			if len(body) < 2 {
+				continue
+			}
+			n := int(body[0])<<8 | int(body[1])
+			b.channels = [][3]int{}
+			for body = body[2:]; n > 0 && len(body) >= 6; n, body = n-1, body[6:] {
+				b.channels = append(b.channels, [3]int{
+					int(body[0])<<8 | int(body[1]),
+					int(body[2])<<8 | int(body[3]),
+					int(body[4])<<8 | int(body[5]),
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// parseColor reads a colr box. Only the first box that gives an
+// enumerated colour space or ICC profile is used.
+func (b *jpxBoxes) parseColor(body []byte) {
+	if len(body) < 3 || b.enumCS != 0 || b.icc != nil {
+		return
+	}
+	switch body[0] {
+	case 1:
+		if len(body) >= 7 {
+			b.enumCS = int(be32(body[3:]))
+		}
+	case 2, 3:
+		b.icc = body[3:]
+	}
+}
+
+// parsePalette reads a pclr box.
+func (b *jpxBoxes) parsePalette(body []byte) error {
+	if len(body) < 3 {
+		return fmt.Errorf("truncated palette")
+	}
+	entries := int(body[0])<<8 | int(body[1])
+	cols := int(body[2])
+	if len(body) < 3+cols {
+		return fmt.Errorf("truncated palette")
+	}
+
+	b.palDepth = make([]int, cols)
+	size := 0
+	for i := range cols {
+		b.palDepth[i] = int(body[3+i]&0x7f) + 1
+		if b.palDepth[i] > 16 {
+			return fmt.Errorf("unsupported palette depth %d", b.palDepth[i])
+		}
+		size += ceilDiv(b.palDepth[i], 8)
+	}
+
+	data := body[3+cols:]
+	if len(data) < entries*size {
+		return fmt.Errorf("truncated palette")
+	}
+	b.palette = make([][]uint16, cols)
+	for range entries {
+		for i, d := range b.palDepth {
+			v := uint16(data[0])
+			if d > 8 {
+				v = v<<8 | uint16(data[1])
+			}
+			data = data[ceilDiv(d, 8):]
+			b.palette[i] = append(b.palette[i], v)
+		}
+	}
+	return nil
+}
+
+// channelSamples returns the 8-bit samples of each channel of the image
+// over the w by h pixels from (x0, y0) on the reference grid. Channels are
+// the components, or the mapping of a palette.
+func (b *jpxBoxes) channelSamples(planes []*jpxPlane, x0, y0, w, h int) ([][]byte, error) {
+	// sample returns the samples of plane p, upsampled to the image grid.
+	sample := func(p *jpxPlane) []uint16 {
+		out := make([]uint16, w*h)
+		for y := range h {
+			py := min(max((y0+y)/p.dy-p.y0, 0), p.h-1)
+			for x := range w {
+				px := min(max((x0+x)/p.dx-p.x0, 0), p.w-1)
+				out[y*w+x] = p.pix[py*p.w+px]
+			}
+		}
+		return out
+	}
+
+	if b.palette == nil || b.mapping == nil {
+		out := make([][]byte, len(planes))
+		for i, p := range planes {
+			out[i] = to8Bit(sample(p), p.precision)
+		}
+		return out, nil
+	}
+
+	out := make([][]byte, len(b.mapping))
+	for i, m := range b.mapping {
+		if m[0] >= len(planes) {
+			return nil, fmt.Errorf("invalid component mapping")
+		}
+		p := planes[m[0]]
+		vals := sample(p)
+		if m[1] == 0 {
+			out[i] = to8Bit(vals, p.precision)
+			continue
+		}
+
+		if m[2] >= len(b.palette) {
+			return nil, fmt.Errorf("invalid palette column")
+		}
+		col := b.palette[m[2]]
+		for j, v := range vals {
+			if int(v) < len(col) {
+				vals[j] = col[v]
+			} else {
+				vals[j] = 0
+			}
+		}
+		out[i] = to8Bit(vals, b.palDepth[m[2]])
+	}
+	return out, nil
+}
+
+// to8Bit scales samples of the given precision to 8 bits.
+func to8Bit(vals []uint16, precision int) []byte {
+	out := make([]byte, len(vals))
+	maxVal := int(1)<<precision - 1
+	for i, v := range vals {
+		switch {
+		case precision == 8:
+			out[i] = byte(v)
+		case precision > 8:
+			out[i] = byte(int(v) >> (precision - 8))
+		default:
+			out[i] = byte((int(v)*255 + maxVal/2) / maxVal)
+		}
+	}
+	return out
+}
@@ -0,0 +1,524 @@
+package parser
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Codestream markers (ITU T.800 Annex A).
+const (
+	jpxSOC = 0xff4f
+	jpxSIZ = 0xff51
+	jpxCOD = 0xff52
+	jpxCOC = 0xff53
+	jpxQCD = 0xff5c
+	jpxQCC = 0xff5d
+	jpxRGN = 0xff5e
+	jpxPOC = 0xff5f
+	jpxPPM = 0xff60
+	jpxPPT = 0xff61
+	jpxSOT = 0xff90
+	jpxSOP = 0xff91
+	jpxEPH = 0xff92
+	jpxSOD = 0xff93
+	jpxEOC = 0xffd9
+)
+
+// Progression orders.
+const (
+	jpxLRCP = iota
+	jpxRLCP
+	jpxRPCL
+	jpxPCRL
+	jpxCPRL
+)
+
+// maxJPXSamples bounds the number of component samples of an image.
+const maxJPXSamples = 1 << 27
+
+// jpxComponent describes one image component as given by the SIZ segment.
+type jpxComponent struct {
+	precision int
+	signed    bool
+
+	// dx and dy are the sub-sampling factors on the reference grid.
+	dx, dy int
+}
+
+// jpxSize holds the image and tile geometry of the SIZ segment.
+type jpxSize struct {
+	x0, y0, x1, y1 int
+	tileW, tileH   int
+	tileX0, tileY0 int
+	tilesX, tilesY int
+	components     []jpxComponent
+}
+
+// jpxCoding is the coding style of a tile-component, from a COD or COC
+// segment.
+type jpxCoding struct {
+	levels     int
+	cbw, cbh   int
+	style      int
+	reversible bool
+
+	// precincts holds PPx | PPy<<4 for each resolution level, or is nil
+	// for the maximum precinct size.
+	precincts []byte
+}
+
+// jpxCodSegment holds a COD segment: the default coding style and the settings
+// that apply to all components.
+type jpxCodSegment struct {
+	jpxCoding
+	sop, eph bool
+	order    int
+	layers   int
+	mct      bool
+}
+
+// jpxQuant is the quantization of a tile-component, from a QCD or QCC
+// segment. Each step holds an exponent in its top five bits and a mantissa
+// in the low eleven.
+type jpxQuant struct {
+	style int
+	guard int
+	steps []uint16
+}
+
+// jpxProgression is one progression of a POC segment, or the whole of the
+// progression given by COD.
+type jpxProgression struct {
+	resStart, compStart int
+	layerEnd            int
+	resEnd, compEnd     int
+	order               int
+}
+
+// jpxParams holds the segments of the main header or of a tile's headers.
+type jpxParams struct {
+	cod *jpxCodSegment
+	coc map[int]*jpxCoding
+	qcd *jpxQuant
+	qcc map[int]*jpxQuant
+	rgn map[int]int
+	poc []jpxProgression
+}
+
+// jpxTile collects the tile-parts of a tile.
+type jpxTile struct {
+	index  int
+	params jpxParams
+	data   []byte
+
+	// headers holds the packet headers given by PPM or PPT segments, if
+	// they are not in the packets.
+	headers    []byte
+	hasHeaders bool
+}
+
+// jpxCodestream is a parsed JPEG 2000 codestream.
+type jpxCodestream struct {
+	size  jpxSize
+	main  jpxParams
+	tiles []*jpxTile
+}
+
+// parseJPXCodestream reads the headers of a codestream and collects the
+// data of its tiles.
+func parseJPXCodestream(data []byte) (*jpxCodestream, error) {
+	if len(data) < 4 || int(data[0])<<8|int(data[1]) != jpxSOC {
+		return nil, fmt.Errorf("missing SOC marker")
+	}
+
+	cs := &jpxCodestream{}
+	byIndex := map[int]*jpxTile{}
+	var ppm []byte
+	var parts []*jpxTile
+	haveSIZ := false
+
+	// segment reads the marker segment at pos, returning its marker and
+	// contents.
+	segment := func(pos int) (int, []byte, error) {
+		if pos+4 > len(data) {
+			return 0, nil, fmt.Errorf("truncated marker segment")
+		}
+		marker := int(data[pos])<<8 | int(data[pos+1])
+		length := int(data[pos+2])<<8 | int(data[pos+3])
+		if length < 2 || pos+2+length > len(data) {
+			return 0, nil, fmt.Errorf("invalid length of marker %04x", marker)
+		}
+		return marker, data[pos+4 : pos+2+length], nil
+	}
+
+	pos := 2
+	for pos+2 <= len(data) {
+		marker := int(data[pos])<<8 | int(data[pos+1])
+		if marker == jpxEOC {
+			break
+		}
+
+		if marker != jpxSOT {
+			m, seg, err := segment(pos)
+			if err != nil {
+				return nil, err
+			}
+			pos += 4 + len(seg)
+
+			switch m {
+			case jpxSIZ:
+				if cs.size, err = parseJPXSize(seg); err != nil {
+					return nil, err
+				}
+				haveSIZ = true
+			case jpxPPM:
+				if len(seg) > 0 {
+					ppm = append(ppm, seg[1:]...)
+				}
+			default:
+				if !haveSIZ {
+					return nil, fmt.Errorf("marker %04x before SIZ", m)
+				}
+				if err := cs.main.parse(m, seg, len(cs.size.components)); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if !haveSIZ {
+			return nil, fmt.Errorf("missing SIZ marker")
+		}
+		if cs.main.cod == nil || cs.main.qcd == nil {
+			return nil, fmt.Errorf("missing COD or QCD marker")
+		}
+
+		// A tile-part: SOT, its header segments, SOD and the packet data.
+		start := pos
+		_, sot, err := segment(pos)
+		if err != nil {
+			return nil, err
+		}
+		if len(sot) < 8 {
+			return nil, fmt.Errorf("truncated SOT marker")
+		}
+		index := int(sot[0])<<8 | int(sot[1])
+		end := start + int(be32(sot[2:]))
+		if end == start || end > len(data) {
+			end = len(data)
+			if end >= 2 && data[end-2] == 0xff && data[end-1] == 0xd9 {
+				end -= 2
+			}
+		}
+		if index >= cs.size.tilesX*cs.size.tilesY {
+			return nil, fmt.Errorf("invalid tile index %d", index)
+		}
+
+		t := byIndex[index]
+		if t == nil {
+			t = &jpxTile{index: index}
+			byIndex[index] = t
+			cs.tiles = append(cs.tiles, t)
+		}
+		parts = append(parts, t)
+
+		pos += 4 + len(sot)
+		for {
+			if pos+2 > end {
+				return nil, fmt.Errorf("missing SOD marker")
+			}
+			m := int(data[pos])<<8 | int(data[pos+1])
+			if m == jpxSOD {
+				pos += 2
+				break
+			}
+			m, seg, err := segment(pos)
+			if err != nil {
+				return nil, err
+			}
+			pos += 4 + len(seg)
+
+			if m == jpxPPT {
+				if len(seg) > 0 {
+					t.headers = append(t.headers, seg[1:]...)
+				}
+				t.hasHeaders = true
+				continue
+			}
+			if err := t.params.parse(m, seg, len(cs.size.components)); err != nil {
+				return nil, err
+			}
+		}
+
+		if pos < end {
+			t.data = append(t.data, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	if cs.main.cod == nil || cs.main.qcd == nil {
+		return nil, fmt.Errorf("missing COD or QCD marker")
+	}
+
+	// PPM segments hold the packet headers of each tile-part in turn,
+	// each preceded by its length.
+	if ppm != nil {
+		for _, t := range parts {
+			if len(ppm) < 4 {
+				break
+			}
+			n := min(int(be32(ppm)), len(ppm)-4)
+			t.headers = append(t.headers, ppm[4:4+n]...)
+			t.hasHeaders = true
+			ppm = ppm[4+n:]
+		}
+	}
+
+	return cs, nil
+}
+
+// parseJPXSize parses a SIZ segment.
+func parseJPXSize(seg []byte) (jpxSize, error) {
+	var s jpxSize
+	if len(seg) < 36 {
+		return s, fmt.Errorf("truncated SIZ marker")
+	}
+
+	s.x1, s.y1 = int(be32(seg[2:])), int(be32(seg[6:]))
+	s.x0, s.y0 = int(be32(seg[10:])), int(be32(seg[14:]))
+	s.tileW, s.tileH = int(be32(seg[18:])), int(be32(seg[22:]))
+	s.tileX0, s.tileY0 = int(be32(seg[26:])), int(be32(seg[30:]))
+	n := int(seg[34])<<8 | int(seg[35])
+
+	switch {
+	case s.x0 >= s.x1 || s.y0 >= s.y1:
+		return s, fmt.Errorf("empty image area")
+	case s.tileW == 0 || s.tileH == 0:
+		return s, fmt.Errorf("invalid tile size")
+	case s.tileX0 > s.x0 || s.tileY0 > s.y0 || s.tileX0+s.tileW <= s.x0 || s.tileY0+s.tileH <= s.y0:
+		return s, fmt.Errorf("invalid tile offset")
+	case n == 0 || len(seg) < 36+3*n:
+		return s, fmt.Errorf("invalid component count %d", n)
+	}
+
+	s.tilesX = ceilDiv(s.x1-s.tileX0, s.tileW)
+	s.tilesY = ceilDiv(s.y1-s.tileY0, s.tileH)
+
+	samples := 0
+	for i := range n {
+		c := seg[36+3*i:]
+		comp := jpxComponent{
+			precision: int(c[0]&0x7f) + 1,
+			signed:    c[0]&0x80 != 0,
+			dx:        int(c[1]),
+			dy:        int(c[2]),
+		}
+		if comp.precision > 16 {
+			return s, fmt.Errorf("unsupported component precision %d", comp.precision)
+		}
+		if comp.dx == 0 || comp.dy == 0 {
+			return s, fmt.Errorf("invalid component sub-sampling")
+		}
+		s.components = append(s.components, comp)
+
+		w := ceilDiv(s.x1, comp.dx) - ceilDiv(s.x0, comp.dx)
+		h := ceilDiv(s.y1, comp.dy) - ceilDiv(s.y0, comp.dy)
+		if w <= 0 || h <= 0 {
+			return s, fmt.Errorf("component %d has no samples", i)
+		}
+		if w > maxJPXSamples/h {
+			return s, fmt.Errorf("image of %dx%d pixels is too large", w, h)
+		}
+		if samples += w * h; samples > maxJPXSamples {
+			return s, fmt.Errorf("image of %dx%d pixels is too large", s.x1-s.x0, s.y1-s.y0)
+		}
+	}
+
+	return s, nil
+}
+
+// parse records a marker segment of the main header or a tile-part header.
+// Segments that do not affect decoding are ignored.
+func (p *jpxParams) parse(marker int, seg []byte, ncomps int) error {
+	// Component indices take two bytes when there are many components.
+	compLen := 1
+	if ncomps > 256 {
+		compLen = 2
+	}
+	component := func() (int, []byte, error) {
+		if len(seg) < compLen {
+			return 0, nil, fmt.Errorf("truncated marker %04x", marker)
+		}
+		c := int(seg[0])
+		if compLen == 2 {
+			c = c<<8 | int(seg[1])
+		}
+		if c >= ncomps {
+			return 0, nil, fmt.Errorf("invalid component %d in marker %04x", c, marker)
+		}
+		return c, seg[compLen:], nil
+	}
+
+	switch marker {
+	case jpxCOD:
+		if len(seg) < 5 {
+			return fmt.Errorf("truncated COD marker")
+		}
+		cod := &jpxCodSegment{
+			sop:    seg[0]&2 != 0,
+			eph:    seg[0]&4 != 0,
+			order:  int(seg[1]),
+			layers: int(seg[2])<<8 | int(seg[3]),
+			mct:    seg[4] != 0,
+		}
+		if cod.order > jpxCPRL || cod.layers == 0 {
+			return fmt.Errorf("invalid COD marker")
+		}
+		if err := cod.parse(seg[0]&1 != 0, seg[5:]); err != nil {
+			return err
+		}
+		p.cod = cod
+
+	case jpxCOC:
+		c, rest, err := component()
+		if err != nil {
+			return err
+		}
+		if len(rest) < 1 {
+			return fmt.Errorf("truncated COC marker")
+		}
+		coc := &jpxCoding{}
+		if err := coc.parse(rest[0]&1 != 0, rest[1:]); err != nil {
+			return err
+		}
+		if p.coc == nil {
+			p.coc = map[int]*jpxCoding{}
+		}
+		p.coc[c] = coc
+
+	case jpxQCD:
+		q, err := parseJPXQuant(seg)
+		if err != nil {
+			return err
+		}
+		p.qcd = q
+
+	case jpxQCC:
+		c, rest, err := component()
+		if err != nil {
+			return err
+		}
+		q, err := parseJPXQuant(rest)
+		if err != nil {
+			return err
+		}
+		if p.qcc == nil {
+			p.qcc = map[int]*jpxQuant{}
+		}
+		p.qcc[c] = q
+
+	case jpxRGN:
+		c, rest, err := component()
+		if err != nil {
+			return err
+		}
+		if len(rest) < 2 {
+			return fmt.Errorf("truncated RGN marker")
+		}
+		if p.rgn == nil {
+			p.rgn = map[int]int{}
+		}
+		p.rgn[c] = int(rest[1])
+
+	case jpxPOC:
+		n := 5 + 2*compLen
+		p.poc = nil
+		for ; len(seg) >= n; seg = seg[n:] {
+			prog := jpxProgression{resStart: int(seg[0]), compStart: int(seg[1])}
+			rest := seg[1+compLen:]
+			if compLen == 2 {
+				prog.compStart = prog.compStart<<8 | int(seg[2])
+			}
+			prog.layerEnd = int(rest[0])<<8 | int(rest[1])
+			prog.resEnd = int(rest[2])
+			prog.compEnd = int(rest[3])
+			if compLen == 2 {
+				prog.compEnd = prog.compEnd<<8 | int(rest[4])
+			}
+			prog.order = int(rest[3+compLen])
+			if prog.compEnd == 0 {
+				// A zero end stands for 256 (or 16384) components.
+				prog.compEnd = 1 << (8 * compLen)
+			}
+			p.poc = append(p.poc, prog)
+		}
+	}
+
+	return nil
+}
+
+// parse reads the SPcod or SPcoc parameters of a coding style segment.
+func (c *jpxCoding) parse(precincts bool, sp []byte) error {
+	if len(sp) < 5 {
+		return fmt.Errorf("truncated coding style marker")
+	}
+
+	c.levels = int(sp[0])
+	c.cbw, c.cbh = int(sp[1])+2, int(sp[2])+2
+	c.style = int(sp[3])
+	c.reversible = sp[4] == 1
+
+	if c.levels > 32 || c.cbw > 10 || c.cbh > 10 || c.cbw+c.cbh > 12 {
+		return fmt.Errorf("invalid coding style")
+	}
+
+	if precincts {
+		if len(sp) < 5+c.levels+1 {
+			return fmt.Errorf("truncated precinct sizes")
+		}
+		c.precincts = sp[5 : 5+c.levels+1]
+		for r, pp := range c.precincts {
+			if r > 0 && (pp&0xf == 0 || pp>>4 == 0) {
+				return fmt.Errorf("invalid precinct size")
+			}
+		}
+	}
+	return nil
+}
+
+// parseJPXQuant parses the Sqcd and SPqcd parameters of a quantization
+// segment.
+func parseJPXQuant(seg []byte) (*jpxQuant, error) {
+	if len(seg) < 1 {
+		return nil, fmt.Errorf("truncated quantization marker")
+	}
+
+	q := &jpxQuant{style: int(seg[0] & 0x1f), guard: int(seg[0] >> 5)}
+	switch q.style {
+	case 0:
+		for _, b := range seg[1:] {
+			q.steps = append(q.steps, uint16(b>>3)<<11)
+		}
+	case 1, 2:
+		for i := 1; i+1 < len(seg); i += 2 {
+			q.steps = append(q.steps, uint16(seg[i])<<8|uint16(seg[i+1]))
+		}
+	default:
+		return nil, fmt.Errorf("invalid quantization style %d", q.style)
+	}
+
+	if len(q.steps) == 0 {
+		return nil, fmt.Errorf("truncated quantization marker")
+	}
+	return q, nil
+}
+
+// ceilDiv returns a/b rounded up, for non-negative a and positive b.
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// log2 returns the base 2 logarithm of n rounded down.
+func log2(n int) int {
+	return bits.Len(uint(n)) - 1
+}
@@ -0,0 +1,400 @@
+package parser
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// errJPXTruncated reports packet data ending early. Decoding stops there
+// and keeps what was decoded.
+var errJPXTruncated = errors.New("truncated packet data")
+
+// jpxBits reads packet header bits. A byte after 0xff holds only seven
+// bits, its first bit being a stuffed zero (T.800 B.10.1).
+type jpxBits struct {
+	data []byte
+	pos  int
+	c    byte
+	ct   int
+	err  error
+}
+
+func (b *jpxBits) bit() int {
+	if b.ct == 0 {
+		b.ct = 8
+		if b.c == 0xff {
+			b.ct = 7
+		}
+		if b.pos >= len(b.data) {
+			b.err = errJPXTruncated
+			b.c = 0
+			return 0
+		}
+		b.c = b.data[b.pos]
+		b.pos++
+	}
+	b.ct--
+	return int(b.c >> b.ct & 1)
+}
+
+func (b *jpxBits) bits(n int) int {
+	v := 0
+	for range n {
+		v = v<<1 | b.bit()
+	}
+	return v
+}
+
+// align skips to the end of the packet header, including the byte
+// following a final 0xff.
+func (b *jpxBits) align() {
+	if b.c == 0xff {
+		b.pos++
+	}
+	b.c, b.ct = 0, 0
+}
+
+// jpxTagTree codes a two-dimensional array of non-negative integers
+// (T.800 B.10.2). Level 0 holds the leaves.
+type jpxTagTree struct {
+	levels []jpxTagLevel
+}
+
+type jpxTagLevel struct {
+	w          int
+	value, low []int
+}
+
+func newJPXTagTree(w, h int) *jpxTagTree {
+	t := &jpxTagTree{}
+	for {
+		lv := jpxTagLevel{w: w, value: make([]int, w*h), low: make([]int, w*h)}
+		for i := range lv.value {
+			lv.value[i] = math.MaxInt
+		}
+		t.levels = append(t.levels, lv)
+		if w == 1 && h == 1 {
+			return t
+		}
+		w, h = ceilDiv(w, 2), ceilDiv(h, 2)
+	}
+}
+
+// decode reads bits until the value of leaf (x, y) is known to be below
+// threshold or not, and reports whether it is.
+func (t *jpxTagTree) decode(b *jpxBits, x, y, threshold int) bool {
+	low := 0
+	for l := len(t.levels) - 1; l >= 0; l-- {
+		lv := &t.levels[l]
+		k := (y>>l)*lv.w + x>>l
+		if low > lv.low[k] {
+			lv.low[k] = low
+		} else {
+			low = lv.low[k]
+		}
+		for low < threshold && low < lv.value[k] {
+			if b.bit() == 1 {
+				lv.value[k] = low
+			} else {
+				low++
+			}
+		}
+		lv.low[k] = low
+	}
+	return t.levels[0].value[y*t.levels[0].w+x] < threshold
+}
+
+// value returns the decoded value of leaf (x, y).
+func (t *jpxTagTree) value(x, y int) int {
+	return t.levels[0].value[y*t.levels[0].w+x]
+}
+
+// jpxPackets reads the packets of a tile.
+type jpxPackets struct {
+	data []byte
+	pos  int
+
+	// headers holds the packet headers when they are not in the
+	// packets, read from hpos.
+	headers []byte
+	hpos    int
+	packed  bool
+
+	sop, eph bool
+	style    int
+}
+
+// jpxContribution is the data a packet adds to a code-block segment.
+type jpxContribution struct {
+	cb     *jpxCodeBlock
+	seg    int
+	length int
+}
+
+// decode reads the packet of precinct p for its next layer (T.800 B.9 and
+// B.10).
+func (r *jpxPackets) decode(p *jpxPrecinct) error {
+	layer := p.layers
+	p.layers++
+
+	// An SOP marker segment may precede the packet.
+	if r.sop && r.pos+6 <= len(r.data) && r.data[r.pos] == 0xff && r.data[r.pos+1] == 0x91 {
+		r.pos += 6
+	}
+
+	b := &jpxBits{data: r.data, pos: r.pos}
+	if r.packed {
+		b = &jpxBits{data: r.headers, pos: r.hpos}
+	}
+
+	var contribs []jpxContribution
+	if b.bit() == 1 {
+		for _, pb := range p.bands {
+			if pb.incl == nil {
+				continue
+			}
+			cw := pb.incl.levels[0].w
+			for i, cb := range pb.blocks {
+				x, y := i%cw, i/cw
+				if cb.included {
+					if b.bit() == 0 {
+						continue
+					}
+				} else {
+					if !pb.incl.decode(b, x, y, layer+1) {
+						continue
+					}
+
+					// The number of missing bit-planes comes with the
+					// first inclusion.
+					t := 1
+					for ; !pb.zero.decode(b, x, y, t); t++ {
+						if b.err != nil || t > 74 {
+							return errJPXTruncated
+						}
+					}
+					cb.zero = pb.zero.value(x, y)
+					cb.included = true
+				}
+
+				passes := jpxPassCount(b)
+				for b.bit() == 1 {
+					cb.lblock++
+					if b.err != nil {
+						return b.err
+					}
+				}
+
+				// The new passes complete the last segment and fill new
+				// ones, each with its own length.
+				for passes > 0 {
+					n := len(cb.segs)
+					if n == 0 || cb.segs[n-1].passes == cb.segs[n-1].maxPasses {
+						cb.segs = append(cb.segs, jpxSegment{maxPasses: jpxSegmentPasses(r.style, cb.passes)})
+						n++
+					}
+					seg := &cb.segs[n-1]
+					take := min(passes, seg.maxPasses-seg.passes)
+					length := b.bits(cb.lblock + log2(take))
+					seg.passes += take
+					cb.passes += take
+					passes -= take
+					contribs = append(contribs, jpxContribution{cb, n - 1, length})
+				}
+				if b.err != nil {
+					return b.err
+				}
+			}
+		}
+	}
+	if b.err != nil {
+		return b.err
+	}
+
+	b.align()
+	if r.eph && b.pos+2 <= len(b.data) && b.data[b.pos] == 0xff && b.data[b.pos+1] == 0x92 {
+		b.pos += 2
+	}
+	if r.packed {
+		r.hpos = b.pos
+	} else {
+		r.pos = b.pos
+	}
+
+	for _, c := range contribs {
+		end := r.pos + c.length
+		if end > len(r.data) {
+			return errJPXTruncated
+		}
+		seg := &c.cb.segs[c.seg]
+		seg.data = append(seg.data, r.data[r.pos:end]...)
+		r.pos = end
+	}
+	return nil
+}
+
+// jpxPassCount reads the number of coding passes a packet adds to a
+// code-block (T.800 Table B.4).
+func jpxPassCount(b *jpxBits) int {
+	if b.bit() == 0 {
+		return 1
+	}
+	if b.bit() == 0 {
+		return 2
+	}
+	if n := b.bits(2); n < 3 {
+		return 3 + n
+	}
+	if n := b.bits(5); n < 31 {
+		return 6 + n
+	}
+	return 37 + b.bits(7)
+}
+
+// jpxSegmentPasses returns the number of passes of a codeword segment that
+// starts with pass k (T.800 D.4.1).
+func jpxSegmentPasses(style, k int) int {
+	switch {
+	case style&jpxTermAll != 0:
+		return 1
+	case style&jpxBypass != 0:
+		// The first ten passes are arithmetically coded. Raw
+		// significance and refinement passes are then terminated
+		// together, and each cleanup pass on its own.
+		if k < 10 {
+			return 10 - k
+		}
+		if (k+2)%3 == 0 {
+			return 2
+		}
+		return 1
+	}
+	return math.MaxInt32
+}
+
+// jpxPacketRef locates a precinct in the progression.
+type jpxPacketRef struct {
+	c, r int
+	p    *jpxPrecinct
+}
+
+// decodePackets reads the packets of a tile in the order of its
+// progressions (T.800 B.12). Packets missing from truncated data are left
+// undecoded.
+func (cs *jpxCodestream) decodePackets(t *jpxTile, tcs []*jpxTileComponent) error {
+	cod := cs.cod(t)
+	r := &jpxPackets{
+		data:    t.data,
+		headers: t.headers,
+		packed:  t.hasHeaders,
+		sop:     cod.sop,
+		eph:     cod.eph,
+	}
+
+	maxRes := 0
+	for _, tc := range tcs {
+		maxRes = max(maxRes, len(tc.resolutions))
+	}
+
+	progs := cs.main.poc
+	if t.params.poc != nil {
+		progs = t.params.poc
+	}
+	if progs == nil {
+		progs = []jpxProgression{{layerEnd: cod.layers, resEnd: maxRes, compEnd: len(tcs), order: cod.order}}
+	}
+
+	for _, prog := range progs {
+		layers := min(prog.layerEnd, cod.layers)
+		resEnd := min(prog.resEnd, maxRes)
+		compEnd := min(prog.compEnd, len(tcs))
+
+		// packet decodes the packet of layer l of a precinct unless an
+		// earlier progression did.
+		packet := func(tc *jpxTileComponent, p *jpxPrecinct, l int) error {
+			if p.layers != l {
+				return nil
+			}
+			r.style = tc.coding.style
+			return r.decode(p)
+		}
+
+		var err error
+		switch prog.order {
+		case jpxLRCP, jpxRLCP:
+			// Layer and resolution outermost, in either order.
+			outer, inner := layers, resEnd
+			if prog.order == jpxRLCP {
+				outer, inner = resEnd, layers
+			}
+			for i := 0; i < outer && err == nil; i++ {
+				for j := 0; j < inner && err == nil; j++ {
+					l, res := i, j
+					if prog.order == jpxRLCP {
+						l, res = j, i
+					}
+					if res < prog.resStart {
+						continue
+					}
+					for c := prog.compStart; c < compEnd && err == nil; c++ {
+						if res >= len(tcs[c].resolutions) {
+							continue
+						}
+						for _, p := range tcs[c].resolutions[res].precincts {
+							if err = packet(tcs[c], p, l); err != nil {
+								break
+							}
+						}
+					}
+				}
+			}
+
+		default:
+			// The position-driven orders visit precincts by their place on
+			// the reference grid, and all their layers in turn.
+			var refs []jpxPacketRef
+			for c := prog.compStart; c < compEnd; c++ {
+				for res := prog.resStart; res < min(resEnd, len(tcs[c].resolutions)); res++ {
+					for _, p := range tcs[c].resolutions[res].precincts {
+						refs = append(refs, jpxPacketRef{c, res, p})
+					}
+				}
+			}
+			sort.SliceStable(refs, func(i, j int) bool {
+				a, b := refs[i], refs[j]
+				ka := [...]int{a.r, a.p.y, a.p.x, a.c}
+				kb := [...]int{b.r, b.p.y, b.p.x, b.c}
+				switch prog.order {
+				case jpxPCRL:
+					ka = [...]int{a.p.y, a.p.x, a.c, a.r}
+					kb = [...]int{b.p.y, b.p.x, b.c, b.r}
+				case jpxCPRL:
+					ka = [...]int{a.c, a.p.y, a.p.x, a.r}
+					kb = [...]int{b.c, b.p.y, b.p.x, b.r}
+				}
+				for k := range ka {
+					if ka[k] != kb[k] {
+						return ka[k] < kb[k]
+					}
+				}
+				return false
+			})
+
+			for _, ref := range refs {
+				for l := ref.p.layers; l < layers && err == nil; l++ {
+					err = packet(tcs[ref.c], ref.p, l)
+				}
+				if err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
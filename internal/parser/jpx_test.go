@@ -0,0 +1,1043 @@
+package parser
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// jpxTestParams describes a codestream written by encodeJPX.
+type jpxTestParams struct {
+	w, h       int
+	x0, y0     int
+	precision  int
+	dx, dy     []int // sub-sampling of each component
+	tileW      int   // 0 for a single tile
+	tileH      int
+	tileX0     int
+	tileY0     int
+	levels     int
+	cb         int // code-block size exponent
+	reversible bool
+	style      int
+	layers     int
+	order      int
+	precincts  []byte
+	sop, eph   bool
+	mct        bool
+}
+
+// encodeJPX writes a codestream holding the component planes given, each
+// in component coordinates. It lays out tiles with the decoder's own
+// geometry and codes them with the encoders below.
+func encodeJPX(t *testing.T, p jpxTestParams, planes [][]int) []byte {
+	t.Helper()
+	ncomps := len(planes)
+	if p.tileW == 0 {
+		p.tileW, p.tileH = p.x0+p.w, p.y0+p.h
+	}
+	if p.layers == 0 {
+		p.layers = 1
+	}
+
+	var hdr bytes.Buffer
+	segment := func(marker int, payload ...byte) {
+		hdr.Write([]byte{byte(marker >> 8), byte(marker), byte((len(payload) + 2) >> 8), byte(len(payload) + 2)})
+		hdr.Write(payload)
+	}
+
+	hdr.Write([]byte{0xff, 0x4f})
+	siz := concat([]byte{0, 0}, be32Bytes(p.x0+p.w), be32Bytes(p.y0+p.h), be32Bytes(p.x0), be32Bytes(p.y0),
+		be32Bytes(p.tileW), be32Bytes(p.tileH), be32Bytes(p.tileX0), be32Bytes(p.tileY0), []byte{0, byte(ncomps)})
+	for c := range ncomps {
+		dx, dy := 1, 1
+		if p.dx != nil {
+			dx, dy = p.dx[c], p.dy[c]
+		}
+		siz = append(siz, byte(p.precision-1), byte(dx), byte(dy))
+	}
+	segment(jpxSIZ, siz...)
+
+	scod := byte(0)
+	if p.precincts != nil {
+		scod |= 1
+	}
+	if p.sop {
+		scod |= 2
+	}
+	if p.eph {
+		scod |= 4
+	}
+	mct, transform := byte(0), byte(0)
+	if p.mct {
+		mct = 1
+	}
+	if p.reversible {
+		transform = 1
+	}
+	segment(jpxCOD, append([]byte{scod, byte(p.order), 0, byte(p.layers), mct,
+		byte(p.levels), byte(p.cb - 2), byte(p.cb - 2), byte(p.style), transform}, p.precincts...)...)
+
+	// Exponents leave room for the growth of each transform; irreversible
+	// steps are a quarter.
+	const guard = 2
+	qcd := []byte{guard << 5}
+	if !p.reversible {
+		qcd[0] |= 2
+	}
+	for i := range 1 + 3*p.levels {
+		gain := 0
+		if i > 0 {
+			gain = [...]int{1, 1, 2}[(i-1)%3]
+		}
+		exp := p.precision + gain + 1
+		if p.reversible {
+			qcd = append(qcd, byte(exp<<3))
+		} else {
+			qcd = append(qcd, byte((exp+2)<<3), 0)
+		}
+	}
+	segment(jpxQCD, qcd...)
+
+	cs, err := parseJPXCodestream(append(hdr.Bytes(), 0xff, 0xd9))
+	if err != nil {
+		t.Fatalf("encoder header: %v", err)
+	}
+
+	out := bytes.NewBuffer(append([]byte(nil), hdr.Bytes()...))
+	s := &cs.size
+	for index := range s.tilesX * s.tilesY {
+		tile := &jpxTile{index: index}
+		tx, ty := index%s.tilesX, index/s.tilesX
+		tx0, ty0 := max(s.tileX0+tx*s.tileW, s.x0), max(s.tileY0+ty*s.tileH, s.y0)
+		tx1, ty1 := min(s.tileX0+(tx+1)*s.tileW, s.x1), min(s.tileY0+(ty+1)*s.tileH, s.y1)
+
+		tcs := make([]*jpxTileComponent, ncomps)
+		samples := make([][]float64, ncomps)
+		for c := range tcs {
+			if tcs[c], err = cs.newTileComponent(tile, c, tx0, ty0, tx1, ty1); err != nil {
+				t.Fatalf("encoder layout: %v", err)
+			}
+			tc, comp := tcs[c], s.components[c]
+			pw := ceilDiv(s.x1, comp.dx) - ceilDiv(s.x0, comp.dx)
+			for y := tc.y0; y < tc.y1; y++ {
+				for x := tc.x0; x < tc.x1; x++ {
+					v := planes[c][(y-ceilDiv(s.y0, comp.dy))*pw+x-ceilDiv(s.x0, comp.dx)]
+					samples[c] = append(samples[c], float64(v-1<<(p.precision-1)))
+				}
+			}
+		}
+
+		if p.mct {
+			forwardMCT(samples[0], samples[1], samples[2], p.reversible)
+		}
+
+		var blocks []*jpxTestBlock
+		for c, tc := range tcs {
+			forwardDWT(tc, samples[c])
+			for _, res := range tc.resolutions {
+				for _, pr := range res.precincts {
+					for _, pb := range pr.bands {
+						for _, cb := range pb.blocks {
+							blocks = append(blocks, encodeBlock(t, tc, pb.band, cb, p.layers))
+						}
+					}
+				}
+			}
+		}
+
+		data := encodePackets(cs, tcs, blocks, p)
+		out.Write([]byte{0xff, 0x90, 0, 10, byte(index >> 8), byte(index)})
+		out.Write(be32Bytes(12 + 2 + len(data)))
+		out.Write([]byte{0, 1, 0xff, 0x93})
+		out.Write(data)
+	}
+
+	out.Write([]byte{0xff, 0xd9})
+	return out.Bytes()
+}
+
+// forwardMCT applies the forward component transform (T.800 G.2 and G.3).
+func forwardMCT(r, g, b []float64, reversible bool) {
+	for i := range r {
+		if reversible {
+			r[i], g[i], b[i] = math.Floor((r[i]+2*g[i]+b[i])/4), b[i]-g[i], r[i]-g[i]
+			continue
+		}
+		r[i], g[i], b[i] = 0.299*r[i]+0.587*g[i]+0.114*b[i],
+			-0.16875*r[i]-0.33126*g[i]+0.5*b[i],
+			0.5*r[i]-0.41869*g[i]-0.08131*b[i]
+	}
+}
+
+// forwardDWT decomposes the samples of tc into its sub-bands, leaving the
+// unquantized coefficients in each band (T.800 Annex F.4).
+func forwardDWT(tc *jpxTileComponent, samples []float64) {
+	a := samples
+	for r := len(tc.resolutions) - 1; r >= 1; r-- {
+		res := tc.resolutions[r]
+		w, h := res.x1-res.x0, res.y1-res.y0
+
+		col := make([]float64, h)
+		for x := range w {
+			for y := range h {
+				col[y] = a[y*w+x]
+			}
+			analyze(col, res.y0, tc.coding.reversible)
+			for y := range h {
+				a[y*w+x] = col[y]
+			}
+		}
+		for y := range h {
+			analyze(a[y*w:(y+1)*w], res.x0, tc.coding.reversible)
+		}
+
+		ll := tc.resolutions[r-1]
+		var next []float64
+		for y := res.y0; y < res.y1; y++ {
+			for x := res.x0; x < res.x1; x++ {
+				v := a[(y-res.y0)*w+x-res.x0]
+				if x&1 == 0 && y&1 == 0 {
+					next = append(next, v)
+					continue
+				}
+				b := res.bands[(x&1|(y&1)<<1)-1]
+				b.coeffs[(y>>1-b.y0)*(b.x1-b.x0)+x>>1-b.x0] = float32(v)
+			}
+		}
+		if len(next) != (ll.x1-ll.x0)*(ll.y1-ll.y0) {
+			panic("forwardDWT: LL size mismatch")
+		}
+		a = next
+	}
+
+	for i, v := range a {
+		tc.resolutions[0].bands[0].coeffs[i] = float32(v)
+	}
+}
+
+// analyze applies the one-dimensional forward transform in place to a
+// signal starting at coordinate i0, leaving low-pass coefficients at even
+// coordinates.
+func analyze(x []float64, i0 int, reversible bool) {
+	n := len(x)
+	if n == 1 {
+		if i0&1 != 0 {
+			x[0] *= 2
+		}
+		return
+	}
+
+	// at reads the symmetrically extended signal.
+	at := func(k int) float64 { return x[mirror(k, n, 2*(n-1))] }
+	odd := func(k int) bool { return (i0+k)&1 != 0 }
+	step := func(high bool, f func(k int) float64) {
+		vals := make([]float64, n)
+		for k := range n {
+			vals[k] = x[k]
+			if odd(k) == high {
+				vals[k] = f(k)
+			}
+		}
+		copy(x, vals)
+	}
+
+	if reversible {
+		step(true, func(k int) float64 { return x[k] - math.Floor((at(k-1)+at(k+1))/2) })
+		step(false, func(k int) float64 { return x[k] + math.Floor((at(k-1)+at(k+1)+2)/4) })
+		return
+	}
+
+	step(true, func(k int) float64 { return x[k] + jpxAlpha*(at(k-1)+at(k+1)) })
+	step(false, func(k int) float64 { return x[k] + jpxBeta*(at(k-1)+at(k+1)) })
+	step(true, func(k int) float64 { return x[k] + jpxGamma*(at(k-1)+at(k+1)) })
+	step(false, func(k int) float64 { return x[k] + jpxDelta*(at(k-1)+at(k+1)) })
+	step(true, func(k int) float64 { return x[k] * jpxK })
+	step(false, func(k int) float64 { return x[k] / jpxK })
+}
+
+// jpxTestBlock is an encoded code-block with its passes divided among the
+// layers.
+type jpxTestBlock struct {
+	cb    *jpxCodeBlock
+	zero  int
+	segs  []jpxSegment
+	total int
+
+	// layerPasses holds the number of passes each layer adds.
+	layerPasses []int
+}
+
+// encodeBlock quantizes and codes the coefficients of a code-block.
+func encodeBlock(t *testing.T, tc *jpxTileComponent, b *jpxBand, cb *jpxCodeBlock, layers int) *jpxTestBlock {
+	w, h := cb.x1-cb.x0, cb.y1-cb.y0
+	q := make([]int32, w*h)
+	maxMag := int32(0)
+	for y := range h {
+		for x := range w {
+			v := float64(b.coeffs[(cb.y0-b.y0+y)*(b.x1-b.x0)+cb.x0-b.x0+x])
+			m := int32(math.Abs(v) / float64(b.step))
+			if v < 0 {
+				q[y*w+x] = -m
+			} else {
+				q[y*w+x] = m
+			}
+			maxMag = max(maxMag, m)
+		}
+	}
+
+	planes := 0
+	for maxMag>>planes != 0 {
+		planes++
+	}
+	if planes > b.planes {
+		t.Fatalf("code-block needs %d bit-planes, band has %d", planes, b.planes)
+	}
+
+	tb := &jpxTestBlock{cb: cb, zero: b.planes - planes}
+	if planes > 0 {
+		e := &jpxBlockEncoder{d: newJPXBlockDecoder(w, h, tc.coding.style, b.orient), q: q}
+		tb.segs = e.encode(planes)
+		for _, s := range tb.segs {
+			tb.total += s.passes
+		}
+	}
+
+	// Spread the passes evenly over the layers.
+	tb.layerPasses = make([]int, layers)
+	for l := range layers {
+		tb.layerPasses[l] = (l+1)*tb.total/layers - l*tb.total/layers
+	}
+	return tb
+}
+
+// jpxBlockEncoder codes a code-block, keeping its state in a decoder.
+type jpxBlockEncoder struct {
+	d   *jpxBlockDecoder
+	q   []int32
+	mq  *mqEncoder
+	raw *jpxRawWriter
+}
+
+// jpxRawWriter writes the bits of bypass passes, stuffing a zero bit after
+// each 0xff.
+type jpxRawWriter struct {
+	out []byte
+	c   byte
+	ct  int
+}
+
+func (r *jpxRawWriter) bit(b int) {
+	if r.ct == 0 {
+		r.ct = 8
+		if len(r.out) > 0 && r.out[len(r.out)-1] == 0xff {
+			r.ct = 7
+		}
+	}
+	r.ct--
+	r.c |= byte(b) << r.ct
+	if r.ct == 0 {
+		r.out = append(r.out, r.c)
+		r.c = 0
+	}
+}
+
+func (r *jpxRawWriter) flush() []byte {
+	if r.ct != 0 {
+		r.out = append(r.out, r.c)
+	}
+	return r.out
+}
+
+func (e *jpxBlockEncoder) encode(planes int) []jpxSegment {
+	d := e.d
+	var segs []jpxSegment
+	var seg *jpxSegment
+
+	finish := func() {
+		if e.mq != nil {
+			data := e.mq.flush()
+			seg.data = data[:len(data)-2]
+		} else if e.raw != nil {
+			seg.data = e.raw.flush()
+		}
+		e.mq, e.raw = nil, nil
+	}
+
+	pass := 0
+	for plane := planes - 1; plane >= 0; {
+		if seg == nil || seg.passes == seg.maxPasses {
+			if seg != nil {
+				finish()
+			}
+			segs = append(segs, jpxSegment{maxPasses: jpxSegmentPasses(d.style, pass)})
+			seg = &segs[len(segs)-1]
+		}
+
+		kind := (pass + 2) % 3
+		d.rawPass = d.style&jpxBypass != 0 && pass >= 10 && kind != 2
+		if d.rawPass && e.raw == nil {
+			e.raw = &jpxRawWriter{}
+		} else if !d.rawPass && e.mq == nil {
+			e.mq = newMQEncoder()
+		}
+
+		switch kind {
+		case 0:
+			e.significancePass(plane)
+		case 1:
+			e.refinementPass(plane)
+		default:
+			e.cleanupPass(plane)
+			plane--
+		}
+		if d.style&jpxReset != 0 {
+			d.resetContexts()
+		}
+		seg.passes++
+		pass++
+	}
+	finish()
+	return segs
+}
+
+func (e *jpxBlockEncoder) put(cx int, bit int) {
+	if e.d.rawPass {
+		e.raw.bit(bit)
+		return
+	}
+	e.mq.encode(&e.d.cx[cx], bit)
+}
+
+func (e *jpxBlockEncoder) sign(f uint16, neg bool) {
+	s := jpxSignContexts[f&0xf|f>>8&0xf<<4]
+	bit := 0
+	if neg {
+		bit = 1
+	}
+	if e.d.rawPass {
+		e.raw.bit(bit)
+		return
+	}
+	e.mq.encode(&e.d.cx[s.cx], bit^int(s.xor))
+}
+
+func (e *jpxBlockEncoder) bitAt(x, y, plane int) (int, bool) {
+	v := e.q[y*e.d.w+x]
+	if v < 0 {
+		return int(-v >> plane & 1), true
+	}
+	return int(v >> plane & 1), false
+}
+
+func (e *jpxBlockEncoder) significancePass(plane int) {
+	d := e.d
+	stride := d.w + 2
+	for y0 := 0; y0 < d.h; y0 += 4 {
+		for x := range d.w {
+			for y := y0; y < min(y0+4, d.h); y++ {
+				i := (y+1)*stride + x + 1
+				f := d.flags[i]
+				if f&jpxSig != 0 || f&jpxSigNeighbours == 0 {
+					continue
+				}
+				d.flags[i] |= jpxVisited
+				bit, neg := e.bitAt(x, y, plane)
+				e.put(int(jpxZeroContexts[d.orient][f&0xff]), bit)
+				if bit == 1 {
+					e.sign(f, neg)
+					d.setSignificant(x, y, plane, neg)
+				}
+			}
+		}
+	}
+}
+
+func (e *jpxBlockEncoder) refinementPass(plane int) {
+	d := e.d
+	stride := d.w + 2
+	for y0 := 0; y0 < d.h; y0 += 4 {
+		for x := range d.w {
+			for y := y0; y < min(y0+4, d.h); y++ {
+				i := (y+1)*stride + x + 1
+				f := d.flags[i]
+				if f&(jpxSig|jpxVisited) != jpxSig {
+					continue
+				}
+				cx := jpxCtxMag
+				if f&jpxRefined != 0 {
+					cx += 2
+				} else if f&jpxSigNeighbours != 0 {
+					cx++
+				}
+				bit, _ := e.bitAt(x, y, plane)
+				e.put(cx, bit)
+				d.flags[i] |= jpxRefined
+			}
+		}
+	}
+}
+
+func (e *jpxBlockEncoder) cleanupPass(plane int) {
+	d := e.d
+	stride := d.w + 2
+	const busy = jpxSig | jpxVisited | jpxSigNeighbours
+	for y0 := 0; y0 < d.h; y0 += 4 {
+		for x := range d.w {
+			y := y0
+			i := (y0+1)*stride + x + 1
+			if y0+4 <= d.h && (d.flags[i]|d.flags[i+stride]|d.flags[i+2*stride]|d.flags[i+3*stride])&busy == 0 {
+				k := 0
+				for ; k < 4; k++ {
+					if bit, _ := e.bitAt(x, y0+k, plane); bit == 1 {
+						break
+					}
+				}
+				if k == 4 {
+					e.put(jpxCtxRun, 0)
+					continue
+				}
+				e.put(jpxCtxRun, 1)
+				e.put(jpxCtxUniform, k>>1)
+				e.put(jpxCtxUniform, k&1)
+				y = y0 + k
+				_, neg := e.bitAt(x, y, plane)
+				e.sign(d.flags[(y+1)*stride+x+1], neg)
+				d.setSignificant(x, y, plane, neg)
+				y++
+			}
+			for ; y < min(y0+4, d.h); y++ {
+				i := (y+1)*stride + x + 1
+				f := d.flags[i]
+				if f&(jpxSig|jpxVisited) == 0 {
+					bit, neg := e.bitAt(x, y, plane)
+					e.put(int(jpxZeroContexts[d.orient][f&0xff]), bit)
+					if bit == 1 {
+						e.sign(f, neg)
+						d.setSignificant(x, y, plane, neg)
+					}
+				}
+				d.flags[i] &^= jpxVisited
+			}
+		}
+	}
+	if d.style&jpxSegSymbols != 0 {
+		for _, b := range []int{1, 0, 1, 0} {
+			e.put(jpxCtxUniform, b)
+		}
+	}
+}
+
+// jpxBitWriter writes packet headers with bit stuffing.
+type jpxBitWriter struct {
+	out []byte
+	c   byte
+	ct  int
+}
+
+func (w *jpxBitWriter) bit(b int) {
+	if w.ct == 0 {
+		w.ct = 8
+		if len(w.out) > 0 && w.out[len(w.out)-1] == 0xff {
+			w.ct = 7
+		}
+	}
+	w.ct--
+	w.c |= byte(b) << w.ct
+	if w.ct == 0 {
+		w.out = append(w.out, w.c)
+		w.c = 0
+	}
+}
+
+func (w *jpxBitWriter) bits(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.bit(v >> i & 1)
+	}
+}
+
+func (w *jpxBitWriter) flush() []byte {
+	if w.ct != 0 {
+		w.out = append(w.out, w.c)
+	}
+	if len(w.out) > 0 && w.out[len(w.out)-1] == 0xff {
+		w.out = append(w.out, 0)
+	}
+	return w.out
+}
+
+// jpxTagEncoder is the encoding side of a tag tree.
+type jpxTagEncoder struct {
+	levels []struct {
+		w                 int
+		value, low, known []int
+	}
+}
+
+func newJPXTagEncoder(w, h int, leaves []int) *jpxTagEncoder {
+	t := &jpxTagEncoder{}
+	for {
+		lv := struct {
+			w                 int
+			value, low, known []int
+		}{w: w, value: make([]int, w*h), low: make([]int, w*h), known: make([]int, w*h)}
+		for i := range lv.value {
+			lv.value[i] = math.MaxInt
+		}
+		t.levels = append(t.levels, lv)
+		if w == 1 && h == 1 {
+			break
+		}
+		w, h = ceilDiv(w, 2), ceilDiv(h, 2)
+	}
+	for i, v := range leaves {
+		x, y := i%t.levels[0].w, i/t.levels[0].w
+		for l := range t.levels {
+			k := (y>>l)*t.levels[l].w + x>>l
+			t.levels[l].value[k] = min(t.levels[l].value[k], v)
+		}
+	}
+	return t
+}
+
+func (t *jpxTagEncoder) encode(w *jpxBitWriter, x, y, threshold int) {
+	low := 0
+	for l := len(t.levels) - 1; l >= 0; l-- {
+		lv := &t.levels[l]
+		k := (y>>l)*lv.w + x>>l
+		if low > lv.low[k] {
+			lv.low[k] = low
+		} else {
+			low = lv.low[k]
+		}
+		for low < threshold {
+			if low >= lv.value[k] {
+				if lv.known[k] == 0 {
+					w.bit(1)
+					lv.known[k] = 1
+				}
+				break
+			}
+			w.bit(0)
+			low++
+		}
+		lv.low[k] = low
+	}
+}
+
+// encodePackets writes the packets of a tile in the progression order of
+// p.
+func encodePackets(cs *jpxCodestream, tcs []*jpxTileComponent, blocks []*jpxTestBlock, p jpxTestParams) []byte {
+	byBlock := map[*jpxCodeBlock]*jpxTestBlock{}
+	for _, b := range blocks {
+		byBlock[b.cb] = b
+	}
+
+	type packet struct {
+		c, r, pi, l int
+		pr          *jpxPrecinct
+	}
+	var packets []packet
+	for c, tc := range tcs {
+		for r, res := range tc.resolutions {
+			for pi, pr := range res.precincts {
+				for l := range p.layers {
+					packets = append(packets, packet{c, r, pi, l, pr})
+				}
+			}
+		}
+	}
+	key := func(k packet) []int {
+		switch p.order {
+		case jpxLRCP:
+			return []int{k.l, k.r, k.c, k.pi}
+		case jpxRLCP:
+			return []int{k.r, k.l, k.c, k.pi}
+		case jpxRPCL:
+			return []int{k.r, k.pr.y, k.pr.x, k.c, k.l}
+		case jpxPCRL:
+			return []int{k.pr.y, k.pr.x, k.c, k.r, k.l}
+		}
+		return []int{k.c, k.pr.y, k.pr.x, k.r, k.l}
+	}
+	sort.SliceStable(packets, func(i, j int) bool {
+		a, b := key(packets[i]), key(packets[j])
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+
+	// State of each precinct band's tag trees and each block's progress.
+	incl := map[*jpxPrecinctBand]*jpxTagEncoder{}
+	zero := map[*jpxPrecinctBand]*jpxTagEncoder{}
+	done := map[*jpxCodeBlock]int{}
+	lblock := map[*jpxCodeBlock]int{}
+
+	var out []byte
+	for n, pk := range packets {
+		if p.sop {
+			out = append(out, 0xff, 0x91, 0, 4, byte(n>>8), byte(n))
+		}
+
+		w := &jpxBitWriter{}
+		var body []byte
+		w.bit(1)
+		for _, pb := range pk.pr.bands {
+			if len(pb.blocks) == 0 {
+				continue
+			}
+			cw := pb.incl.levels[0].w
+			if incl[pb] == nil {
+				first, zeros := make([]int, len(pb.blocks)), make([]int, len(pb.blocks))
+				for i, cb := range pb.blocks {
+					tb := byBlock[cb]
+					first[i] = math.MaxInt32
+					for l, n := range tb.layerPasses {
+						if n > 0 {
+							first[i] = l
+							break
+						}
+					}
+					zeros[i] = tb.zero
+				}
+				incl[pb] = newJPXTagEncoder(cw, len(pb.blocks)/cw, first)
+				zero[pb] = newJPXTagEncoder(cw, len(pb.blocks)/cw, zeros)
+			}
+
+			for i, cb := range pb.blocks {
+				tb := byBlock[cb]
+				x, y := i%cw, i/cw
+				passes := tb.layerPasses[pk.l]
+				start := done[cb]
+				if !startedBefore(tb, pk.l) {
+					incl[pb].encode(w, x, y, pk.l+1)
+					if passes == 0 {
+						continue
+					}
+					for th := 1; th <= tb.zero+1; th++ {
+						zero[pb].encode(w, x, y, th)
+					}
+				} else {
+					if passes == 0 {
+						w.bit(0)
+						continue
+					}
+					w.bit(1)
+				}
+
+				// Number of passes (Table B.4).
+				switch {
+				case passes == 1:
+					w.bit(0)
+				case passes == 2:
+					w.bits(2, 2)
+				case passes <= 5:
+					w.bits(0xc|(passes-3), 4)
+				case passes <= 36:
+					w.bits(0x1e0|(passes-6), 9)
+				default:
+					w.bits(0xff80|(passes-37), 16)
+				}
+
+				// Split the new passes into the contributions to each
+				// segment, taking data in proportion.
+				type contrib struct{ n, length int }
+				var contribs []contrib
+				k := start
+				for k < start+passes {
+					s, first := 0, 0
+					for s = range tb.segs {
+						if k < first+tb.segs[s].passes {
+							break
+						}
+						first += tb.segs[s].passes
+					}
+					seg := tb.segs[s]
+					take := min(start+passes, first+seg.passes) - k
+					lo := len(seg.data) * (k - first) / seg.passes
+					hi := len(seg.data) * (k + take - first) / seg.passes
+					contribs = append(contribs, contrib{take, hi - lo})
+					body = append(body, seg.data[lo:hi]...)
+					k += take
+				}
+
+				if lblock[cb] == 0 {
+					lblock[cb] = 3
+				}
+				need := lblock[cb]
+				for _, c := range contribs {
+					bits := 0
+					for c.length>>bits != 0 {
+						bits++
+					}
+					need = max(need, bits-log2(c.n))
+				}
+				for range need - lblock[cb] {
+					w.bit(1)
+				}
+				w.bit(0)
+				lblock[cb] = need
+				for _, c := range contribs {
+					w.bits(c.length, need+log2(c.n))
+				}
+				done[cb] = start + passes
+			}
+		}
+
+		out = append(out, w.flush()...)
+		if p.eph {
+			out = append(out, 0xff, 0x92)
+		}
+		out = append(out, body...)
+	}
+	return out
+}
+
+// startedBefore reports whether a code-block was included before layer l.
+func startedBefore(tb *jpxTestBlock, l int) bool {
+	for _, n := range tb.layerPasses[:l] {
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// jpxTestPlane returns a w by h plane of samples mixing gradients and
+// noise.
+func jpxTestPlane(w, h, precision int, seed int64) []int {
+	rng := rand.New(rand.NewSource(seed))
+	maxVal := 1<<precision - 1
+	out := make([]int, w*h)
+	for y := range h {
+		for x := range w {
+			v := float64(maxVal) * (0.5 + 0.3*math.Sin(float64(x+int(seed))/5) + 0.15*math.Cos(float64(y)/3))
+			v += float64(rng.Intn(maxVal/8+1)) - float64(maxVal/16)
+			out[y*w+x] = max(0, min(maxVal, int(v)))
+		}
+	}
+	return out
+}
+
+// decodeJPXPlanes decodes a codestream into its component planes.
+func decodeJPXPlanes(t *testing.T, data []byte) []*jpxPlane {
+	t.Helper()
+	cs, err := parseJPXCodestream(data)
+	if err != nil {
+		t.Fatalf("parseJPXCodestream: %v", err)
+	}
+	planes, err := cs.decode()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return planes
+}
+
+// checkJPXPlanes compares decoded planes with the originals, allowing each
+// sample to differ by tolerance.
+func checkJPXPlanes(t *testing.T, got []*jpxPlane, want [][]int, tolerance int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%d components, expected %d", len(got), len(want))
+	}
+	for c, p := range got {
+		if len(p.pix) != len(want[c]) {
+			t.Fatalf("component %d: %d samples, expected %d", c, len(p.pix), len(want[c]))
+		}
+		worst := 0
+		for i, v := range p.pix {
+			worst = max(worst, abs(int(v)-want[c][i]))
+		}
+		if worst > tolerance {
+			t.Errorf("component %d differs by up to %d, expected at most %d", c, worst, tolerance)
+		}
+	}
+}
+
+func TestJPXReversible(t *testing.T) {
+	tests := []struct {
+		name string
+		p    jpxTestParams
+	}{
+		{"single tile", jpxTestParams{w: 37, h: 29, precision: 8, levels: 3, cb: 4}},
+		{"no levels", jpxTestParams{w: 9, h: 7, precision: 8, levels: 0, cb: 2}},
+		{"offset tiles", jpxTestParams{w: 40, h: 33, x0: 3, y0: 5, tileW: 16, tileH: 12, tileX0: 1, tileY0: 2, precision: 8, levels: 2, cb: 3}},
+		{"12-bit", jpxTestParams{w: 24, h: 20, precision: 12, levels: 2, cb: 4}},
+		{"1-bit", jpxTestParams{w: 17, h: 13, precision: 1, levels: 1, cb: 3}},
+		{"layers", jpxTestParams{w: 32, h: 32, precision: 8, levels: 3, cb: 3, layers: 4, sop: true, eph: true}},
+		{"bypass", jpxTestParams{w: 32, h: 24, precision: 8, levels: 2, cb: 4, style: jpxBypass, layers: 3}},
+		{"termall reset", jpxTestParams{w: 32, h: 24, precision: 8, levels: 2, cb: 4, style: jpxTermAll | jpxReset, layers: 2}},
+		{"causal segsym", jpxTestParams{w: 21, h: 19, precision: 8, levels: 2, cb: 3, style: jpxCausal | jpxSegSymbols}},
+		{"all styles", jpxTestParams{w: 32, h: 24, precision: 8, levels: 2, cb: 4, style: 0x3f, layers: 2}},
+		{"precincts RLCP", jpxTestParams{w: 48, h: 40, precision: 8, levels: 2, cb: 3, order: jpxRLCP, precincts: []byte{0x33, 0x44, 0x44}, layers: 2}},
+		{"precincts RPCL", jpxTestParams{w: 48, h: 40, x0: 5, y0: 3, precision: 8, levels: 2, cb: 3, order: jpxRPCL, precincts: []byte{0x33, 0x44, 0x44}, layers: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := [][]int{jpxTestPlane(tt.p.w, tt.p.h, tt.p.precision, 1)}
+			tt.p.reversible = true
+			data := encodeJPX(t, tt.p, want)
+			checkJPXPlanes(t, decodeJPXPlanes(t, data), want, 0)
+		})
+	}
+}
+
+func TestJPXComponents(t *testing.T) {
+	w, h := 30, 22
+	rgb := [][]int{jpxTestPlane(w, h, 8, 1), jpxTestPlane(w, h, 8, 2), jpxTestPlane(w, h, 8, 3)}
+
+	t.Run("RCT", func(t *testing.T) {
+		p := jpxTestParams{w: w, h: h, precision: 8, levels: 2, cb: 4, reversible: true, mct: true, order: jpxPCRL}
+		checkJPXPlanes(t, decodeJPXPlanes(t, encodeJPX(t, p, rgb)), rgb, 0)
+	})
+
+	t.Run("ICT", func(t *testing.T) {
+		p := jpxTestParams{w: w, h: h, precision: 8, levels: 3, cb: 4, mct: true, tileW: 16, tileH: 16}
+		checkJPXPlanes(t, decodeJPXPlanes(t, encodeJPX(t, p, rgb)), rgb, 2)
+	})
+
+	t.Run("sub-sampled", func(t *testing.T) {
+		// Chroma planes at half the resolution, in component coordinates.
+		planes := [][]int{rgb[0], jpxTestPlane(15, 11, 8, 4), jpxTestPlane(15, 11, 8, 5)}
+		p := jpxTestParams{w: w, h: h, precision: 8, levels: 2, cb: 3, reversible: true,
+			dx: []int{1, 2, 2}, dy: []int{1, 2, 2}, order: jpxCPRL, precincts: []byte{0x44, 0x55, 0x55}}
+		checkJPXPlanes(t, decodeJPXPlanes(t, encodeJPX(t, p, planes)), planes, 0)
+	})
+}
+
+// jp2Box wraps a body in a box of the given type.
+func jp2Box(kind string, body ...[]byte) []byte {
+	b := concat(body...)
+	return concat(be32Bytes(8+len(b)), []byte(kind), b)
+}
+
+func TestDecodeJPX(t *testing.T) {
+	w, h := 12, 10
+	gray := jpxTestPlane(w, h, 8, 1)
+	codestream := func(planes ...[]int) []byte {
+		return encodeJPX(t, jpxTestParams{w: w, h: h, precision: 8, levels: 1, cb: 3, reversible: true}, planes)
+	}
+	ihdr := func(n int) []byte {
+		return jp2Box("ihdr", be32Bytes(h), be32Bytes(w), []byte{0, byte(n), 7, 7, 0, 0})
+	}
+	colr := func(cs int) []byte { return jp2Box("colr", []byte{1, 0, 0}, be32Bytes(cs)) }
+
+	t.Run("codestream", func(t *testing.T) {
+		img, err := DecodeJPX(codestream(gray), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Width != w || img.Height != h || img.NComponents != 1 || img.ColorSpace != "" || img.Alpha != nil {
+			t.Fatalf("got %dx%d with %d components in %q", img.Width, img.Height, img.NComponents, img.ColorSpace)
+		}
+		for i, v := range img.Samples {
+			if int(v) != gray[i] {
+				t.Fatalf("sample %d = %d, expected %d", i, v, gray[i])
+			}
+		}
+	})
+
+	t.Run("alpha", func(t *testing.T) {
+		alpha := jpxTestPlane(w, h, 8, 9)
+		file := concat(
+			jp2Box("jP  ", []byte{0x0d, 0x0a, 0x87, 0x0a}),
+			jp2Box("ftyp", []byte("jp2 "), be32Bytes(0), []byte("jp2 ")),
+			jp2Box("jp2h", ihdr(2), colr(jpxGray),
+				// Opacity first, then the grey channel.
+				jp2Box("cdef", []byte{0, 2, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1})),
+			jp2Box("jp2c", codestream(alpha, gray)),
+		)
+		img, err := DecodeJPX(file, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.ColorSpace != "DeviceGray" || img.NComponents != 1 || len(img.Alpha) != w*h {
+			t.Fatalf("got %d components in %q with %d alpha samples", img.NComponents, img.ColorSpace, len(img.Alpha))
+		}
+		for i := range gray {
+			if int(img.Samples[i]) != gray[i] || int(img.Alpha[i]) != alpha[i] {
+				t.Fatalf("pixel %d = (%d, %d), expected (%d, %d)", i, img.Samples[i], img.Alpha[i], gray[i], alpha[i])
+			}
+		}
+	})
+
+	t.Run("SMaskInData", func(t *testing.T) {
+		alpha := jpxTestPlane(w, h, 8, 9)
+		img, err := DecodeJPX(codestream(gray, alpha), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.NComponents != 1 || len(img.Alpha) != w*h || int(img.Alpha[5]) != alpha[5] {
+			t.Fatalf("got %d components and %d alpha samples", img.NComponents, len(img.Alpha))
+		}
+		if img, _ = DecodeJPX(codestream(gray, alpha), false); img.NComponents != 2 || img.Alpha != nil {
+			t.Fatalf("without SMaskInData got %d components, alpha %v", img.NComponents, img.Alpha != nil)
+		}
+	})
+
+	t.Run("palette", func(t *testing.T) {
+		index := make([]int, w*h)
+		for i := range index {
+			index[i] = i % 3
+		}
+		pclr := jp2Box("pclr", []byte{0, 3, 3, 7, 7, 7,
+			255, 0, 0,
+			0, 255, 0,
+			0, 0, 255})
+		cmap := jp2Box("cmap", []byte{0, 0, 1, 0, 0, 0, 1, 1, 0, 0, 1, 2})
+		file := concat(jp2Box("jp2h", ihdr(1), colr(jpxSRGB), pclr, cmap), jp2Box("jp2c", codestream(index)))
+		img, err := DecodeJPX(file, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.ColorSpace != "DeviceRGB" || img.NComponents != 3 {
+			t.Fatalf("got %d components in %q", img.NComponents, img.ColorSpace)
+		}
+		if got := img.Samples[3:9]; !bytes.Equal(got, []byte{0, 255, 0, 0, 0, 255}) {
+			t.Fatalf("pixels 1 and 2 = %v", got)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data := codestream(gray)
+		img, err := DecodeJPX(data[:len(data)-20], false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(img.Samples) != w*h {
+			t.Fatalf("%d samples, expected %d", len(img.Samples), w*h)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := DecodeJPX([]byte{0xff, 0x4f, 0xff, 0x51, 0, 2}, false); err == nil {
+			t.Fatal("expected an error for a truncated header")
+		}
+		if _, err := DecodeJPX(jp2Box("jp2h", ihdr(1)), false); err == nil {
+			t.Fatal("expected an error for a file without a codestream")
+		}
+		// A one-row image whose component is sub-sampled to no rows.
+		siz := concat([]byte{0xff, 0x4f, 0xff, 0x51, 0, 41, 0, 0}, be32Bytes(1), be32Bytes(2), be32Bytes(0), be32Bytes(1),
+			be32Bytes(1), be32Bytes(2), be32Bytes(0), be32Bytes(0), []byte{0, 1, 7, 1, 32})
+		if _, err := DecodeJPX(siz, false); err == nil {
+			t.Fatal("expected an error for a component without samples")
+		}
+	})
+}
+
+func TestJPXDecodeFilter(t *testing.T) {
+	rgb := [][]int{jpxTestPlane(8, 6, 8, 1), jpxTestPlane(8, 6, 8, 2), jpxTestPlane(8, 6, 8, 3)}
+	data := encodeJPX(t, jpxTestParams{w: 8, h: 6, precision: 8, levels: 1, cb: 2, reversible: true, mct: true}, rgb)
+	got, err := ApplyFilters(data, []FilterSpec{{Name: "JPXDecode"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 8*6*3 || int(got[3*7+1]) != rgb[1][7] {
+		t.Fatalf("got %d bytes", len(got))
+	}
+}
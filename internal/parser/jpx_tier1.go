@@ -0,0 +1,417 @@
+package parser
+
+// Code-block style flags of COD and COC segments (ITU T.800 A.6.1).
+const (
+	jpxBypass      = 0x01
+	jpxReset       = 0x02
+	jpxTermAll     = 0x04
+	jpxCausal      = 0x08
+	jpxPredictable = 0x10
+	jpxSegSymbols  = 0x20
+)
+
+// Sub-band orientations.
+const (
+	jpxLL = iota
+	jpxHL
+	jpxLH
+	jpxHH
+)
+
+// Coefficient state flags used by the coding passes. The low byte holds the
+// significance of the eight neighbours, the next four bits the signs of the
+// horizontal and vertical ones.
+const (
+	jpxSigW = 1 << iota
+	jpxSigE
+	jpxSigN
+	jpxSigS
+	jpxSigNW
+	jpxSigNE
+	jpxSigSW
+	jpxSigSE
+	jpxNegW
+	jpxNegE
+	jpxNegN
+	jpxNegS
+	jpxSig
+	jpxVisited
+	jpxRefined
+	jpxNeg
+
+	jpxSigNeighbours = 0xff
+)
+
+// Context labels of the coding passes (T.800 D.3). Labels 0 to 8 are zero
+// coding contexts and 9 to 13 sign coding contexts.
+const (
+	jpxCtxMag     = 14
+	jpxCtxRun     = 17
+	jpxCtxUniform = 18
+	jpxContexts   = 19
+)
+
+// jpxZeroContexts maps the neighbour significance bits of a coefficient to
+// its zero coding context, by orientation (T.800 Table D.1).
+var jpxZeroContexts = func() (t [4][256]uint8) {
+	for f := range 256 {
+		h := f&jpxSigW/jpxSigW + f&jpxSigE/jpxSigE
+		v := f&jpxSigN/jpxSigN + f&jpxSigS/jpxSigS
+		d := f&jpxSigNW/jpxSigNW + f&jpxSigNE/jpxSigNE + f&jpxSigSW/jpxSigSW + f&jpxSigSE/jpxSigSE
+
+		t[jpxLL][f] = zeroContext(h, v, d)
+		t[jpxLH][f] = t[jpxLL][f]
+		t[jpxHL][f] = zeroContext(v, h, d)
+
+		var cx uint8
+		switch hv := h + v; {
+		case d >= 3:
+			cx = 8
+		case d == 2 && hv >= 1:
+			cx = 7
+		case d == 2:
+			cx = 6
+		case d == 1 && hv >= 2:
+			cx = 5
+		case d == 1 && hv == 1:
+			cx = 4
+		case d == 1:
+			cx = 3
+		case hv >= 2:
+			cx = 2
+		default:
+			cx = uint8(hv)
+		}
+		t[jpxHH][f] = cx
+	}
+	return t
+}()
+
+// zeroContext returns the zero coding context of the LL and LH sub-bands.
+func zeroContext(h, v, d int) uint8 {
+	switch {
+	case h == 2:
+		return 8
+	case h == 1 && v >= 1:
+		return 7
+	case h == 1 && d >= 1:
+		return 6
+	case h == 1:
+		return 5
+	case v == 2:
+		return 4
+	case v == 1:
+		return 3
+	default:
+		return uint8(min(d, 2))
+	}
+}
+
+// jpxSignContexts maps the significance and sign bits of the horizontal and
+// vertical neighbours, flags>>0 & 0xf | flags>>8 & 0xf << 4, to a sign
+// coding context and the bit to XOR with the decoded sign (T.800 Table D.3).
+var jpxSignContexts = func() (t [256]struct{ cx, xor uint8 }) {
+	contribution := func(sig, neg int) int {
+		switch {
+		case sig == 0:
+			return 0
+		case neg != 0:
+			return -1
+		}
+		return 1
+	}
+	clamp := func(v int) int { return max(-1, min(1, v)) }
+
+	for f := range 256 {
+		h := clamp(contribution(f&jpxSigW, f>>4&1) + contribution(f&jpxSigE, f>>5&1))
+		v := clamp(contribution(f&jpxSigN, f>>6&1) + contribution(f&jpxSigS, f>>7&1))
+
+		xor := uint8(0)
+		if h < 0 || h == 0 && v < 0 {
+			h, v = -h, -v
+			xor = 1
+		}
+		cx := 9 + v
+		if h == 1 {
+			cx = 12 + v
+		}
+		t[f].cx, t[f].xor = uint8(cx), xor
+	}
+	return t
+}()
+
+// jpxSegment is a codeword segment of a code-block: the data of one or
+// more coding passes terminated together.
+type jpxSegment struct {
+	data   []byte
+	passes int
+
+	// maxPasses is the number of passes the segment holds when complete.
+	maxPasses int
+}
+
+// jpxRaw reads the raw bits of passes coded in bypass mode. A byte after
+// 0xff holds only seven bits.
+type jpxRaw struct {
+	data []byte
+	pos  int
+	c    byte
+	ct   int
+}
+
+func (r *jpxRaw) bit() int {
+	if r.ct == 0 {
+		next := byte(0xff)
+		if r.pos < len(r.data) {
+			next = r.data[r.pos]
+		}
+		switch {
+		case r.c == 0xff && next > 0x8f:
+			// A marker: read 1 bits without advancing.
+			r.c, r.ct = 0xff, 8
+		case r.c == 0xff:
+			r.c, r.ct = next, 7
+			r.pos++
+		default:
+			r.c, r.ct = next, 8
+			r.pos++
+		}
+	}
+	r.ct--
+	return int(r.c >> r.ct & 1)
+}
+
+// jpxBlockDecoder decodes the coding passes of one code-block (T.800 Annex
+// D). Coefficient magnitudes are kept at twice their value so that the
+// midpoint of the interval left by undecoded bit-planes is an integer.
+type jpxBlockDecoder struct {
+	w, h   int
+	style  int
+	orient int
+
+	// flags holds the state of each coefficient with a border of one
+	// coefficient on every side.
+	flags []uint16
+	mag   []int32
+
+	cx  [jpxContexts]mqContext
+	mq  *mqDecoder
+	raw *jpxRaw
+
+	// rawPass is set while decoding a pass coded in bypass mode.
+	rawPass bool
+}
+
+func newJPXBlockDecoder(w, h, style, orient int) *jpxBlockDecoder {
+	d := &jpxBlockDecoder{
+		w: w, h: h, style: style, orient: orient,
+		flags: make([]uint16, (w+2)*(h+2)),
+		mag:   make([]int32, w*h),
+	}
+	d.resetContexts()
+	return d
+}
+
+// resetContexts sets the initial context states of T.800 Table D.7.
+func (d *jpxBlockDecoder) resetContexts() {
+	d.cx = [jpxContexts]mqContext{}
+	d.cx[0] = 4 << 1
+	d.cx[jpxCtxRun] = 3 << 1
+	d.cx[jpxCtxUniform] = 46 << 1
+}
+
+// decode runs the coding passes held by segs, starting with the cleanup
+// pass of bit-plane planes-1. It returns the signed coefficients at twice
+// their magnitude.
+func (d *jpxBlockDecoder) decode(segs []jpxSegment, planes int) []int32 {
+	pass := 0
+	plane := planes - 1
+	for _, seg := range segs {
+		d.mq, d.raw = nil, nil
+		for range seg.passes {
+			if plane < 0 {
+				break
+			}
+
+			// Passes are numbered from the first cleanup pass, which is
+			// followed by significance, refinement and cleanup passes.
+			kind := (pass + 2) % 3
+			d.rawPass = d.style&jpxBypass != 0 && pass >= 10 && kind != 2
+			if d.rawPass {
+				if d.raw == nil {
+					d.raw = &jpxRaw{data: seg.data}
+				}
+			} else if d.mq == nil {
+				d.mq = newMQDecoder(seg.data)
+			}
+
+			switch kind {
+			case 0:
+				d.significancePass(plane)
+			case 1:
+				d.refinementPass(plane)
+			default:
+				d.cleanupPass(plane)
+				plane--
+			}
+
+			if d.style&jpxReset != 0 {
+				d.resetContexts()
+			}
+			pass++
+		}
+	}
+
+	for y := range d.h {
+		for x := range d.w {
+			if d.flags[(y+1)*(d.w+2)+x+1]&jpxNeg != 0 {
+				d.mag[y*d.w+x] = -d.mag[y*d.w+x]
+			}
+		}
+	}
+	return d.mag
+}
+
+// setSignificant makes the coefficient at (x, y) significant at bit-plane
+// plane and records its significance and sign in the flags of its
+// neighbours.
+func (d *jpxBlockDecoder) setSignificant(x, y, plane int, neg bool) {
+	stride := d.w + 2
+	i := (y+1)*stride + x + 1
+
+	var n uint16
+	if neg {
+		n = 1
+	}
+	d.flags[i] |= jpxSig | n*jpxNeg
+	d.mag[y*d.w+x] = 3 << plane
+
+	d.flags[i-1] |= jpxSigE | n*jpxNegE
+	d.flags[i+1] |= jpxSigW | n*jpxNegW
+	d.flags[i+stride] |= jpxSigN | n*jpxNegN
+	d.flags[i+stride-1] |= jpxSigNE
+	d.flags[i+stride+1] |= jpxSigNW
+
+	// In vertically causal mode the last row of a stripe does not see
+	// the stripe below.
+	if d.style&jpxCausal == 0 || y%4 != 0 {
+		d.flags[i-stride] |= jpxSigS | n*jpxNegS
+		d.flags[i-stride-1] |= jpxSigSE
+		d.flags[i-stride+1] |= jpxSigSW
+	}
+}
+
+// decodeSign decodes the sign of a coefficient with flags f, reporting
+// whether it is negative.
+func (d *jpxBlockDecoder) decodeSign(f uint16) bool {
+	if d.rawPass {
+		return d.raw.bit() == 1
+	}
+	s := jpxSignContexts[f&0xf|f>>8&0xf<<4]
+	return d.mq.decode(&d.cx[s.cx])^int(s.xor) == 1
+}
+
+// significancePass decodes the coefficients that are not yet significant
+// but have a significant neighbour (T.800 D.3.1).
+func (d *jpxBlockDecoder) significancePass(plane int) {
+	stride := d.w + 2
+	zero := &jpxZeroContexts[d.orient]
+	for y0 := 0; y0 < d.h; y0 += 4 {
+		for x := range d.w {
+			for y := y0; y < min(y0+4, d.h); y++ {
+				i := (y+1)*stride + x + 1
+				f := d.flags[i]
+				if f&jpxSig != 0 || f&jpxSigNeighbours == 0 {
+					continue
+				}
+
+				d.flags[i] |= jpxVisited
+				var bit int
+				if d.rawPass {
+					bit = d.raw.bit()
+				} else {
+					bit = d.mq.decode(&d.cx[zero[f&0xff]])
+				}
+				if bit == 1 {
+					d.setSignificant(x, y, plane, d.decodeSign(f))
+				}
+			}
+		}
+	}
+}
+
+// refinementPass decodes the next bit of the coefficients that became
+// significant in earlier bit-planes (T.800 D.3.3).
+func (d *jpxBlockDecoder) refinementPass(plane int) {
+	stride := d.w + 2
+	for y0 := 0; y0 < d.h; y0 += 4 {
+		for x := range d.w {
+			for y := y0; y < min(y0+4, d.h); y++ {
+				i := (y+1)*stride + x + 1
+				f := d.flags[i]
+				if f&(jpxSig|jpxVisited) != jpxSig {
+					continue
+				}
+
+				var bit int
+				if d.rawPass {
+					bit = d.raw.bit()
+				} else {
+					cx := jpxCtxMag
+					if f&jpxRefined != 0 {
+						cx += 2
+					} else if f&jpxSigNeighbours != 0 {
+						cx++
+					}
+					bit = d.mq.decode(&d.cx[cx])
+				}
+
+				if bit == 1 {
+					d.mag[y*d.w+x] += 1 << plane
+				} else {
+					d.mag[y*d.w+x] -= 1 << plane
+				}
+				d.flags[i] |= jpxRefined
+			}
+		}
+	}
+}
+
+// cleanupPass decodes the coefficients skipped by the significance pass,
+// using run-length coding for columns of four without significant
+// neighbours (T.800 D.3.4).
+func (d *jpxBlockDecoder) cleanupPass(plane int) {
+	stride := d.w + 2
+	zero := &jpxZeroContexts[d.orient]
+	const busy = jpxSig | jpxVisited | jpxSigNeighbours
+
+	for y0 := 0; y0 < d.h; y0 += 4 {
+		for x := range d.w {
+			y := y0
+			i := (y0+1)*stride + x + 1
+			if y0+4 <= d.h && (d.flags[i]|d.flags[i+stride]|d.flags[i+2*stride]|d.flags[i+3*stride])&busy == 0 {
+				if d.mq.decode(&d.cx[jpxCtxRun]) == 0 {
+					continue
+				}
+				y += d.mq.decode(&d.cx[jpxCtxUniform])<<1 | d.mq.decode(&d.cx[jpxCtxUniform])
+				d.setSignificant(x, y, plane, d.decodeSign(d.flags[(y+1)*stride+x+1]))
+				y++
+			}
+
+			for ; y < min(y0+4, d.h); y++ {
+				i := (y+1)*stride + x + 1
+				f := d.flags[i]
+				if f&(jpxSig|jpxVisited) == 0 && d.mq.decode(&d.cx[zero[f&0xff]]) == 1 {
+					d.setSignificant(x, y, plane, d.decodeSign(f))
+				}
+				d.flags[i] &^= jpxVisited
+			}
+		}
+	}
+
+	if d.style&jpxSegSymbols != 0 {
+		for range 4 {
+			d.mq.decode(&d.cx[jpxCtxUniform])
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+)
+
+// jpxTileComponent is one component of a tile, divided into resolution
+// levels, sub-bands, precincts and code-blocks (T.800 B.5 to B.7).
+type jpxTileComponent struct {
+	x0, y0, x1, y1 int
+	coding         *jpxCoding
+	roiShift       int
+	resolutions    []*jpxResolution
+}
+
+// jpxResolution is a resolution level of a tile-component. Level 0 holds
+// the LL sub-band, the others the HL, LH and HH sub-bands.
+type jpxResolution struct {
+	x0, y0, x1, y1 int
+	ppx, ppy       int
+	pw, ph         int
+	bands          []*jpxBand
+	precincts      []*jpxPrecinct
+}
+
+// jpxBand is a sub-band and its dequantized coefficients.
+type jpxBand struct {
+	orient         int
+	x0, y0, x1, y1 int
+
+	// planes is the number of magnitude bit-planes, including the ROI
+	// shift, and step the quantization step size.
+	planes int
+	step   float32
+
+	coeffs []float32
+}
+
+// jpxPrecinct is a precinct of a resolution level: the unit of data of a
+// packet.
+type jpxPrecinct struct {
+	// layers is the number of quality layers decoded so far.
+	layers int
+
+	// x and y locate the precinct on the reference grid, for the
+	// position-driven progression orders.
+	x, y int
+
+	bands []*jpxPrecinctBand
+}
+
+// jpxPrecinctBand holds the code-blocks of a sub-band within a precinct and
+// the tag trees coding their inclusion and zero bit-planes.
+type jpxPrecinctBand struct {
+	band       *jpxBand
+	blocks     []*jpxCodeBlock
+	incl, zero *jpxTagTree
+}
+
+// jpxCodeBlock accumulates the coding passes of a code-block over the
+// layers.
+type jpxCodeBlock struct {
+	x0, y0, x1, y1 int
+
+	included bool
+	lblock   int
+	zero     int
+	passes   int
+	segs     []jpxSegment
+}
+
+// newTileComponent lays out component c of the tile covering (tx0, ty0) to
+// (tx1, ty1) on the reference grid.
+func (cs *jpxCodestream) newTileComponent(t *jpxTile, c, tx0, ty0, tx1, ty1 int) (*jpxTileComponent, error) {
+	comp := cs.size.components[c]
+	coding := cs.coding(t, c)
+	quant := cs.quant(t, c)
+
+	tc := &jpxTileComponent{
+		x0:       ceilDiv(tx0, comp.dx),
+		y0:       ceilDiv(ty0, comp.dy),
+		x1:       ceilDiv(tx1, comp.dx),
+		y1:       ceilDiv(ty1, comp.dy),
+		coding:   coding,
+		roiShift: cs.roiShift(t, c),
+	}
+
+	n := coding.levels
+	for r := 0; r <= n; r++ {
+		scale := 1 << (n - r)
+		res := &jpxResolution{
+			x0:  ceilDiv(tc.x0, scale),
+			y0:  ceilDiv(tc.y0, scale),
+			x1:  ceilDiv(tc.x1, scale),
+			y1:  ceilDiv(tc.y1, scale),
+			ppx: 15,
+			ppy: 15,
+		}
+		if coding.precincts != nil {
+			res.ppx, res.ppy = int(coding.precincts[r]&0xf), int(coding.precincts[r]>>4)
+		}
+		if res.x1 > res.x0 {
+			res.pw = ceilDiv(res.x1, 1<<res.ppx) - res.x0>>res.ppx
+		}
+		if res.y1 > res.y0 {
+			res.ph = ceilDiv(res.y1, 1<<res.ppy) - res.y0>>res.ppy
+		}
+
+		// Sub-bands at level r come from decomposition level nb.
+		nb := n - r + 1
+		orients := []int{jpxHL, jpxLH, jpxHH}
+		if r == 0 {
+			nb = n
+			orients = []int{jpxLL}
+		}
+		for _, o := range orients {
+			b := &jpxBand{orient: o, x0: res.x0, y0: res.y0, x1: res.x1, y1: res.y1}
+			if r > 0 {
+				// High-pass sub-bands are offset by half a sample.
+				xo, yo := (o&1)<<(nb-1), (o>>1)<<(nb-1)
+				b.x0, b.x1 = ceilDiv(tc.x0-xo, 1<<nb), ceilDiv(tc.x1-xo, 1<<nb)
+				b.y0, b.y1 = ceilDiv(tc.y0-yo, 1<<nb), ceilDiv(tc.y1-yo, 1<<nb)
+			}
+
+			if err := b.setQuant(quant, comp.precision, coding, r, nb, tc.roiShift); err != nil {
+				return nil, err
+			}
+			b.coeffs = make([]float32, (b.x1-b.x0)*(b.y1-b.y0))
+			res.bands = append(res.bands, b)
+		}
+
+		// Code-blocks do not cross precincts, whose size in a sub-band is
+		// halved above level 0.
+		pbx, pby := res.ppx, res.ppy
+		if r > 0 {
+			pbx, pby = pbx-1, pby-1
+		}
+		cbw, cbh := min(coding.cbw, pbx), min(coding.cbh, pby)
+
+		for py := range res.ph {
+			for px := range res.pw {
+				// The precinct's top-left corner in resolution coordinates.
+				rx := (res.x0>>res.ppx + px) << res.ppx
+				ry := (res.y0>>res.ppy + py) << res.ppy
+				p := &jpxPrecinct{
+					x: max(tx0, rx<<(n-r)*comp.dx),
+					y: max(ty0, ry<<(n-r)*comp.dy),
+				}
+
+				for _, b := range res.bands {
+					bx0, by0 := (res.x0>>res.ppx+px)<<pbx, (res.y0>>res.ppy+py)<<pby
+					p.bands = append(p.bands, newPrecinctBand(b,
+						max(bx0, b.x0), max(by0, b.y0),
+						min(bx0+1<<pbx, b.x1), min(by0+1<<pby, b.y1),
+						cbw, cbh))
+				}
+				res.precincts = append(res.precincts, p)
+			}
+		}
+
+		tc.resolutions = append(tc.resolutions, res)
+	}
+
+	return tc, nil
+}
+
+// newPrecinctBand divides the area (x0, y0) to (x1, y1) of sub-band b
+// into code-blocks of 1<<cbw by 1<<cbh.
+func newPrecinctBand(b *jpxBand, x0, y0, x1, y1, cbw, cbh int) *jpxPrecinctBand {
+	pb := &jpxPrecinctBand{band: b}
+	if x1 <= x0 || y1 <= y0 {
+		return pb
+	}
+
+	cx0, cx1 := x0>>cbw, ceilDiv(x1, 1<<cbw)
+	cy0, cy1 := y0>>cbh, ceilDiv(y1, 1<<cbh)
+	for cy := cy0; cy < cy1; cy++ {
+		for cx := cx0; cx < cx1; cx++ {
+			pb.blocks = append(pb.blocks, &jpxCodeBlock{
+				x0:     max(cx<<cbw, x0),
+				y0:     max(cy<<cbh, y0),
+				x1:     min((cx+1)<<cbw, x1),
+				y1:     min((cy+1)<<cbh, y1),
+				lblock: 3,
+			})
+		}
+	}
+
+	pb.incl = newJPXTagTree(cx1-cx0, cy1-cy0)
+	pb.zero = newJPXTagTree(cx1-cx0, cy1-cy0)
+	return pb
+}
+
+// setQuant sets the bit-plane count and step size of a sub-band of
+// resolution level r and decomposition level nb (T.800 E.1).
+func (b *jpxBand) setQuant(q *jpxQuant, precision int, coding *jpxCoding, r, nb, roiShift int) error {
+	var step uint16
+	switch q.style {
+	case 1:
+		// Derived: the steps of all sub-bands follow from that of LL.
+		exp := int(q.steps[0]>>11) - coding.levels + nb
+		step = uint16(max(exp, 0))<<11 | q.steps[0]&0x7ff
+	default:
+		i := 0
+		if r > 0 {
+			i = 3*(r-1) + b.orient
+		}
+		if i >= len(q.steps) {
+			return fmt.Errorf("missing quantization step")
+		}
+		step = q.steps[i]
+	}
+
+	exp, mant := int(step>>11), float64(step&0x7ff)
+	b.planes = q.guard + exp - 1 + roiShift
+	if b.planes > 30 {
+		return fmt.Errorf("too many bit-planes")
+	}
+
+	// The dynamic range grows by one bit for each high-pass filtering.
+	gain := [...]int{jpxLL: 0, jpxHL: 1, jpxLH: 1, jpxHH: 2}[b.orient]
+	b.step = float32(math.Ldexp(1+mant/2048, precision+gain-exp))
+	if coding.reversible {
+		b.step = 1
+	}
+	return nil
+}
+
+// coding returns the coding style of component c of tile t. Tile-part
+// segments take precedence over the main header and COC over COD.
+func (cs *jpxCodestream) coding(t *jpxTile, c int) *jpxCoding {
+	if coc := t.params.coc[c]; coc != nil {
+		return coc
+	}
+	if t.params.cod != nil {
+		return &t.params.cod.jpxCoding
+	}
+	if coc := cs.main.coc[c]; coc != nil {
+		return coc
+	}
+	return &cs.main.cod.jpxCoding
+}
+
+// quant returns the quantization of component c of tile t.
+func (cs *jpxCodestream) quant(t *jpxTile, c int) *jpxQuant {
+	if q := t.params.qcc[c]; q != nil {
+		return q
+	}
+	if t.params.qcd != nil {
+		return t.params.qcd
+	}
+	if q := cs.main.qcc[c]; q != nil {
+		return q
+	}
+	return cs.main.qcd
+}
+
+// roiShift returns the region of interest shift of component c of tile t.
+func (cs *jpxCodestream) roiShift(t *jpxTile, c int) int {
+	if s, ok := t.params.rgn[c]; ok {
+		return s
+	}
+	return cs.main.rgn[c]
+}
+
+// cod returns the COD segment in effect for tile t.
+func (cs *jpxCodestream) cod(t *jpxTile) *jpxCodSegment {
+	if t.params.cod != nil {
+		return t.params.cod
+	}
+	return cs.main.cod
+}
+
+// decodeBlocks runs the coding passes of every code-block of tc and stores
+// the dequantized coefficients in its sub-bands.
+func (tc *jpxTileComponent) decodeBlocks() {
+	for _, res := range tc.resolutions {
+		for _, p := range res.precincts {
+			for _, pb := range p.bands {
+				for _, cb := range pb.blocks {
+					tc.decodeBlock(pb.band, cb)
+				}
+			}
+		}
+	}
+}
+
+func (tc *jpxTileComponent) decodeBlock(b *jpxBand, cb *jpxCodeBlock) {
+	planes := b.planes - cb.zero
+	if cb.passes == 0 || planes <= 0 {
+		return
+	}
+
+	w, h := cb.x1-cb.x0, cb.y1-cb.y0
+	d := newJPXBlockDecoder(w, h, tc.coding.style, b.orient)
+	vals := d.decode(cb.segs, planes)
+
+	bw := b.x1 - b.x0
+	for y := range h {
+		row := b.coeffs[(cb.y0-b.y0+y)*bw+cb.x0-b.x0:]
+		for x, v := range vals[y*w : (y+1)*w] {
+			if v == 0 {
+				continue
+			}
+
+			// With the maximum shift method, coefficients above the
+			// background's range belong to the region of interest.
+			if s := tc.roiShift; s > 0 {
+				if m := abs32(v); m >= 2<<s {
+					m >>= s
+					if v < 0 {
+						m = -m
+					}
+					v = m
+				}
+			}
+
+			// Values are held at twice their magnitude.
+			if tc.coding.reversible {
+				row[x] = float32(v / 2)
+			} else {
+				row[x] = float32(v) * b.step / 2
+			}
+		}
+	}
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// jpxPlane holds the decoded samples of one image component, offset to be
+// unsigned.
+type jpxPlane struct {
+	jpxComponent
+
+	// x0 and y0 are the component coordinates of the first sample.
+	x0, y0 int
+	w, h   int
+	pix    []uint16
+}
+
+// decode decodes the tiles of the codestream into one plane per component.
+// Tiles without data are left at zero.
+func (cs *jpxCodestream) decode() ([]*jpxPlane, error) {
+	s := &cs.size
+	planes := make([]*jpxPlane, len(s.components))
+	for c, comp := range s.components {
+		p := &jpxPlane{
+			jpxComponent: comp,
+			x0:           ceilDiv(s.x0, comp.dx),
+			y0:           ceilDiv(s.y0, comp.dy),
+		}
+		p.w = ceilDiv(s.x1, comp.dx) - p.x0
+		p.h = ceilDiv(s.y1, comp.dy) - p.y0
+		p.pix = make([]uint16, p.w*p.h)
+		planes[c] = p
+	}
+
+	for _, t := range cs.tiles {
+		if err := cs.decodeTile(t, planes); err != nil {
+			return nil, fmt.Errorf("tile %d: %w", t.index, err)
+		}
+	}
+	return planes, nil
+}
+
+// decodeTile decodes tile t into planes. Truncated packet data is not an
+// error: the code-blocks decode from the passes received.
+func (cs *jpxCodestream) decodeTile(t *jpxTile, planes []*jpxPlane) error {
+	s := &cs.size
+	p, q := t.index%s.tilesX, t.index/s.tilesX
+	tx0 := max(s.tileX0+p*s.tileW, s.x0)
+	ty0 := max(s.tileY0+q*s.tileH, s.y0)
+	tx1 := min(s.tileX0+(p+1)*s.tileW, s.x1)
+	ty1 := min(s.tileY0+(q+1)*s.tileH, s.y1)
+
+	tcs := make([]*jpxTileComponent, len(s.components))
+	for c := range tcs {
+		tc, err := cs.newTileComponent(t, c, tx0, ty0, tx1, ty1)
+		if err != nil {
+			return err
+		}
+		tcs[c] = tc
+	}
+
+	if err := cs.decodePackets(t, tcs); err != nil && err != errJPXTruncated {
+		return err
+	}
+
+	samples := make([][]float32, len(tcs))
+	for c, tc := range tcs {
+		tc.decodeBlocks()
+		samples[c] = tc.reconstruct()
+	}
+
+	if cs.cod(t).mct && len(tcs) >= 3 {
+		if len(samples[1]) != len(samples[0]) || len(samples[2]) != len(samples[0]) {
+			return fmt.Errorf("component transform of sub-sampled components")
+		}
+		inverseMCT(samples[0], samples[1], samples[2], tcs[0].coding.reversible)
+	}
+
+	for c, tc := range tcs {
+		pl := planes[c]
+		offset := float32(int(1) << (pl.precision - 1))
+		maxVal := float32(int(1)<<pl.precision - 1)
+
+		w := tc.x1 - tc.x0
+		for y := range tc.y1 - tc.y0 {
+			row := pl.pix[(tc.y0-pl.y0+y)*pl.w+tc.x0-pl.x0:]
+			for x, v := range samples[c][y*w : (y+1)*w] {
+				// Signed samples are offset as unsigned ones are level
+				// shifted.
+				v = max(0, min(maxVal, v+offset+0.5))
+				row[x] = uint16(v)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package parser
+
+import "math"
+
+// jpxExtension is the number of samples by which a signal is extended on
+// each side before filtering, enough for the four lifting steps of the 9/7
+// filter.
+const jpxExtension = 6
+
+// Lifting constants of the irreversible 9/7 filter (T.800 Table F.4).
+const (
+	jpxAlpha = -1.586134342059924
+	jpxBeta  = -0.052980118572961
+	jpxGamma = 0.882911075530934
+	jpxDelta = 0.443506852043971
+	jpxK     = 1.230174104914001
+)
+
+// reconstruct applies the inverse wavelet transform to the sub-bands of tc
+// and returns its samples, row by row (T.800 F.3).
+func (tc *jpxTileComponent) reconstruct() []float32 {
+	ll := tc.resolutions[0].bands[0].coeffs
+	reversible := tc.coding.reversible
+
+	var buf []float32
+	for _, res := range tc.resolutions[1:] {
+		w, h := res.x1-res.x0, res.y1-res.y0
+		out := make([]float32, w*h)
+
+		// Interleave the low-pass coefficients at even positions and the
+		// high-pass ones at odd positions.
+		prev := &jpxBand{orient: jpxLL, x0: ceilDiv(res.x0, 2), y0: ceilDiv(res.y0, 2),
+			x1: ceilDiv(res.x1, 2), y1: ceilDiv(res.y1, 2), coeffs: ll}
+		for _, b := range append([]*jpxBand{prev}, res.bands...) {
+			bw := b.x1 - b.x0
+			xo, yo := b.orient&1, b.orient>>1
+			for y := b.y0; y < b.y1; y++ {
+				v := 2*y + yo - res.y0
+				for x := b.x0; x < b.x1; x++ {
+					out[v*w+2*x+xo-res.x0] = b.coeffs[(y-b.y0)*bw+x-b.x0]
+				}
+			}
+		}
+
+		if n := max(w, h) + 2*jpxExtension; len(buf) < n {
+			buf = make([]float32, n)
+		}
+		for y := range h {
+			row := out[y*w : (y+1)*w]
+			copy(buf[jpxExtension:], row)
+			synthesize(buf, w, res.x0, reversible)
+			copy(row, buf[jpxExtension:jpxExtension+w])
+		}
+		for x := range w {
+			for y := range h {
+				buf[jpxExtension+y] = out[y*w+x]
+			}
+			synthesize(buf, h, res.y0, reversible)
+			for y := range h {
+				out[y*w+x] = buf[jpxExtension+y]
+			}
+		}
+
+		ll = out
+	}
+
+	return ll
+}
+
+// synthesize applies the one-dimensional inverse transform to the n
+// interleaved coefficients at buf[jpxExtension:] of a signal starting at
+// coordinate i0. Low-pass coefficients lie at even coordinates (T.800
+// F.3.6).
+func synthesize(buf []float32, n, i0 int, reversible bool) {
+	x := buf[jpxExtension : jpxExtension+n]
+	if n <= 1 {
+		if n == 1 && i0&1 != 0 {
+			if reversible {
+				x[0] = float32(int32(x[0]) / 2)
+			} else {
+				x[0] /= 2
+			}
+		}
+		return
+	}
+
+	// Extend the signal symmetrically about its first and last samples.
+	period := 2 * (n - 1)
+	for k := 1; k <= jpxExtension; k++ {
+		buf[jpxExtension-k] = x[mirror(-k, n, period)]
+		buf[jpxExtension+n-1+k] = x[mirror(n-1+k, n, period)]
+	}
+
+	// Buffer index j holds coordinate i0-jpxExtension+j; first is the
+	// first index of each parity from which a lifting step can run.
+	s := buf[:n+2*jpxExtension]
+	even := 1 + (i0-jpxExtension+1)&1
+	odd := 1 + (i0-jpxExtension)&1
+	lift := func(first int, f func(j int)) {
+		for j := first; j < len(s)-1; j += 2 {
+			f(j)
+		}
+	}
+
+	if reversible {
+		lift(even, func(j int) {
+			s[j] -= float32(math.Floor(float64(s[j-1]+s[j+1]+2) / 4))
+		})
+		lift(odd, func(j int) {
+			s[j] += float32(math.Floor(float64(s[j-1]+s[j+1]) / 2))
+		})
+		return
+	}
+
+	lift(even, func(j int) { s[j] *= jpxK })
+	lift(odd, func(j int) { s[j] *= 1 / jpxK })
+	lift(even, func(j int) { s[j] -= jpxDelta * (s[j-1] + s[j+1]) })
+	lift(odd, func(j int) { s[j] -= jpxGamma * (s[j-1] + s[j+1]) })
+	lift(even, func(j int) { s[j] -= jpxBeta * (s[j-1] + s[j+1]) })
+	lift(odd, func(j int) { s[j] -= jpxAlpha * (s[j-1] + s[j+1]) })
+}
+
+// mirror maps index k of a signal of n samples extended symmetrically to
+// the sample it repeats.
+func mirror(k, n, period int) int {
+	k %= period
+	if k < 0 {
+		k += period
+	}
+	if k >= n {
+		k = period - k
+	}
+	return k
+}
+
+// inverseMCT undoes the multiple component transform of the first three
+// components of a tile (T.800 G.2 and G.3).
+func inverseMCT(c0, c1, c2 []float32, reversible bool) {
+	for i := range c0 {
+		y, u, v := c0[i], c1[i], c2[i]
+		if reversible {
+			g := y - float32(math.Floor(float64(u+v)/4))
+			c0[i], c1[i], c2[i] = v+g, g, u+g
+			continue
+		}
+		c0[i] = y + 1.402*v
+		c1[i] = y - 0.34413*u - 0.71414*v
+		c2[i] = y + 1.772*u
+	}
+}
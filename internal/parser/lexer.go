@@ -119,6 +119,13 @@ func (l *Lexer) NextToken() (model.Token, error) {
 	case model.CloseLBracket:
 		return model.Token{Type: model.TokArrayEnd, Value: string(model.CloseLBracket)}, nil
 
+	// Braces only delimit PostScript calculator procedures.
+	case model.OpenBrace:
+		return model.Token{Type: model.TokProcStart, Value: string(model.OpenBrace)}, nil
+
+	case model.CloseBrace:
+		return model.Token{Type: model.TokProcEnd, Value: string(model.CloseBrace)}, nil
+
 	case model.LessThan:
 		b2, err := l.ReadByte()
 
@@ -252,6 +259,16 @@ func (l *Lexer) ReadLiteralString() (model.Token, error) {
 			return model.Token{}, err
 		}
 
+		// An escaped byte is kept verbatim and never balances parentheses.
+		if b == '\\' {
+			buff.WriteByte(b)
+			if b, err = l.ReadByte(); err != nil {
+				return model.Token{}, err
+			}
+			buff.WriteByte(b)
+			continue
+		}
+
 		if b == model.OpenParen {
 			depth++
 		} else if b == model.CloseParen {
@@ -144,6 +144,20 @@ func TestLexer_NextToken(t *testing.T) {
 				{Type: model.TokEOF},
 			},
 		},
+		{
+			name:  "Procedures",
+			input: "{ 2 {exch} if }",
+			expected: []model.Token{
+				{Type: model.TokProcStart, Value: "{"},
+				{Type: model.TokNumber, Value: "2"},
+				{Type: model.TokProcStart, Value: "{"},
+				{Type: model.TokKeyword, Value: "exch"},
+				{Type: model.TokProcEnd, Value: "}"},
+				{Type: model.TokKeyword, Value: "if"},
+				{Type: model.TokProcEnd, Value: "}"},
+				{Type: model.TokEOF},
+			},
+		},
 		{
 			name:  "Arrays",
 			input: "[ 123 /Name (String) ]",
@@ -41,3 +41,28 @@ func (o *ObjectTable) GetObjectValue(objectNum int, gen int) (model.PDFValue, bo
 
 	return obj.Value, true
 }
+
+// maxResolveDepth bounds chains of references to references.
+const maxResolveDepth = 32
+
+// Resolve follows indirect references until a direct value is reached.
+// References to missing objects resolve to PDFNull, as the specification
+// requires.
+func (o *ObjectTable) Resolve(v model.PDFValue) model.PDFValue {
+
+	for range maxResolveDepth {
+		ref, ok := v.(model.PDFIndirectRef)
+		if !ok {
+			return v
+		}
+
+		val, ok := o.GetObjectValue(ref.ObjectNumber, ref.Generation)
+		if !ok {
+			return model.PDFNull{}
+		}
+
+		v = val
+	}
+
+	return model.PDFNull{}
+}
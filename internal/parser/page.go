@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// maxPageTreeDepth bounds the /Parent chain walked for inherited attributes.
+const maxPageTreeDepth = 64
+
+// defaultMediaBox is US Letter, used when a page tree provides no /MediaBox.
+var defaultMediaBox = model.Rectangle{LLX: 0, LLY: 0, URX: 612, URY: 792}
+
+// Resolve follows indirect references within the document.
+func (doc *Document) Resolve(v model.PDFValue) model.PDFValue {
+	return doc.Objects.Resolve(v)
+}
+
+// PageAttribute returns the value of key on a page dictionary, walking up the
+// page tree for attributes inherited from ancestor /Pages nodes.
+func (doc *Document) PageAttribute(page model.PDFDict, key string) model.PDFValue {
+
+	node := page
+
+	for range maxPageTreeDepth {
+		if v, ok := node[key]; ok {
+			return doc.Resolve(v)
+		}
+
+		parent, ok := doc.Resolve(node["Parent"]).(model.PDFDict)
+		if !ok {
+			return nil
+		}
+		node = parent
+	}
+
+	return nil
+}
+
+// ParseRectangle converts a four-number array into a normalised rectangle.
+func ParseRectangle(v model.PDFValue, r model.Resolver) (model.Rectangle, bool) {
+	arr, ok := r.Resolve(v).(model.PDFArray)
+	if !ok || len(arr) != 4 {
+		return model.Rectangle{}, false
+	}
+
+	var n [4]float64
+	for i, e := range arr {
+		num, ok := r.Resolve(e).(model.PDFNumber)
+		if !ok {
+			return model.Rectangle{}, false
+		}
+		n[i] = float64(num)
+	}
+
+	return model.Rectangle{
+		LLX: min(n[0], n[2]), LLY: min(n[1], n[3]),
+		URX: max(n[0], n[2]), URY: max(n[1], n[3]),
+	}, true
+}
+
+// Page returns the attributes and decoded contents of the page with the
+// zero-based index i.
+func (doc *Document) Page(i int) (*model.Page, error) {
+
+	if i < 0 || i >= len(doc.Pages) {
+		return nil, fmt.Errorf("page %d out of range (document has %d pages)", i+1, len(doc.Pages))
+	}
+
+	dict, ok := doc.Pages[i].Value.(model.PDFDict)
+	if !ok {
+		return nil, fmt.Errorf("page %d is not a dictionary", i+1)
+	}
+
+	page := &model.Page{UserUnit: 1}
+
+	page.MediaBox, ok = ParseRectangle(doc.PageAttribute(dict, "MediaBox"), doc)
+	if !ok {
+		page.MediaBox = defaultMediaBox
+	}
+
+	// The crop box defaults to, and is clipped by, the media box.
+	page.CropBox = page.MediaBox
+	if crop, ok := ParseRectangle(doc.PageAttribute(dict, "CropBox"), doc); ok {
+		page.CropBox = crop.Intersect(page.MediaBox)
+	}
+
+	if rot, ok := doc.PageAttribute(dict, "Rotate").(model.PDFNumber); ok {
+		page.Rotate = ((int(rot)/90)%4 + 4) % 4 * 90
+	}
+
+	if uu, ok := doc.Resolve(dict["UserUnit"]).(model.PDFNumber); ok && uu > 0 {
+		page.UserUnit = float64(uu)
+	}
+
+	page.Resources, _ = doc.PageAttribute(dict, "Resources").(model.PDFDict)
+
+	contents, err := doc.pageContents(dict)
+	if err != nil {
+		return nil, fmt.Errorf("page %d contents: %w", i+1, err)
+	}
+	page.Contents = contents
+
+	return page, nil
+}
+
+// pageContents decodes and concatenates the page's content streams. Streams
+// in a /Contents array are separated by whitespace, since an operator may not
+// be split across them.
+func (doc *Document) pageContents(page model.PDFDict) ([]byte, error) {
+
+	var streams []model.PDFStream
+
+	switch c := doc.Resolve(page["Contents"]).(type) {
+	case nil, model.PDFNull:
+		return nil, nil
+	case model.PDFStream:
+		streams = append(streams, c)
+	case model.PDFArray:
+		for _, e := range c {
+			s, ok := doc.Resolve(e).(model.PDFStream)
+			if !ok {
+				return nil, fmt.Errorf("content array entry is not a stream: %v", e)
+			}
+			streams = append(streams, s)
+		}
+	default:
+		return nil, fmt.Errorf("invalid /Contents %v", c)
+	}
+
+	var out []byte
+	for _, s := range streams {
+		data, err := DecodeStream(s, doc)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data...)
+		out = append(out, '\n')
+	}
+
+	return out, nil
+}
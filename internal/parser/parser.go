@@ -9,7 +9,7 @@ import (
 
 type Parser struct {
 	l       *Lexer
-	buf     *model.Token
+	buf     []model.Token
 	objects *ObjectTable
 }
 
@@ -20,17 +20,19 @@ func NewParser(l *Lexer) *Parser {
 }
 
 func (p *Parser) next() (model.Token, error) {
-	if p.buf != nil {
-		t := *p.buf
-		p.buf = nil
+	if n := len(p.buf); n > 0 {
+		t := p.buf[n-1]
+		p.buf = p.buf[:n-1]
 		return t, nil
 	}
 
 	return p.l.NextToken()
 }
 
+// unread pushes t back so that the next call to next returns it. Tokens are
+// returned in the reverse order they were unread.
 func (p *Parser) unread(t model.Token) {
-	p.buf = &t
+	p.buf = append(p.buf, t)
 }
 
 func (p *Parser) Parse() (model.PDFValue, error) {
@@ -107,6 +109,7 @@ func (p *Parser) parseNumberOrRef(first model.Token) (model.PDFValue, error) {
 	}
 
 	p.unread(tok3)
+	p.unread(tok2)
 
 	return model.PDFNumber(float64(n1)), nil
 }
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -105,3 +106,106 @@ func TestParseDict(t *testing.T) {
 		t.Errorf("Expected value Page, got %v", nameVal)
 	}
 }
+
+func TestParseOperation(t *testing.T) {
+	input := "1 0 0 1 72 72 cm\n/F1 18 Tf [(A) -120 (B)] TJ\nq"
+	l := NewLexer(strings.NewReader(input))
+	p := NewParser(l)
+
+	expected := []Operation{
+		{Operator: "cm", Operands: []model.PDFValue{
+			model.PDFNumber(1), model.PDFNumber(0), model.PDFNumber(0),
+			model.PDFNumber(1), model.PDFNumber(72), model.PDFNumber(72),
+		}},
+		{Operator: "Tf", Operands: []model.PDFValue{model.PDFName("F1"), model.PDFNumber(18)}},
+		{Operator: "TJ", Operands: []model.PDFValue{
+			model.PDFArray{model.PDFString("A"), model.PDFNumber(-120), model.PDFString("B")},
+		}},
+		{Operator: "q"},
+	}
+
+	for i, want := range expected {
+		got, err := p.ParseOperation()
+		if err != nil {
+			t.Fatalf("ParseOperation() #%d error = %v", i, err)
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("ParseOperation() #%d = %v, want %v", i, *got, want)
+		}
+	}
+
+	if _, err := p.ParseOperation(); err != io.EOF {
+		t.Errorf("ParseOperation() at end = %v, want io.EOF", err)
+	}
+}
+
+func TestParseInlineImage(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		dict  model.PDFDict
+		data  string
+	}{
+		{
+			name:  "Abbreviations",
+			input: "BI /W 2 /H 1 /CS /RGB /BPC 8 /F /AHx ID 00ff00ff0000> EI Q",
+			dict: model.PDFDict{
+				"Width": model.PDFNumber(2), "Height": model.PDFNumber(1),
+				"ColorSpace": model.PDFName("DeviceRGB"), "BitsPerComponent": model.PDFNumber(8),
+				"Filter": model.PDFName("AHx"),
+			},
+			data: "00ff00ff0000>",
+		},
+		{
+			name:  "EIInsideData",
+			input: "BI /W 4 /H 1 /CS /G /BPC 8 ID \x00EI\x01\nEI Q",
+			dict: model.PDFDict{
+				"Width": model.PDFNumber(4), "Height": model.PDFNumber(1),
+				"ColorSpace": model.PDFName("DeviceGray"), "BitsPerComponent": model.PDFNumber(8),
+			},
+			data: "\x00EI\x01",
+		},
+		{
+			// The declared length is used even where the data contains an
+			// apparent EI.
+			name:  "Length",
+			input: "BI /W 2 /H 1 /CS [/I /G 1 <00ff>] /BPC 8 /L 4 ID \x00 EI EI Q",
+			dict: model.PDFDict{
+				"Width": model.PDFNumber(2), "Height": model.PDFNumber(1),
+				"ColorSpace": model.PDFArray{
+					model.PDFName("Indexed"), model.PDFName("DeviceGray"),
+					model.PDFNumber(1), model.PDFHexString("00ff"),
+				},
+				"BitsPerComponent": model.PDFNumber(8), "Length": model.PDFNumber(4),
+			},
+			data: "\x00 EI",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewParser(NewLexer(strings.NewReader(tc.input)))
+			op, err := p.ParseOperation()
+			if err != nil {
+				t.Fatalf("ParseOperation() error = %v", err)
+			}
+
+			want := Operation{Operator: "BI", Operands: []model.PDFValue{
+				model.PDFStream{Dict: tc.dict, Data: []byte(tc.data)},
+			}}
+			if !reflect.DeepEqual(*op, want) {
+				t.Errorf("ParseOperation() = %v, want %v", *op, want)
+			}
+
+			next, err := p.ParseOperation()
+			if err != nil || next.Operator != "Q" {
+				t.Errorf("ParseOperation() after image = %v, %v, want Q", next, err)
+			}
+		})
+	}
+
+	p := NewParser(NewLexer(strings.NewReader("BI /W 1 /H 1 ID \x00")))
+	if _, err := p.ParseOperation(); err == nil {
+		t.Errorf("ParseOperation() of an image without EI expected an error")
+	}
+}
@@ -1 +1,453 @@
 package parser
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// Filter decodes data encoded with a single stream filter. params is the
+// filter's resolved /DecodeParms dictionary and may be nil.
+type Filter func(data []byte, params model.PDFDict) ([]byte, error)
+
+var filters = map[string]Filter{
+	"FlateDecode":     decodeFlate,
+	"ASCIIHexDecode":  decodeASCIIHex,
+	"ASCII85Decode":   decodeASCII85,
+	"LZWDecode":       decodeLZW,
+	"RunLengthDecode": decodeRunLength,
+	"CCITTFaxDecode":  decodeCCITTFax,
+	"DCTDecode":       decodeDCT,
+	"JPXDecode":       decodeJPX,
+	"JBIG2Decode":     decodeJBIG2,
+}
+
+// filterAbbreviations maps the short filter names allowed in inline images to
+// their full names.
+var filterAbbreviations = map[string]string{
+	"Fl":  "FlateDecode",
+	"AHx": "ASCIIHexDecode",
+	"A85": "ASCII85Decode",
+	"LZW": "LZWDecode",
+	"RL":  "RunLengthDecode",
+	"CCF": "CCITTFaxDecode",
+	"DCT": "DCTDecode",
+}
+
+// FilterSpec is one entry of a stream's filter pipeline.
+type FilterSpec struct {
+	Name   string
+	Params model.PDFDict
+}
+
+// StreamFilters returns the filter pipeline declared by a stream dictionary,
+// in the order the filters must be applied.
+func StreamFilters(dict model.PDFDict, r model.Resolver) ([]FilterSpec, error) {
+	var names, params model.PDFArray
+
+	switch f := r.Resolve(dict["Filter"]).(type) {
+	case nil, model.PDFNull:
+		return nil, nil
+	case model.PDFName:
+		names = model.PDFArray{f}
+		params = model.PDFArray{r.Resolve(dict["DecodeParms"])}
+	case model.PDFArray:
+		names = f
+		if p, ok := r.Resolve(dict["DecodeParms"]).(model.PDFArray); ok {
+			params = p
+		}
+	default:
+		return nil, fmt.Errorf("invalid /Filter %v", f)
+	}
+
+	specs := make([]FilterSpec, len(names))
+	for i, n := range names {
+		name, ok := r.Resolve(n).(model.PDFName)
+		if !ok {
+			return nil, fmt.Errorf("filter name is not a name: %v", n)
+		}
+
+		full := string(name)
+		if long, ok := filterAbbreviations[full]; ok {
+			full = long
+		}
+		specs[i].Name = full
+
+		if i < len(params) {
+			specs[i].Params, _ = r.Resolve(params[i]).(model.PDFDict)
+		}
+
+		if full == "JBIG2Decode" {
+			p, err := resolveJBIG2Globals(specs[i].Params, r)
+			if err != nil {
+				return nil, err
+			}
+			specs[i].Params = p
+		}
+	}
+
+	return specs, nil
+}
+
+// resolveJBIG2Globals returns params with its /JBIG2Globals stream replaced
+// by one holding the stream's decoded data, as filters cannot resolve
+// references.
+func resolveJBIG2Globals(params model.PDFDict, r model.Resolver) (model.PDFDict, error) {
+	g, ok := r.Resolve(params["JBIG2Globals"]).(model.PDFStream)
+	if !ok {
+		return params, nil
+	}
+
+	data, err := DecodeStream(g, r)
+	if err != nil {
+		return nil, fmt.Errorf("JBIG2Globals: %w", err)
+	}
+
+	out := make(model.PDFDict, len(params))
+	for k, v := range params {
+		out[k] = v
+	}
+	out["JBIG2Globals"] = model.PDFStream{Data: data}
+	return out, nil
+}
+
+// DecodeStream returns the data of s with all of its filters applied.
+func DecodeStream(s model.PDFStream, r model.Resolver) ([]byte, error) {
+	specs, err := StreamFilters(s.Dict, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ApplyFilters(s.Data, specs)
+}
+
+// ApplyFilters runs data through a filter pipeline.
+func ApplyFilters(data []byte, specs []FilterSpec) ([]byte, error) {
+	for _, spec := range specs {
+		f, ok := filters[spec.Name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported filter %s", spec.Name)
+		}
+
+		out, err := f(data, spec.Params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", spec.Name, err)
+		}
+		data = out
+	}
+
+	return data, nil
+}
+
+// intParam returns an integer entry of a decode parameter dictionary.
+func intParam(params model.PDFDict, key string, def int) int {
+	if n, ok := params[key].(model.PDFNumber); ok {
+		return int(n)
+	}
+	return def
+}
+
+// boolParam returns a boolean entry of a decode parameter dictionary.
+func boolParam(params model.PDFDict, key string, def bool) bool {
+	if b, ok := params[key].(model.PDFBoolean); ok {
+		return bool(b)
+	}
+	return def
+}
+
+// ---- FlateDecode ----
+
+func decodeFlate(data []byte, params model.PDFDict) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(zr)
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+
+	// Truncated streams and bad checksums are common; keep whatever was
+	// decoded.
+	return applyPredictor(out, params)
+}
+
+// ---- LZWDecode ----
+
+func decodeLZW(data []byte, params model.PDFDict) ([]byte, error) {
+	early := intParam(params, "EarlyChange", 1)
+
+	const (
+		clearCode = 256
+		eodCode   = 257
+	)
+
+	var (
+		out   []byte
+		table [][]byte
+		prev  []byte
+		width = 9
+	)
+
+	reset := func() {
+		table = table[:0]
+		for i := 0; i < 256; i++ {
+			table = append(table, []byte{byte(i)})
+		}
+		table = append(table, nil, nil)
+		width = 9
+		prev = nil
+	}
+	reset()
+
+	br := util.NewBitReader(data)
+	for {
+		code, ok := br.ReadBits(width)
+		if !ok || code == eodCode {
+			break
+		}
+
+		if code == clearCode {
+			reset()
+			continue
+		}
+
+		var entry []byte
+		switch {
+		case int(code) < len(table) && table[code] != nil:
+			entry = table[code]
+		case int(code) == len(table) && prev != nil:
+			entry = append(append([]byte(nil), prev...), prev[0])
+		default:
+			return out, fmt.Errorf("invalid code %d", code)
+		}
+
+		out = append(out, entry...)
+
+		if prev != nil && len(table) < 4096 {
+			table = append(table, append(append([]byte(nil), prev...), entry[0]))
+		}
+		prev = entry
+
+		switch n := len(table) + early; {
+		case n >= 2048:
+			width = 12
+		case n >= 1024:
+			width = 11
+		case n >= 512:
+			width = 10
+		}
+	}
+
+	return applyPredictor(out, params)
+}
+
+// ---- ASCIIHexDecode ----
+
+func decodeASCIIHex(data []byte, params model.PDFDict) ([]byte, error) {
+	digits := make([]byte, 0, len(data))
+
+	for _, b := range data {
+		if b == '>' {
+			break
+		}
+		if IsWhiteSpace(b) {
+			continue
+		}
+		digits = append(digits, b)
+	}
+
+	// A missing final digit is taken to be 0.
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ---- ASCII85Decode ----
+
+func decodeASCII85(data []byte, params model.PDFDict) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+
+	// Each z stands for four bytes, which is the most any input byte gives.
+	out := make([]byte, 4*len(data))
+	n, nsrc, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, err
+	}
+	if nsrc < len(data) {
+		return nil, fmt.Errorf("ASCII85 data decoded up to byte %d of %d", nsrc, len(data))
+	}
+	return out[:n], nil
+}
+
+// ---- RunLengthDecode ----
+
+func decodeRunLength(data []byte, params model.PDFDict) ([]byte, error) {
+	var out []byte
+
+	for i := 0; i < len(data); {
+		n := int(data[i])
+		i++
+
+		switch {
+		case n == 128:
+			return out, nil
+		case n < 128:
+			end := min(i+n+1, len(data))
+			out = append(out, data[i:end]...)
+			i = end
+		default:
+			if i >= len(data) {
+				return out, nil
+			}
+			out = append(out, bytes.Repeat(data[i:i+1], 257-n)...)
+			i++
+		}
+	}
+
+	return out, nil
+}
+
+// ---- predictors ----
+
+// applyPredictor reverses the TIFF or PNG predictor selected by /Predictor.
+func applyPredictor(data []byte, params model.PDFDict) ([]byte, error) {
+	predictor := intParam(params, "Predictor", 1)
+	if predictor == 1 {
+		return data, nil
+	}
+
+	colors := intParam(params, "Colors", 1)
+	bpc := intParam(params, "BitsPerComponent", 8)
+	columns := intParam(params, "Columns", 1)
+
+	bitsPerPixel := colors * bpc
+	rowBytes := (columns*bitsPerPixel + 7) / 8
+	bpp := max(1, bitsPerPixel/8)
+
+	if rowBytes <= 0 {
+		return nil, fmt.Errorf("invalid predictor parameters")
+	}
+
+	if predictor == 2 {
+		return tiffPredictor(data, rowBytes, colors, bpc, columns), nil
+	}
+
+	if predictor < 10 {
+		return nil, fmt.Errorf("unsupported predictor %d", predictor)
+	}
+
+	// Each PNG row is preceded by a filter-type byte.
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowBytes)
+	row := make([]byte, rowBytes)
+
+	for i := 0; i < len(data); i += rowBytes + 1 {
+		ft := data[i]
+		n := copy(row, data[i+1:min(i+1+rowBytes, len(data))])
+		clear(row[n:])
+
+		for j := 0; j < rowBytes; j++ {
+			var left, upLeft byte
+			if j >= bpp {
+				left = row[j-bpp]
+				upLeft = prev[j-bpp]
+			}
+			up := prev[j]
+
+			switch ft {
+			case 1:
+				row[j] += left
+			case 2:
+				row[j] += up
+			case 3:
+				row[j] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[j] += paeth(left, up, upLeft)
+			}
+		}
+
+		out = append(out, row...)
+		prev, row = row, prev
+	}
+
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa := abs(p - int(a))
+	pb := abs(p - int(b))
+	pc := abs(p - int(c))
+
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func tiffPredictor(data []byte, rowBytes, colors, bpc, columns int) []byte {
+	out := append([]byte(nil), data...)
+
+	for start := 0; start+rowBytes <= len(out); start += rowBytes {
+		row := out[start : start+rowBytes]
+
+		if bpc == 8 {
+			for j := colors; j < rowBytes; j++ {
+				row[j] += row[j-colors]
+			}
+			continue
+		}
+
+		// Sub-byte and 16-bit samples are unpacked, summed and repacked.
+		br := util.NewBitReader(row)
+		samples := make([]uint32, columns*colors)
+		for j := range samples {
+			samples[j], _ = br.ReadBits(bpc)
+			if j >= colors {
+				samples[j] = (samples[j] + samples[j-colors]) & (1<<bpc - 1)
+			}
+		}
+
+		clear(row)
+		bit := 0
+		for _, s := range samples {
+			for k := bpc - 1; k >= 0; k-- {
+				if s>>k&1 == 1 {
+					row[bit>>3] |= 0x80 >> (bit & 7)
+				}
+				bit++
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func ascii85Encode(data []byte) []byte {
+	out := make([]byte, ascii85.MaxEncodedLen(len(data)))
+	n := ascii85.Encode(out, data)
+	return append(out[:n], '~', '>')
+}
+
+func TestDecodeStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		dict     model.PDFDict
+		data     []byte
+		expected []byte
+	}{
+		{
+			name:     "Unfiltered",
+			dict:     model.PDFDict{},
+			data:     []byte("0 0 m"),
+			expected: []byte("0 0 m"),
+		},
+		{
+			name:     "Flate",
+			dict:     model.PDFDict{"Filter": model.PDFName("FlateDecode")},
+			data:     deflate([]byte("BT /F1 12 Tf ET")),
+			expected: []byte("BT /F1 12 Tf ET"),
+		},
+		{
+			name: "FlatePNGUpPredictor",
+			dict: model.PDFDict{
+				"Filter":      model.PDFName("FlateDecode"),
+				"DecodeParms": model.PDFDict{"Predictor": model.PDFNumber(12), "Columns": model.PDFNumber(3)},
+			},
+			data:     deflate([]byte{2, 1, 2, 3, 2, 1, 1, 1}),
+			expected: []byte{1, 2, 3, 2, 3, 4},
+		},
+		{
+			name: "TIFFPredictor",
+			dict: model.PDFDict{
+				"Filter":      model.PDFName("FlateDecode"),
+				"DecodeParms": model.PDFDict{"Predictor": model.PDFNumber(2), "Columns": model.PDFNumber(4)},
+			},
+			data:     deflate([]byte{10, 1, 1, 1}),
+			expected: []byte{10, 11, 12, 13},
+		},
+		{
+			name:     "ASCIIHex",
+			dict:     model.PDFDict{"Filter": model.PDFName("ASCIIHexDecode")},
+			data:     []byte("48 65 6c\n6C 6f 7>"),
+			expected: []byte("Hello\x70"),
+		},
+		{
+			name:     "ASCII85",
+			dict:     model.PDFDict{"Filter": model.PDFName("ASCII85Decode")},
+			data:     ascii85Encode([]byte("Hello, PDF")),
+			expected: []byte("Hello, PDF"),
+		},
+		{
+			// Each z stands for four zero bytes.
+			name:     "ASCII85Zeros",
+			dict:     model.PDFDict{"Filter": model.PDFName("ASCII85Decode")},
+			data:     []byte("<~zzzz~>"),
+			expected: make([]byte, 16),
+		},
+		{
+			name:     "ASCII85ZerosAndText",
+			dict:     model.PDFDict{"Filter": model.PDFName("ASCII85Decode")},
+			data:     append([]byte("z z"), ascii85Encode([]byte("Hello, PDF"))...),
+			expected: append(make([]byte, 8), "Hello, PDF"...),
+		},
+		{
+			// The example from ISO 32000-1 §7.4.4.2.
+			name:     "LZW",
+			dict:     model.PDFDict{"Filter": model.PDFName("LZWDecode")},
+			data:     []byte{0x80, 0x0B, 0x60, 0x50, 0x22, 0x0C, 0x0C, 0x85, 0x01},
+			expected: []byte{45, 45, 45, 45, 45, 65, 45, 45, 45, 66},
+		},
+		{
+			name:     "RunLength",
+			dict:     model.PDFDict{"Filter": model.PDFName("RunLengthDecode")},
+			data:     []byte{2, 'a', 'b', 'c', 254, 'x', 128},
+			expected: []byte("abcxxx"),
+		},
+		{
+			name: "Chain",
+			dict: model.PDFDict{"Filter": model.PDFArray{
+				model.PDFName("ASCII85Decode"), model.PDFName("FlateDecode"),
+			}},
+			data:     ascii85Encode(deflate([]byte("q Q"))),
+			expected: []byte("q Q"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DecodeStream(model.PDFStream{Dict: tc.dict, Data: tc.data}, NewObjectTable())
+			if err != nil {
+				t.Fatalf("DecodeStream() error = %v", err)
+			}
+			if !bytes.Equal(got, tc.expected) {
+				t.Errorf("DecodeStream() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDecodeStreamUnsupportedFilter(t *testing.T) {
+	s := model.PDFStream{Dict: model.PDFDict{"Filter": model.PDFName("Bogus")}}
+
+	if _, err := DecodeStream(s, NewObjectTable()); err == nil {
+		t.Errorf("DecodeStream() with an unknown filter expected an error")
+	}
+}
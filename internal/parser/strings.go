@@ -0,0 +1,75 @@
+package parser
+
+import "github.com/Kantha2004/go-pdfviewer/internal/model"
+
+// StringBytes returns the bytes of a literal or hexadecimal string object.
+// The lexer keeps both forms as written, so escape sequences and hex digits
+// are decoded here.
+func StringBytes(v model.PDFValue) ([]byte, bool) {
+	switch s := v.(type) {
+	case model.PDFString:
+		return unescapeLiteral(string(s)), true
+	case model.PDFHexString:
+		b, err := decodeASCIIHex([]byte(s), nil)
+		return b, err == nil
+	default:
+		return nil, false
+	}
+}
+
+// unescapeLiteral decodes the backslash escapes of a literal string and
+// normalises its end-of-line markers to a single line feed.
+func unescapeLiteral(s string) []byte {
+	out := make([]byte, 0, len(s))
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c == '\r' {
+			// CR and CR LF both stand for a line feed.
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+			out = append(out, '\n')
+			continue
+		}
+
+		if c != '\\' || i+1 == len(s) {
+			out = append(out, c)
+			continue
+		}
+
+		i++
+		switch c = s[i]; c {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case '\r':
+			// A backslash before an end-of-line continues the string.
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// Up to three octal digits; overflow is ignored.
+			v := int(c - '0')
+			for n := 1; n < 3 && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '7'; n++ {
+				i++
+				v = v*8 + int(s[i]-'0')
+			}
+			out = append(out, byte(v))
+		default:
+			// Unknown escapes, including \( \) and \\, yield the byte itself.
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
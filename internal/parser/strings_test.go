@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Plain", "(abc)", "abc"},
+		{"Escapes", `(a\nb\tc\\d)`, "a\nb\tc\\d"},
+		{"EscapedParens", `(\(x\) \))`, "(x) )"},
+		{"BalancedParens", "(f(o)o)", "f(o)o"},
+		{"Octal", `(\101\0530\7)`, "A+0\x07"},
+		{"LineContinuation", "(ab\\\ncd)", "abcd"},
+		{"CRLF", "(a\r\nb)", "a\nb"},
+		{"Hex", "<41 42 4>", "AB@"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewParser(NewLexer(strings.NewReader(tc.input)))
+			v, err := p.Parse()
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			got, ok := StringBytes(v)
+			if !ok {
+				t.Fatalf("StringBytes(%v) failed", v)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("StringBytes() = %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
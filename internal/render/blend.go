@@ -0,0 +1,206 @@
+package render
+
+import "math"
+
+// BlendMode selects the function that combines a painted colour with the
+// backdrop (PDF 32000-1 11.3.5).
+type BlendMode int
+
+// BlendMode values. The separable modes come before BlendHue.
+const (
+	BlendNormal BlendMode = iota
+	BlendMultiply
+	BlendScreen
+	BlendOverlay
+	BlendDarken
+	BlendLighten
+	BlendColorDodge
+	BlendColorBurn
+	BlendHardLight
+	BlendSoftLight
+	BlendDifference
+	BlendExclusion
+	BlendHue
+	BlendSaturation
+	BlendColor
+	BlendLuminosity
+)
+
+var blendModes = map[string]BlendMode{
+	"Normal":     BlendNormal,
+	"Compatible": BlendNormal,
+	"Multiply":   BlendMultiply,
+	"Screen":     BlendScreen,
+	"Overlay":    BlendOverlay,
+	"Darken":     BlendDarken,
+	"Lighten":    BlendLighten,
+	"ColorDodge": BlendColorDodge,
+	"ColorBurn":  BlendColorBurn,
+	"HardLight":  BlendHardLight,
+	"SoftLight":  BlendSoftLight,
+	"Difference": BlendDifference,
+	"Exclusion":  BlendExclusion,
+	"Hue":        BlendHue,
+	"Saturation": BlendSaturation,
+	"Color":      BlendColor,
+	"Luminosity": BlendLuminosity,
+}
+
+// ParseBlendMode returns the blend mode with the given name.
+func ParseBlendMode(name string) (BlendMode, bool) {
+	m, ok := blendModes[name]
+	return m, ok
+}
+
+// blendComponent applies a separable blend mode to a backdrop component b
+// and source component s.
+func blendComponent(m BlendMode, b, s float64) float64 {
+	switch m {
+	case BlendMultiply:
+		return b * s
+	case BlendScreen:
+		return b + s - b*s
+	case BlendOverlay:
+		return blendComponent(BlendHardLight, s, b)
+	case BlendDarken:
+		return math.Min(b, s)
+	case BlendLighten:
+		return math.Max(b, s)
+	case BlendColorDodge:
+		if b == 0 {
+			return 0
+		}
+		if s >= 1 {
+			return 1
+		}
+		return math.Min(1, b/(1-s))
+	case BlendColorBurn:
+		if b >= 1 {
+			return 1
+		}
+		if s <= 0 {
+			return 0
+		}
+		return 1 - math.Min(1, (1-b)/s)
+	case BlendHardLight:
+		if s <= 0.5 {
+			return b * 2 * s
+		}
+		return blendComponent(BlendScreen, b, 2*s-1)
+	case BlendSoftLight:
+		if s <= 0.5 {
+			return b - (1-2*s)*b*(1-b)
+		}
+		d := math.Sqrt(b)
+		if b <= 0.25 {
+			d = ((16*b-12)*b + 4) * b
+		}
+		return b + (2*s-1)*(d-b)
+	case BlendDifference:
+		return math.Abs(b - s)
+	case BlendExclusion:
+		return b + s - 2*b*s
+	}
+	return s
+}
+
+// blendColor applies a blend mode to a backdrop colour cb and source colour
+// cs, both unpremultiplied RGB.
+func blendColor(m BlendMode, cb, cs [3]float64) [3]float64 {
+	switch m {
+	case BlendHue:
+		return setLum(setSat(cs, sat(cb)), lum(cb))
+	case BlendSaturation:
+		return setLum(setSat(cb, sat(cs)), lum(cb))
+	case BlendColor:
+		return setLum(cs, lum(cb))
+	case BlendLuminosity:
+		return setLum(cb, lum(cs))
+	}
+
+	var out [3]float64
+	for i := range out {
+		out[i] = blendComponent(m, cb[i], cs[i])
+	}
+	return out
+}
+
+// lum returns the luminosity of a colour as the non-separable blend modes
+// define it.
+func lum(c [3]float64) float64 {
+	return 0.3*c[0] + 0.59*c[1] + 0.11*c[2]
+}
+
+// setLum shifts c to luminosity l, bringing it back into gamut with its
+// luminosity kept.
+func setLum(c [3]float64, l float64) [3]float64 {
+	d := l - lum(c)
+	for i := range c {
+		c[i] += d
+	}
+
+	l = lum(c)
+	lo := math.Min(c[0], math.Min(c[1], c[2]))
+	hi := math.Max(c[0], math.Max(c[1], c[2]))
+	for i := range c {
+		if lo < 0 {
+			c[i] = l + (c[i]-l)*l/(l-lo)
+		}
+		if hi > 1 {
+			c[i] = l + (c[i]-l)*(1-l)/(hi-l)
+		}
+	}
+	return c
+}
+
+// sat returns the saturation of a colour.
+func sat(c [3]float64) float64 {
+	return math.Max(c[0], math.Max(c[1], c[2])) - math.Min(c[0], math.Min(c[1], c[2]))
+}
+
+// setSat scales c to saturation s, keeping the order of its components.
+func setSat(c [3]float64, s float64) [3]float64 {
+	// Sort the indices of the components into minimum, middle and maximum.
+	lo, mid, hi := 0, 1, 2
+	if c[lo] > c[mid] {
+		lo, mid = mid, lo
+	}
+	if c[mid] > c[hi] {
+		mid, hi = hi, mid
+	}
+	if c[lo] > c[mid] {
+		lo, mid = mid, lo
+	}
+
+	var out [3]float64
+	if c[hi] > c[lo] {
+		out[mid] = (c[mid] - c[lo]) * s / (c[hi] - c[lo])
+		out[hi] = s
+	}
+	return out
+}
+
+// composite returns the result of painting the premultiplied colour s over
+// the premultiplied backdrop b with blend mode m (11.3.6). Components lie
+// in [0, 1], alpha last.
+func composite(m BlendMode, b, s [4]float64) [4]float64 {
+	sa, ba := s[3], b[3]
+	var out [4]float64
+	if m == BlendNormal || sa == 0 || ba == 0 {
+		for i := range out {
+			out[i] = s[i] + b[i]*(1-sa)
+		}
+		return out
+	}
+
+	var cb, cs [3]float64
+	for i := range cb {
+		cb[i], cs[i] = b[i]/ba, s[i]/sa
+	}
+	bl := blendColor(m, cb, cs)
+	for i := range bl {
+		out[i] = (1-sa)*b[i] + (1-ba)*s[i] + sa*ba*bl[i]
+	}
+	out[3] = sa + ba - sa*ba
+	return out
+}
@@ -0,0 +1,215 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestBlendComponent(t *testing.T) {
+	tests := []struct {
+		mode     BlendMode
+		b, s     float64
+		expected float64
+	}{
+		{BlendNormal, 0.3, 0.6, 0.6},
+		{BlendMultiply, 0.5, 0.5, 0.25},
+		{BlendScreen, 0.5, 0.5, 0.75},
+		{BlendOverlay, 0.25, 0.5, 0.25},
+		{BlendOverlay, 0.75, 0.5, 0.75},
+		{BlendDarken, 0.3, 0.6, 0.3},
+		{BlendLighten, 0.3, 0.6, 0.6},
+		{BlendColorDodge, 0.5, 0.5, 1},
+		{BlendColorDodge, 0, 1, 0},
+		{BlendColorBurn, 0.5, 0.5, 0},
+		{BlendColorBurn, 1, 0, 1},
+		{BlendHardLight, 0.5, 0.25, 0.25},
+		{BlendHardLight, 0.5, 0.75, 0.75},
+		{BlendSoftLight, 0.25, 1, 0.5},
+		{BlendSoftLight, 0.5, 0, 0.25},
+		{BlendDifference, 0.2, 0.7, 0.5},
+		{BlendExclusion, 0.5, 0.5, 0.5},
+	}
+
+	for _, tc := range tests {
+		if got := blendComponent(tc.mode, tc.b, tc.s); math.Abs(got-tc.expected) > 1e-9 {
+			t.Errorf("mode %d on (%v, %v) = %v, expected %v", tc.mode, tc.b, tc.s, got, tc.expected)
+		}
+	}
+}
+
+func TestBlendNonSeparable(t *testing.T) {
+	red := [3]float64{1, 0, 0}
+	gray := [3]float64{0.5, 0.5, 0.5}
+	tinted := [3]float64{1, 2.0 / 7, 2.0 / 7}
+
+	tests := []struct {
+		name     string
+		mode     BlendMode
+		cb, cs   [3]float64
+		expected [3]float64
+	}{
+		{"Luminosity", BlendLuminosity, red, gray, tinted},
+		{"Color", BlendColor, gray, red, tinted},
+		{"Saturation", BlendSaturation, gray, red, gray},
+		{"Hue", BlendHue, [3]float64{0.2, 0.4, 0.6}, red, [3]float64{0.642, 0.242, 0.242}},
+	}
+
+	for _, tc := range tests {
+		got := blendColor(tc.mode, tc.cb, tc.cs)
+		for i := range got {
+			if math.Abs(got[i]-tc.expected[i]) > 1e-9 {
+				t.Errorf("%s = %v, expected %v", tc.name, got, tc.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestParseBlendMode(t *testing.T) {
+	if m, ok := ParseBlendMode("ColorDodge"); !ok || m != BlendColorDodge {
+		t.Errorf("ParseBlendMode(ColorDodge) = %v, %v", m, ok)
+	}
+	if m, ok := ParseBlendMode("Compatible"); !ok || m != BlendNormal {
+		t.Errorf("ParseBlendMode(Compatible) = %v, %v", m, ok)
+	}
+	if _, ok := ParseBlendMode("Dissolve"); ok {
+		t.Errorf("ParseBlendMode(Dissolve) succeeded")
+	}
+}
+
+// fillRect paints an axis-aligned rectangle of the canvas.
+func fillRect(c *Canvas, x, y, w, h float64, col color.Color) {
+	p := NewPath()
+	p.Rect(x, y, w, h)
+	c.Fill(p, NonZero, nil, col)
+}
+
+func TestCanvasTransparency(t *testing.T) {
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	red := color.RGBA{0xff, 0, 0, 0xff}
+
+	t.Run("Alpha", func(t *testing.T) {
+		c := NewCanvas(4, 4)
+		fillRect(c, 0, 0, 4, 4, white)
+		c.Alpha = 0.5
+		fillRect(c, 0, 0, 4, 4, color.Black)
+		if got := c.Img.RGBAAt(1, 1); !near(got, color.RGBA{0x80, 0x80, 0x80, 0xff}, 1) {
+			t.Errorf("pixel = %v, expected mid grey", got)
+		}
+	})
+
+	t.Run("SoftMask", func(t *testing.T) {
+		c := NewCanvas(4, 1)
+		c.SoftMask = image.NewAlpha(image.Rect(0, 0, 2, 1))
+		c.SoftMask.Pix[0] = 0xff
+		c.SoftMask.Pix[1] = 0x80
+		fillRect(c, 0, 0, 4, 1, red)
+
+		expected := []color.RGBA{red, {0x80, 0, 0, 0x80}, {}, {}}
+		for x, e := range expected {
+			if got := c.Img.RGBAAt(x, 0); !near(got, e, 1) {
+				t.Errorf("pixel %d = %v, expected %v", x, got, e)
+			}
+		}
+	})
+
+	t.Run("Multiply", func(t *testing.T) {
+		c := NewCanvas(2, 1)
+		fillRect(c, 0, 0, 1, 1, color.RGBA{0xff, 0x80, 0, 0xff})
+		c.Blend = BlendMultiply
+		fillRect(c, 0, 0, 2, 1, color.RGBA{0x80, 0x80, 0x80, 0xff})
+
+		// Over a transparent backdrop the source shows unchanged.
+		expected := []color.RGBA{{0x80, 0x40, 0, 0xff}, {0x80, 0x80, 0x80, 0xff}}
+		for x, e := range expected {
+			if got := c.Img.RGBAAt(x, 0); !near(got, e, 1) {
+				t.Errorf("pixel %d = %v, expected %v", x, got, e)
+			}
+		}
+	})
+}
+
+func TestGroups(t *testing.T) {
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	bounds := image.Rect(0, 0, 3, 1)
+
+	t.Run("Isolated", func(t *testing.T) {
+		// Overlapping objects in a group are composited as one, so the
+		// overlap is no darker.
+		c := NewCanvas(3, 1)
+		fillRect(c, 0, 0, 3, 1, white)
+		g := c.NewGroup(bounds, true, false)
+		fillRect(g, 0, 0, 2, 1, red)
+		fillRect(g, 1, 0, 2, 1, red)
+		c.Alpha = 0.5
+		c.Composite(g.GroupResult(), nil, nil)
+
+		pink := color.RGBA{0xff, 0x80, 0x80, 0xff}
+		for x := range 3 {
+			if got := c.Img.RGBAAt(x, 0); !near(got, pink, 1) {
+				t.Errorf("pixel %d = %v, expected %v", x, got, pink)
+			}
+		}
+	})
+
+	t.Run("Knockout", func(t *testing.T) {
+		c := NewCanvas(3, 1)
+		g := c.NewGroup(bounds, true, true)
+		g.Alpha = 0.5
+		fillRect(g, 0, 0, 2, 1, red)
+		fillRect(g, 1, 0, 2, 1, blue)
+
+		// The blue object replaces the red one where they overlap.
+		result := g.GroupResult()
+		expected := []color.RGBA{{0x80, 0, 0, 0x80}, {0, 0, 0x80, 0x80}, {0, 0, 0x80, 0x80}}
+		for x, e := range expected {
+			if got := result.RGBAAt(x, 0); !near(got, e, 1) {
+				t.Errorf("pixel %d = %v, expected %v", x, got, e)
+			}
+		}
+	})
+
+	t.Run("BackdropRemoved", func(t *testing.T) {
+		c := NewCanvas(3, 1)
+		fillRect(c, 0, 0, 3, 1, white)
+		g := c.NewGroup(bounds, false, false)
+		g.Alpha = 0.5
+		fillRect(g, 0, 0, 1, 1, red)
+
+		result := g.GroupResult()
+		if got := result.RGBAAt(0, 0); !near(got, color.RGBA{0x80, 0, 0, 0x80}, 1) {
+			t.Errorf("painted pixel = %v, expected half-opaque red", got)
+		}
+		if got := result.RGBAAt(2, 0); got != (color.RGBA{}) {
+			t.Errorf("unpainted pixel = %v, expected transparent", got)
+		}
+	})
+
+	t.Run("NonIsolatedBlend", func(t *testing.T) {
+		// Blend modes inside a non-isolated group see the backdrop; inside
+		// an isolated one they do not.
+		yellow := color.RGBA{0xff, 0xff, 0, 0xff}
+		cyan := color.RGBA{0, 0xff, 0xff, 0xff}
+		for _, tc := range []struct {
+			isolated bool
+			expected color.RGBA
+		}{
+			{false, color.RGBA{0, 0xff, 0, 0xff}},
+			{true, cyan},
+		} {
+			c := NewCanvas(3, 1)
+			fillRect(c, 0, 0, 3, 1, yellow)
+			g := c.NewGroup(bounds, tc.isolated, false)
+			g.Blend = BlendMultiply
+			fillRect(g, 0, 0, 3, 1, cyan)
+			c.Composite(g.GroupResult(), nil, nil)
+			if got := c.Img.RGBAAt(1, 0); !near(got, tc.expected, 1) {
+				t.Errorf("isolated %v: pixel = %v, expected %v", tc.isolated, got, tc.expected)
+			}
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package render
+
+import (
+	"image"
+	"math"
+)
+
+// Rect is an axis-aligned rectangle in device space.
+type Rect struct {
+	X0, Y0, X1, Y1 float64
+}
+
+// Empty reports whether r contains no area.
+func (r Rect) Empty() bool {
+	return r.X0 >= r.X1 || r.Y0 >= r.Y1
+}
+
+// Intersect returns the largest rectangle contained in both r and s.
+func (r Rect) Intersect(s Rect) Rect {
+	out := Rect{
+		math.Max(r.X0, s.X0), math.Max(r.Y0, s.Y0),
+		math.Min(r.X1, s.X1), math.Min(r.Y1, s.Y1),
+	}
+	if out.Empty() {
+		return Rect{}
+	}
+	return out
+}
+
+// pixels returns the smallest pixel rectangle covering r.
+func (r Rect) pixels() image.Rectangle {
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+	return image.Rect(
+		int(math.Floor(r.X0)), int(math.Floor(r.Y0)),
+		int(math.Ceil(r.X1)), int(math.Ceil(r.Y1)),
+	)
+}
+
+// Clip is a device-space clipping region with anti-aliased edges.
+//
+// Regions that are a single axis-aligned rectangle are kept in analytic form
+// so that the common case of page and form bounding boxes needs no mask.
+// Clips are immutable; intersecting returns a new Clip so that saved graphics
+// states can share them.
+type Clip struct {
+	rect Rect
+	mask *image.Alpha
+}
+
+// NewRectClip returns a clip that admits exactly r.
+func NewRectClip(r Rect) *Clip {
+	return &Clip{rect: r}
+}
+
+// Bounds returns the pixel rectangle outside which the clip admits nothing.
+func (c *Clip) Bounds() image.Rectangle {
+	if c.mask != nil {
+		return c.mask.Rect
+	}
+	return c.rect.pixels()
+}
+
+// IsRect reports whether the clip is a plain rectangle.
+func (c *Clip) IsRect() bool {
+	return c.mask == nil
+}
+
+// Coverage returns how much of pixel (x, y) lies inside the clip, from 0 to
+// 255.
+func (c *Clip) Coverage(x, y int) uint8 {
+	if c.mask != nil {
+		if !(image.Point{x, y}.In(c.mask.Rect)) {
+			return 0
+		}
+		return c.mask.Pix[c.mask.PixOffset(x, y)]
+	}
+
+	fx, fy := float64(x), float64(y)
+	w := math.Min(fx+1, c.rect.X1) - math.Max(fx, c.rect.X0)
+	h := math.Min(fy+1, c.rect.Y1) - math.Max(fy, c.rect.Y0)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	return coverageByte(w * h)
+}
+
+// IntersectRect returns the intersection of c with r.
+func (c *Clip) IntersectRect(r Rect) *Clip {
+	if c.mask == nil {
+		return &Clip{rect: c.rect.Intersect(r)}
+	}
+
+	// Fold the rectangle into the mask with the same anti-aliasing as an
+	// analytic clip would have.
+	rc := NewRectClip(r)
+	return c.intersectMask(rc.Bounds(), func(x, y int) uint8 { return rc.Coverage(x, y) })
+}
+
+// IntersectPath returns the intersection of c with the inside of the
+// device-space path p under rule.
+func (c *Clip) IntersectPath(p *Path, rule FillRule) *Clip {
+	if r, ok := rectFromPath(p); ok {
+		return c.IntersectRect(r)
+	}
+
+	bounds := c.Bounds()
+	if bounds.Empty() {
+		return &Clip{}
+	}
+
+	mask := Rasterize(p, rule, bounds)
+	return c.intersectMask(bounds, func(x, y int) uint8 { return mask.Pix[mask.PixOffset(x, y)] })
+}
+
+func (c *Clip) intersectMask(area image.Rectangle, cov func(x, y int) uint8) *Clip {
+	bounds := c.Bounds().Intersect(area)
+	out := &Clip{rect: c.rect, mask: image.NewAlpha(bounds)}
+	if bounds.Empty() {
+		return out
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			a := uint32(c.Coverage(x, y))
+			if a == 0 {
+				continue
+			}
+			out.mask.Pix[out.mask.PixOffset(x, y)] = uint8(a * uint32(cov(x, y)) / 0xff)
+		}
+	}
+
+	return out
+}
+
+// rectFromPath reports whether p is a single axis-aligned rectangle and
+// returns it. Such paths are clipped analytically.
+func rectFromPath(p *Path) (Rect, bool) {
+	var pts []Point
+
+	for i, s := range p.Segs {
+		switch s.Kind {
+		case SegMoveTo:
+			if i != 0 {
+				return Rect{}, false
+			}
+			pts = append(pts, s.Pts[0])
+		case SegLineTo:
+			pts = append(pts, s.Pts[0])
+		case SegClose:
+			if i != len(p.Segs)-1 {
+				return Rect{}, false
+			}
+		default:
+			return Rect{}, false
+		}
+	}
+
+	if len(pts) == 5 && nearlyEqual(pts[0], pts[4]) {
+		pts = pts[:4]
+	}
+	if len(pts) != 4 {
+		return Rect{}, false
+	}
+
+	// Consecutive edges must alternate between horizontal and vertical.
+	horiz := pts[0].Y == pts[1].Y
+	for i := 0; i < 4; i++ {
+		a, b := pts[i], pts[(i+1)%4]
+		if horiz && a.Y != b.Y || !horiz && a.X != b.X {
+			return Rect{}, false
+		}
+		horiz = !horiz
+	}
+
+	r := Rect{
+		math.Min(pts[0].X, pts[2].X), math.Min(pts[0].Y, pts[2].Y),
+		math.Max(pts[0].X, pts[2].X), math.Max(pts[0].Y, pts[2].Y),
+	}
+	return r, true
+}
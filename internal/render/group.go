@@ -0,0 +1,128 @@
+package render
+
+import (
+	"image"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// NewGroup returns a canvas for painting a transparency group over the
+// pixels of bounds (PDF 32000-1 11.4). An isolated group starts
+// transparent; any other starts with the content of c as its backdrop.
+// Objects painted in a knockout group composite with that initial backdrop
+// rather than with each other.
+func (c *Canvas) NewGroup(bounds image.Rectangle, isolated, knockout bool) *Canvas {
+	bounds = bounds.Intersect(c.Bounds())
+	g := &Canvas{Img: image.NewRGBA(bounds), Alpha: 1, knockout: knockout}
+	if isolated {
+		return g
+	}
+
+	g.backdrop = image.NewRGBA(bounds)
+	g.groupAlpha = image.NewAlpha(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		i := c.Img.PixOffset(bounds.Min.X, y)
+		row := c.Img.Pix[i : i+4*bounds.Dx()]
+		copy(g.Img.Pix[g.Img.PixOffset(bounds.Min.X, y):], row)
+		copy(g.backdrop.Pix[g.backdrop.PixOffset(bounds.Min.X, y):], row)
+	}
+	return g
+}
+
+// GroupResult returns what was painted onto a group canvas, as
+// premultiplied colour and opacity, for compositing onto the canvas the
+// group was made from. The backdrop a non-isolated group started with is
+// removed (11.4.8).
+func (c *Canvas) GroupResult() *image.RGBA {
+	if c.backdrop == nil {
+		return c.Img
+	}
+
+	out := image.NewRGBA(c.Img.Rect)
+	for i := range c.groupAlpha.Pix {
+		ag := float64(c.groupAlpha.Pix[i]) / 0xff
+		if ag == 0 {
+			continue
+		}
+
+		n := c.Img.Pix[4*i : 4*i+4 : 4*i+4]
+		b := c.backdrop.Pix[4*i : 4*i+4 : 4*i+4]
+		an, a0 := float64(n[3])/0xff, float64(b[3])/0xff
+		d := out.Pix[4*i : 4*i+4 : 4*i+4]
+		for k := range 3 {
+			cn, c0 := 0.0, 0.0
+			if an > 0 {
+				cn = float64(n[k]) / 0xff / an
+			}
+			if a0 > 0 {
+				c0 = float64(b[k]) / 0xff / a0
+			}
+			v := cn + (cn-c0)*(a0/ag-a0)
+			d[k] = util.ToByte(util.Clamp(v, 0, 1) * ag)
+		}
+		d[3] = c.groupAlpha.Pix[i]
+	}
+	return out
+}
+
+// blendTransparent is blend for canvases with transparency parameters or
+// group state.
+func (c *Canvas) blendTransparent(x, y int, r, g, b, a, cov uint32) {
+	k := c.Alpha
+	if c.SoftMask != nil {
+		m := uint8(0)
+		if (image.Point{x, y}).In(c.SoftMask.Rect) {
+			m = c.SoftMask.Pix[c.SoftMask.PixOffset(x, y)]
+		}
+		k *= float64(m) / 0xff
+	}
+	if k <= 0 {
+		return
+	}
+
+	src := [4]float64{float64(r) / 0xffff * k, float64(g) / 0xffff * k, float64(b) / 0xffff * k, float64(a) / 0xffff * k}
+	shape := float64(cov) / 0xffff
+
+	i := c.Img.PixOffset(x, y)
+	d := c.Img.Pix[i : i+4 : i+4]
+	var dst [4]float64
+	for j := range dst {
+		dst[j] = float64(d[j]) / 0xff
+	}
+
+	var out [4]float64
+	if c.knockout {
+		// The object replaces what the group painted before in
+		// proportion to its coverage.
+		var back [4]float64
+		if c.backdrop != nil {
+			bp := c.backdrop.Pix[i : i+4 : i+4]
+			for j := range back {
+				back[j] = float64(bp[j]) / 0xff
+			}
+		}
+		res := composite(c.Blend, back, src)
+		for j := range out {
+			out[j] = dst[j] + (res[j]-dst[j])*shape
+		}
+	} else {
+		for j := range src {
+			src[j] *= shape
+		}
+		out = composite(c.Blend, dst, src)
+	}
+	for j := range out {
+		d[j] = util.ToByte(out[j])
+	}
+
+	if c.groupAlpha != nil {
+		j := c.groupAlpha.PixOffset(x, y)
+		ag := float64(c.groupAlpha.Pix[j]) / 0xff
+		if c.knockout {
+			ag += (src[3] - ag) * shape
+		} else {
+			ag += src[3] * (1 - ag)
+		}
+		c.groupAlpha.Pix[j] = util.ToByte(ag)
+	}
+}
@@ -1 +1,384 @@
 package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// maxImagePixels bounds the size of a decoded image.
+const maxImagePixels = 1 << 26
+
+// Image is the sample data of an image XObject or inline image after its
+// stream filters have been applied.
+type Image struct {
+	Width, Height    int
+	BitsPerComponent int
+
+	// Space is the colour space of the samples. It is nil for stencil
+	// masks, whose single-bit samples select where the fill colour is
+	// painted.
+	Space colorspace.ColorSpace
+
+	// Decode maps sample values to colour components as [min0 max0 min1
+	// max1 ...]; nil selects the default of the colour space.
+	Decode []float64
+
+	// ColorKey holds the ranges of raw sample values given by a /Mask
+	// array. Pixels whose components all lie within them are not painted.
+	ColorKey []int
+
+	Data []byte
+
+	// Alpha holds the opacity of each pixel when the image data carries
+	// it, as JPEG 2000 images with /SMaskInData do. Pixels beyond it are
+	// opaque.
+	Alpha []byte
+
+	// SoftMask, if set, gives the opacity of the image instead of Alpha.
+	// It is stretched over the image if their sizes differ.
+	SoftMask *image.Gray
+}
+
+func (im *Image) check() error {
+	if im.Width <= 0 || im.Height <= 0 {
+		return fmt.Errorf("invalid image size %dx%d", im.Width, im.Height)
+	}
+	if im.Width > maxImagePixels/im.Height {
+		return fmt.Errorf("image of %dx%d pixels is too large", im.Width, im.Height)
+	}
+
+	switch im.BitsPerComponent {
+	case 1, 2, 4, 8, 16:
+	default:
+		return fmt.Errorf("invalid /BitsPerComponent %d", im.BitsPerComponent)
+	}
+	return nil
+}
+
+// RGBA converts the samples to a premultiplied sRGB image. Pixels excluded
+// by the colour key are transparent, and others take their opacity from
+// Alpha or SoftMask. Missing data is read as zero samples,
+// as truncated images are common.
+func (im *Image) RGBA() (*image.RGBA, error) {
+	if err := im.check(); err != nil {
+		return nil, err
+	}
+	if im.Space == nil {
+		return nil, fmt.Errorf("image has no colour space")
+	}
+
+	n := im.Space.NComponents()
+	decode := im.Space.DefaultDecode(im.BitsPerComponent)
+	if len(im.Decode) >= 2*n {
+		decode = im.Decode
+	}
+	maxVal := float64(uint32(1)<<im.BitsPerComponent - 1)
+
+	comps := make([]float64, n)
+	convert := func(raw []uint32) color.RGBA {
+		for i, v := range raw {
+			comps[i] = util.Interpolate(float64(v), 0, maxVal, decode[2*i], decode[2*i+1])
+		}
+		return colorspace.ToRGBA(im.Space, comps)
+	}
+
+	// Single-component images have few enough distinct samples to convert
+	// them all up front.
+	var table []color.RGBA
+	if n == 1 && im.BitsPerComponent <= 8 {
+		table = make([]color.RGBA, int(maxVal)+1)
+		for v := range table {
+			table[v] = convert([]uint32{uint32(v)})
+		}
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, im.Width, im.Height))
+	br := util.NewBitReader(im.Data)
+	raw := make([]uint32, n)
+	last := make([]uint32, n)
+	var lastColor color.RGBA
+	haveLast := false
+
+	for y := 0; y < im.Height; y++ {
+		for x := 0; x < im.Width; x++ {
+			for i := range raw {
+				raw[i], _ = br.ReadBits(im.BitsPerComponent)
+			}
+			if im.keyed(raw) {
+				continue
+			}
+
+			var c color.RGBA
+			switch {
+			case table != nil:
+				c = table[raw[0]]
+			case haveLast && equalSamples(raw, last):
+				c = lastColor
+			default:
+				c = convert(raw)
+				copy(last, raw)
+				lastColor, haveLast = c, true
+			}
+
+			if a := im.alphaAt(x, y); a != 0xff {
+				c = premultiply(c, a)
+			}
+
+			i := out.PixOffset(x, y)
+			out.Pix[i+0] = c.R
+			out.Pix[i+1] = c.G
+			out.Pix[i+2] = c.B
+			out.Pix[i+3] = c.A
+		}
+		// Rows start on byte boundaries.
+		br.Align()
+	}
+
+	return out, nil
+}
+
+// keyed reports whether a pixel is excluded by the colour key.
+func (im *Image) keyed(raw []uint32) bool {
+	if len(im.ColorKey) < 2*len(raw) {
+		return false
+	}
+	for i, v := range raw {
+		if int(v) < im.ColorKey[2*i] || int(v) > im.ColorKey[2*i+1] {
+			return false
+		}
+	}
+	return true
+}
+
+// alphaAt returns the opacity of pixel (x, y).
+func (im *Image) alphaAt(x, y int) uint8 {
+	if m := im.SoftMask; m != nil {
+		mw, mh := m.Rect.Dx(), m.Rect.Dy()
+		return m.GrayAt(m.Rect.Min.X+x*mw/im.Width, m.Rect.Min.Y+y*mh/im.Height).Y
+	}
+	if k := y*im.Width + x; k < len(im.Alpha) {
+		return im.Alpha[k]
+	}
+	return 0xff
+}
+
+// premultiply scales an opaque colour by the opacity a.
+func premultiply(c color.RGBA, a uint8) color.RGBA {
+	scale := func(v uint8) uint8 { return uint8((uint32(v)*uint32(a) + 0x7f) / 0xff) }
+	return color.RGBA{scale(c.R), scale(c.G), scale(c.B), a}
+}
+
+func equalSamples(a, b []uint32) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Stencil returns the coverage of a stencil mask. Samples of 0 are painted
+// unless the decode array is [1 0], which paints samples of 1.
+func (im *Image) Stencil() (*image.Alpha, error) {
+	if err := im.check(); err != nil {
+		return nil, err
+	}
+	if im.BitsPerComponent != 1 {
+		return nil, fmt.Errorf("image mask with %d bits per component", im.BitsPerComponent)
+	}
+
+	paint := uint32(0)
+	if len(im.Decode) >= 2 && im.Decode[0] > im.Decode[1] {
+		paint = 1
+	}
+
+	out := image.NewAlpha(image.Rect(0, 0, im.Width, im.Height))
+	br := util.NewBitReader(im.Data)
+	for y := 0; y < im.Height; y++ {
+		for x := 0; x < im.Width; x++ {
+			if v, _ := br.ReadBit(); v == paint {
+				out.Pix[out.PixOffset(x, y)] = 0xff
+			}
+		}
+		br.Align()
+	}
+
+	return out, nil
+}
+
+// DrawImage paints img onto the canvas through clip. ctm maps the unit
+// square, which the image fills with its first row at the top, to the
+// device.
+func (c *Canvas) DrawImage(img *image.RGBA, ctm Matrix, clip *Clip) {
+	bounds := c.Bounds().Intersect(clip.Bounds())
+	if src, edges := ResampleImage(img, ctm, bounds); src != nil {
+		c.Composite(src, edges, clip)
+	}
+}
+
+// DrawStencil paints col through a stencil mask, placed as by DrawImage.
+func (c *Canvas) DrawStencil(mask *image.Alpha, ctm Matrix, clip *Clip, col color.Color) {
+	bounds := c.Bounds().Intersect(clip.Bounds())
+	if cov := ResampleMask(mask, ctm, bounds); cov != nil {
+		c.FillMask(cov, clip, col)
+	}
+}
+
+// ResampleImage maps img through ctm, as for DrawImage, into the device
+// pixels of bounds with bilinear interpolation. It returns the premultiplied
+// samples and the anti-aliased coverage of the image's edges, or nils if the
+// image covers none of bounds.
+func ResampleImage(img *image.RGBA, ctm Matrix, bounds image.Rectangle) (*image.RGBA, *image.Alpha) {
+	src := samplerFor(img.Pix, img.Stride, img.Rect.Dx(), img.Rect.Dy(), 4)
+	edges := imageEdges(ctm, bounds)
+	if edges == nil {
+		return nil, nil
+	}
+
+	out := image.NewRGBA(edges.Rect)
+	resample(src, ctm, edges, func(x, y int, px []uint8) {
+		i := out.PixOffset(x, y)
+		copy(out.Pix[i:i+4], px)
+	})
+	return out, edges
+}
+
+// ResampleMask maps a coverage mask through ctm, as for DrawImage, into the
+// device pixels of bounds. The result includes the coverage of the mask's
+// edges; it is nil if the mask covers none of bounds.
+func ResampleMask(mask *image.Alpha, ctm Matrix, bounds image.Rectangle) *image.Alpha {
+	src := samplerFor(mask.Pix, mask.Stride, mask.Rect.Dx(), mask.Rect.Dy(), 1)
+	edges := imageEdges(ctm, bounds)
+	if edges == nil {
+		return nil
+	}
+
+	resample(src, ctm, edges, func(x, y int, px []uint8) {
+		i := edges.PixOffset(x, y)
+		edges.Pix[i] = uint8(uint32(edges.Pix[i]) * uint32(px[0]) / 0xff)
+	})
+	return edges
+}
+
+// imageEdges rasterizes the unit square mapped through ctm within bounds.
+func imageEdges(ctm Matrix, bounds image.Rectangle) *image.Alpha {
+	if _, ok := ctm.Invert(); !ok {
+		return nil
+	}
+
+	square := NewPath()
+	square.Rect(0, 0, 1, 1)
+	area := square.Transform(ctm)
+
+	lo, hi := area.Bounds()
+	bounds = bounds.Intersect(Rect{lo.X, lo.Y, hi.X, hi.Y}.pixels())
+	if bounds.Empty() {
+		return nil
+	}
+	return Rasterize(area, NonZero, bounds)
+}
+
+// resample calls put with the interpolated sample at the centre of each
+// device pixel that edges covers.
+func resample(src *sampler, ctm Matrix, edges *image.Alpha, put func(x, y int, px []uint8)) {
+	inv, _ := ctm.Invert()
+
+	// Sources much larger than their device area are first reduced, as
+	// bilinear interpolation alone would skip most of their samples.
+	src = src.reduce(math.Hypot(ctm[0], ctm[1]), math.Hypot(ctm[2], ctm[3]))
+
+	px := make([]uint8, src.ch)
+	b := edges.Rect
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if edges.Pix[edges.PixOffset(x, y)] == 0 {
+				continue
+			}
+
+			ux, uy := inv.Transform(float64(x)+0.5, float64(y)+0.5)
+			src.sample(ux*float64(src.w), (1-uy)*float64(src.h), px)
+			put(x, y, px)
+		}
+	}
+}
+
+// sampler reads interpolated pixels of an 8-bit raster with ch channels.
+type sampler struct {
+	pix      []uint8
+	stride   int
+	w, h, ch int
+}
+
+func samplerFor(pix []uint8, stride, w, h, ch int) *sampler {
+	return &sampler{pix: pix, stride: stride, w: w, h: h, ch: ch}
+}
+
+// reduce halves the sampler's resolution, averaging pairs of samples, while
+// it is more than twice the device width dw or height dh.
+func (s *sampler) reduce(dw, dh float64) *sampler {
+	for {
+		halveX := s.w > 1 && float64(s.w) > 2*dw
+		halveY := s.h > 1 && float64(s.h) > 2*dh
+		if !halveX && !halveY {
+			return s
+		}
+
+		w, h := s.w, s.h
+		fx, fy := 1, 1
+		if halveX {
+			w, fx = (s.w+1)/2, 2
+		}
+		if halveY {
+			h, fy = (s.h+1)/2, 2
+		}
+
+		out := &sampler{pix: make([]uint8, w*h*s.ch), stride: w * s.ch, w: w, h: h, ch: s.ch}
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				for c := 0; c < s.ch; c++ {
+					sum, n := 0, 0
+					for sy := y * fy; sy < min((y+1)*fy, s.h); sy++ {
+						for sx := x * fx; sx < min((x+1)*fx, s.w); sx++ {
+							sum += int(s.pix[sy*s.stride+sx*s.ch+c])
+							n++
+						}
+					}
+					out.pix[y*out.stride+x*s.ch+c] = uint8((sum + n/2) / n)
+				}
+			}
+		}
+		s = out
+	}
+}
+
+// sample interpolates bilinearly at (u, v) in pixel units, with pixel
+// centres at half-integers. Positions outside the raster take the nearest
+// edge pixel.
+func (s *sampler) sample(u, v float64, dst []uint8) {
+	fx, fy := u-0.5, v-0.5
+	x0, y0 := math.Floor(fx), math.Floor(fy)
+	tx, ty := fx-x0, fy-y0
+
+	clampInt := func(v float64, n int) int {
+		return int(util.Clamp(v, 0, float64(n-1)))
+	}
+	ix0, ix1 := clampInt(x0, s.w), clampInt(x0+1, s.w)
+	iy0, iy1 := clampInt(y0, s.h), clampInt(y0+1, s.h)
+
+	r0, r1 := iy0*s.stride, iy1*s.stride
+	for c := 0; c < s.ch; c++ {
+		p00 := float64(s.pix[r0+ix0*s.ch+c])
+		p10 := float64(s.pix[r0+ix1*s.ch+c])
+		p01 := float64(s.pix[r1+ix0*s.ch+c])
+		p11 := float64(s.pix[r1+ix1*s.ch+c])
+
+		top := util.Lerp(p00, p10, tx)
+		bottom := util.Lerp(p01, p11, tx)
+		dst[c] = uint8(util.Lerp(top, bottom, ty) + 0.5)
+	}
+}
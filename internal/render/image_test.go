@@ -0,0 +1,216 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func TestImageRGBA(t *testing.T) {
+	gray := func(v uint8) color.RGBA { return color.RGBA{v, v, v, 0xff} }
+
+	indexed, err := colorspace.Parse(model.PDFArray{
+		model.PDFName("Indexed"), model.PDFName("DeviceRGB"), model.PDFNumber(1),
+		model.PDFString("\xff\x00\x00\x00\x00\xff"),
+	}, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		img      Image
+		expected []color.RGBA
+	}{
+		{
+			name:     "Gray1",
+			img:      Image{Width: 3, Height: 2, BitsPerComponent: 1, Space: colorspace.DeviceGray, Data: []byte{0xa0, 0x40}},
+			expected: []color.RGBA{gray(0xff), gray(0), gray(0xff), gray(0), gray(0xff), gray(0)},
+		},
+		{
+			name:     "Gray2",
+			img:      Image{Width: 4, Height: 1, BitsPerComponent: 2, Space: colorspace.DeviceGray, Data: []byte{0x1b}},
+			expected: []color.RGBA{gray(0), gray(0x55), gray(0xaa), gray(0xff)},
+		},
+		{
+			name:     "Gray4Decode",
+			img:      Image{Width: 2, Height: 1, BitsPerComponent: 4, Space: colorspace.DeviceGray, Decode: []float64{1, 0}, Data: []byte{0x0f}},
+			expected: []color.RGBA{gray(0xff), gray(0)},
+		},
+		{
+			name:     "RGB8",
+			img:      Image{Width: 2, Height: 1, BitsPerComponent: 8, Space: colorspace.DeviceRGB, Data: []byte{0xff, 0, 0, 0, 0x80, 0xff}},
+			expected: []color.RGBA{{0xff, 0, 0, 0xff}, {0, 0x80, 0xff, 0xff}},
+		},
+		{
+			name:     "RGB16",
+			img:      Image{Width: 1, Height: 1, BitsPerComponent: 16, Space: colorspace.DeviceRGB, Data: []byte{0xff, 0xff, 0x80, 0x00, 0, 0}},
+			expected: []color.RGBA{{0xff, 0x80, 0, 0xff}},
+		},
+		{
+			// Inverted CMYK, as in Adobe JPEGs, with the /Decode array
+			// that accompanies them.
+			name: "InvertedCMYK",
+			img: Image{Width: 2, Height: 1, BitsPerComponent: 8, Space: colorspace.DeviceCMYK,
+				Decode: []float64{1, 0, 1, 0, 1, 0, 1, 0}, Data: []byte{0xff, 0xff, 0xff, 0xff, 0x00, 0xff, 0xff, 0xff}},
+			expected: []color.RGBA{gray(0xff), colorspace.ToRGBA(colorspace.DeviceCMYK, []float64{1, 0, 0, 0})},
+		},
+		{
+			name:     "Indexed",
+			img:      Image{Width: 2, Height: 1, BitsPerComponent: 1, Space: indexed, Data: []byte{0x40}},
+			expected: []color.RGBA{{0xff, 0, 0, 0xff}, {0, 0, 0xff, 0xff}},
+		},
+		{
+			name:     "ColorKey",
+			img:      Image{Width: 3, Height: 1, BitsPerComponent: 8, Space: colorspace.DeviceGray, ColorKey: []int{0x10, 0x20}, Data: []byte{0x0f, 0x18, 0x21}},
+			expected: []color.RGBA{gray(0x0f), {}, gray(0x21)},
+		},
+		{
+			name:     "Alpha",
+			img:      Image{Width: 3, Height: 1, BitsPerComponent: 8, Space: colorspace.DeviceGray, Data: []byte{0xff, 0xff, 0x80}, Alpha: []byte{0x80, 0}},
+			expected: []color.RGBA{{0x80, 0x80, 0x80, 0x80}, {}, gray(0x80)},
+		},
+		{
+			name:     "Truncated",
+			img:      Image{Width: 2, Height: 1, BitsPerComponent: 8, Space: colorspace.DeviceGray, Data: []byte{0xff}},
+			expected: []color.RGBA{gray(0xff), gray(0)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			img, err := tc.img.RGBA()
+			if err != nil {
+				t.Fatalf("RGBA() error = %v", err)
+			}
+			for i, expected := range tc.expected {
+				x, y := i%tc.img.Width, i/tc.img.Width
+				if got := img.RGBAAt(x, y); got != expected {
+					t.Errorf("pixel (%d, %d) = %v, expected %v", x, y, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestImageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		img  Image
+	}{
+		{"NoSize", Image{BitsPerComponent: 8, Space: colorspace.DeviceGray}},
+		{"TooLarge", Image{Width: 1 << 20, Height: 1 << 20, BitsPerComponent: 8, Space: colorspace.DeviceGray}},
+		{"BitsPerComponent", Image{Width: 1, Height: 1, BitsPerComponent: 3, Space: colorspace.DeviceGray}},
+		{"NoSpace", Image{Width: 1, Height: 1, BitsPerComponent: 8}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.img.RGBA(); err == nil {
+				t.Errorf("RGBA() expected an error")
+			}
+		})
+	}
+}
+
+func TestImageStencil(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		decode   []float64
+		expected []uint8
+	}{
+		{"Default", nil, []uint8{0xff, 0, 0, 0xff}},
+		{"Inverted", []float64{1, 0}, []uint8{0, 0xff, 0xff, 0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			im := Image{Width: 2, Height: 2, BitsPerComponent: 1, Decode: tc.decode, Data: []byte{0x40, 0x80}}
+			mask, err := im.Stencil()
+			if err != nil {
+				t.Fatalf("Stencil() error = %v", err)
+			}
+			if string(mask.Pix) != string(tc.expected) {
+				t.Errorf("Stencil() = %v, expected %v", mask.Pix, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDrawImage(t *testing.T) {
+	// Black on the left, white on the right.
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	copy(src.Pix, []uint8{0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	c := NewCanvas(20, 20)
+	clip := NewRectClip(Rect{0, 0, 20, 20})
+	// The unit square maps to (2, 2)-(18, 12), with y pointing down.
+	c.DrawImage(src, Matrix{16, 0, 0, -10, 2, 12}, clip)
+
+	checkShaded(t, c.Img, []shadedPixel{
+		{1, 5, color.RGBA{}},
+		{3, 5, color.RGBA{0, 0, 0, 0xff}},
+		{16, 5, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{5, 15, color.RGBA{}},
+		// Pixel 10 is centred 9/16 of the way from the first sample to the
+		// second.
+		{10, 5, color.RGBA{0x8f, 0x8f, 0x8f, 0xff}},
+	})
+}
+
+func TestDrawImageFlipped(t *testing.T) {
+	// A red row above a blue row; a CTM with y pointing up in device space
+	// shows the first row at the bottom.
+	src := image.NewRGBA(image.Rect(0, 0, 1, 2))
+	copy(src.Pix, []uint8{0xff, 0, 0, 0xff, 0, 0, 0xff, 0xff})
+
+	c := NewCanvas(10, 10)
+	c.DrawImage(src, Matrix{10, 0, 0, 10, 0, 0}, NewRectClip(Rect{0, 0, 10, 10}))
+
+	checkShaded(t, c.Img, []shadedPixel{
+		{5, 0, color.RGBA{0, 0, 0xff, 0xff}},
+		{5, 9, color.RGBA{0xff, 0, 0, 0xff}},
+	})
+}
+
+func TestDrawImageReduced(t *testing.T) {
+	// A fine checkerboard drawn at a tenth of its size averages to grey
+	// rather than aliasing to one of its colours.
+	src := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if (x+y)%2 == 0 {
+				src.SetRGBA(x, y, color.RGBA{0xff, 0xff, 0xff, 0xff})
+			} else {
+				src.SetRGBA(x, y, color.RGBA{0, 0, 0, 0xff})
+			}
+		}
+	}
+
+	c := NewCanvas(10, 10)
+	c.DrawImage(src, Matrix{10, 0, 0, -10, 0, 10}, NewRectClip(Rect{0, 0, 10, 10}))
+
+	for _, p := range []image.Point{{2, 2}, {5, 7}} {
+		if got := c.Img.RGBAAt(p.X, p.Y); !near(got, color.RGBA{0x80, 0x80, 0x80, 0xff}, 8) {
+			t.Errorf("pixel %v = %v, expected grey", p, got)
+		}
+	}
+}
+
+func TestDrawStencil(t *testing.T) {
+	mask := image.NewAlpha(image.Rect(0, 0, 2, 2))
+	copy(mask.Pix, []uint8{0xff, 0, 0, 0xff})
+
+	c := NewCanvas(20, 20)
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	c.DrawStencil(mask, Matrix{20, 0, 0, -20, 0, 20}, NewRectClip(Rect{0, 0, 20, 20}), red)
+
+	checkShaded(t, c.Img, []shadedPixel{
+		{2, 2, red},
+		{17, 17, red},
+		{17, 2, color.RGBA{}},
+		{2, 17, color.RGBA{}},
+	})
+}
@@ -0,0 +1,438 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+	"github.com/Kantha2004/go-pdfviewer/internal/util"
+)
+
+// meshVertex is a vertex of a triangle mesh with its colour components, or
+// its parametric value when the shading has a function.
+type meshVertex struct {
+	p     Point
+	comps []float64
+}
+
+// mesh holds the triangles of a Gouraud-shaded mesh (Types 4 and 5) or the
+// patches of a patch mesh (Types 6 and 7), in shading space.
+type mesh struct {
+	triangles [][3]meshVertex
+	patches   []patch
+}
+
+// patch is a tensor-product patch. Coons patches are converted to this form
+// when parsed. p[i][j] is the control point for u = i/3, v = j/3; colours
+// are given at the corners (0,0), (0,1), (1,1) and (1,0).
+type patch struct {
+	p     [4][4]Point
+	comps [4][]float64
+}
+
+// meshReader decodes the packed vertex data of a mesh shading stream.
+type meshReader struct {
+	br        *util.BitReader
+	bpc, bpf  int // bits per coordinate and flag
+	bpcomp    int
+	decode    []float64
+	ncomps    int
+	coordMax  float64
+	componMax float64
+}
+
+func newMeshReader(s *Shading, stream model.PDFStream, r model.Resolver, needFlag bool) (*meshReader, error) {
+	num := func(key string) int {
+		n, _ := r.Resolve(stream.Dict[key]).(model.PDFNumber)
+		return int(n)
+	}
+
+	mr := &meshReader{bpc: num("BitsPerCoordinate"), bpcomp: num("BitsPerComponent"), bpf: num("BitsPerFlag")}
+
+	switch mr.bpc {
+	case 1, 2, 4, 8, 12, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("invalid /BitsPerCoordinate %d", mr.bpc)
+	}
+	switch mr.bpcomp {
+	case 1, 2, 4, 8, 12, 16:
+	default:
+		return nil, fmt.Errorf("invalid /BitsPerComponent %d", mr.bpcomp)
+	}
+	if needFlag && mr.bpf != 2 && mr.bpf != 4 && mr.bpf != 8 {
+		return nil, fmt.Errorf("invalid /BitsPerFlag %d", mr.bpf)
+	}
+
+	mr.ncomps = s.Space.NComponents()
+	if s.fn != nil {
+		if s.fn.NumInputs() != 1 {
+			return nil, fmt.Errorf("mesh shading function must have one input")
+		}
+		mr.ncomps = 1
+	}
+
+	var ok bool
+	if mr.decode, ok = model.ResolveNumbers(stream.Dict["Decode"], r); !ok || len(mr.decode) < 4+2*mr.ncomps {
+		return nil, fmt.Errorf("mesh shading /Decode has wrong length")
+	}
+
+	data, err := parser.DecodeStream(stream, r)
+	if err != nil {
+		return nil, fmt.Errorf("mesh shading: %w", err)
+	}
+
+	mr.br = util.NewBitReader(data)
+	mr.coordMax = math.Exp2(float64(mr.bpc)) - 1
+	mr.componMax = math.Exp2(float64(mr.bpcomp)) - 1
+	return mr, nil
+}
+
+func (mr *meshReader) flag() (int, bool) {
+	f, ok := mr.br.ReadBits(mr.bpf)
+	return int(f), ok
+}
+
+func (mr *meshReader) point() (Point, bool) {
+	x, ok1 := mr.br.ReadBits(mr.bpc)
+	y, ok2 := mr.br.ReadBits(mr.bpc)
+	return Point{
+		util.Interpolate(float64(x), 0, mr.coordMax, mr.decode[0], mr.decode[1]),
+		util.Interpolate(float64(y), 0, mr.coordMax, mr.decode[2], mr.decode[3]),
+	}, ok1 && ok2
+}
+
+func (mr *meshReader) color() ([]float64, bool) {
+	comps := make([]float64, mr.ncomps)
+	for i := range comps {
+		v, ok := mr.br.ReadBits(mr.bpcomp)
+		if !ok {
+			return nil, false
+		}
+		comps[i] = util.Interpolate(float64(v), 0, mr.componMax, mr.decode[4+2*i], mr.decode[5+2*i])
+	}
+	return comps, true
+}
+
+func (mr *meshReader) vertex() (meshVertex, bool) {
+	p, ok := mr.point()
+	if !ok {
+		return meshVertex{}, false
+	}
+	c, ok := mr.color()
+	return meshVertex{p, c}, ok
+}
+
+// maxMeshElements bounds the number of triangles or patches read from one
+// stream.
+const maxMeshElements = 1 << 20
+
+func parseMesh(s *Shading, stream model.PDFStream, r model.Resolver) (shader, error) {
+	mr, err := newMeshReader(s, stream, r, s.Type != 5)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &mesh{}
+	switch s.Type {
+	case 4:
+		m.readFreeForm(mr)
+	case 5:
+		perRow, _ := r.Resolve(stream.Dict["VerticesPerRow"]).(model.PDFNumber)
+		if perRow < 2 {
+			return nil, fmt.Errorf("lattice shading /VerticesPerRow must be at least 2")
+		}
+		m.readLattice(mr, int(perRow))
+	case 6, 7:
+		m.readPatches(mr, s.Type == 7)
+	}
+
+	return m, nil
+}
+
+// readFreeForm reads a Type 4 mesh, in which a flag on each vertex starts a
+// new triangle or continues from an edge of the previous one. Truncated data
+// ends the mesh.
+func (m *mesh) readFreeForm(mr *meshReader) {
+	var tri [3]meshVertex
+	n := 0 // vertices read of a triangle started by flag 0
+
+	for len(m.triangles) < maxMeshElements {
+		f, ok := mr.flag()
+		if !ok {
+			return
+		}
+		v, ok := mr.vertex()
+		if !ok {
+			return
+		}
+		mr.br.Align()
+
+		if n > 0 {
+			// The flags of the second and third vertices are ignored.
+			tri[n] = v
+			if n++; n == 3 {
+				m.triangles = append(m.triangles, tri)
+				n = 0
+			}
+			continue
+		}
+
+		have := len(m.triangles) > 0
+		switch {
+		case f == 0:
+			tri[0], n = v, 1
+		case f == 1 && have:
+			tri = [3]meshVertex{tri[1], tri[2], v}
+			m.triangles = append(m.triangles, tri)
+		case f == 2 && have:
+			tri = [3]meshVertex{tri[0], tri[2], v}
+			m.triangles = append(m.triangles, tri)
+		default:
+			// The rest of the mesh refers to an undefined triangle.
+			return
+		}
+	}
+}
+
+// readLattice reads a Type 5 mesh, a grid of vertices in rows that is split
+// into pairs of triangles.
+func (m *mesh) readLattice(mr *meshReader, perRow int) {
+	var rows [][]meshVertex
+	for len(rows)*perRow < maxMeshElements {
+		row := make([]meshVertex, perRow)
+		for i := range row {
+			v, ok := mr.vertex()
+			if !ok {
+				m.latticeTriangles(rows)
+				return
+			}
+			row[i] = v
+		}
+		rows = append(rows, row)
+	}
+	m.latticeTriangles(rows)
+}
+
+func (m *mesh) latticeTriangles(rows [][]meshVertex) {
+	for i := 0; i+1 < len(rows); i++ {
+		a, b := rows[i], rows[i+1]
+		for j := 0; j+1 < len(a); j++ {
+			m.triangles = append(m.triangles,
+				[3]meshVertex{a[j], a[j+1], b[j]},
+				[3]meshVertex{a[j+1], b[j+1], b[j]})
+		}
+	}
+}
+
+// boundary lists the control points of a patch in the order they appear in
+// the stream: counter-clockwise around the edge, starting at (0,0).
+var boundary = [12][2]int{
+	{0, 0}, {0, 1}, {0, 2}, {0, 3}, {1, 3}, {2, 3},
+	{3, 3}, {3, 2}, {3, 1}, {3, 0}, {2, 0}, {1, 0},
+}
+
+// interior lists the remaining control points of a tensor-product patch in
+// stream order.
+var interior = [4][2]int{{1, 1}, {1, 2}, {2, 2}, {2, 1}}
+
+// readPatches reads a Type 6 (Coons) or Type 7 (tensor-product) patch mesh.
+// A non-zero flag shares one edge, and its two corner colours, with the
+// previous patch.
+func (m *mesh) readPatches(mr *meshReader, tensor bool) {
+	var prev *patch
+
+	for len(m.patches) < maxMeshElements {
+		f, ok := mr.flag()
+		if !ok || (f != 0 && prev == nil) || f > 3 {
+			return
+		}
+
+		var pts [12]Point
+		var comps [4][]float64
+		first, firstColor := 0, 0
+
+		if f != 0 {
+			// Edge f of the previous patch starts at boundary point 3f
+			// and corner colour f.
+			for i := range 4 {
+				ij := boundary[(3*f+i)%12]
+				pts[i] = prev.p[ij[0]][ij[1]]
+			}
+			comps[0], comps[1] = prev.comps[f], prev.comps[(f+1)%4]
+			first, firstColor = 4, 2
+		}
+
+		for i := first; i < 12; i++ {
+			if pts[i], ok = mr.point(); !ok {
+				return
+			}
+		}
+
+		var p patch
+		for i, ij := range boundary {
+			p.p[ij[0]][ij[1]] = pts[i]
+		}
+		if tensor {
+			for _, ij := range interior {
+				if p.p[ij[0]][ij[1]], ok = mr.point(); !ok {
+					return
+				}
+			}
+		}
+
+		for i := firstColor; i < 4; i++ {
+			if comps[i], ok = mr.color(); !ok {
+				return
+			}
+		}
+		p.comps = comps
+		mr.br.Align()
+
+		if !tensor {
+			p.coonsInterior()
+		}
+
+		m.patches = append(m.patches, p)
+		prev = &m.patches[len(m.patches)-1]
+	}
+}
+
+// coonsInterior sets the interior control points that make a tensor-product
+// patch equivalent to the Coons patch with the same boundary.
+func (p *patch) coonsInterior() {
+	q := &p.p
+	q[1][1] = coonsPoint(q[0][0], q[0][1], q[1][0], q[0][3], q[3][0], q[3][1], q[1][3], q[3][3])
+	q[1][2] = coonsPoint(q[0][3], q[0][2], q[1][3], q[0][0], q[3][3], q[3][2], q[1][0], q[3][0])
+	q[2][1] = coonsPoint(q[3][0], q[3][1], q[2][0], q[3][3], q[0][0], q[0][1], q[2][3], q[0][3])
+	q[2][2] = coonsPoint(q[3][3], q[3][2], q[2][3], q[3][0], q[0][3], q[0][2], q[2][0], q[0][0])
+}
+
+// coonsPoint computes the interior control point next to corner from the
+// boundary control points, weighted by their distance from it.
+func coonsPoint(corner, near1, near2, edge1, edge2, far1, far2, opposite Point) Point {
+	return corner.Mul(-4).
+		Add(near1.Add(near2).Mul(6)).
+		Add(edge1.Add(edge2).Mul(-2)).
+		Add(far1.Add(far2).Mul(3)).
+		Add(opposite.Mul(-1)).
+		Mul(1.0 / 9)
+}
+
+// eval returns the point of the patch surface at (u, v).
+func (p *patch) eval(u, v float64) Point {
+	bu, bv := bernstein(u), bernstein(v)
+
+	var out Point
+	for i := range 4 {
+		for j := range 4 {
+			out = out.Add(p.p[i][j].Mul(bu[i] * bv[j]))
+		}
+	}
+	return out
+}
+
+func bernstein(t float64) [4]float64 {
+	s := 1 - t
+	return [4]float64{s * s * s, 3 * t * s * s, 3 * t * t * s, t * t * t}
+}
+
+// colorAt interpolates the corner colours bilinearly.
+func (p *patch) colorAt(u, v float64) []float64 {
+	out := make([]float64, len(p.comps[0]))
+	for k := range out {
+		c00, c01, c11, c10 := p.comps[0][k], p.comps[1][k], p.comps[2][k], p.comps[3][k]
+		out[k] = util.Lerp(util.Lerp(c00, c01, v), util.Lerp(c10, c11, v), u)
+	}
+	return out
+}
+
+// patchSteps returns how many subdivisions along each parameter keep the
+// pieces of a patch around two device pixels across.
+func (p *patch) patchSteps(ctm Matrix) int {
+	var lo, hi Point
+	for i := range 4 {
+		for j := range 4 {
+			d := ctm.TransformPoint(p.p[i][j])
+			if i == 0 && j == 0 {
+				lo, hi = d, d
+				continue
+			}
+			lo = Point{min(lo.X, d.X), min(lo.Y, d.Y)}
+			hi = Point{max(hi.X, d.X), max(hi.Y, d.Y)}
+		}
+	}
+	size := max(hi.X-lo.X, hi.Y-lo.Y)
+	return max(2, min(int(size/2), 64))
+}
+
+func (m *mesh) render(s *Shading, ctm, _ Matrix, img *image.RGBA) {
+	for _, t := range m.triangles {
+		var d [3]meshVertex
+		for i, v := range t {
+			d[i] = meshVertex{ctm.TransformPoint(v.p), v.comps}
+		}
+		gouraud(s, d, img)
+	}
+
+	for i := range m.patches {
+		p := &m.patches[i]
+		n := p.patchSteps(ctm)
+
+		grid := make([][]meshVertex, n+1)
+		for a := range grid {
+			grid[a] = make([]meshVertex, n+1)
+			for b := range grid[a] {
+				u, v := float64(a)/float64(n), float64(b)/float64(n)
+				grid[a][b] = meshVertex{ctm.TransformPoint(p.eval(u, v)), p.colorAt(u, v)}
+			}
+		}
+
+		for a := range n {
+			for b := range n {
+				gouraud(s, [3]meshVertex{grid[a][b], grid[a+1][b], grid[a][b+1]}, img)
+				gouraud(s, [3]meshVertex{grid[a+1][b], grid[a+1][b+1], grid[a][b+1]}, img)
+			}
+		}
+	}
+}
+
+// gouraud paints a device-space triangle, interpolating the colour
+// components of its vertices linearly across it.
+func gouraud(s *Shading, t [3]meshVertex, img *image.RGBA) {
+	p0, p1, p2 := t[0].p, t[1].p, t[2].p
+
+	area := (p1.X-p0.X)*(p2.Y-p0.Y) - (p2.X-p0.X)*(p1.Y-p0.Y)
+	if area == 0 || math.IsNaN(area) {
+		return
+	}
+
+	b := image.Rect(
+		int(math.Floor(min(p0.X, p1.X, p2.X))), int(math.Floor(min(p0.Y, p1.Y, p2.Y))),
+		int(math.Ceil(max(p0.X, p1.X, p2.X))), int(math.Ceil(max(p0.Y, p1.Y, p2.Y))),
+	).Intersect(img.Rect)
+
+	// Pixel centres exactly on a shared edge are painted by both triangles;
+	// a small tolerance avoids cracks from rounding.
+	const eps = 1e-9
+	comps := make([]float64, len(t[0].comps))
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+
+			w0 := ((p1.X-px)*(p2.Y-py) - (p2.X-px)*(p1.Y-py)) / area
+			w1 := ((p2.X-px)*(p0.Y-py) - (p0.X-px)*(p2.Y-py)) / area
+			w2 := 1 - w0 - w1
+			if w0 < -eps || w1 < -eps || w2 < -eps {
+				continue
+			}
+
+			for k := range comps {
+				comps[k] = w0*t[0].comps[k] + w1*t[1].comps[k] + w2*t[2].comps[k]
+			}
+			setPixel(img, x, y, s.color(comps))
+		}
+	}
+}
@@ -1 +1,330 @@
 package render
+
+import "math"
+
+// Point is a position in either user or device space.
+type Point struct {
+	X, Y float64
+}
+
+// Add returns p + q.
+func (p Point) Add(q Point) Point {
+	return Point{p.X + q.X, p.Y + q.Y}
+}
+
+// Sub returns p - q.
+func (p Point) Sub(q Point) Point {
+	return Point{p.X - q.X, p.Y - q.Y}
+}
+
+// Mul returns p scaled by s.
+func (p Point) Mul(s float64) Point {
+	return Point{p.X * s, p.Y * s}
+}
+
+// Len returns the Euclidean length of p as a vector.
+func (p Point) Len() float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+// SegmentKind identifies the type of a path segment.
+type SegmentKind uint8
+
+// SegmentKind values.
+const (
+	SegMoveTo SegmentKind = iota
+	SegLineTo
+	SegQuadTo
+	SegCubeTo
+	SegClose
+)
+
+// Segment is a single path construction step. Only the first N points of Pts
+// are meaningful: 1 for MoveTo and LineTo, 2 for QuadTo, 3 for CubeTo and 0 for
+// Close.
+type Segment struct {
+	Kind SegmentKind
+	Pts  [3]Point
+}
+
+// Path is a sequence of subpaths built from lines and Bézier curves.
+type Path struct {
+	Segs []Segment
+
+	start   Point
+	current Point
+	open    bool
+}
+
+// NewPath returns an empty path.
+func NewPath() *Path {
+	return &Path{}
+}
+
+// Empty reports whether the path has no segments.
+func (p *Path) Empty() bool {
+	return p == nil || len(p.Segs) == 0
+}
+
+// CurrentPoint returns the current point. The second result is false when no
+// subpath has been started.
+func (p *Path) CurrentPoint() (Point, bool) {
+	return p.current, p.open
+}
+
+// MoveTo starts a new subpath at (x, y).
+func (p *Path) MoveTo(x, y float64) {
+	pt := Point{x, y}
+	p.Segs = append(p.Segs, Segment{Kind: SegMoveTo, Pts: [3]Point{pt}})
+	p.start = pt
+	p.current = pt
+	p.open = true
+}
+
+// LineTo appends a straight line to (x, y).
+func (p *Path) LineTo(x, y float64) {
+	if !p.open {
+		p.MoveTo(x, y)
+		return
+	}
+
+	pt := Point{x, y}
+	p.Segs = append(p.Segs, Segment{Kind: SegLineTo, Pts: [3]Point{pt}})
+	p.current = pt
+}
+
+// QuadTo appends a quadratic Bézier curve with control point (x1, y1).
+func (p *Path) QuadTo(x1, y1, x, y float64) {
+	if !p.open {
+		p.MoveTo(x1, y1)
+	}
+
+	pt := Point{x, y}
+	p.Segs = append(p.Segs, Segment{Kind: SegQuadTo, Pts: [3]Point{{x1, y1}, pt}})
+	p.current = pt
+}
+
+// CubeTo appends a cubic Bézier curve with control points (x1, y1) and
+// (x2, y2).
+func (p *Path) CubeTo(x1, y1, x2, y2, x, y float64) {
+	if !p.open {
+		p.MoveTo(x1, y1)
+	}
+
+	pt := Point{x, y}
+	p.Segs = append(p.Segs, Segment{Kind: SegCubeTo, Pts: [3]Point{{x1, y1}, {x2, y2}, pt}})
+	p.current = pt
+}
+
+// Close closes the current subpath with a straight line back to its start.
+func (p *Path) Close() {
+	if !p.open {
+		return
+	}
+
+	p.Segs = append(p.Segs, Segment{Kind: SegClose})
+	p.current = p.start
+}
+
+// Rect appends a closed rectangle subpath, as the re operator does.
+func (p *Path) Rect(x, y, w, h float64) {
+	p.MoveTo(x, y)
+	p.LineTo(x+w, y)
+	p.LineTo(x+w, y+h)
+	p.LineTo(x, y+h)
+	p.Close()
+}
+
+// Append adds all segments of q to p.
+func (p *Path) Append(q *Path) {
+	if q.Empty() {
+		return
+	}
+
+	p.Segs = append(p.Segs, q.Segs...)
+	p.start = q.start
+	p.current = q.current
+	p.open = q.open
+}
+
+// Clone returns a deep copy of p.
+func (p *Path) Clone() *Path {
+	if p == nil {
+		return NewPath()
+	}
+
+	q := *p
+	q.Segs = append([]Segment(nil), p.Segs...)
+	return &q
+}
+
+// Transform returns a copy of p with every point mapped through m.
+func (p *Path) Transform(m Matrix) *Path {
+	q := p.Clone()
+
+	for i := range q.Segs {
+		for j := range q.Segs[i].Pts {
+			q.Segs[i].Pts[j] = m.TransformPoint(q.Segs[i].Pts[j])
+		}
+	}
+
+	q.start = m.TransformPoint(q.start)
+	q.current = m.TransformPoint(q.current)
+	return q
+}
+
+// Bounds returns the bounding box of all points of p, including Bézier control
+// points. The result is empty (min > max) for an empty path.
+func (p *Path) Bounds() (min, max Point) {
+	min = Point{math.Inf(1), math.Inf(1)}
+	max = Point{math.Inf(-1), math.Inf(-1)}
+
+	for _, s := range p.Segs {
+		for _, pt := range s.Pts[:s.Kind.numPoints()] {
+			min.X = math.Min(min.X, pt.X)
+			min.Y = math.Min(min.Y, pt.Y)
+			max.X = math.Max(max.X, pt.X)
+			max.Y = math.Max(max.Y, pt.Y)
+		}
+	}
+
+	return min, max
+}
+
+func (k SegmentKind) numPoints() int {
+	switch k {
+	case SegMoveTo, SegLineTo:
+		return 1
+	case SegQuadTo:
+		return 2
+	case SegCubeTo:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Polyline is a flattened subpath.
+type Polyline struct {
+	Pts    []Point
+	Closed bool
+}
+
+// Flatten converts p into polylines whose maximum deviation from the true
+// curves is at most tol.
+func (p *Path) Flatten(tol float64) []Polyline {
+	if tol <= 0 {
+		tol = 0.1
+	}
+
+	var (
+		out []Polyline
+		cur *Polyline
+		pen Point
+	)
+
+	// A lone moveto paints nothing, but a closed or zero-length subpath is
+	// kept so that the stroker can draw round and square caps for it.
+	flush := func() {
+		if cur != nil && (len(cur.Pts) > 1 || cur.Closed) {
+			out = append(out, *cur)
+		}
+		cur = nil
+	}
+
+	for _, s := range p.Segs {
+		switch s.Kind {
+		case SegMoveTo:
+			flush()
+			pen = s.Pts[0]
+			cur = &Polyline{Pts: []Point{pen}}
+
+		case SegLineTo:
+			if cur == nil {
+				cur = &Polyline{Pts: []Point{pen}}
+			}
+			pen = s.Pts[0]
+			cur.Pts = append(cur.Pts, pen)
+
+		case SegQuadTo:
+			if cur == nil {
+				cur = &Polyline{Pts: []Point{pen}}
+			}
+			cur.Pts = flattenQuad(cur.Pts, pen, s.Pts[0], s.Pts[1], tol)
+			pen = s.Pts[1]
+
+		case SegCubeTo:
+			if cur == nil {
+				cur = &Polyline{Pts: []Point{pen}}
+			}
+			cur.Pts = flattenCube(cur.Pts, pen, s.Pts[0], s.Pts[1], s.Pts[2], tol)
+			pen = s.Pts[2]
+
+		case SegClose:
+			if cur != nil {
+				cur.Closed = true
+				pen = cur.Pts[0]
+				flush()
+			}
+		}
+	}
+
+	flush()
+
+	return out
+}
+
+// maxFlattenSteps bounds the number of line segments a single curve is split
+// into.
+const maxFlattenSteps = 1000
+
+func flattenSteps(dd, tol float64) int {
+	n := int(math.Ceil(math.Sqrt(dd / tol)))
+	if n < 1 {
+		return 1
+	}
+	if n > maxFlattenSteps {
+		return maxFlattenSteps
+	}
+	return n
+}
+
+func flattenQuad(dst []Point, p0, p1, p2 Point, tol float64) []Point {
+	// The deviation of a uniformly subdivided quadratic is |p0-2p1+p2| / (4n²).
+	dd := p0.Sub(p1.Mul(2)).Add(p2).Len() / 4
+	n := flattenSteps(dd, tol)
+
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		dst = append(dst, Point{
+			mt*mt*p0.X + 2*mt*t*p1.X + t*t*p2.X,
+			mt*mt*p0.Y + 2*mt*t*p1.Y + t*t*p2.Y,
+		})
+	}
+
+	return dst
+}
+
+func flattenCube(dst []Point, p0, p1, p2, p3 Point, tol float64) []Point {
+	// The deviation of a uniformly subdivided cubic is bounded by 3/4 of the
+	// largest second difference of its control polygon divided by n².
+	d1 := p0.Sub(p1.Mul(2)).Add(p2).Len()
+	d2 := p1.Sub(p2.Mul(2)).Add(p3).Len()
+	n := flattenSteps(0.75*math.Max(d1, d2), tol)
+
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		a := mt * mt * mt
+		b := 3 * mt * mt * t
+		c := 3 * mt * t * t
+		d := t * t * t
+		dst = append(dst, Point{
+			a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+			a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+		})
+	}
+
+	return dst
+}
@@ -1 +1,319 @@
 package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// FillRule selects how the inside of a path is determined.
+type FillRule int
+
+// FillRule values.
+const (
+	NonZero FillRule = iota
+	EvenOdd
+)
+
+// subSamples is the number of sample rows per pixel used for vertical
+// anti-aliasing. Horizontal coverage is computed exactly.
+const subSamples = 16
+
+// fillTolerance is the curve flattening tolerance, in device pixels, used when
+// filling.
+const fillTolerance = 0.1
+
+// FlattenFill returns the device-space path p with its curves replaced by
+// the lines filling would split them into, so that a path filled many
+// times, such as a glyph outline, is only flattened once.
+func FlattenFill(p *Path) *Path {
+	q := NewPath()
+	for _, pl := range p.Flatten(fillTolerance) {
+		q.MoveTo(pl.Pts[0].X, pl.Pts[0].Y)
+		for _, pt := range pl.Pts[1:] {
+			q.LineTo(pt.X, pt.Y)
+		}
+		q.Close()
+	}
+	return q
+}
+
+type edge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+// Rasterize computes the anti-aliased coverage of the device-space path p
+// within bounds. Pixel (x, y) covers the square [x, x+1) × [y, y+1).
+func Rasterize(p *Path, rule FillRule, bounds image.Rectangle) *image.Alpha {
+	mask := image.NewAlpha(bounds)
+	if p.Empty() || bounds.Empty() {
+		return mask
+	}
+
+	var edges []edge
+	for _, pl := range p.Flatten(fillTolerance) {
+		n := len(pl.Pts)
+		// Filling implicitly closes every subpath.
+		for i := 0; i < n; i++ {
+			a, b := pl.Pts[i], pl.Pts[(i+1)%n]
+			if a.Y == b.Y {
+				continue
+			}
+			if a.Y < b.Y {
+				edges = append(edges, edge{a.X, a.Y, b.X, b.Y, 1})
+			} else {
+				edges = append(edges, edge{b.X, b.Y, a.X, a.Y, -1})
+			}
+		}
+	}
+
+	if len(edges) == 0 {
+		return mask
+	}
+
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+
+	x0, x1 := bounds.Min.X, bounds.Max.X
+	width := x1 - x0
+
+	area := make([]float64, width+2)
+	cover := make([]float64, width+2)
+
+	type crossing struct {
+		x   float64
+		dir int
+	}
+
+	var (
+		active    []int
+		crossings []crossing
+		next      int
+	)
+
+	const w = 1.0 / subSamples
+
+	addSpan := func(xa, xb float64) {
+		xa = math.Max(xa-float64(x0), 0)
+		xb = math.Min(xb-float64(x0), float64(width))
+		if xb <= xa {
+			return
+		}
+
+		ia, ib := int(xa), int(xb)
+		if ia == ib {
+			area[ia] += (xb - xa) * w
+			return
+		}
+
+		area[ia] += (float64(ia+1) - xa) * w
+		cover[ia+1] += w
+		cover[ib] -= w
+		area[ib] += (xb - float64(ib)) * w
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		touched := false
+
+		for s := 0; s < subSamples; s++ {
+			sy := float64(y) + (float64(s)+0.5)/subSamples
+
+			for next < len(edges) && edges[next].y0 <= sy {
+				active = append(active, next)
+				next++
+			}
+
+			crossings = crossings[:0]
+			kept := active[:0]
+			for _, i := range active {
+				e := &edges[i]
+				if e.y1 <= sy {
+					continue
+				}
+				kept = append(kept, i)
+				if e.y0 > sy {
+					continue
+				}
+				t := (sy - e.y0) / (e.y1 - e.y0)
+				crossings = append(crossings, crossing{e.x0 + t*(e.x1-e.x0), e.dir})
+			}
+			active = kept
+
+			if len(crossings) == 0 {
+				continue
+			}
+
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			wind := 0
+			for i := 0; i+1 < len(crossings); i++ {
+				wind += crossings[i].dir
+				inside := wind != 0
+				if rule == EvenOdd {
+					inside = wind%2 != 0
+				}
+				if inside {
+					addSpan(crossings[i].x, crossings[i+1].x)
+					touched = true
+				}
+			}
+		}
+
+		if !touched {
+			continue
+		}
+
+		row := mask.Pix[(y-bounds.Min.Y)*mask.Stride:]
+		acc := 0.0
+		for i := 0; i < width; i++ {
+			acc += cover[i]
+			row[i] = coverageByte(acc + area[i])
+			area[i] = 0
+			cover[i] = 0
+		}
+		area[width] = 0
+		cover[width] = 0
+		cover[width+1] = 0
+	}
+
+	return mask
+}
+
+func coverageByte(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 0xff
+	}
+	return uint8(v*0xff + 0.5)
+}
+
+// Canvas is an RGBA raster that paths are painted onto.
+type Canvas struct {
+	Img *image.RGBA
+
+	// Alpha and SoftMask scale the opacity of everything painted, the
+	// latter per device pixel, and Blend combines it with the backdrop.
+	// Pixels outside SoftMask are not painted.
+	Alpha    float64
+	Blend    BlendMode
+	SoftMask *image.Alpha
+
+	// The canvas of a transparency group keeps the backdrop it started
+	// with, unless it is isolated, and tracks the opacity of the group
+	// alone when it started with one. Objects painted in a knockout group
+	// replace each other rather than compositing.
+	backdrop   *image.RGBA
+	groupAlpha *image.Alpha
+	knockout   bool
+}
+
+// NewCanvas returns a transparent canvas of the given size in pixels.
+func NewCanvas(width, height int) *Canvas {
+	return &Canvas{Img: image.NewRGBA(image.Rect(0, 0, width, height)), Alpha: 1}
+}
+
+// Bounds returns the pixel bounds of the canvas.
+func (c *Canvas) Bounds() image.Rectangle {
+	return c.Img.Bounds()
+}
+
+// Fill paints the device-space path p with col using rule, restricted to clip.
+// A nil clip paints the whole canvas.
+func (c *Canvas) Fill(p *Path, rule FillRule, clip *Clip, col color.Color) {
+	bounds := c.Bounds()
+	if clip != nil {
+		bounds = bounds.Intersect(clip.Bounds())
+	}
+
+	min, max := p.Bounds()
+	bounds = bounds.Intersect(image.Rect(
+		int(math.Floor(min.X)), int(math.Floor(min.Y)),
+		int(math.Ceil(max.X)), int(math.Ceil(max.Y)),
+	))
+	if bounds.Empty() {
+		return
+	}
+
+	c.FillMask(Rasterize(p, rule, bounds), clip, col)
+}
+
+// FillMask composites col onto the canvas through the coverage in mask and
+// clip using the source-over operator.
+func (c *Canvas) FillMask(mask *image.Alpha, clip *Clip, col color.Color) {
+	sr, sg, sb, sa := col.RGBA()
+	if sa == 0 {
+		return
+	}
+
+	bounds := mask.Rect.Intersect(c.Bounds())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			m := uint32(mask.Pix[mask.PixOffset(x, y)])
+			if clip != nil {
+				m = m * uint32(clip.Coverage(x, y)) / 0xff
+			}
+			if m == 0 {
+				continue
+			}
+
+			c.blend(x, y, sr, sg, sb, sa, m*0x101)
+		}
+	}
+}
+
+// Composite draws the premultiplied image src onto the canvas with the
+// source-over operator, through the coverage in mask and clip. A nil mask
+// admits all of src.
+func (c *Canvas) Composite(src *image.RGBA, mask *image.Alpha, clip *Clip) {
+	bounds := src.Rect.Intersect(c.Bounds())
+	if mask != nil {
+		bounds = bounds.Intersect(mask.Rect)
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			m := uint32(0xff)
+			if mask != nil {
+				m = uint32(mask.Pix[mask.PixOffset(x, y)])
+			}
+			if clip != nil {
+				m = m * uint32(clip.Coverage(x, y)) / 0xff
+			}
+			if m == 0 {
+				continue
+			}
+
+			i := src.PixOffset(x, y)
+			s := src.Pix[i : i+4 : i+4]
+			if s[3] == 0 {
+				continue
+			}
+
+			// Widen the 8-bit samples and coverage to 16 bits.
+			c.blend(x, y, uint32(s[0])*0x101, uint32(s[1])*0x101,
+				uint32(s[2])*0x101, uint32(s[3])*0x101, m*0x101)
+		}
+	}
+}
+
+// blend composites a 16-bit premultiplied colour over pixel (x, y), of
+// which it covers cov (0..0xffff).
+func (c *Canvas) blend(x, y int, r, g, b, a, cov uint32) {
+	if c.Alpha < 1 || c.Blend != BlendNormal || c.SoftMask != nil || c.groupAlpha != nil || c.knockout {
+		c.blendTransparent(x, y, r, g, b, a, cov)
+		return
+	}
+
+	r, g, b, a = r*cov/0xffff, g*cov/0xffff, b*cov/0xffff, a*cov/0xffff
+	i := c.Img.PixOffset(x, y)
+	d := c.Img.Pix[i : i+4 : i+4]
+	inv := 0xffff - a
+	d[0] = uint8((uint32(d[0])*0x101*inv/0xffff + r) >> 8)
+	d[1] = uint8((uint32(d[1])*0x101*inv/0xffff + g) >> 8)
+	d[2] = uint8((uint32(d[2])*0x101*inv/0xffff + b) >> 8)
+	d[3] = uint8((uint32(d[3])*0x101*inv/0xffff + a) >> 8)
+}
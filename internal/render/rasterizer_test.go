@@ -0,0 +1,157 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func alphaAt(m *image.Alpha, x, y int) uint8 {
+	return m.Pix[m.PixOffset(x, y)]
+}
+
+func TestRasterizeRect(t *testing.T) {
+	p := NewPath()
+	p.Rect(2, 2, 4, 4)
+
+	m := Rasterize(p, NonZero, image.Rect(0, 0, 10, 10))
+
+	tests := []struct {
+		x, y     int
+		expected uint8
+	}{
+		{3, 3, 0xff},
+		{5, 5, 0xff},
+		{1, 3, 0},
+		{6, 3, 0},
+		{3, 6, 0},
+	}
+
+	for _, tc := range tests {
+		if got := alphaAt(m, tc.x, tc.y); got != tc.expected {
+			t.Errorf("coverage at (%d, %d) = %d, expected %d", tc.x, tc.y, got, tc.expected)
+		}
+	}
+}
+
+func TestRasterizeAntiAliasedEdge(t *testing.T) {
+	p := NewPath()
+	p.Rect(0, 0, 2.5, 4)
+
+	m := Rasterize(p, NonZero, image.Rect(0, 0, 4, 4))
+
+	if got := alphaAt(m, 2, 1); got < 0x7c || got > 0x82 {
+		t.Errorf("coverage of half-covered pixel = %d, expected about 128", got)
+	}
+}
+
+func TestRasterizeFillRules(t *testing.T) {
+	// Two nested squares drawn in the same direction: the inner one is a hole
+	// under even-odd but filled under nonzero.
+	p := NewPath()
+	p.Rect(0, 0, 10, 10)
+	p.Rect(3, 3, 4, 4)
+
+	bounds := image.Rect(0, 0, 10, 10)
+
+	if got := alphaAt(Rasterize(p, NonZero, bounds), 5, 5); got != 0xff {
+		t.Errorf("nonzero coverage at centre = %d, expected 255", got)
+	}
+	if got := alphaAt(Rasterize(p, EvenOdd, bounds), 5, 5); got != 0 {
+		t.Errorf("even-odd coverage at centre = %d, expected 0", got)
+	}
+	if got := alphaAt(Rasterize(p, EvenOdd, bounds), 1, 1); got != 0xff {
+		t.Errorf("even-odd coverage at border = %d, expected 255", got)
+	}
+}
+
+func TestFlattenFill(t *testing.T) {
+	p := NewPath()
+	p.MoveTo(1, 1)
+	p.QuadTo(9, 1, 9, 9)
+	p.CubeTo(5, 9, 1, 5, 1, 1)
+
+	q := FlattenFill(p)
+	for _, s := range q.Segs {
+		if s.Kind == SegQuadTo || s.Kind == SegCubeTo {
+			t.Fatalf("flattened path has a %v segment", s.Kind)
+		}
+	}
+
+	bounds := image.Rect(0, 0, 10, 10)
+	want, got := Rasterize(p, NonZero, bounds), Rasterize(q, NonZero, bounds)
+	if !bytes.Equal(want.Pix, got.Pix) {
+		t.Errorf("flattened path covers %v, expected %v", got.Pix, want.Pix)
+	}
+}
+
+func TestClipRectFastPath(t *testing.T) {
+	c := NewRectClip(Rect{0, 0, 10, 10})
+
+	p := NewPath()
+	p.Rect(2, 2, 4.5, 4)
+
+	c = c.IntersectPath(p, NonZero)
+	if !c.IsRect() {
+		t.Fatalf("rectangular clip was rasterized")
+	}
+
+	if got := c.Coverage(6, 3); got < 0x7c || got > 0x82 {
+		t.Errorf("edge coverage = %d, expected about 128", got)
+	}
+	if got := c.Coverage(3, 3); got != 0xff {
+		t.Errorf("inner coverage = %d, expected 255", got)
+	}
+	if got := c.Coverage(8, 3); got != 0 {
+		t.Errorf("outer coverage = %d, expected 0", got)
+	}
+}
+
+func TestClipIntersection(t *testing.T) {
+	c := NewRectClip(Rect{0, 0, 20, 20})
+
+	tri := NewPath()
+	tri.MoveTo(0, 0)
+	tri.LineTo(20, 0)
+	tri.LineTo(0, 20)
+	tri.Close()
+
+	c = c.IntersectPath(tri, NonZero)
+	if c.IsRect() {
+		t.Fatalf("triangular clip reported as a rectangle")
+	}
+
+	c = c.IntersectRect(Rect{0, 0, 5, 20})
+
+	tests := []struct {
+		x, y     int
+		expected uint8
+	}{
+		{2, 2, 0xff},
+		{7, 2, 0},
+		{2, 18, 0},
+	}
+
+	for _, tc := range tests {
+		if got := c.Coverage(tc.x, tc.y); got != tc.expected {
+			t.Errorf("coverage at (%d, %d) = %d, expected %d", tc.x, tc.y, got, tc.expected)
+		}
+	}
+}
+
+func TestCanvasFillRespectsClip(t *testing.T) {
+	canvas := NewCanvas(10, 10)
+	clip := NewRectClip(Rect{0, 0, 5, 10})
+
+	p := NewPath()
+	p.Rect(0, 0, 10, 10)
+	canvas.Fill(p, NonZero, clip, color.Black)
+
+	if got := canvas.Img.RGBAAt(2, 2).A; got != 0xff {
+		t.Errorf("alpha inside clip = %d, expected 255", got)
+	}
+	if got := canvas.Img.RGBAAt(7, 2).A; got != 0 {
+		t.Errorf("alpha outside clip = %d, expected 0", got)
+	}
+}
@@ -0,0 +1,335 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/colorspace"
+	"github.com/Kantha2004/go-pdfviewer/internal/function"
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+)
+
+// Shading is a parsed shading dictionary: a smooth colour gradient defined in
+// its own coordinate space and painted by the sh operator or a shading
+// pattern.
+type Shading struct {
+	Type  int
+	Space colorspace.ColorSpace
+
+	// Background holds the colour components used outside the shading's
+	// geometry when it is painted as a pattern, or nil.
+	Background []float64
+
+	// BBox bounds the shading in shading space, if HasBBox is set.
+	BBox    model.Rectangle
+	HasBBox bool
+
+	// fn maps a parametric value, or the coordinates of a function-based
+	// shading, to colour components. It is nil for meshes with per-vertex
+	// colours.
+	fn function.Function
+
+	kind shader
+}
+
+// shader renders one family of shadings.
+type shader interface {
+	// render paints the pixels of img that the shading covers. inv maps
+	// device space back to shading space.
+	render(s *Shading, ctm, inv Matrix, img *image.RGBA)
+}
+
+// ParseShading parses a shading dictionary or, for mesh shadings, stream.
+func ParseShading(v model.PDFValue, r model.Resolver) (*Shading, error) {
+	var dict model.PDFDict
+	var stream *model.PDFStream
+
+	switch sh := r.Resolve(v).(type) {
+	case model.PDFDict:
+		dict = sh
+	case model.PDFStream:
+		dict, stream = sh.Dict, &sh
+	default:
+		return nil, fmt.Errorf("invalid shading %v", sh)
+	}
+
+	typ, _ := r.Resolve(dict["ShadingType"]).(model.PDFNumber)
+
+	cs, err := colorspace.Parse(dict["ColorSpace"], r)
+	if err != nil {
+		return nil, fmt.Errorf("shading: %w", err)
+	}
+
+	s := &Shading{Type: int(typ), Space: cs}
+
+	if bg, ok := model.ResolveNumbers(dict["Background"], r); ok && len(bg) == cs.NComponents() {
+		s.Background = bg
+	}
+
+	if bbox, ok := model.ResolveNumbers(dict["BBox"], r); ok && len(bbox) == 4 {
+		s.BBox = model.Rectangle{
+			LLX: min(bbox[0], bbox[2]), LLY: min(bbox[1], bbox[3]),
+			URX: max(bbox[0], bbox[2]), URY: max(bbox[1], bbox[3]),
+		}
+		s.HasBBox = true
+	}
+
+	if f, ok := dict["Function"]; ok {
+		if s.fn, err = function.Parse(f, r); err != nil {
+			return nil, fmt.Errorf("shading: %w", err)
+		}
+		if s.fn.NumOutputs() != cs.NComponents() {
+			return nil, fmt.Errorf("shading function has %d outputs, colour space has %d components",
+				s.fn.NumOutputs(), cs.NComponents())
+		}
+	}
+
+	switch s.Type {
+	case 1:
+		s.kind, err = parseFunctionShading(s, dict, r)
+	case 2, 3:
+		s.kind, err = parseGradient(s, dict, r)
+	case 4, 5, 6, 7:
+		if stream == nil {
+			return nil, fmt.Errorf("mesh shading is not a stream")
+		}
+		s.kind, err = parseMesh(s, *stream, r)
+	default:
+		return nil, fmt.Errorf("unsupported shading type %v", dict["ShadingType"])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Render paints the shading into a new image covering bounds, with ctm
+// mapping shading space to device space. Pixels outside the shading are left
+// transparent, or painted with the background colour if background is set
+// and the shading has one.
+func (s *Shading) Render(ctm Matrix, bounds image.Rectangle, background bool) *image.RGBA {
+	img := image.NewRGBA(bounds)
+
+	inv, ok := ctm.Invert()
+	if !ok || bounds.Empty() {
+		return img
+	}
+
+	if background && s.Background != nil {
+		bg := colorspace.ToRGBA(s.Space, s.Background)
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = bg.R, bg.G, bg.B, bg.A
+		}
+	}
+
+	s.kind.render(s, ctm, inv, img)
+	return img
+}
+
+// BBoxPath returns the /BBox of the shading as a path in shading space.
+func (s *Shading) BBoxPath() *Path {
+	p := NewPath()
+	p.Rect(s.BBox.LLX, s.BBox.LLY, s.BBox.Width(), s.BBox.Height())
+	return p
+}
+
+// color converts the input of the shading function, or the colour components
+// themselves when there is no function, to an opaque colour.
+func (s *Shading) color(in []float64) color.RGBA {
+	if s.fn != nil {
+		in = s.fn.Evaluate(in)
+	}
+	return colorspace.ToRGBA(s.Space, in)
+}
+
+// setPixel stores an opaque colour in img.
+func setPixel(img *image.RGBA, x, y int, c color.RGBA) {
+	i := img.PixOffset(x, y)
+	img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+}
+
+// functionShading is a Type 1 shading, whose colour at each point of its
+// domain is given directly by a two-input function.
+type functionShading struct {
+	domain [4]float64
+	matrix Matrix
+}
+
+func parseFunctionShading(s *Shading, dict model.PDFDict, r model.Resolver) (shader, error) {
+	if s.fn == nil || s.fn.NumInputs() != 2 {
+		return nil, fmt.Errorf("function-based shading needs a two-input /Function")
+	}
+
+	f := &functionShading{domain: [4]float64{0, 1, 0, 1}, matrix: Identity}
+	if d, ok := model.ResolveNumbers(dict["Domain"], r); ok && len(d) == 4 {
+		f.domain = [4]float64(d)
+	}
+	if m, ok := model.ResolveNumbers(dict["Matrix"], r); ok && len(m) == 6 {
+		f.matrix = Matrix(m)
+	}
+
+	return f, nil
+}
+
+func (f *functionShading) render(s *Shading, ctm, _ Matrix, img *image.RGBA) {
+	inv, ok := f.matrix.Multiply(ctm).Invert()
+	if !ok {
+		return
+	}
+
+	b := img.Rect
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			u, v := inv.Transform(float64(x)+0.5, float64(y)+0.5)
+			if u < f.domain[0] || u > f.domain[1] || v < f.domain[2] || v > f.domain[3] {
+				continue
+			}
+			setPixel(img, x, y, s.color([]float64{u, v}))
+		}
+	}
+}
+
+// gradientSteps is the number of colours precomputed along the parametric
+// axis of axial and radial shadings.
+const gradientSteps = 1024
+
+// gradient is an axial (Type 2) or radial (Type 3) shading.
+type gradient struct {
+	radial bool
+	coords []float64
+	extend [2]bool
+	lut    []color.RGBA
+}
+
+func parseGradient(s *Shading, dict model.PDFDict, r model.Resolver) (shader, error) {
+	if s.fn == nil || s.fn.NumInputs() != 1 {
+		return nil, fmt.Errorf("axial and radial shadings need a one-input /Function")
+	}
+
+	g := &gradient{radial: s.Type == 3}
+
+	n := 4
+	if g.radial {
+		n = 6
+	}
+	var ok bool
+	if g.coords, ok = model.ResolveNumbers(dict["Coords"], r); !ok || len(g.coords) != n {
+		return nil, fmt.Errorf("shading /Coords must have %d elements", n)
+	}
+	if g.radial && (g.coords[2] < 0 || g.coords[5] < 0) {
+		return nil, fmt.Errorf("radial shading has a negative radius")
+	}
+
+	t0, t1 := 0.0, 1.0
+	if d, ok := model.ResolveNumbers(dict["Domain"], r); ok && len(d) == 2 {
+		t0, t1 = d[0], d[1]
+	}
+
+	if e, ok := r.Resolve(dict["Extend"]).(model.PDFArray); ok && len(e) == 2 {
+		for i := range 2 {
+			b, _ := r.Resolve(e[i]).(model.PDFBoolean)
+			g.extend[i] = bool(b)
+		}
+	}
+
+	g.lut = make([]color.RGBA, gradientSteps)
+	for i := range g.lut {
+		t := t0 + (t1-t0)*float64(i)/float64(gradientSteps-1)
+		g.lut[i] = s.color([]float64{t})
+	}
+
+	return g, nil
+}
+
+func (g *gradient) render(_ *Shading, _, inv Matrix, img *image.RGBA) {
+	b := img.Rect
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			px, py := inv.Transform(float64(x)+0.5, float64(y)+0.5)
+
+			var s float64
+			var ok bool
+			if g.radial {
+				s, ok = g.radialParam(px, py)
+			} else {
+				s, ok = g.axialParam(px, py)
+			}
+			if !ok {
+				continue
+			}
+
+			i := int(s*(gradientSteps-1) + 0.5)
+			setPixel(img, x, y, g.lut[max(0, min(i, gradientSteps-1))])
+		}
+	}
+}
+
+// axialParam projects (x, y) onto the axis, returning its position in [0, 1]
+// or false if it lies beyond an end that is not extended.
+func (g *gradient) axialParam(x, y float64) (float64, bool) {
+	x0, y0, x1, y1 := g.coords[0], g.coords[1], g.coords[2], g.coords[3]
+	dx, dy := x1-x0, y1-y0
+
+	den := dx*dx + dy*dy
+	if den == 0 {
+		return 0, false
+	}
+
+	s := ((x-x0)*dx + (y-y0)*dy) / den
+	return g.clampParam(s)
+}
+
+// radialParam finds the largest s for which (x, y) lies on the circle
+// interpolated between the start and end circles, as the blend of a radial
+// shading paints later circles over earlier ones.
+func (g *gradient) radialParam(x, y float64) (float64, bool) {
+	x0, y0, r0 := g.coords[0], g.coords[1], g.coords[2]
+	dx, dy, dr := g.coords[3]-x0, g.coords[4]-y0, g.coords[5]-r0
+	px, py := x-x0, y-y0
+
+	// |p - s·d|² = (r0 + s·dr)² expands to a·s² - 2b·s + c = 0.
+	a := dx*dx + dy*dy - dr*dr
+	b := px*dx + py*dy + r0*dr
+	c := px*px + py*py - r0*r0
+
+	var roots []float64
+	if a == 0 {
+		if b == 0 {
+			return 0, false
+		}
+		roots = []float64{c / (2 * b)}
+	} else {
+		disc := b*b - a*c
+		if disc < 0 {
+			return 0, false
+		}
+		sq := math.Sqrt(disc)
+		s1, s2 := (b+sq)/a, (b-sq)/a
+		roots = []float64{max(s1, s2), min(s1, s2)}
+	}
+
+	for _, s := range roots {
+		if r0+s*dr < 0 {
+			continue
+		}
+		if t, ok := g.clampParam(s); ok {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// clampParam applies /Extend to a parametric position.
+func (g *gradient) clampParam(s float64) (float64, bool) {
+	switch {
+	case s < 0:
+		return 0, g.extend[0]
+	case s > 1:
+		return 1, g.extend[1]
+	default:
+		return s, true
+	}
+}
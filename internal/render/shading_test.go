@@ -0,0 +1,237 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Kantha2004/go-pdfviewer/internal/model"
+	"github.com/Kantha2004/go-pdfviewer/internal/parser"
+)
+
+func nums(v ...float64) model.PDFArray {
+	arr := make(model.PDFArray, len(v))
+	for i, x := range v {
+		arr[i] = model.PDFNumber(x)
+	}
+	return arr
+}
+
+// redToBlue is an exponential function from red to blue.
+var redToBlue = model.PDFDict{
+	"FunctionType": model.PDFNumber(2),
+	"Domain":       nums(0, 1),
+	"C0":           nums(1, 0, 0),
+	"C1":           nums(0, 0, 1),
+	"N":            model.PDFNumber(1),
+}
+
+func renderShading(t *testing.T, v model.PDFValue, size int) *image.RGBA {
+	t.Helper()
+
+	sh, err := ParseShading(v, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("ParseShading() error = %v", err)
+	}
+	return sh.Render(Identity, image.Rect(0, 0, size, size), false)
+}
+
+// near reports whether two colours differ by at most tol in each channel.
+func near(a, b color.RGBA, tol int) bool {
+	d := func(x, y uint8) bool { return int(x)-int(y) <= tol && int(y)-int(x) <= tol }
+	return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B) && d(a.A, b.A)
+}
+
+type shadedPixel struct {
+	x, y int
+	c    color.RGBA
+}
+
+func checkShaded(t *testing.T, img *image.RGBA, pixels []shadedPixel) {
+	t.Helper()
+
+	for _, px := range pixels {
+		if got := img.RGBAAt(px.x, px.y); !near(got, px.c, 8) {
+			t.Errorf("pixel (%d, %d) = %v, expected %v", px.x, px.y, got, px.c)
+		}
+	}
+}
+
+var (
+	red         = color.RGBA{0xff, 0, 0, 0xff}
+	blue        = color.RGBA{0, 0, 0xff, 0xff}
+	purple      = color.RGBA{0x80, 0, 0x80, 0xff}
+	transparent = color.RGBA{}
+)
+
+func TestAxialShading(t *testing.T) {
+	dict := model.PDFDict{
+		"ShadingType": model.PDFNumber(2),
+		"ColorSpace":  model.PDFName("DeviceRGB"),
+		"Coords":      nums(5, 0, 15, 0),
+		"Function":    redToBlue,
+	}
+
+	checkShaded(t, renderShading(t, dict, 20), []shadedPixel{
+		{5, 5, color.RGBA{0xf3, 0, 0x0c, 0xff}},
+		{10, 5, color.RGBA{0x73, 0, 0x8c, 0xff}},
+		{14, 5, color.RGBA{0x0c, 0, 0xf3, 0xff}},
+		{2, 5, transparent},
+		{18, 5, transparent},
+	})
+
+	dict["Extend"] = model.PDFArray{model.PDFBoolean(true), model.PDFBoolean(true)}
+	checkShaded(t, renderShading(t, dict, 20), []shadedPixel{
+		{2, 5, red},
+		{18, 5, blue},
+	})
+}
+
+func TestRadialShading(t *testing.T) {
+	dict := model.PDFDict{
+		"ShadingType": model.PDFNumber(3),
+		"ColorSpace":  model.PDFName("DeviceRGB"),
+		"Coords":      nums(10, 10, 0, 10, 10, 8),
+		"Function":    redToBlue,
+	}
+
+	checkShaded(t, renderShading(t, dict, 20), []shadedPixel{
+		{9, 9, color.RGBA{0xf0, 0, 0x0f, 0xff}},
+		{14, 9, color.RGBA{0x6f, 0, 0x90, 0xff}},
+		{0, 0, transparent},
+	})
+
+	dict["Extend"] = model.PDFArray{model.PDFBoolean(false), model.PDFBoolean(true)}
+	checkShaded(t, renderShading(t, dict, 20), []shadedPixel{{0, 0, blue}})
+}
+
+func TestFunctionShading(t *testing.T) {
+	dict := model.PDFDict{
+		"ShadingType": model.PDFNumber(1),
+		"ColorSpace":  model.PDFName("DeviceGray"),
+		"Domain":      nums(0, 1, 0, 1),
+		"Matrix":      nums(10, 0, 0, 10, 0, 0),
+		"Function": model.PDFStream{
+			Dict: model.PDFDict{"FunctionType": model.PDFNumber(4), "Domain": nums(0, 1, 0, 1), "Range": nums(0, 1)},
+			Data: []byte("{ pop }"),
+		},
+	}
+
+	checkShaded(t, renderShading(t, dict, 20), []shadedPixel{
+		{0, 5, color.RGBA{0x0d, 0x0d, 0x0d, 0xff}},
+		{9, 5, color.RGBA{0xf2, 0xf2, 0xf2, 0xff}},
+		{15, 5, transparent},
+	})
+}
+
+func TestShadingBackground(t *testing.T) {
+	sh, err := ParseShading(model.PDFDict{
+		"ShadingType": model.PDFNumber(2),
+		"ColorSpace":  model.PDFName("DeviceRGB"),
+		"Coords":      nums(5, 0, 15, 0),
+		"Function":    redToBlue,
+		"Background":  nums(0, 1, 0),
+	}, parser.NewObjectTable())
+	if err != nil {
+		t.Fatalf("ParseShading() error = %v", err)
+	}
+
+	img := sh.Render(Identity, image.Rect(0, 0, 20, 20), true)
+	checkShaded(t, img, []shadedPixel{{2, 5, color.RGBA{0, 0xff, 0, 0xff}}, {10, 5, color.RGBA{0x73, 0, 0x8c, 0xff}}})
+}
+
+func meshStream(typ int, extra model.PDFDict, data ...byte) model.PDFStream {
+	dict := model.PDFDict{
+		"ShadingType":       model.PDFNumber(typ),
+		"ColorSpace":        model.PDFName("DeviceRGB"),
+		"BitsPerCoordinate": model.PDFNumber(8),
+		"BitsPerComponent":  model.PDFNumber(8),
+		"BitsPerFlag":       model.PDFNumber(8),
+		"Decode":            nums(0, 255, 0, 255, 0, 1, 0, 1, 0, 1),
+	}
+	for k, v := range extra {
+		dict[k] = v
+	}
+	return model.PDFStream{Dict: dict, Data: data}
+}
+
+func TestFreeFormMesh(t *testing.T) {
+	// Two triangles covering the square (0,0)-(20,20), split along x = y;
+	// the second continues from the edge of the first with flag 2.
+	sh := meshStream(4, nil,
+		0, 0, 0, 255, 0, 0,
+		0, 20, 0, 255, 0, 0,
+		0, 0, 20, 255, 0, 0,
+		2, 20, 20, 0, 0, 255,
+	)
+
+	checkShaded(t, renderShading(t, sh, 20), []shadedPixel{
+		{5, 1, red},
+		{18, 18, color.RGBA{0x13, 0, 0xec, 0xff}},
+	})
+}
+
+func TestLatticeMesh(t *testing.T) {
+	sh := meshStream(5, model.PDFDict{"VerticesPerRow": model.PDFNumber(2)},
+		0, 0, 255, 0, 0, 20, 0, 255, 0, 0,
+		0, 20, 0, 0, 255, 20, 20, 0, 0, 255,
+	)
+
+	checkShaded(t, renderShading(t, sh, 20), []shadedPixel{
+		{10, 0, color.RGBA{0xf3, 0, 0x0c, 0xff}},
+		{10, 10, purple},
+		{10, 19, color.RGBA{0x0c, 0, 0xf3, 0xff}},
+	})
+}
+
+// squarePatch returns the twelve boundary points of a Coons patch covering
+// (0,0)-(20,20) with straight edges, in stream order.
+func squarePatch() []byte {
+	return []byte{
+		0, 0, 0, 7, 0, 13, 0, 20,
+		7, 20, 13, 20, 20, 20,
+		20, 13, 20, 7, 20, 0,
+		13, 0, 7, 0,
+	}
+}
+
+func TestCoonsPatch(t *testing.T) {
+	data := append([]byte{0}, squarePatch()...)
+	// Corners (0,0) and (0,20) red, (20,20) and (20,0) blue.
+	data = append(data, 255, 0, 0, 255, 0, 0, 0, 0, 255, 0, 0, 255)
+
+	checkShaded(t, renderShading(t, meshStream(6, nil, data...), 20), []shadedPixel{
+		{0, 10, color.RGBA{0xf3, 0, 0x0c, 0xff}},
+		{10, 10, purple},
+		{19, 10, color.RGBA{0x0c, 0, 0xf3, 0xff}},
+	})
+}
+
+func TestTensorPatch(t *testing.T) {
+	data := append([]byte{0}, squarePatch()...)
+	data = append(data, 7, 7, 7, 13, 13, 13, 13, 7)
+	data = append(data, 255, 0, 0, 255, 0, 0, 0, 0, 255, 0, 0, 255)
+
+	checkShaded(t, renderShading(t, meshStream(7, nil, data...), 20), []shadedPixel{
+		{10, 10, purple},
+	})
+}
+
+func TestParseShadingErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    model.PDFValue
+	}{
+		{"UnknownType", model.PDFDict{"ShadingType": model.PDFNumber(9), "ColorSpace": model.PDFName("DeviceRGB")}},
+		{"NoFunction", model.PDFDict{"ShadingType": model.PDFNumber(2), "ColorSpace": model.PDFName("DeviceRGB"), "Coords": nums(0, 0, 1, 1)}},
+		{"BadCoords", model.PDFDict{"ShadingType": model.PDFNumber(2), "ColorSpace": model.PDFName("DeviceRGB"), "Coords": nums(0, 0), "Function": redToBlue}},
+		{"WrongOutputs", model.PDFDict{"ShadingType": model.PDFNumber(2), "ColorSpace": model.PDFName("DeviceGray"), "Coords": nums(0, 0, 1, 1), "Function": redToBlue}},
+		{"MeshNotStream", model.PDFDict{"ShadingType": model.PDFNumber(4), "ColorSpace": model.PDFName("DeviceRGB")}},
+	}
+
+	for _, tc := range tests {
+		if _, err := ParseShading(tc.v, parser.NewObjectTable()); err == nil {
+			t.Errorf("%s: ParseShading() expected an error", tc.name)
+		}
+	}
+}
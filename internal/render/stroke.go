@@ -0,0 +1,405 @@
+package render
+
+import "math"
+
+// LineCap is the shape drawn at the open ends of stroked subpaths.
+type LineCap int
+
+// LineCap values, numbered as for the J operator.
+const (
+	ButtCap LineCap = iota
+	RoundCap
+	SquareCap
+)
+
+// LineJoin is the shape drawn where two segments of a stroked path meet.
+type LineJoin int
+
+// LineJoin values, numbered as for the j operator.
+const (
+	MiterJoin LineJoin = iota
+	RoundJoin
+	BevelJoin
+)
+
+// StrokeStyle holds the graphics state parameters that control stroking.
+// Width, Dash and DashPhase are expressed in user space.
+type StrokeStyle struct {
+	Width      float64
+	Cap        LineCap
+	Join       LineJoin
+	MiterLimit float64
+	Dash       []float64
+	DashPhase  float64
+}
+
+// DefaultStrokeStyle returns the initial stroke parameters of a PDF graphics
+// state.
+func DefaultStrokeStyle() StrokeStyle {
+	return StrokeStyle{
+		Width:      1,
+		Cap:        ButtCap,
+		Join:       MiterJoin,
+		MiterLimit: 10,
+	}
+}
+
+// strokeTolerance is the maximum deviation, in device pixels, allowed when
+// approximating curves and round caps and joins with line segments.
+const strokeTolerance = 0.1
+
+// Stroke converts a user-space path into a device-space outline that, filled
+// with the nonzero winding rule, paints the same pixels as stroking p with
+// style under the transformation ctm.
+//
+// The pen is applied in user space before mapping through ctm, so non-uniform
+// scaling produces correctly distorted line widths. A zero width selects the
+// thinnest line the device can render, one device pixel wide.
+func Stroke(p *Path, style StrokeStyle, ctm Matrix) *Path {
+	out := NewPath()
+	if p.Empty() {
+		return out
+	}
+
+	scale := ctm.Expansion()
+	if scale == 0 {
+		return out
+	}
+
+	lines := dashPolylines(p.Flatten(strokeTolerance/scale), style.Dash, style.DashPhase, scale)
+
+	s := stroker{out: out, cap: style.Cap, join: style.Join, miterLimit: style.MiterLimit}
+
+	if style.Width <= 0 {
+		// Hairlines are constructed directly in device space.
+		for i := range lines {
+			for j, pt := range lines[i].Pts {
+				lines[i].Pts[j] = ctm.TransformPoint(pt)
+			}
+		}
+
+		s.hw = 0.5
+		s.tol = strokeTolerance
+		for _, pl := range lines {
+			s.polyline(pl)
+		}
+		return out
+	}
+
+	s.hw = style.Width / 2
+	s.tol = strokeTolerance / scale
+	for _, pl := range lines {
+		s.polyline(pl)
+	}
+
+	return out.Transform(ctm)
+}
+
+// stroker builds a stroke outline as a union of convex pieces: one
+// quadrilateral per segment plus join and cap polygons. Every piece is emitted
+// with the same orientation so that the nonzero rule merges the overlaps.
+type stroker struct {
+	out        *Path
+	hw         float64
+	tol        float64
+	cap        LineCap
+	join       LineJoin
+	miterLimit float64
+}
+
+func (s *stroker) polyline(pl Polyline) {
+	pts := dedupPoints(pl.Pts)
+
+	if pl.Closed && len(pts) > 1 && nearlyEqual(pts[0], pts[len(pts)-1]) {
+		pts = pts[:len(pts)-1]
+	}
+
+	if len(pts) == 1 {
+		s.dot(pts[0])
+		return
+	}
+
+	n := len(pts)
+	segs := n - 1
+	if pl.Closed {
+		segs = n
+	}
+
+	for i := 0; i < segs; i++ {
+		a, b := pts[i], pts[(i+1)%n]
+		nv := normal(b.Sub(a)).Mul(s.hw)
+		s.polygon(a.Add(nv), b.Add(nv), b.Sub(nv), a.Sub(nv))
+	}
+
+	if pl.Closed {
+		for i := 0; i < n; i++ {
+			s.joinAt(pts[(i+n-1)%n], pts[i], pts[(i+1)%n])
+		}
+		return
+	}
+
+	for i := 1; i < n-1; i++ {
+		s.joinAt(pts[i-1], pts[i], pts[i+1])
+	}
+
+	s.capAt(pts[0], pts[0].Sub(pts[1]))
+	s.capAt(pts[n-1], pts[n-1].Sub(pts[n-2]))
+}
+
+// dot paints a zero-length subpath, which only round and square caps make
+// visible.
+func (s *stroker) dot(p Point) {
+	switch s.cap {
+	case RoundCap:
+		s.circle(p)
+	case SquareCap:
+		s.polygon(
+			Point{p.X - s.hw, p.Y - s.hw},
+			Point{p.X + s.hw, p.Y - s.hw},
+			Point{p.X + s.hw, p.Y + s.hw},
+			Point{p.X - s.hw, p.Y + s.hw},
+		)
+	}
+}
+
+// capAt draws the cap at endpoint p of a subpath whose outward direction is
+// dir.
+func (s *stroker) capAt(p, dir Point) {
+	switch s.cap {
+	case RoundCap:
+		s.circle(p)
+	case SquareCap:
+		d := unit(dir).Mul(s.hw)
+		nv := normal(dir).Mul(s.hw)
+		s.polygon(p.Add(nv), p.Add(nv).Add(d), p.Sub(nv).Add(d), p.Sub(nv))
+	}
+}
+
+// joinAt fills the gap on the outside of the corner at b between segments
+// a→b and b→c.
+func (s *stroker) joinAt(a, b, c Point) {
+	d0 := unit(b.Sub(a))
+	d1 := unit(c.Sub(b))
+
+	cross := d0.X*d1.Y - d0.Y*d1.X
+	dot := d0.X*d1.X + d0.Y*d1.Y
+	if math.Abs(cross) < 1e-12 && dot > 0 {
+		return
+	}
+
+	if s.join == RoundJoin {
+		s.circle(b)
+		return
+	}
+
+	// The outer side of a left turn is on the right of both segments.
+	o0 := Point{d0.Y, -d0.X}
+	o1 := Point{d1.Y, -d1.X}
+	if cross < 0 {
+		o0, o1 = o0.Mul(-1), o1.Mul(-1)
+	}
+
+	p0 := b.Add(o0.Mul(s.hw))
+	p1 := b.Add(o1.Mul(s.hw))
+
+	if s.join == MiterJoin {
+		// The miter length ratio is 1/sin(φ/2) where φ is the angle between
+		// the segments; cos(α) = o0·o1 with α = π - φ.
+		cosA := o0.X*o1.X + o0.Y*o1.Y
+		if 1+cosA > 1e-12 {
+			ratio := math.Sqrt(2 / (1 + cosA))
+			if ratio <= s.miterLimit {
+				tip := b.Add(o0.Add(o1).Mul(s.hw / (1 + cosA)))
+				s.polygon(b, p0, tip, p1)
+				return
+			}
+		}
+	}
+
+	s.polygon(b, p0, p1)
+}
+
+// circle approximates a disc of radius hw around c.
+func (s *stroker) circle(c Point) {
+	n := 8
+	if s.hw > s.tol {
+		n = int(math.Ceil(math.Pi / math.Acos(1-s.tol/s.hw)))
+	}
+	n = max(8, min(n, 256))
+
+	pts := make([]Point, n)
+	for i := range pts {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		pts[i] = Point{c.X + s.hw*cos, c.Y + s.hw*sin}
+	}
+	s.polygon(pts...)
+}
+
+// polygon emits a closed subpath with positive (counter-clockwise) orientation.
+func (s *stroker) polygon(pts ...Point) {
+	area := 0.0
+	for i := range pts {
+		j := (i + 1) % len(pts)
+		area += pts[i].X*pts[j].Y - pts[j].X*pts[i].Y
+	}
+
+	if area == 0 {
+		return
+	}
+
+	if area < 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+
+	s.out.MoveTo(pts[0].X, pts[0].Y)
+	for _, p := range pts[1:] {
+		s.out.LineTo(p.X, p.Y)
+	}
+	s.out.Close()
+}
+
+// maxDashes bounds the dashes of one subpath. Subpaths that would have more
+// are stroked solid, as are patterns whose period is under a device pixel:
+// either could otherwise make dashes without end.
+const maxDashes = 100000
+
+// dashPolylines splits polylines into the "on" pieces of a dash pattern,
+// which scale maps to device pixels. An empty or all-zero pattern leaves the
+// input unchanged.
+func dashPolylines(lines []Polyline, dash []float64, phase, scale float64) []Polyline {
+	total := 0.0
+	for _, d := range dash {
+		if d < 0 {
+			return lines
+		}
+		total += d
+	}
+
+	if total <= 0 || total*scale < 1 {
+		return lines
+	}
+
+	// An odd-length pattern repeats with on and off swapped.
+	if len(dash)%2 == 1 {
+		dash = append(append([]float64(nil), dash...), dash...)
+		total *= 2
+	}
+
+	var out []Polyline
+
+subpaths:
+	for _, pl := range lines {
+		pts := pl.Pts
+		if pl.Closed {
+			pts = append(append([]Point(nil), pts...), pts[0])
+		}
+
+		// Every subpath restarts the pattern at the dash phase.
+		idx := 0
+		rem := dash[0]
+		on := true
+		off := math.Mod(phase, total)
+		if off < 0 {
+			off += total
+		}
+		for off > 0 {
+			if off < rem {
+				rem -= off
+				break
+			}
+			off -= rem
+			idx = (idx + 1) % len(dash)
+			rem = dash[idx]
+			on = idx%2 == 0
+		}
+
+		first := len(out)
+		startOn := on
+
+		var cur []Point
+		if on {
+			cur = []Point{pts[0]}
+		}
+		dashes := 0
+
+		for i := 0; i+1 < len(pts); i++ {
+			a, b := pts[i], pts[i+1]
+			segLen := b.Sub(a).Len()
+			pos := 0.0
+
+			for segLen-pos > rem {
+				if dashes++; dashes > maxDashes {
+					out = append(out[:first], pl)
+					continue subpaths
+				}
+				pos += rem
+				pt := a.Add(b.Sub(a).Mul(pos / segLen))
+
+				if on {
+					out = append(out, Polyline{Pts: append(cur, pt)})
+					cur = nil
+				} else {
+					cur = []Point{pt}
+				}
+
+				idx = (idx + 1) % len(dash)
+				rem = dash[idx]
+				on = idx%2 == 0
+			}
+
+			rem -= segLen - pos
+			if on {
+				cur = append(cur, b)
+			}
+		}
+
+		if !on || len(cur) == 0 {
+			continue
+		}
+
+		switch {
+		case pl.Closed && len(out) == first:
+			// The whole subpath fits in one dash.
+			out = append(out, pl)
+		case pl.Closed && startOn:
+			// Join the trailing dash with the leading one across the start
+			// point.
+			out[first].Pts = append(cur, out[first].Pts[1:]...)
+		default:
+			out = append(out, Polyline{Pts: cur})
+		}
+	}
+
+	return out
+}
+
+func dedupPoints(pts []Point) []Point {
+	out := make([]Point, 0, len(pts))
+	for _, p := range pts {
+		if len(out) > 0 && nearlyEqual(out[len(out)-1], p) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func nearlyEqual(a, b Point) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+}
+
+func unit(v Point) Point {
+	l := v.Len()
+	if l == 0 {
+		return Point{1, 0}
+	}
+	return v.Mul(1 / l)
+}
+
+// normal returns the unit vector pointing to the left of direction v.
+func normal(v Point) Point {
+	u := unit(v)
+	return Point{-u.Y, u.X}
+}
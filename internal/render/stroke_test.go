@@ -0,0 +1,279 @@
+package render
+
+import (
+	"testing"
+)
+
+// winding returns the nonzero winding number of p around pt, treating every
+// subpath as a closed polygon.
+func winding(p *Path, pt Point) int {
+	w := 0
+
+	for _, pl := range p.Flatten(0.01) {
+		n := len(pl.Pts)
+		for i := 0; i < n; i++ {
+			a, b := pl.Pts[i], pl.Pts[(i+1)%n]
+			if a.Y <= pt.Y {
+				if b.Y > pt.Y && (b.X-a.X)*(pt.Y-a.Y)-(pt.X-a.X)*(b.Y-a.Y) > 0 {
+					w++
+				}
+			} else if b.Y <= pt.Y && (b.X-a.X)*(pt.Y-a.Y)-(pt.X-a.X)*(b.Y-a.Y) < 0 {
+				w--
+			}
+		}
+	}
+
+	return w
+}
+
+type probe struct {
+	pt     Point
+	inside bool
+}
+
+func checkProbes(t *testing.T, outline *Path, probes []probe) {
+	t.Helper()
+
+	for _, pr := range probes {
+		if got := winding(outline, pr.pt) != 0; got != pr.inside {
+			t.Errorf("point %v inside = %v, expected %v", pr.pt, got, pr.inside)
+		}
+	}
+}
+
+func line(x0, y0, x1, y1 float64) *Path {
+	p := NewPath()
+	p.MoveTo(x0, y0)
+	p.LineTo(x1, y1)
+	return p
+}
+
+func corner() *Path {
+	p := NewPath()
+	p.MoveTo(0, 0)
+	p.LineTo(10, 0)
+	p.LineTo(10, 10)
+	return p
+}
+
+func TestStrokeCaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		cap    LineCap
+		probes []probe
+	}{
+		{
+			name: "Butt",
+			cap:  ButtCap,
+			probes: []probe{
+				{Point{5, 0.9}, true},
+				{Point{5, 1.1}, false},
+				{Point{-0.5, 0}, false},
+				{Point{10.5, 0}, false},
+			},
+		},
+		{
+			name: "Square",
+			cap:  SquareCap,
+			probes: []probe{
+				{Point{-0.9, 0.9}, true},
+				{Point{10.9, -0.9}, true},
+				{Point{-1.1, 0}, false},
+			},
+		},
+		{
+			name: "Round",
+			cap:  RoundCap,
+			probes: []probe{
+				{Point{-0.9, 0}, true},
+				{Point{10.6, 0.6}, true},
+				{Point{-0.8, 0.8}, false},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			style := DefaultStrokeStyle()
+			style.Width = 2
+			style.Cap = tc.cap
+			checkProbes(t, Stroke(line(0, 0, 10, 0), style, Identity), tc.probes)
+		})
+	}
+}
+
+func TestStrokeJoins(t *testing.T) {
+	tests := []struct {
+		name       string
+		join       LineJoin
+		miterLimit float64
+		probes     []probe
+	}{
+		{
+			name:       "Miter",
+			join:       MiterJoin,
+			miterLimit: 10,
+			probes: []probe{
+				{Point{10.9, -0.9}, true},
+				{Point{9.5, 5}, true},
+			},
+		},
+		{
+			name:       "MiterLimitFallsBackToBevel",
+			join:       MiterJoin,
+			miterLimit: 1.2,
+			probes: []probe{
+				{Point{10.9, -0.9}, false},
+				{Point{10.4, -0.4}, true},
+			},
+		},
+		{
+			name: "Round",
+			join: RoundJoin,
+			probes: []probe{
+				{Point{10.6, -0.6}, true},
+				{Point{10.9, -0.9}, false},
+			},
+		},
+		{
+			name: "Bevel",
+			join: BevelJoin,
+			probes: []probe{
+				{Point{10.4, -0.4}, true},
+				{Point{10.6, -0.6}, false},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			style := DefaultStrokeStyle()
+			style.Width = 2
+			style.Join = tc.join
+			style.MiterLimit = tc.miterLimit
+			checkProbes(t, Stroke(corner(), style, Identity), tc.probes)
+		})
+	}
+}
+
+func TestStrokeDash(t *testing.T) {
+	style := DefaultStrokeStyle()
+	style.Width = 1
+	style.Dash = []float64{2, 2}
+
+	checkProbes(t, Stroke(line(0, 0, 10, 0), style, Identity), []probe{
+		{Point{1, 0}, true},
+		{Point{3, 0}, false},
+		{Point{5, 0}, true},
+		{Point{7, 0}, false},
+	})
+
+	style.DashPhase = 1
+	checkProbes(t, Stroke(line(0, 0, 10, 0), style, Identity), []probe{
+		{Point{0.5, 0}, true},
+		{Point{1.5, 0}, false},
+		{Point{3.5, 0}, true},
+	})
+}
+
+func TestStrokeDashRoundDots(t *testing.T) {
+	style := DefaultStrokeStyle()
+	style.Width = 1
+	style.Cap = RoundCap
+	style.Dash = []float64{0, 4}
+
+	checkProbes(t, Stroke(line(0, 0, 10, 0), style, Identity), []probe{
+		{Point{0, 0.4}, true},
+		{Point{4, 0.4}, true},
+		{Point{2, 0}, false},
+	})
+}
+
+func TestStrokeHairline(t *testing.T) {
+	style := DefaultStrokeStyle()
+	style.Width = 0
+
+	// A zero-width line is one device pixel wide regardless of the CTM.
+	checkProbes(t, Stroke(line(0, 0, 10, 0), style, Scale(10, 10)), []probe{
+		{Point{50, 0.4}, true},
+		{Point{50, 0.6}, false},
+	})
+}
+
+func TestStrokeNonUniformScale(t *testing.T) {
+	style := DefaultStrokeStyle()
+	style.Width = 2
+
+	ctm := Scale(1, 4)
+
+	checkProbes(t, Stroke(line(0, 0, 10, 0), style, ctm), []probe{
+		{Point{5, 3.9}, true},
+		{Point{5, 4.1}, false},
+	})
+
+	checkProbes(t, Stroke(line(0, 0, 0, 10), style, ctm), []probe{
+		{Point{0.9, 20}, true},
+		{Point{1.1, 20}, false},
+	})
+}
+
+func TestStrokeClosedSubpathHasNoCaps(t *testing.T) {
+	style := DefaultStrokeStyle()
+	style.Width = 2
+	style.Cap = SquareCap
+
+	p := NewPath()
+	p.Rect(0, 0, 10, 10)
+
+	checkProbes(t, Stroke(p, style, Identity), []probe{
+		{Point{-0.9, -0.9}, true},
+		{Point{5, 5}, false},
+		{Point{-1.1, 5}, false},
+	})
+}
+
+func TestMatrixInvert(t *testing.T) {
+	m := Matrix{2, 1, -1, 3, 5, 7}
+
+	inv, ok := m.Invert()
+	if !ok {
+		t.Fatalf("Invert() reported a singular matrix")
+	}
+
+	got := m.Multiply(inv)
+	for i := range got {
+		if d := got[i] - Identity[i]; d > 1e-12 || d < -1e-12 {
+			t.Fatalf("m × m⁻¹ = %v, expected identity", got)
+		}
+	}
+
+	if _, ok := (Matrix{1, 2, 2, 4, 0, 0}).Invert(); ok {
+		t.Errorf("Invert() of a singular matrix reported success")
+	}
+}
+
+func TestStrokeDashTooFine(t *testing.T) {
+	style := DefaultStrokeStyle()
+	style.Width = 1
+
+	// A period under a device pixel strokes solid, as does a subpath that
+	// would have too many dashes.
+	for _, tc := range []struct {
+		dash []float64
+		ctm  Matrix
+	}{
+		{[]float64{0.0001}, Identity},
+		{[]float64{0.5, 0.5}, Scale(0.5, 0.5)},
+		{[]float64{1, 1}, Identity},
+	} {
+		style.Dash = tc.dash
+		outline := Stroke(line(0, 100, 1e6, 100), style, tc.ctm)
+		if n := len(outline.Segs); n > 10 {
+			t.Errorf("dash %v: %d segments, expected a solid stroke", tc.dash, n)
+		}
+		pt := tc.ctm.TransformPoint(Point{1.5, 100})
+		if winding(outline, pt) == 0 {
+			t.Errorf("dash %v: %v not stroked", tc.dash, pt)
+		}
+	}
+}
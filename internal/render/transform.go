@@ -1 +1,96 @@
 package render
+
+import "math"
+
+// Matrix is a PDF affine transformation [a b c d e f].
+//
+// A point (x, y) is mapped to (a*x + c*y + e, b*x + d*y + f).
+type Matrix [6]float64
+
+// Identity is the identity transformation.
+var Identity = Matrix{1, 0, 0, 1, 0, 0}
+
+// Translate returns a matrix translating by (tx, ty).
+func Translate(tx, ty float64) Matrix {
+	return Matrix{1, 0, 0, 1, tx, ty}
+}
+
+// Scale returns a matrix scaling by (sx, sy).
+func Scale(sx, sy float64) Matrix {
+	return Matrix{sx, 0, 0, sy, 0, 0}
+}
+
+// Rotate returns a matrix rotating counter-clockwise by theta radians.
+func Rotate(theta float64) Matrix {
+	s, c := math.Sincos(theta)
+	return Matrix{c, s, -s, c, 0, 0}
+}
+
+// Multiply returns m × n, the transformation that applies m first and then n.
+//
+// This is the order used by the cm operator: CTM' = M × CTM.
+func (m Matrix) Multiply(n Matrix) Matrix {
+	return Matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// Transform maps the point (x, y) through m.
+func (m Matrix) Transform(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// TransformPoint maps p through m.
+func (m Matrix) TransformPoint(p Point) Point {
+	x, y := m.Transform(p.X, p.Y)
+	return Point{x, y}
+}
+
+// TransformVector maps the vector (dx, dy) through m, ignoring translation.
+func (m Matrix) TransformVector(dx, dy float64) (float64, float64) {
+	return m[0]*dx + m[2]*dy, m[1]*dx + m[3]*dy
+}
+
+// Determinant returns the determinant of the linear part of m.
+func (m Matrix) Determinant() float64 {
+	return m[0]*m[3] - m[1]*m[2]
+}
+
+// Invert returns the inverse of m. The second result is false when m is
+// singular.
+func (m Matrix) Invert() (Matrix, bool) {
+	det := m.Determinant()
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Matrix{}, false
+	}
+
+	a := m[3] / det
+	b := -m[1] / det
+	c := -m[2] / det
+	d := m[0] / det
+
+	return Matrix{a, b, c, d, -(m[4]*a + m[5]*c), -(m[4]*b + m[5]*d)}, true
+}
+
+// Expansion returns the largest factor by which m can stretch a unit vector.
+//
+// It is used to convert device-space tolerances into user space.
+func (m Matrix) Expansion() float64 {
+	// Largest singular value of the 2x2 linear part.
+	a, b, c, d := m[0], m[1], m[2], m[3]
+	s1 := a*a + b*b + c*c + d*d
+	s2 := math.Sqrt(math.Max(0, (a*a+c*c-b*b-d*d)*(a*a+c*c-b*b-d*d)+4*(a*b+c*d)*(a*b+c*d)))
+
+	return math.Sqrt((s1 + s2) / 2)
+}
+
+// IsRectilinear reports whether m maps axis-aligned rectangles to
+// axis-aligned rectangles.
+func (m Matrix) IsRectilinear() bool {
+	return (m[1] == 0 && m[2] == 0) || (m[0] == 0 && m[3] == 0)
+}
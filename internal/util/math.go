@@ -1 +1,32 @@
 package util
+
+// Clamp limits v to the interval [lo, hi].
+func Clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Lerp interpolates linearly between a and b.
+func Lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// Interpolate maps x from the interval [xmin, xmax] onto [ymin, ymax], as
+// defined for PDF functions and image decode arrays.
+func Interpolate(x, xmin, xmax, ymin, ymax float64) float64 {
+	if xmax == xmin {
+		return ymin
+	}
+	return ymin + (x-xmin)*(ymax-ymin)/(xmax-xmin)
+}
+
+// ToByte converts a value in [0, 1] to a byte in [0, 255], clamping values
+// outside the interval.
+func ToByte(v float64) uint8 {
+	return uint8(Clamp(v, 0, 1)*255 + 0.5)
+}
@@ -1 +1,86 @@
 package util
+
+// BitReader reads big-endian bit fields from a byte slice, as used by packed
+// image samples and most PDF compression formats.
+type BitReader struct {
+	data []byte
+	pos  int
+}
+
+// NewBitReader returns a BitReader positioned at the first bit of data.
+func NewBitReader(data []byte) *BitReader {
+	return &BitReader{data: data}
+}
+
+// ReadBits reads the next n bits (n <= 32) as an unsigned integer, most
+// significant bit first. The second result is false if fewer than n bits
+// remain; the reader is then left at the end of the data.
+func (r *BitReader) ReadBits(n int) (uint32, bool) {
+	if n == 0 {
+		return 0, true
+	}
+
+	if r.pos+n > len(r.data)*8 {
+		r.pos = len(r.data) * 8
+		return 0, false
+	}
+
+	var v uint32
+	for n > 0 {
+		byteIdx := r.pos >> 3
+		bitOff := r.pos & 7
+		avail := 8 - bitOff
+		take := min(avail, n)
+
+		b := uint32(r.data[byteIdx]) >> (avail - take) & (1<<take - 1)
+		v = v<<take | b
+
+		r.pos += take
+		n -= take
+	}
+
+	return v, true
+}
+
+// ReadBit reads a single bit.
+func (r *BitReader) ReadBit() (uint32, bool) {
+	return r.ReadBits(1)
+}
+
+// PeekBits returns the next n bits without consuming them. Missing bits past
+// the end of the data read as zero.
+func (r *BitReader) PeekBits(n int) uint32 {
+	save := r.pos
+
+	var v uint32
+	for i := 0; i < n; i++ {
+		b, ok := r.ReadBit()
+		if !ok {
+			b = 0
+		}
+		v = v<<1 | b
+	}
+
+	r.pos = save
+	return v
+}
+
+// Skip advances the reader by n bits.
+func (r *BitReader) Skip(n int) {
+	r.pos = min(r.pos+n, len(r.data)*8)
+}
+
+// Align advances the reader to the next byte boundary.
+func (r *BitReader) Align() {
+	r.pos = (r.pos + 7) &^ 7
+}
+
+// BitsLeft returns the number of unread bits.
+func (r *BitReader) BitsLeft() int {
+	return len(r.data)*8 - r.pos
+}
+
+// BytePos returns the index of the byte containing the next unread bit.
+func (r *BitReader) BytePos() int {
+	return r.pos >> 3
+}